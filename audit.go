@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"photocloud/internal/app"
+	"photocloud/internal/domain/services"
+)
+
+const auditUsage = `usage: photocloud audit verify [-public-key KEY]
+       photocloud audit checkpoint
+       photocloud audit public-key`
+
+// runAudit dispatches the audit chain subcommands
+func runAudit(container *app.Container, args []string) error {
+	if len(args) == 0 {
+		return errors.New(auditUsage)
+	}
+
+	switch args[0] {
+	case "verify":
+		return runAuditVerify(container, args[1:])
+	case "checkpoint":
+		return runAuditCheckpoint(container)
+	case "public-key":
+		return runAuditPublicKey(container)
+	default:
+		return errors.New(auditUsage)
+	}
+}
+
+// runAuditVerify walks the audit chain, prints the report as JSON and fails if a link is broken
+func runAuditVerify(container *app.Container, args []string) error {
+	flags := flag.NewFlagSet("audit verify", flag.ContinueOnError)
+	publicKeyFlag := flags.String("public-key", "", "base64 Ed25519 key to check checkpoints with (default: derived from AUDIT_SIGNING_KEY)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	publicKey := container.AuditService.PublicKey()
+	if *publicKeyFlag != "" {
+		decoded, err := base64.StdEncoding.DecodeString(*publicKeyFlag)
+		if err != nil || len(decoded) != ed25519.PublicKeySize {
+			return errors.New("-public-key must be a base64 encoded Ed25519 public key")
+		}
+		publicKey = decoded
+	}
+
+	report, err := container.AuditService.Verify(context.Background(), publicKey)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "%d entries (%d to %d), %d verified and %d unverified checkpoints\n",
+		report.Entries, report.FirstSequence, report.LastSequence, report.Checkpoints, report.Unverified)
+	if report.Broken != nil {
+		return fmt.Errorf("audit chain is broken at entry %d: %s", report.Broken.Sequence, report.Broken.Reason)
+	}
+	if publicKey == nil {
+		fmt.Fprintln(os.Stderr, "checkpoint signatures were not checked: no public key")
+	}
+	return nil
+}
+
+// runAuditCheckpoint signs the head of the audit chain now
+func runAuditCheckpoint(container *app.Container) error {
+	checkpoint, err := container.AuditService.Checkpoint(context.Background())
+	if err != nil {
+		return err
+	}
+	if checkpoint == nil {
+		fmt.Println("No new entries since the last checkpoint")
+		return nil
+	}
+	fmt.Printf("Signed the chain up to entry %d (%s)\n", checkpoint.Sequence, checkpoint.Hash)
+	return nil
+}
+
+// runAuditPublicKey prints the key that checkpoints are verified with, for auditors
+func runAuditPublicKey(container *app.Container) error {
+	publicKey := container.AuditService.PublicKey()
+	if publicKey == nil {
+		return services.ErrCheckpointsDisabled
+	}
+	fmt.Printf("%s (key ID %s)\n", base64.StdEncoding.EncodeToString(publicKey), services.AuditKeyID(publicKey))
+	return nil
+}
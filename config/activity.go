@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultActivityBufferSize    = 1024
+	defaultActivityEnqueueWait   = 100 * time.Millisecond
+	defaultActivityPurgeInterval = time.Hour
+)
+
+// ActivityRetention is the policy for removing old user activity. Activity
+// types without a period of their own use Default; zero periods keep activity forever.
+type ActivityRetention struct {
+	Default time.Duration
+	Types   map[string]time.Duration
+	// TTL asks for removal by a MongoDB TTL index instead of the purger. It is refused,
+	// since the index would delete audit chain entries that must be pruned to stubs.
+	TTL bool
+	// Rollup adds expired activity to daily aggregates before it is removed
+	Rollup bool
+	// Archive exports expired activity to storage as gzipped JSON Lines before it is removed
+	Archive bool
+}
+
+// GetActivityBufferSize returns how many user activities may wait to be written
+// before recording further activities has to wait
+func GetActivityBufferSize() int {
+	if value := os.Getenv("ACTIVITY_BUFFER_SIZE"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return defaultActivityBufferSize
+}
+
+// GetActivityEnqueueWait returns how long recording an activity waits for room in a
+// full buffer before the activity is given up and counted as a gap
+func GetActivityEnqueueWait() time.Duration {
+	return durationFromEnv("ACTIVITY_ENQUEUE_WAIT", defaultActivityEnqueueWait)
+}
+
+// GetActivityRetention reads the retention periods from ACTIVITY_RETENTION_DAYS, a
+// comma-separated list of type:days entries such as "view:30,download:90,*:365"
+// where * is the default. Malformed entries are ignored.
+func GetActivityRetention() ActivityRetention {
+	retention := ActivityRetention{
+		Types:   make(map[string]time.Duration),
+		TTL:     os.Getenv("ACTIVITY_RETENTION_MODE") == "ttl",
+		Rollup:  os.Getenv("ACTIVITY_ROLLUP") == "true",
+		Archive: os.Getenv("ACTIVITY_ARCHIVE") == "true",
+	}
+
+	for _, entry := range strings.Split(os.Getenv("ACTIVITY_RETENTION_DAYS"), ",") {
+		activityType, value, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			continue
+		}
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 {
+			continue
+		}
+		period := time.Duration(days) * 24 * time.Hour
+		if activityType == "*" {
+			retention.Default = period
+		} else {
+			retention.Types[activityType] = period
+		}
+	}
+	return retention
+}
+
+// GetActivityPurgeInterval returns how often expired user activity is purged
+func GetActivityPurgeInterval() time.Duration {
+	return durationFromEnv("ACTIVITY_PURGE_INTERVAL", defaultActivityPurgeInterval)
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+const defaultAuditCheckpointInterval = time.Hour
+
+// AuditConfig holds the settings of the tamper-evident activity audit chain
+type AuditConfig struct {
+	// SigningKey is the secret the Ed25519 checkpoint signing key is derived from.
+	// Checkpoints are not written when it is empty.
+	SigningKey []byte
+	// CheckpointInterval is how often the head of the chain is signed
+	CheckpointInterval time.Duration
+}
+
+// GetAuditConfig returns the audit chain settings
+func GetAuditConfig() AuditConfig {
+	return AuditConfig{
+		SigningKey:         []byte(os.Getenv("AUDIT_SIGNING_KEY")),
+		CheckpointInterval: durationFromEnv("AUDIT_CHECKPOINT_INTERVAL", defaultAuditCheckpointInterval),
+	}
+}
@@ -0,0 +1,10 @@
+package config
+
+import "os"
+
+// GetAuthRequired reports whether every API request must carry a user API token, from
+// AUTH_REQUIRED. Until it is "true", requests without a token act as the anonymous
+// account, so clients from before accounts existed keep working while they move to tokens.
+func GetAuthRequired() bool {
+	return os.Getenv("AUTH_REQUIRED") == "true"
+}
@@ -0,0 +1,10 @@
+package config
+
+import "time"
+
+const defaultBulkJobPollInterval = 5 * time.Second
+
+// GetBulkJobPollInterval returns how often queued bulk jobs are looked for
+func GetBulkJobPollInterval() time.Duration {
+	return durationFromEnv("BULK_JOB_POLL_INTERVAL", defaultBulkJobPollInterval)
+}
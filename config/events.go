@@ -0,0 +1,41 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultEventBufferSize      = 1024
+	defaultStreamTicketLifetime = time.Minute
+)
+
+// EventConfig holds the settings of the real-time event stream
+type EventConfig struct {
+	// Shared publishes events through a MongoDB change stream so that clients connected
+	// to any API instance receive them. It needs MongoDB to run as a replica set.
+	Shared bool
+	// BufferSize is how many events may wait to be shared before further events are dropped
+	BufferSize int
+}
+
+// GetEventConfig returns the event stream settings. EVENT_BUS=mongo shares events
+// between instances; by default they are only delivered by the instance they happen on.
+func GetEventConfig() EventConfig {
+	cfg := EventConfig{
+		Shared:     os.Getenv("EVENT_BUS") == "mongo",
+		BufferSize: defaultEventBufferSize,
+	}
+	if value := os.Getenv("EVENT_BUFFER_SIZE"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			cfg.BufferSize = parsed
+		}
+	}
+	return cfg
+}
+
+// GetStreamTicketLifetime returns how long a ticket for opening an event stream stays valid
+func GetStreamTicketLifetime() time.Duration {
+	return durationFromEnv("STREAM_TICKET_LIFETIME", defaultStreamTicketLifetime)
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+const (
+	defaultExportStreamMaxBytes = 1 << 30
+	defaultExportLinkExpiry     = 24 * time.Hour
+	defaultExportPollInterval   = 5 * time.Second
+
+	// maxExportLinkExpiry is the longest a presigned storage URL may stay valid
+	maxExportLinkExpiry = 7 * 24 * time.Hour
+)
+
+// ExportConfig holds the settings of ZIP exports
+type ExportConfig struct {
+	// MaxStreamBytes is the largest export, counting the size of the originals, that is streamed
+	// in the response. Larger exports are written to storage by a background job.
+	MaxStreamBytes int64
+	// LinkExpiry is how long the archive of an export job can be downloaded before it is deleted
+	LinkExpiry time.Duration
+	// PollInterval is how often queued export jobs and expired archives are looked for
+	PollInterval time.Duration
+}
+
+// GetExportConfig returns the ZIP export settings
+func GetExportConfig() ExportConfig {
+	cfg := ExportConfig{
+		MaxStreamBytes: defaultExportStreamMaxBytes,
+		LinkExpiry:     min(durationFromEnv("EXPORT_LINK_EXPIRY", defaultExportLinkExpiry), maxExportLinkExpiry),
+		PollInterval:   durationFromEnv("EXPORT_JOB_POLL_INTERVAL", defaultExportPollInterval),
+	}
+	if value := os.Getenv("EXPORT_STREAM_MAX_BYTES"); value != "" {
+		if parsed, ok := ParseSize(value); ok {
+			cfg.MaxStreamBytes = parsed
+		}
+	}
+	return cfg
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+const (
+	defaultImportMaxArchiveBytes = 10 << 30
+	defaultImportPollInterval    = 5 * time.Second
+)
+
+// ImportConfig holds the settings of imports from other photo libraries
+type ImportConfig struct {
+	// MaxArchiveBytes is the largest export archive that can be uploaded for import
+	MaxArchiveBytes int64
+	// MaxFileBytes is the largest file imported as a photo, the same as for uploads
+	MaxFileBytes int64
+	// PollInterval is how often queued import jobs are looked for
+	PollInterval time.Duration
+}
+
+// GetImportConfig returns the import settings
+func GetImportConfig() ImportConfig {
+	cfg := ImportConfig{
+		MaxArchiveBytes: defaultImportMaxArchiveBytes,
+		MaxFileBytes:    GetMaxUploadSize(),
+		PollInterval:    durationFromEnv("IMPORT_JOB_POLL_INTERVAL", defaultImportPollInterval),
+	}
+	if value := os.Getenv("IMPORT_MAX_ARCHIVE_BYTES"); value != "" {
+		if parsed, ok := ParseSize(value); ok {
+			cfg.MaxArchiveBytes = parsed
+		}
+	}
+	return cfg
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultUsageVerifyInterval = 6 * time.Hour
+
+// sizeUnits are the suffixes accepted in quota sizes
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"TB", 1 << 40},
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+}
+
+// QuotaPlan limits what users on a plan may store. Zero means no limit.
+type QuotaPlan struct {
+	MaxBytes  int64
+	MaxPhotos int64
+}
+
+// QuotaConfig holds the quota plans. Users without a plan get DefaultPlan;
+// plans that are not configured have no limits.
+type QuotaConfig struct {
+	DefaultPlan string
+	Plans       map[string]QuotaPlan
+}
+
+// GetQuotaConfig reads the plans from QUOTA_PLANS, a comma-separated list of
+// name:max_bytes:max_photos entries such as "free:5GB:1000,pro:1TB:0".
+// Malformed entries are ignored.
+func GetQuotaConfig() QuotaConfig {
+	cfg := QuotaConfig{
+		DefaultPlan: os.Getenv("QUOTA_DEFAULT_PLAN"),
+		Plans:       make(map[string]QuotaPlan),
+	}
+	if cfg.DefaultPlan == "" {
+		cfg.DefaultPlan = "free"
+	}
+
+	for _, entry := range strings.Split(os.Getenv("QUOTA_PLANS"), ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) != 3 || parts[0] == "" {
+			continue
+		}
+		maxBytes, ok := ParseSize(parts[1])
+		if !ok {
+			continue
+		}
+		maxPhotos, err := strconv.ParseInt(strings.TrimSpace(parts[2]), 10, 64)
+		if err != nil || maxPhotos < 0 {
+			continue
+		}
+		cfg.Plans[parts[0]] = QuotaPlan{MaxBytes: maxBytes, MaxPhotos: maxPhotos}
+	}
+	return cfg
+}
+
+// GetUsageVerifyInterval returns how often storage usage is recomputed from the photo records
+func GetUsageVerifyInterval() time.Duration {
+	return durationFromEnv("USAGE_VERIFY_INTERVAL", defaultUsageVerifyInterval)
+}
+
+// ParseSize parses a byte count with an optional KB, MB, GB or TB suffix (powers of 1024)
+func ParseSize(value string) (int64, bool) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || parsed < 0 || parsed > (1<<63-1)/multiplier {
+		return 0, false
+	}
+	return parsed * multiplier, true
+}
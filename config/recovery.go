@@ -0,0 +1,31 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+const (
+	defaultPendingOperationTimeout = 15 * time.Minute
+	defaultRecoveryInterval        = 5 * time.Minute
+)
+
+// GetPendingOperationTimeout returns how old a journaled operation must be before recovery treats it as interrupted
+func GetPendingOperationTimeout() time.Duration {
+	return durationFromEnv("PENDING_OPERATION_TIMEOUT", defaultPendingOperationTimeout)
+}
+
+// GetRecoveryInterval returns how often interrupted operations are recovered after startup
+func GetRecoveryInterval() time.Duration {
+	return durationFromEnv("RECOVERY_INTERVAL", defaultRecoveryInterval)
+}
+
+// durationFromEnv parses a positive Go duration from an environment variable, falling back to a default
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	if value := os.Getenv(name); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return fallback
+}
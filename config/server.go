@@ -0,0 +1,19 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// GetTrustedProxies returns the addresses and CIDR ranges of the proxies whose
+// X-Forwarded-For and X-Real-IP headers name the client, from the comma-separated
+// TRUSTED_PROXIES. Without any, the client IP is the address of the connection.
+func GetTrustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
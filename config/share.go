@@ -0,0 +1,18 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+const defaultShareURLExpiryMinutes = 5
+
+// GetShareURLExpiry returns for how many minutes storage URLs minted for share links stay valid
+func GetShareURLExpiry() int {
+	if value := os.Getenv("SHARE_URL_EXPIRY_MINUTES"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return defaultShareURLExpiryMinutes
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultTransformMaxDimension = 4096
+	defaultTransformMaxPixels    = 50_000_000
+	defaultTransformQueueTimeout = 10 * time.Second
+	defaultTransformURLLifetime  = 24 * time.Hour
+	defaultEncoderTimeout        = 30 * time.Second
+)
+
+// TransformConfig holds the settings of the image transformation endpoint
+type TransformConfig struct {
+	// SigningKey authenticates transform URLs. Transforms are disabled when it is empty.
+	SigningKey []byte
+	// URLLifetime is how long a signed transform URL stays valid
+	URLLifetime time.Duration
+	// Concurrency is the number of transforms allowed to run at once
+	Concurrency int
+	// QueueTimeout is how long a transform waits for a free slot before it is rejected
+	QueueTimeout time.Duration
+	// MaxDimension is the largest output width or height
+	MaxDimension int
+	// MaxPixels is the largest source image, in pixels, that will be decoded
+	MaxPixels int
+	// WebPEncoderCommand and AVIFEncoderCommand are optional external encoder
+	// command lines; without them those formats are never produced
+	WebPEncoderCommand string
+	AVIFEncoderCommand string
+	// EncoderTimeout is how long an external encoder may run before it is killed
+	EncoderTimeout time.Duration
+}
+
+// GetTransformConfig returns the image transformation settings
+func GetTransformConfig() TransformConfig {
+	return TransformConfig{
+		SigningKey:   []byte(os.Getenv("TRANSFORM_SIGNING_KEY")),
+		URLLifetime:  durationFromEnv("TRANSFORM_URL_LIFETIME", defaultTransformURLLifetime),
+		Concurrency:  intFromEnv("TRANSFORM_CONCURRENCY", runtime.NumCPU()),
+		QueueTimeout: durationFromEnv("TRANSFORM_QUEUE_TIMEOUT", defaultTransformQueueTimeout),
+		MaxDimension: intFromEnv("TRANSFORM_MAX_DIMENSION", defaultTransformMaxDimension),
+		MaxPixels:    intFromEnv("TRANSFORM_MAX_PIXELS", defaultTransformMaxPixels),
+
+		WebPEncoderCommand: os.Getenv("WEBP_ENCODER_COMMAND"),
+		AVIFEncoderCommand: os.Getenv("AVIF_ENCODER_COMMAND"),
+		EncoderTimeout:     durationFromEnv("ENCODER_TIMEOUT", defaultEncoderTimeout),
+	}
+}
+
+// intFromEnv parses a positive integer from an environment variable, falling back to a default
+func intFromEnv(name string, fallback int) int {
+	if value := os.Getenv(name); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return fallback
+}
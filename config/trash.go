@@ -0,0 +1,28 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultTrashRetentionDays = 30
+	defaultTrashPurgeInterval = time.Hour
+)
+
+// GetTrashRetention returns how long deleted photos stay in the trash before they are purged
+func GetTrashRetention() time.Duration {
+	days := defaultTrashRetentionDays
+	if value := os.Getenv("TRASH_RETENTION_DAYS"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			days = parsed
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// GetTrashPurgeInterval returns how often the trash purger runs
+func GetTrashPurgeInterval() time.Duration {
+	return durationFromEnv("TRASH_PURGE_INTERVAL", defaultTrashPurgeInterval)
+}
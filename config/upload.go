@@ -0,0 +1,15 @@
+package config
+
+import "os"
+
+const defaultMaxUploadSize = 10 << 20
+
+// GetMaxUploadSize returns the largest photo file that can be uploaded, in bytes
+func GetMaxUploadSize() int64 {
+	if value := os.Getenv("MAX_UPLOAD_SIZE"); value != "" {
+		if parsed, ok := ParseSize(value); ok {
+			return parsed
+		}
+	}
+	return defaultMaxUploadSize
+}
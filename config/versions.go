@@ -0,0 +1,39 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+const defaultVersionPruneInterval = time.Hour
+
+// VersionRetention is the policy for pruning previous versions of photos. The
+// current version is never pruned.
+type VersionRetention struct {
+	// Keep is how many previous versions each photo keeps, or -1 for no limit
+	Keep int
+	// MaxAge is how long previous versions are kept, or zero for no limit
+	MaxAge time.Duration
+}
+
+// GetVersionRetention returns the version retention policy
+func GetVersionRetention() VersionRetention {
+	retention := VersionRetention{Keep: -1}
+	if value := os.Getenv("VERSION_RETENTION_COUNT"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			retention.Keep = parsed
+		}
+	}
+	if value := os.Getenv("VERSION_RETENTION_DAYS"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			retention.MaxAge = time.Duration(parsed) * 24 * time.Hour
+		}
+	}
+	return retention
+}
+
+// GetVersionPruneInterval returns how often previous versions are pruned
+func GetVersionPruneInterval() time.Duration {
+	return durationFromEnv("VERSION_PRUNE_INTERVAL", defaultVersionPruneInterval)
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+const (
+	defaultWebhookTimeout      = 10 * time.Second
+	defaultWebhookMaxAttempts  = 8
+	defaultWebhookRetryDelay   = time.Minute
+	defaultWebhookDisableAfter = 20
+	defaultWebhookConcurrency  = 4
+	defaultWebhookPollInterval = 10 * time.Second
+)
+
+// WebhookConfig holds the settings of outgoing webhook deliveries
+type WebhookConfig struct {
+	// Timeout bounds a single delivery attempt
+	Timeout time.Duration
+	// MaxAttempts is how often a delivery is attempted before it fails for good
+	MaxAttempts int
+	// RetryDelay is the wait before the first retry; it doubles with every further attempt
+	RetryDelay time.Duration
+	// DisableAfter is how many attempts in a row may fail before a webhook is disabled
+	DisableAfter int
+	// Concurrency is the number of deliveries attempted at once
+	Concurrency int
+	// PollInterval is how often due retries are looked for
+	PollInterval time.Duration
+	// AllowPrivateNetworks permits endpoints on loopback, private and link-local
+	// addresses. Without it webhooks cannot reach internal services.
+	AllowPrivateNetworks bool
+}
+
+// GetWebhookConfig returns the webhook delivery settings
+func GetWebhookConfig() WebhookConfig {
+	return WebhookConfig{
+		Timeout:              durationFromEnv("WEBHOOK_TIMEOUT", defaultWebhookTimeout),
+		MaxAttempts:          intFromEnv("WEBHOOK_MAX_ATTEMPTS", defaultWebhookMaxAttempts),
+		RetryDelay:           durationFromEnv("WEBHOOK_RETRY_DELAY", defaultWebhookRetryDelay),
+		DisableAfter:         intFromEnv("WEBHOOK_DISABLE_AFTER", defaultWebhookDisableAfter),
+		Concurrency:          intFromEnv("WEBHOOK_CONCURRENCY", defaultWebhookConcurrency),
+		PollInterval:         durationFromEnv("WEBHOOK_POLL_INTERVAL", defaultWebhookPollInterval),
+		AllowPrivateNetworks: os.Getenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS") == "true",
+	}
+}
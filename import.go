@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"photocloud/config"
+	"photocloud/internal/app"
+	"photocloud/internal/domain/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const importUsage = "usage: photocloud import -username NAME [-concurrency N] [-journal FILE] [-albums=false] DIR"
+
+const (
+	// defaultImportConcurrency is the number of files a folder import uploads at once by default
+	defaultImportConcurrency = 4
+	// defaultImportJournal is where a folder import records its progress by default
+	defaultImportJournal = "photocloud-import.journal"
+)
+
+// Outcomes of the files of a folder import
+const (
+	importUploaded = "uploaded"
+	importSkipped  = "skipped"
+	importFailed   = "failed"
+)
+
+// runImport uploads the photos in a directory tree to a user's account, each folder
+// becoming an album named after its path. Files are validated like uploads through the
+// API. Progress is kept in a journal, so running the command again after it was
+// interrupted continues with the files not uploaded yet.
+func runImport(container *app.Container, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	username := flags.String("username", "", "name of the user to upload the photos for")
+	concurrency := flags.Int("concurrency", defaultImportConcurrency, "number of files uploaded at once")
+	journalName := flags.String("journal", defaultImportJournal, "file recording the progress of the import")
+	albums := flags.Bool("albums", true, "add the photos of each folder to an album named after its path")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" || flags.NArg() != 1 {
+		return errors.New(importUsage)
+	}
+	if *concurrency < 1 {
+		return fmt.Errorf("invalid -concurrency %d", *concurrency)
+	}
+
+	root, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(root); err != nil {
+		return err
+	} else if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", root)
+	}
+	journalPath, err := filepath.Abs(*journalName)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, err = userContext(ctx, container, *username)
+	if err != nil {
+		return err
+	}
+	journal, err := openImportJournal(journalPath, root, *username)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	folder := &folderImport{
+		container:   container,
+		root:        root,
+		journal:     journal,
+		journalPath: journalPath,
+		albums:      *albums,
+		maxSize:     config.GetMaxUploadSize(),
+		cancel:      cancel,
+	}
+	walkErr := folder.run(ctx, *concurrency)
+
+	fmt.Fprintf(os.Stderr, "%d files: %d uploaded, %d uploaded by earlier runs, %d skipped, %d failed; %d albums created\n",
+		folder.total.Load(), folder.uploaded.Load(), folder.resumed.Load(), folder.skipped.Load(), folder.failed.Load(), folder.albumsCreated.Load())
+	if cause := context.Cause(ctx); cause != nil {
+		return fmt.Errorf("import stopped, run the command again to continue: %w", cause)
+	}
+	if walkErr != nil {
+		return walkErr
+	}
+	if failed := folder.failed.Load(); failed > 0 {
+		return fmt.Errorf("%d files failed, run the command again to retry them", failed)
+	}
+	return nil
+}
+
+// folderImport uploads the files of a directory tree with a number of workers
+type folderImport struct {
+	container   *app.Container
+	root        string
+	journal     *importJournal
+	journalPath string
+	albums      bool
+	maxSize     int64
+	// cancel stops the import, for instance when the storage quota is reached
+	cancel context.CancelCauseFunc
+
+	// albumMu guards the albums of the journal, so that each folder gets one album
+	albumMu sync.Mutex
+
+	total, uploaded, resumed, skipped, failed, albumsCreated atomic.Int64
+}
+
+// run walks the directory and hands its files to the workers, leaving out hidden files and
+// folders, the journal and files uploaded by earlier runs
+func (f *folderImport) run(ctx context.Context, concurrency int) error {
+	paths := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range paths {
+				f.importFile(ctx, name)
+			}
+		}()
+	}
+
+	err := filepath.WalkDir(f.root, func(name string, entry fs.DirEntry, err error) error {
+		rel, relErr := filepath.Rel(f.root, name)
+		if relErr != nil {
+			return relErr
+		}
+		rel = filepath.ToSlash(rel)
+		if err != nil {
+			// Unreadable folders are reported and left out, like files that cannot be read
+			f.total.Add(1)
+			f.report(rel, importFailed, err)
+			if entry != nil && entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if rel != "." && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() || name == f.journalPath {
+			return nil
+		}
+
+		f.total.Add(1)
+		if _, ok := f.journal.uploaded[rel]; ok {
+			f.resumed.Add(1)
+			return nil
+		}
+		select {
+		case paths <- rel:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	close(paths)
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// importFile validates a file and uploads it, unless an interrupted earlier run already did,
+// and adds it to the album of its folder
+func (f *folderImport) importFile(ctx context.Context, rel string) {
+	name := path.Base(rel)
+	contentType, ok := services.PhotoContentType(name)
+	if !ok {
+		f.report(rel, importSkipped, fmt.Errorf("invalid file extension %s, allowed extensions: .jpg, .jpeg, .png, .gif, .webp", path.Ext(name)))
+		return
+	}
+	file := filepath.Join(f.root, filepath.FromSlash(rel))
+	info, err := os.Stat(file)
+	if err != nil {
+		f.report(rel, importFailed, err)
+		return
+	}
+	if info.Size() > f.maxSize {
+		f.report(rel, importSkipped, fmt.Errorf("file size %d bytes exceeds maximum limit of %d bytes", info.Size(), f.maxSize))
+		return
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		f.report(rel, importFailed, err)
+		return
+	}
+	size := int64(len(data))
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	// A file whose upload started in an earlier run may have been stored before it stopped
+	var photoID primitive.ObjectID
+	if pending, ok := f.journal.pending[rel]; ok && pending.SHA256 == hash {
+		found, err := f.container.PhotoService.CheckUploaded(ctx, []services.FileDigest{{SHA256: hash, Size: size}})
+		if err != nil {
+			f.report(rel, importFailed, err)
+			return
+		}
+		if !found[0].PhotoID.IsZero() && !found[0].Trashed {
+			photoID = found[0].PhotoID
+		}
+	}
+
+	if photoID.IsZero() {
+		if err := f.journal.write(journalEntry{Path: rel, SHA256: hash, Size: size}); err != nil {
+			f.cancel(fmt.Errorf("failed to write the journal: %w", err))
+			return
+		}
+		photo, err := f.container.PhotoService.UploadPhoto(ctx, name, "", bytes.NewReader(data), contentType, size)
+		if err != nil {
+			if errors.Is(err, services.ErrQuotaExceeded) {
+				// Every further upload would fail as well
+				f.cancel(err)
+			}
+			f.report(rel, importFailed, err)
+			return
+		}
+		photoID = photo.ID
+	}
+
+	if folder := path.Dir(rel); f.albums && folder != "." {
+		if err := f.addToAlbum(ctx, folder, photoID); err != nil {
+			// The photo is found by its hash when the file is tried again
+			f.report(rel, importFailed, err)
+			return
+		}
+	}
+	if err := f.journal.write(journalEntry{Path: rel, Status: importUploaded, PhotoID: photoID.Hex()}); err != nil {
+		f.cancel(fmt.Errorf("failed to write the journal: %w", err))
+	}
+	f.report(rel, importUploaded, nil)
+}
+
+// addToAlbum adds a photo to the album of a folder, creating the album for the first photo
+// of the folder and again if it was deleted since
+func (f *folderImport) addToAlbum(ctx context.Context, folder string, photoID primitive.ObjectID) error {
+	albumID, err := f.album(ctx, folder, primitive.NilObjectID)
+	if err != nil {
+		return err
+	}
+	_, err = f.container.AlbumService.AddPhotos(ctx, albumID, []primitive.ObjectID{photoID})
+	if errors.Is(err, services.ErrAlbumNotFound) {
+		if albumID, err = f.album(ctx, folder, albumID); err != nil {
+			return err
+		}
+		_, err = f.container.AlbumService.AddPhotos(ctx, albumID, []primitive.ObjectID{photoID})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add the photo to album %s: %w", folder, err)
+	}
+	return nil
+}
+
+// album returns the album of a folder, creating it if there is none or it is the deleted one
+func (f *folderImport) album(ctx context.Context, folder string, deleted primitive.ObjectID) (primitive.ObjectID, error) {
+	f.albumMu.Lock()
+	defer f.albumMu.Unlock()
+
+	if known, ok := f.journal.albums[folder]; ok {
+		if albumID, err := primitive.ObjectIDFromHex(known); err == nil && albumID != deleted {
+			return albumID, nil
+		}
+	}
+	album, err := f.container.AlbumService.CreateAlbum(ctx, folder, "")
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("failed to create album %s: %w", folder, err)
+	}
+	f.albumsCreated.Add(1)
+	f.journal.albums[folder] = album.ID.Hex()
+	if err := f.journal.write(journalEntry{Folder: folder, AlbumID: album.ID.Hex()}); err != nil {
+		return primitive.NilObjectID, fmt.Errorf("failed to write the journal: %w", err)
+	}
+	return album.ID, nil
+}
+
+// report counts the outcome of a file and prints it
+func (f *folderImport) report(rel, status string, err error) {
+	switch status {
+	case importUploaded:
+		f.uploaded.Add(1)
+	case importSkipped:
+		f.skipped.Add(1)
+	case importFailed:
+		f.failed.Add(1)
+	}
+	if err != nil {
+		fmt.Printf("%s\t%s\t%s\n", status, rel, err)
+	} else {
+		fmt.Printf("%s\t%s\n", status, rel)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"photocloud/internal/app"
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+)
+
+const importArchiveUsage = "usage: photocloud import-archive -username NAME -source google_takeout|apple_photos PATH"
+
+// runImportArchive imports an export of another photo library into a user's account and
+// prints the outcome of every file. The export is a ZIP archive or the directory it was
+// extracted into; Takeouts split into several archives are imported as one directory.
+func runImportArchive(container *app.Container, args []string) error {
+	flags := flag.NewFlagSet("import-archive", flag.ContinueOnError)
+	username := flags.String("username", "", "name of the user to import the photos for")
+	source := flags.String("source", "", "service the export comes from: google_takeout or apple_photos")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" || flags.NArg() != 1 {
+		return errors.New(importArchiveUsage)
+	}
+
+	ctx, err := userContext(context.Background(), container, *username)
+	if err != nil {
+		return err
+	}
+	fsys, closeExport, err := openExport(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer closeExport()
+
+	counts, err := container.ImportService.ImportLocal(ctx, models.ImportSource(*source), fsys, func(file models.ImportFile) {
+		if file.Error != "" {
+			fmt.Printf("%s\t%s\t%s\n", file.Status, file.Path, file.Error)
+		} else {
+			fmt.Printf("%s\t%s\n", file.Status, file.Path)
+		}
+	})
+	if counts != nil {
+		fmt.Fprintf(os.Stderr, "%d of %d files processed: %d imported, %d duplicates, %d skipped, %d failed\n",
+			counts.Processed, counts.Total, counts.Imported, counts.Duplicates, counts.Skipped, counts.Failed)
+	}
+	return err
+}
+
+// userContext returns a context acting as the named user
+func userContext(ctx context.Context, container *app.Container, username string) (context.Context, error) {
+	user, err := container.UserRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user %q not found", username)
+	}
+	return auth.WithUser(ctx, user), nil
+}
+
+// openExport opens a ZIP archive or a directory for reading
+func openExport(name string) (fs.FS, func() error, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		return os.DirFS(name), func() error { return nil }, nil
+	}
+
+	archive, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s is neither a directory nor a ZIP archive: %w", name, err)
+	}
+	return &archive.Reader, archive.Close, nil
+}
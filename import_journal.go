@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// journalEntry is a line of a folder import journal. The first line names the import; the
+// others record an album created for a folder, a file about to be uploaded with its hash,
+// or the outcome of a file.
+type journalEntry struct {
+	Root     string `json:"root,omitempty"`
+	Username string `json:"username,omitempty"`
+
+	Folder  string `json:"folder,omitempty"`
+	AlbumID string `json:"album_id,omitempty"`
+
+	Path    string `json:"path,omitempty"`
+	SHA256  string `json:"sha256,omitempty"`
+	Size    int64  `json:"size,omitempty"`
+	Status  string `json:"status,omitempty"`
+	PhotoID string `json:"photo_id,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// importJournal records the progress of a folder import as JSON lines, so that an
+// interrupted import continues where it stopped. Lines are appended as they happen
+// and are safe to write from several goroutines.
+type importJournal struct {
+	mu   sync.Mutex
+	file *os.File
+
+	// albums maps folders to the albums created for them
+	albums map[string]string
+	// uploaded maps the files uploaded to their photos
+	uploaded map[string]string
+	// pending maps files whose upload started, and may have finished, to their hash and size
+	pending map[string]journalEntry
+}
+
+// openImportJournal opens the journal of an import of a directory for a user, reading the
+// progress of earlier runs, or starts it. A journal started for another directory or user
+// is not reused.
+func openImportJournal(name, root, username string) (*importJournal, error) {
+	journal := &importJournal{
+		albums:   make(map[string]string),
+		uploaded: make(map[string]string),
+		pending:  make(map[string]journalEntry),
+	}
+
+	file, err := os.Open(name)
+	if err == nil {
+		err = journal.read(file, name, root, username)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	journal.file, err = os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := journal.file.Stat(); err == nil && info.Size() == 0 {
+		if err := journal.write(journalEntry{Root: root, Username: username}); err != nil {
+			journal.file.Close()
+			return nil, err
+		}
+	}
+	return journal, nil
+}
+
+func (j *importJournal) read(file *os.File, name, root, username string) error {
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for scanner.Scan() {
+		var entry journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// The line being written when an earlier run was killed is left out
+			continue
+		}
+
+		switch {
+		case entry.Root != "":
+			if entry.Root != root || entry.Username != username {
+				return fmt.Errorf("journal %s belongs to an import of %s for %s", name, entry.Root, entry.Username)
+			}
+		case entry.Folder != "":
+			j.albums[entry.Folder] = entry.AlbumID
+		case entry.Status == importUploaded:
+			j.uploaded[entry.Path] = entry.PhotoID
+			delete(j.pending, entry.Path)
+		case entry.Status == "" && entry.SHA256 != "":
+			j.pending[entry.Path] = entry
+		}
+	}
+	return scanner.Err()
+}
+
+// write appends a line to the journal
+func (j *importJournal) write(entry journalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_, err = j.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the journal
+func (j *importJournal) Close() error {
+	return j.file.Close()
+}
@@ -0,0 +1,198 @@
+package app
+
+import (
+	"context"
+
+	"photocloud/config"
+	"photocloud/internal/domain/repositories"
+	"photocloud/internal/domain/services"
+	"photocloud/internal/imaging"
+	"photocloud/internal/infrastructure/mongodb"
+	s3repo "photocloud/internal/infrastructure/s3"
+	"photocloud/internal/workers"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Container holds the repositories and services shared by the HTTP server and background workers
+type Container struct {
+	PhotoRepo    repositories.PhotoRepository
+	StorageRepo  repositories.StorageRepository
+	OpRepo       repositories.PendingOperationRepository
+	UserRepo     repositories.UserRepository
+	AlbumRepo    repositories.AlbumRepository
+	ShareRepo    repositories.ShareLinkRepository
+	InviteRepo   repositories.AlbumInvitationRepository
+	UsageRepo    repositories.UsageRepository
+	ActivityRepo repositories.UserActivityRepository
+	AuditRepo    repositories.AuditCheckpointRepository
+	RollupRepo   repositories.ActivityRollupRepository
+	EventRepo    repositories.EventRepository
+	WebhookRepo  repositories.WebhookRepository
+	DeliveryRepo repositories.WebhookDeliveryRepository
+	SyncRepo     repositories.SyncChangeRepository
+	BulkJobRepo  repositories.BulkJobRepository
+	ExportRepo   repositories.ExportJobRepository
+	ImportRepo   repositories.ImportJobRepository
+	// ImportFileRepo keeps the outcome of each file of an import job
+	ImportFileRepo repositories.ImportFileRepository
+
+	PhotoService          services.PhotoService
+	UserService           services.UserService
+	AlbumService          services.AlbumService
+	ShareService          services.ShareService
+	UsageService          services.UsageService
+	ActivityService       services.ActivityService
+	AuditService          services.AuditService
+	RetentionService      services.ActivityRetentionService
+	ReconciliationService services.ReconciliationService
+	TransformService      services.TransformService
+	EventService          services.EventService
+	WebhookService        services.WebhookService
+	SyncService           services.SyncService
+	BulkService           services.BulkService
+	ExportService         services.ExportService
+	ImportService         services.ImportService
+
+	// ActivityWriter writes recorded user activity; it must be started before use
+	ActivityWriter *workers.ActivityWriter
+	// EventRelay shares events with other instances when configured; it must be started before use
+	EventRelay *workers.EventRelay
+	// WebhookDispatcher stores webhook deliveries of events and, once started, attempts them
+	WebhookDispatcher *workers.WebhookDispatcher
+	// SyncRecorder records changes for sync clients
+	SyncRecorder *workers.SyncRecorder
+
+	db *mongo.Database
+}
+
+// NewContainer wires repositories and services from the database and storage clients
+func NewContainer(mongoClient *mongo.Client, s3Client *s3.Client) *Container {
+	// Initialize repositories
+	db := config.GetDatabase(mongoClient)
+	photoRepo := mongodb.NewPhotoRepository(db)
+	storageRepo := s3repo.NewStorageRepository(s3Client, config.GetBucketName())
+	opRepo := mongodb.NewPendingOperationRepository(db)
+	userRepo := mongodb.NewUserRepository(db)
+	albumRepo := mongodb.NewAlbumRepository(db)
+	shareRepo := mongodb.NewShareLinkRepository(db)
+	inviteRepo := mongodb.NewAlbumInvitationRepository(db)
+	usageRepo := mongodb.NewUsageRepository(db)
+	activityRepo := mongodb.NewUserActivityRepository(db)
+	auditRepo := mongodb.NewAuditCheckpointRepository(db)
+	rollupRepo := mongodb.NewActivityRollupRepository(db)
+	eventRepo := mongodb.NewEventRepository(db)
+	webhookRepo := mongodb.NewWebhookRepository(db)
+	deliveryRepo := mongodb.NewWebhookDeliveryRepository(db)
+	syncRepo := mongodb.NewSyncChangeRepository(db)
+	bulkJobRepo := mongodb.NewBulkJobRepository(db)
+	exportRepo := mongodb.NewExportJobRepository(db)
+	importRepo := mongodb.NewImportJobRepository(db)
+	importFileRepo := mongodb.NewImportFileRepository(db)
+	ticketRepo := mongodb.NewStreamTicketRepository(db)
+	retention := config.GetActivityRetention()
+	auditService := services.NewAuditService(activityRepo, auditRepo, config.GetAuditConfig().SigningKey, retention)
+	activityWriter := workers.NewActivityWriter(auditService, config.GetActivityBufferSize(), config.GetActivityEnqueueWait())
+
+	// Events are delivered by this instance alone unless they are shared through the database
+	eventConfig := config.GetEventConfig()
+	eventService := services.NewEventService()
+	var streamPublisher services.EventPublisher = eventService
+	var eventRelay *workers.EventRelay
+	if eventConfig.Shared {
+		eventRelay = workers.NewEventRelay(eventRepo, eventService, eventConfig.BufferSize)
+		streamPublisher = eventRelay
+	}
+	// Webhook deliveries are created by the instance an event happens on
+	webhookConfig := config.GetWebhookConfig()
+	webhookService := services.NewWebhookService(webhookRepo, deliveryRepo, webhookConfig)
+	webhookDispatcher := workers.NewWebhookDispatcher(webhookService, webhookConfig.Concurrency, webhookConfig.PollInterval)
+	// Sync change feeds are recorded by the instance an event happens on
+	syncService := services.NewSyncService(syncRepo, photoRepo, albumRepo)
+	syncRecorder := workers.NewSyncRecorder(syncService)
+	eventPublisher := services.NewEventFanout(streamPublisher, webhookDispatcher, syncRecorder)
+
+	// Initialize services
+	quotas := config.GetQuotaConfig()
+	photoService := services.NewPhotoService(photoRepo, albumRepo, userRepo, usageRepo, storageRepo, opRepo, quotas)
+	photoService = services.NewEventPhotoService(photoService, photoRepo, albumRepo, eventPublisher)
+	photoService = services.NewActivityPhotoService(photoService, activityWriter)
+	userService := services.NewUserService(userRepo, photoRepo, usageRepo, ticketRepo, config.GetStreamTicketLifetime(), config.GetAuthRequired())
+	usageService := services.NewUsageService(usageRepo, photoRepo, userRepo, storageRepo, quotas)
+	activityService := services.NewActivityService(activityRepo, photoRepo, albumRepo, userRepo)
+	retentionService := services.NewActivityRetentionService(activityRepo, rollupRepo, storageRepo, auditService, retention)
+	albumService := services.NewAlbumService(albumRepo, photoRepo, userRepo, inviteRepo)
+	albumService = services.NewEventAlbumService(albumService, photoRepo, albumRepo, eventPublisher)
+	reconciliationService := services.NewReconciliationService(photoRepo, storageRepo, config.GetPendingOperationTimeout())
+	transformConfig := config.GetTransformConfig()
+	registerEncoders(transformConfig)
+	transformService := services.NewTransformService(photoRepo, albumRepo, usageRepo, storageRepo, photoService, transformConfig)
+	transformService = services.NewActivityTransformService(transformService, activityWriter)
+	bulkService := services.NewBulkService(bulkJobRepo, photoRepo, albumRepo, userRepo, photoService, albumService)
+	exportService := services.NewExportService(exportRepo, photoRepo, albumRepo, userRepo, shareRepo, activityRepo, storageRepo, transformService, eventPublisher, config.GetExportConfig(), transformConfig.MaxDimension)
+	importService := services.NewImportService(importRepo, importFileRepo, photoRepo, userRepo, storageRepo, photoService, albumService, config.GetImportConfig())
+	shareService := services.NewShareService(shareRepo, photoRepo, albumRepo, storageRepo, transformService, config.GetShareURLExpiry())
+
+	return &Container{
+		PhotoRepo:             photoRepo,
+		StorageRepo:           storageRepo,
+		OpRepo:                opRepo,
+		UserRepo:              userRepo,
+		AlbumRepo:             albumRepo,
+		ShareRepo:             shareRepo,
+		InviteRepo:            inviteRepo,
+		UsageRepo:             usageRepo,
+		ActivityRepo:          activityRepo,
+		AuditRepo:             auditRepo,
+		RollupRepo:            rollupRepo,
+		EventRepo:             eventRepo,
+		WebhookRepo:           webhookRepo,
+		DeliveryRepo:          deliveryRepo,
+		SyncRepo:              syncRepo,
+		BulkJobRepo:           bulkJobRepo,
+		ExportRepo:            exportRepo,
+		ImportRepo:            importRepo,
+		ImportFileRepo:        importFileRepo,
+		PhotoService:          photoService,
+		UserService:           userService,
+		AlbumService:          albumService,
+		ShareService:          shareService,
+		UsageService:          usageService,
+		ActivityService:       activityService,
+		AuditService:          auditService,
+		RetentionService:      retentionService,
+		ReconciliationService: reconciliationService,
+		TransformService:      transformService,
+		EventService:          eventService,
+		WebhookService:        webhookService,
+		SyncService:           syncService,
+		BulkService:           bulkService,
+		ExportService:         exportService,
+		ImportService:         importService,
+		ActivityWriter:        activityWriter,
+		EventRelay:            eventRelay,
+		WebhookDispatcher:     webhookDispatcher,
+		SyncRecorder:          syncRecorder,
+		db:                    db,
+	}
+}
+
+// EnsureIndexes creates the database indexes the repositories rely on. A TTL index
+// that expired user activity is removed, since it would delete audit chain entries.
+func (c *Container) EnsureIndexes(ctx context.Context) error {
+	if err := mongodb.EnsureIndexes(ctx, c.db); err != nil {
+		return err
+	}
+	return c.ActivityRepo.SetExpiry(ctx, 0)
+}
+
+// registerEncoders enables the optional external encoders named in the configuration
+func registerEncoders(cfg config.TransformConfig) {
+	if cfg.WebPEncoderCommand != "" {
+		imaging.RegisterEncoder(imaging.FormatWebP, imaging.CommandEncoder(cfg.WebPEncoderCommand, cfg.EncoderTimeout))
+	}
+	if cfg.AVIFEncoderCommand != "" {
+		imaging.RegisterEncoder(imaging.FormatAVIF, imaging.CommandEncoder(cfg.AVIFEncoderCommand, cfg.EncoderTimeout))
+	}
+}
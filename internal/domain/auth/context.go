@@ -0,0 +1,48 @@
+// Package auth carries the authenticated user through request contexts.
+package auth
+
+import (
+	"context"
+
+	"photocloud/internal/domain/models"
+)
+
+type userKey struct{}
+
+type systemKey struct{}
+
+// WithUser returns a context carrying the authenticated user
+func WithUser(ctx context.Context, user *models.User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFromContext returns the authenticated user, if any. Contexts without a
+// user belong to the system itself, such as background workers and the CLI.
+func UserFromContext(ctx context.Context) (*models.User, bool) {
+	user, ok := ctx.Value(userKey{}).(*models.User)
+	return user, ok && user != nil
+}
+
+// WithSystem returns a context acting for the system itself, such as a background
+// worker or an admin command, rather than for a user. Checks that fail closed for
+// contexts without a user only pass such contexts.
+func WithSystem(ctx context.Context) context.Context {
+	return context.WithValue(ctx, systemKey{}, true)
+}
+
+// IsSystem reports whether the context acts for the system and carries no user
+func IsSystem(ctx context.Context) bool {
+	if _, ok := UserFromContext(ctx); ok {
+		return false
+	}
+	system, _ := ctx.Value(systemKey{}).(bool)
+	return system
+}
+
+// UserID returns the ID of the authenticated user as a hex string, or an empty string
+func UserID(ctx context.Context) string {
+	if user, ok := UserFromContext(ctx); ok {
+		return user.ID.Hex()
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+package dto
+
+import "time"
+
+// ActivityResponse represents a recorded user activity
+type ActivityResponse struct {
+	ID        string                 `json:"id"`
+	UserID    string                 `json:"user_id,omitempty"`
+	PhotoID   string                 `json:"photo_id"`
+	Type      string                 `json:"type"`
+	Timestamp time.Time              `json:"timestamp"`
+	IP        string                 `json:"ip,omitempty"`
+	UserAgent string                 `json:"user_agent,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// ActivityListResponse represents a page of activities, newest first
+type ActivityListResponse struct {
+	Activities []ActivityResponse `json:"activities"`
+	Page       int                `json:"page"`
+	Limit      int                `json:"limit"`
+}
+
+// DailyActivityResponse represents the number of activities of each type on one day
+type DailyActivityResponse struct {
+	Date   string           `json:"date"`
+	Counts map[string]int64 `json:"counts"`
+	Total  int64            `json:"total"`
+}
+
+// DailyActivityListResponse represents the activity per day over a period
+type DailyActivityListResponse struct {
+	Start    time.Time               `json:"start"`
+	End      time.Time               `json:"end"`
+	Timezone string                  `json:"timezone"`
+	Days     []DailyActivityResponse `json:"days"`
+}
+
+// PhotoActivityCountResponse represents the activity on one photo
+type PhotoActivityCountResponse struct {
+	PhotoID        string    `json:"photo_id"`
+	Count          int64     `json:"count"`
+	Users          int64     `json:"users"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+}
+
+// TopPhotosResponse represents the most active photos over a period
+type TopPhotosResponse struct {
+	Start  time.Time                    `json:"start"`
+	End    time.Time                    `json:"end"`
+	Photos []PhotoActivityCountResponse `json:"photos"`
+}
+
+// ActiveUserResponse represents the activity of one user
+type ActiveUserResponse struct {
+	UserID          string    `json:"user_id"`
+	Username        string    `json:"username,omitempty"`
+	Count           int64     `json:"count"`
+	Photos          int64     `json:"photos"`
+	FirstActivityAt time.Time `json:"first_activity_at"`
+	LastActivityAt  time.Time `json:"last_activity_at"`
+}
+
+// ActiveUsersResponse represents the most active users over a period
+type ActiveUsersResponse struct {
+	Start time.Time            `json:"start"`
+	End   time.Time            `json:"end"`
+	Users []ActiveUserResponse `json:"users"`
+}
@@ -0,0 +1,7 @@
+package dto
+
+// ReconcileRequest represents the request data for a storage reconciliation run
+type ReconcileRequest struct {
+	// Action is one of "report" (default), "quarantine" or "delete"
+	Action string `json:"action"`
+}
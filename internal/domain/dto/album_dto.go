@@ -0,0 +1,87 @@
+package dto
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreateAlbumRequest represents the request data for creating an album
+type CreateAlbumRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+// UpdateAlbumRequest represents the request data for changing an album; omitted fields are left unchanged
+type UpdateAlbumRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+}
+
+// AlbumPhotosRequest represents a set of photos to add to or remove from an album
+type AlbumPhotosRequest struct {
+	PhotoIDs []string `json:"photo_ids" binding:"required"`
+}
+
+// AlbumResponse represents the response data for album operations
+type AlbumResponse struct {
+	ID          primitive.ObjectID    `json:"id"`
+	OwnerID     string                `json:"owner_id"`
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Members     []AlbumMemberResponse `json:"members"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+// AlbumListResponse represents a page of albums
+type AlbumListResponse struct {
+	Albums []AlbumResponse `json:"albums"`
+	Page   int             `json:"page"`
+	Limit  int             `json:"limit"`
+}
+
+// AlbumMemberResponse represents a user an album is shared with
+type AlbumMemberResponse struct {
+	UserID   string    `json:"user_id"`
+	Username string    `json:"username"`
+	Role     string    `json:"role"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+// AlbumMemberListResponse represents the users an album is shared with
+type AlbumMemberListResponse struct {
+	Members []AlbumMemberResponse `json:"members"`
+}
+
+// UpdateAlbumMemberRequest represents the request data for changing the role of an album member
+type UpdateAlbumMemberRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// InviteAlbumMemberRequest represents the request data for inviting a user to an album.
+// Exactly one of Username and Email must be set.
+type InviteAlbumMemberRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role" binding:"required"`
+}
+
+// AlbumInvitationResponse represents an invitation to an album
+type AlbumInvitationResponse struct {
+	ID          primitive.ObjectID `json:"id"`
+	AlbumID     primitive.ObjectID `json:"album_id"`
+	AlbumName   string             `json:"album_name"`
+	InviterID   string             `json:"inviter_id"`
+	Username    string             `json:"username,omitempty"`
+	Email       string             `json:"email,omitempty"`
+	Role        string             `json:"role"`
+	Status      string             `json:"status"`
+	CreatedAt   time.Time          `json:"created_at"`
+	RespondedAt *time.Time         `json:"responded_at,omitempty"`
+}
+
+// AlbumInvitationListResponse represents a list of album invitations
+type AlbumInvitationListResponse struct {
+	Invitations []AlbumInvitationResponse `json:"invitations"`
+}
@@ -0,0 +1,66 @@
+package dto
+
+import "time"
+
+// PhotoFilterRequest selects the caller's photos by album, tags and upload time
+type PhotoFilterRequest struct {
+	AlbumID string `json:"album_id"`
+	// Tags are the tags photos must all have
+	Tags           []string   `json:"tags"`
+	UploadedAfter  *time.Time `json:"uploaded_after"`
+	UploadedBefore *time.Time `json:"uploaded_before"`
+}
+
+// CreateBulkJobRequest represents the request data for an action on many photos. Exactly
+// one of PhotoIDs and Filter selects the photos.
+type CreateBulkJobRequest struct {
+	Action   string              `json:"action" binding:"required"`
+	PhotoIDs []string            `json:"photo_ids"`
+	Filter   *PhotoFilterRequest `json:"filter"`
+	// AlbumID is the album of add_to_album and remove_from_album
+	AlbumID    string   `json:"album_id"`
+	AddTags    []string `json:"add_tags"`
+	RemoveTags []string `json:"remove_tags"`
+}
+
+// PhotoFilterResponse represents the filter a bulk job selects photos with
+type PhotoFilterResponse struct {
+	AlbumID        string     `json:"album_id,omitempty"`
+	Tags           []string   `json:"tags,omitempty"`
+	UploadedAfter  *time.Time `json:"uploaded_after,omitempty"`
+	UploadedBefore *time.Time `json:"uploaded_before,omitempty"`
+}
+
+// BulkFailureResponse represents a photo a bulk job left alone, and why
+type BulkFailureResponse struct {
+	PhotoID string `json:"photo_id"`
+	Error   string `json:"error"`
+}
+
+// BulkJobResponse represents a bulk job and its progress
+type BulkJobResponse struct {
+	ID         string               `json:"id"`
+	Action     string               `json:"action"`
+	Status     string               `json:"status"`
+	Filter     *PhotoFilterResponse `json:"filter,omitempty"`
+	AlbumID    string               `json:"album_id,omitempty"`
+	AddTags    []string             `json:"add_tags,omitempty"`
+	RemoveTags []string             `json:"remove_tags,omitempty"`
+	Total      int64                `json:"total"`
+	Processed  int64                `json:"processed"`
+	Succeeded  int64                `json:"succeeded"`
+	Failed     int64                `json:"failed"`
+	// Failures describes the first photos that failed
+	Failures   []BulkFailureResponse `json:"failures,omitempty"`
+	Error      string                `json:"error,omitempty"`
+	CreatedAt  time.Time             `json:"created_at"`
+	StartedAt  *time.Time            `json:"started_at,omitempty"`
+	FinishedAt *time.Time            `json:"finished_at,omitempty"`
+}
+
+// BulkJobListResponse represents a page of bulk jobs
+type BulkJobListResponse struct {
+	Jobs  []BulkJobResponse `json:"jobs"`
+	Page  int               `json:"page"`
+	Limit int               `json:"limit"`
+}
@@ -0,0 +1,17 @@
+package dto
+
+import "time"
+
+// EventResponse represents a change to a photo or album, or a finished export, delivered over an event stream
+type EventResponse struct {
+	ID        string     `json:"id"`
+	Type      string     `json:"type"`
+	PhotoID   string     `json:"photo_id,omitempty"`
+	AlbumID   string     `json:"album_id,omitempty"`
+	ExportID  string     `json:"export_id,omitempty"`
+	URL       string     `json:"url,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	ActorID   string     `json:"actor_id,omitempty"`
+	Timestamp time.Time  `json:"timestamp"`
+}
@@ -0,0 +1,67 @@
+package dto
+
+import "time"
+
+// ExportRenditionRequest selects the size and format photos are exported in. Empty
+// fields keep the size and format of the original.
+type ExportRenditionRequest struct {
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	Fit     string `json:"fit"`
+	Format  string `json:"format"`
+	Quality int    `json:"quality"`
+}
+
+// CreateExportRequest represents the request data for a ZIP export. Exactly one of
+// PhotoIDs and AlbumID selects the photos.
+type CreateExportRequest struct {
+	PhotoIDs []string `json:"photo_ids"`
+	AlbumID  string   `json:"album_id"`
+	// Rendition exports resized or converted photos instead of the originals
+	Rendition *ExportRenditionRequest `json:"rendition"`
+	// Manifest adds a manifest.json with the photos' metadata to the archive
+	Manifest bool `json:"manifest"`
+	// Async writes the archive to storage even when it is small enough to stream
+	Async bool `json:"async"`
+}
+
+// ExportRenditionResponse represents the rendition of an export
+type ExportRenditionResponse struct {
+	Width   int    `json:"width,omitempty"`
+	Height  int    `json:"height,omitempty"`
+	Fit     string `json:"fit,omitempty"`
+	Format  string `json:"format,omitempty"`
+	Quality int    `json:"quality,omitempty"`
+}
+
+// ExportJobResponse represents an export job and its progress
+type ExportJobResponse struct {
+	ID        string                   `json:"id"`
+	Kind      string                   `json:"kind"`
+	Name      string                   `json:"name"`
+	AlbumID   string                   `json:"album_id,omitempty"`
+	Rendition *ExportRenditionResponse `json:"rendition,omitempty"`
+	Manifest  bool                     `json:"manifest"`
+	Status    string                   `json:"status"`
+	Total     int64                    `json:"total"`
+	Processed int64                    `json:"processed"`
+	Failed    int64                    `json:"failed"`
+	// Failures describes the first photos left out of the archive
+	Failures []BulkFailureResponse `json:"failures,omitempty"`
+	Error    string                `json:"error,omitempty"`
+	// Size is the size of the archive in bytes, once it has been written
+	Size int64 `json:"size,omitempty"`
+	// URL downloads the archive until it expires
+	URL        string     `json:"url,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// ExportJobListResponse represents a page of export jobs
+type ExportJobListResponse struct {
+	Jobs  []ExportJobResponse `json:"jobs"`
+	Page  int                 `json:"page"`
+	Limit int                 `json:"limit"`
+}
@@ -0,0 +1,54 @@
+package dto
+
+import "time"
+
+// ImportAlbumResponse represents an album created by an import for a folder of its archive
+type ImportAlbumResponse struct {
+	Folder  string `json:"folder"`
+	AlbumID string `json:"album_id"`
+}
+
+// ImportJobResponse represents an import job and its progress
+type ImportJobResponse struct {
+	ID     string `json:"id"`
+	Source string `json:"source"`
+	Name   string `json:"name"`
+	// Size is the size of the archive in bytes
+	Size   int64  `json:"size"`
+	Status string `json:"status"`
+	// Total is the number of files in the archive, not counting sidecars, once the job started
+	Total      int64                 `json:"total"`
+	Processed  int64                 `json:"processed"`
+	Imported   int64                 `json:"imported"`
+	Duplicates int64                 `json:"duplicates"`
+	Skipped    int64                 `json:"skipped"`
+	Failed     int64                 `json:"failed"`
+	Albums     []ImportAlbumResponse `json:"albums,omitempty"`
+	Error      string                `json:"error,omitempty"`
+	CreatedAt  time.Time             `json:"created_at"`
+	StartedAt  *time.Time            `json:"started_at,omitempty"`
+	FinishedAt *time.Time            `json:"finished_at,omitempty"`
+}
+
+// ImportJobListResponse represents a page of import jobs
+type ImportJobListResponse struct {
+	Jobs  []ImportJobResponse `json:"jobs"`
+	Page  int                 `json:"page"`
+	Limit int                 `json:"limit"`
+}
+
+// ImportFileResponse represents the outcome of importing one file of an archive
+type ImportFileResponse struct {
+	Path    string `json:"path"`
+	Status  string `json:"status"`
+	PhotoID string `json:"photo_id,omitempty"`
+	Sidecar string `json:"sidecar,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ImportFileListResponse represents a page of file outcomes of an import job
+type ImportFileListResponse struct {
+	Files []ImportFileResponse `json:"files"`
+	Page  int                  `json:"page"`
+	Limit int                  `json:"limit"`
+}
@@ -4,6 +4,8 @@ import (
 	"mime/multipart"
 	"time"
 
+	"photocloud/internal/domain/models"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -16,12 +18,92 @@ type PhotoUploadRequest struct {
 
 // PhotoResponse represents the response data for photo operations
 type PhotoResponse struct {
-	ID          primitive.ObjectID `json:"id"`
-	Name        string             `json:"name"`
-	Description string             `json:"description"`
-	Size        int64              `json:"size"`
-	ContentType string             `json:"content_type"`
-	URL         string             `json:"url"`
-	UploadedAt  time.Time          `json:"uploaded_at"`
-	UpdatedAt   time.Time          `json:"updated_at"`
+	ID          primitive.ObjectID     `json:"id"`
+	OwnerID     string                 `json:"owner_id,omitempty"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Size        int64                  `json:"size"`
+	ContentType string                 `json:"content_type"`
+	ContentHash string                 `json:"content_hash,omitempty"`
+	Orientation int                    `json:"orientation,omitempty"`
+	Edits       []models.EditOperation `json:"edits,omitempty"`
+	Version     int                    `json:"version,omitempty"`
+	AlbumIDs    []primitive.ObjectID   `json:"album_ids,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+	TakenAt     *time.Time             `json:"taken_at,omitempty"`
+	Location    *models.GeoLocation    `json:"location,omitempty"`
+	URL         string                 `json:"url,omitempty"`
+	UploadedAt  time.Time              `json:"uploaded_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+	DeletedAt   *time.Time             `json:"deleted_at,omitempty"`
+}
+
+// PhotoListResponse represents a page of photos
+type PhotoListResponse struct {
+	Photos []PhotoResponse `json:"photos"`
+	Page   int             `json:"page"`
+	Limit  int             `json:"limit"`
+}
+
+// RotatePhotoRequest represents the request data for rotating or flipping a photo.
+// The rotation is applied first, then the flip.
+type RotatePhotoRequest struct {
+	// Degrees is the clockwise rotation: 0, 90, 180 or 270
+	Degrees int `json:"degrees"`
+	// Flip is "horizontal", "vertical" or empty
+	Flip string `json:"flip"`
+}
+
+// UpdateEditsRequest represents the request data for replacing a photo's edit recipe
+type UpdateEditsRequest struct {
+	// Operations are applied in order on top of the upright original
+	Operations []models.EditOperation `json:"operations"`
+}
+
+// ExportPhotoRequest represents the request data for exporting an edited copy of a photo
+type ExportPhotoRequest struct {
+	// Name of the new photo; defaults to the source name with an "(edited)" suffix
+	Name string `json:"name"`
+}
+
+// PhotoVersionResponse represents one stored version of a photo
+type PhotoVersionResponse struct {
+	Number       int       `json:"number"`
+	Size         int64     `json:"size"`
+	ContentType  string    `json:"content_type"`
+	Hash         string    `json:"hash,omitempty"`
+	Author       string    `json:"author,omitempty"`
+	RevertedFrom int       `json:"reverted_from,omitempty"`
+	Current      bool      `json:"current"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// PhotoVersionListResponse represents the kept versions of a photo, oldest first
+type PhotoVersionListResponse struct {
+	Versions []PhotoVersionResponse `json:"versions"`
+}
+
+// FileDigestRequest identifies a file by the hex SHA-256 of its content and its size
+type FileDigestRequest struct {
+	SHA256 string `json:"sha256" binding:"required"`
+	Size   int64  `json:"size"`
+}
+
+// CheckUploadedRequest represents the request data for checking which files were already uploaded
+type CheckUploadedRequest struct {
+	Files []FileDigestRequest `json:"files" binding:"required"`
+}
+
+// UploadedFileResponse tells whether a file was already uploaded, and as which photo
+type UploadedFileResponse struct {
+	SHA256   string `json:"sha256"`
+	Size     int64  `json:"size"`
+	Uploaded bool   `json:"uploaded"`
+	PhotoID  string `json:"photo_id,omitempty"`
+	Trashed  bool   `json:"trashed,omitempty"`
+}
+
+// CheckUploadedResponse represents the answer for each checked file, in request order
+type CheckUploadedResponse struct {
+	Files []UploadedFileResponse `json:"files"`
 }
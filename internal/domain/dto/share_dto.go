@@ -0,0 +1,74 @@
+package dto
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreateShareRequest represents the request data for creating a share link.
+// Exactly one of PhotoID and AlbumID must be set.
+type CreateShareRequest struct {
+	PhotoID       string     `json:"photo_id"`
+	AlbumID       string     `json:"album_id"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	Password      string     `json:"password"`
+	AllowDownload bool       `json:"allow_download"`
+}
+
+// ShareLinkResponse represents a share link. Token and URL are only returned when the link is created.
+type ShareLinkResponse struct {
+	ID                primitive.ObjectID `json:"id"`
+	TargetType        string             `json:"target_type"`
+	TargetID          primitive.ObjectID `json:"target_id"`
+	AllowDownload     bool               `json:"allow_download"`
+	PasswordProtected bool               `json:"password_protected"`
+	ExpiresAt         *time.Time         `json:"expires_at,omitempty"`
+	ViewCount         int64              `json:"view_count"`
+	LastViewedAt      *time.Time         `json:"last_viewed_at,omitempty"`
+	RevokedAt         *time.Time         `json:"revoked_at,omitempty"`
+	CreatedAt         time.Time          `json:"created_at"`
+	Token             string             `json:"token,omitempty"`
+	URL               string             `json:"url,omitempty"`
+}
+
+// ShareLinkListResponse represents a page of share links
+type ShareLinkListResponse struct {
+	Shares []ShareLinkResponse `json:"shares"`
+	Page   int                 `json:"page"`
+	Limit  int                 `json:"limit"`
+}
+
+// SharedPhotoResponse represents a photo as shown to viewers of a share link
+type SharedPhotoResponse struct {
+	ID          primitive.ObjectID `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	ContentType string             `json:"content_type"`
+	Size        int64              `json:"size"`
+	UploadedAt  time.Time          `json:"uploaded_at"`
+}
+
+// SharedAlbumResponse represents an album as shown to viewers of a share link
+type SharedAlbumResponse struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// SharedContentResponse represents what a share link exposes
+type SharedContentResponse struct {
+	TargetType    string                `json:"target_type"`
+	AllowDownload bool                  `json:"allow_download"`
+	ExpiresAt     *time.Time            `json:"expires_at,omitempty"`
+	Album         *SharedAlbumResponse  `json:"album,omitempty"`
+	Photos        []SharedPhotoResponse `json:"photos"`
+	Page          int                   `json:"page"`
+	Limit         int                   `json:"limit"`
+}
+
+// SharedPhotoURLsResponse represents short-lived storage URLs for a shared photo
+type SharedPhotoURLsResponse struct {
+	ViewURL     string    `json:"view_url"`
+	DownloadURL string    `json:"download_url,omitempty"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
@@ -0,0 +1,19 @@
+package dto
+
+// SyncChangeResponse represents the current state of a photo or album that changed.
+// Deleted changes carry no state: the photo or album is gone or no longer visible.
+type SyncChangeResponse struct {
+	Sequence int64          `json:"seq"`
+	Kind     string         `json:"kind"`
+	ID       string         `json:"id"`
+	Deleted  bool           `json:"deleted"`
+	Photo    *PhotoResponse `json:"photo,omitempty"`
+	Album    *AlbumResponse `json:"album,omitempty"`
+}
+
+// SyncChangesResponse represents a batch of changes and the token to ask for the next one
+type SyncChangesResponse struct {
+	Changes []SyncChangeResponse `json:"changes"`
+	Next    string               `json:"next"`
+	HasMore bool                 `json:"has_more"`
+}
@@ -0,0 +1,28 @@
+package dto
+
+import "time"
+
+// UsageCounterResponse represents the size and number of stored files of one kind
+type UsageCounterResponse struct {
+	Bytes int64 `json:"bytes"`
+	Files int64 `json:"files"`
+}
+
+// QuotaResponse represents the limits of a quota; zero means no limit
+type QuotaResponse struct {
+	MaxBytes  int64 `json:"max_bytes"`
+	MaxPhotos int64 `json:"max_photos"`
+}
+
+// UsageResponse represents a user's storage usage and quota. UsedBytes, the
+// bytes counted against the quota, covers originals and versions only.
+type UsageResponse struct {
+	Plan       string               `json:"plan"`
+	Quota      QuotaResponse        `json:"quota"`
+	UsedBytes  int64                `json:"used_bytes"`
+	Photos     int64                `json:"photos"`
+	Originals  UsageCounterResponse `json:"originals"`
+	Renditions UsageCounterResponse `json:"renditions"`
+	Versions   UsageCounterResponse `json:"versions"`
+	VerifiedAt *time.Time           `json:"verified_at,omitempty"`
+}
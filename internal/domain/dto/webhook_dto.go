@@ -0,0 +1,77 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CreateWebhookRequest represents the request data for registering a webhook
+type CreateWebhookRequest struct {
+	URL string `json:"url" binding:"required"`
+	// Events are the event types to receive; all of them when empty
+	Events      []string `json:"events"`
+	Description string   `json:"description"`
+	// Secret is the key deliveries are signed with; one is generated when it is empty
+	Secret string `json:"secret"`
+}
+
+// UpdateWebhookRequest represents the request data for changing a webhook; omitted fields are left unchanged
+type UpdateWebhookRequest struct {
+	URL         *string   `json:"url"`
+	Events      *[]string `json:"events"`
+	Description *string   `json:"description"`
+	Enabled     *bool     `json:"enabled"`
+}
+
+// WebhookResponse represents a registered webhook
+type WebhookResponse struct {
+	ID                  string     `json:"id"`
+	URL                 string     `json:"url"`
+	Events              []string   `json:"events"`
+	Description         string     `json:"description"`
+	Enabled             bool       `json:"enabled"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	DisabledReason      string     `json:"disabled_reason,omitempty"`
+	// Secret is only returned when the webhook is created
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookListResponse represents a page of webhooks
+type WebhookListResponse struct {
+	Webhooks []WebhookResponse `json:"webhooks"`
+	Page     int               `json:"page"`
+	Limit    int               `json:"limit"`
+}
+
+// WebhookAttemptResponse represents one attempt to post a delivery
+type WebhookAttemptResponse struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Response   string    `json:"response,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+}
+
+// WebhookDeliveryResponse represents an event posted to a webhook
+type WebhookDeliveryResponse struct {
+	ID            string                   `json:"id"`
+	WebhookID     string                   `json:"webhook_id"`
+	EventID       string                   `json:"event_id"`
+	EventType     string                   `json:"event_type"`
+	Status        string                   `json:"status"`
+	Payload       json.RawMessage          `json:"payload"`
+	Attempts      []WebhookAttemptResponse `json:"attempts"`
+	NextAttemptAt *time.Time               `json:"next_attempt_at,omitempty"`
+	RedeliveryOf  string                   `json:"redelivery_of,omitempty"`
+	CreatedAt     time.Time                `json:"created_at"`
+}
+
+// WebhookDeliveryListResponse represents a page of deliveries, newest first
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
+	Page       int                       `json:"page"`
+	Limit      int                       `json:"limit"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ActivityRollup counts the activities of one type by one user on one photo
+// during one UTC day. Expired activity can be added to rollups before it is
+// removed, so that long-term totals survive retention.
+type ActivityRollup struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Date    string             `bson:"date" json:"date"`
+	Type    ActivityType       `bson:"type" json:"type"`
+	UserID  string             `bson:"user_id" json:"user_id"`
+	PhotoID primitive.ObjectID `bson:"photo_id" json:"photo_id"`
+	Count   int64              `bson:"count" json:"count"`
+	FirstAt time.Time          `bson:"first_at" json:"first_at"`
+	LastAt  time.Time          `bson:"last_at" json:"last_at"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AlbumRole string
+
+const (
+	// AlbumRoleViewer can see the album and its photos
+	AlbumRoleViewer AlbumRole = "viewer"
+	// AlbumRoleContributor can also add their own photos and remove them again
+	AlbumRoleContributor AlbumRole = "contributor"
+	// AlbumRoleEditor can also rename the album, remove any photo from it and edit its photos
+	AlbumRoleEditor AlbumRole = "editor"
+)
+
+// IsValid reports whether the role is one of the known album roles
+func (r AlbumRole) IsValid() bool {
+	return r == AlbumRoleViewer || r == AlbumRoleContributor || r == AlbumRoleEditor
+}
+
+type Album struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OwnerID     string             `bson:"owner_id" json:"owner_id"`
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description" json:"description"`
+	Members     []AlbumMember      `bson:"members,omitempty" json:"members,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// AlbumMember is a user the album has been shared with
+type AlbumMember struct {
+	UserID   string    `bson:"user_id" json:"user_id"`
+	Username string    `bson:"username" json:"username"`
+	Role     AlbumRole `bson:"role" json:"role"`
+	AddedAt  time.Time `bson:"added_at" json:"added_at"`
+}
+
+// Member returns the membership of a user, if the album is shared with them
+func (a *Album) Member(userID string) (*AlbumMember, bool) {
+	for i := range a.Members {
+		if a.Members[i].UserID == userID {
+			return &a.Members[i], true
+		}
+	}
+	return nil, false
+}
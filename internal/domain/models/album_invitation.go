@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type InvitationStatus string
+
+const (
+	InvitationStatusPending   InvitationStatus = "pending"
+	InvitationStatusAccepted  InvitationStatus = "accepted"
+	InvitationStatusDeclined  InvitationStatus = "declined"
+	InvitationStatusCancelled InvitationStatus = "cancelled"
+)
+
+// AlbumInvitation offers a user a role on an album. Invitations to a username or
+// email without an account wait until an account with that name or email exists.
+// Username and Email hold what the inviter typed.
+type AlbumInvitation struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AlbumID     primitive.ObjectID `bson:"album_id" json:"album_id"`
+	AlbumName   string             `bson:"album_name" json:"album_name"`
+	InviterID   string             `bson:"inviter_id" json:"inviter_id"`
+	InviteeID   string             `bson:"invitee_id,omitempty" json:"invitee_id,omitempty"`
+	Username    string             `bson:"username,omitempty" json:"username,omitempty"`
+	Email       string             `bson:"email,omitempty" json:"email,omitempty"`
+	Role        AlbumRole          `bson:"role" json:"role"`
+	Status      InvitationStatus   `bson:"status" json:"status"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	RespondedAt *time.Time         `bson:"responded_at,omitempty" json:"responded_at,omitempty"`
+}
+
+// IsFor reports whether the invitation was addressed to the user
+func (i *AlbumInvitation) IsFor(user *User) bool {
+	switch {
+	case i.InviteeID != "":
+		return i.InviteeID == user.ID.Hex()
+	case i.Username != "":
+		return i.Username == user.Username
+	default:
+		return i.Email != "" && i.Email == user.Email
+	}
+}
@@ -0,0 +1,170 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditCheckpoint is a signed statement of the head of the activity hash chain
+// at one point in time. Entries up to Sequence cannot be changed, removed or
+// reordered without breaking the link to the signed hash.
+type AuditCheckpoint struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Sequence  int64              `bson:"seq" json:"seq"`
+	Hash      string             `bson:"hash" json:"hash"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	// PrefixPruned marks a checkpoint made when retention deleted the entries up to
+	// Sequence. The chain may only start right after the newest such checkpoint.
+	PrefixPruned bool `bson:"prefix_pruned,omitempty" json:"prefix_pruned,omitempty"`
+	// KeyID identifies the Ed25519 key the checkpoint was signed with
+	KeyID     string `bson:"key_id" json:"key_id"`
+	Signature []byte `bson:"signature" json:"signature"`
+}
+
+// SignedPayload returns the bytes the checkpoint signature covers
+func (c *AuditCheckpoint) SignedPayload() []byte {
+	return []byte(fmt.Sprintf("photocloud-audit-checkpoint\n%d\n%s\n%s\n%t",
+		c.Sequence, c.Hash, canonicalTime(c.CreatedAt), c.PrefixPruned))
+}
+
+// AuditReport is the outcome of walking the activity hash chain
+type AuditReport struct {
+	// FirstSequence and LastSequence are the oldest and newest entries checked.
+	// Entries before FirstSequence were removed by retention, as a checkpoint records.
+	FirstSequence int64 `json:"first_seq"`
+	LastSequence  int64 `json:"last_seq"`
+	Entries       int64 `json:"entries"`
+	// Pruned is the number of entries whose content was removed by retention. Only
+	// their links could be checked.
+	Pruned int64 `json:"pruned"`
+	// Checkpoints is the number of checkpoints whose signature and hash were verified.
+	// Checkpoints signed with another key, or checked without a key, are Unverified.
+	Checkpoints int `json:"checkpoints"`
+	Unverified  int `json:"unverified_checkpoints"`
+	// KeyID identifies the key signatures were checked with, if any
+	KeyID string `json:"key_id,omitempty"`
+	// Broken is the first broken link, or nil when the chain is intact
+	Broken *AuditBreak `json:"broken,omitempty"`
+}
+
+// NoteBreak records a broken link unless an earlier one was already found
+func (r *AuditReport) NoteBreak(sequence int64, activityID, reason string) {
+	if r.Broken == nil || sequence < r.Broken.Sequence {
+		r.Broken = &AuditBreak{Sequence: sequence, ActivityID: activityID, Reason: reason}
+	}
+}
+
+// AuditBreak describes where the activity hash chain stops being trustworthy
+type AuditBreak struct {
+	Sequence   int64  `json:"seq"`
+	ActivityID string `json:"activity_id,omitempty"`
+	Reason     string `json:"reason"`
+}
+
+// ContentDigest computes the hash of the recorded fields of the activity, which is
+// stored as its content hash. Timestamps are hashed at the millisecond precision
+// the database stores.
+func (a *UserActivity) ContentDigest() (string, error) {
+	// Empty metadata is not stored, so it reads back as nil
+	var metadata interface{}
+	if len(a.Metadata) > 0 {
+		metadata = canonicalValue(a.Metadata)
+	}
+
+	record, err := json.Marshal(struct {
+		ID        string      `json:"id"`
+		UserID    string      `json:"user_id"`
+		PhotoID   string      `json:"photo_id"`
+		Type      string      `json:"type"`
+		Timestamp string      `json:"timestamp"`
+		IP        string      `json:"ip"`
+		UserAgent string      `json:"user_agent"`
+		RequestID string      `json:"request_id"`
+		Metadata  interface{} `json:"metadata"`
+	}{
+		ID:        a.ID.Hex(),
+		UserID:    a.UserID,
+		PhotoID:   a.PhotoID.Hex(),
+		Type:      string(a.Type),
+		Timestamp: canonicalTime(a.Timestamp),
+		IP:        a.IP,
+		UserAgent: a.UserAgent,
+		RequestID: a.RequestID,
+		Metadata:  metadata,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode activity %s for hashing: %w", a.ID.Hex(), err)
+	}
+
+	sum := sha256.Sum256(record)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ChainHash computes the hash linking the activity to the previous entry of the
+// chain. It covers the sequence number, the previous hash, the type, the timestamp
+// and the content hash, which pruned entries keep, so the link can be checked
+// without the rest of the content and the type and time of a pruned entry cannot
+// be changed to make it look expired.
+func (a *UserActivity) ChainHash() string {
+	record, _ := json.Marshal(struct {
+		Sequence    int64  `json:"seq"`
+		PrevHash    string `json:"prev_hash"`
+		Type        string `json:"type"`
+		Timestamp   string `json:"timestamp"`
+		ContentHash string `json:"content_hash"`
+	}{
+		Sequence:    a.Sequence,
+		PrevHash:    a.PrevHash,
+		Type:        string(a.Type),
+		Timestamp:   canonicalTime(a.Timestamp),
+		ContentHash: a.ContentHash,
+	})
+
+	sum := sha256.Sum256(record)
+	return hex.EncodeToString(sum[:])
+}
+
+func canonicalTime(t time.Time) string {
+	return t.UTC().Truncate(time.Millisecond).Format(time.RFC3339Nano)
+}
+
+// canonicalValue converts metadata to the same form whether it was just
+// recorded or read back from the database, where nested documents and times
+// decode to driver types. Maps are encoded with sorted keys.
+func canonicalValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[key] = canonicalValue(item)
+		}
+		return converted
+	case primitive.M:
+		return canonicalValue(map[string]interface{}(v))
+	case primitive.D:
+		converted := make(map[string]interface{}, len(v))
+		for _, element := range v {
+			converted[element.Key] = canonicalValue(element.Value)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			converted[i] = canonicalValue(item)
+		}
+		return converted
+	case primitive.A:
+		return canonicalValue([]interface{}(v))
+	case time.Time:
+		return canonicalTime(v)
+	case primitive.DateTime:
+		return canonicalTime(v.Time())
+	default:
+		return v
+	}
+}
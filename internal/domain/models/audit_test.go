@@ -0,0 +1,95 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestChainHash(t *testing.T) {
+	base := UserActivity{
+		Sequence:    1,
+		Type:        ActivityTypeUpload,
+		Timestamp:   time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		ContentHash: "abc",
+	}
+	// sha256 of {"seq":1,"prev_hash":"","type":"upload","timestamp":"2024-01-01T12:00:00Z","content_hash":"abc"}
+	const want = "d690b3c0c8b85b592419f58bac3425de8c2017fda74de5b59d462afa6aa3d282"
+
+	tests := []struct {
+		name   string
+		change func(a *UserActivity)
+		same   bool
+	}{
+		{name: "unchanged", change: func(a *UserActivity) {}, same: true},
+		{name: "other time zone", change: func(a *UserActivity) { a.Timestamp = a.Timestamp.In(time.FixedZone("CET", 3600)) }, same: true},
+		{name: "below millisecond precision", change: func(a *UserActivity) { a.Timestamp = a.Timestamp.Add(999 * time.Microsecond) }, same: true},
+		{name: "content of a pruned entry", change: func(a *UserActivity) { a.IP, a.Metadata, a.PrunedAt = "", nil, &a.Timestamp }, same: true},
+		{name: "sequence", change: func(a *UserActivity) { a.Sequence = 2 }},
+		{name: "previous hash", change: func(a *UserActivity) { a.PrevHash = "def" }},
+		{name: "type", change: func(a *UserActivity) { a.Type = ActivityTypeDelete }},
+		{name: "timestamp", change: func(a *UserActivity) { a.Timestamp = a.Timestamp.Add(time.Millisecond) }},
+		{name: "content hash", change: func(a *UserActivity) { a.ContentHash = "abd" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			activity := base
+			activity.IP = "192.0.2.1"
+			tt.change(&activity)
+			if got := activity.ChainHash(); (got == want) != tt.same {
+				t.Errorf("ChainHash() = %s, want same as %s: %v", got, want, tt.same)
+			}
+		})
+	}
+}
+
+func TestContentDigestSurvivesStorage(t *testing.T) {
+	activity := &UserActivity{
+		ID:        primitive.NewObjectID(),
+		UserID:    "user-1",
+		PhotoID:   primitive.NewObjectID(),
+		Type:      ActivityTypeDownload,
+		Timestamp: time.Date(2024, 1, 1, 12, 0, 0, 123456789, time.FixedZone("CET", 3600)),
+		IP:        "192.0.2.1",
+		UserAgent: "curl/8.0",
+		RequestID: "req-1",
+		Metadata: map[string]interface{}{
+			"size":    int64(1024),
+			"expires": time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			"nested":  map[string]interface{}{"b": "2", "a": []interface{}{"x", "y"}},
+		},
+	}
+	want, err := activity.ContentDigest()
+	if err != nil {
+		t.Fatalf("ContentDigest() error = %v", err)
+	}
+
+	data, err := bson.Marshal(activity)
+	if err != nil {
+		t.Fatalf("bson.Marshal() error = %v", err)
+	}
+	var stored UserActivity
+	if err := bson.Unmarshal(data, &stored); err != nil {
+		t.Fatalf("bson.Unmarshal() error = %v", err)
+	}
+	if got, err := stored.ContentDigest(); err != nil || got != want {
+		t.Errorf("ContentDigest() after storage = %s, %v, want %s", got, err, want)
+	}
+
+	stored.Metadata["size"] = int64(2048)
+	if got, _ := stored.ContentDigest(); got == want {
+		t.Errorf("ContentDigest() did not change with the metadata")
+	}
+}
+
+func TestContentDigestEmptyMetadata(t *testing.T) {
+	activity := &UserActivity{ID: primitive.NewObjectID(), Type: ActivityTypeView}
+	withoutMetadata, _ := activity.ContentDigest()
+	activity.Metadata = map[string]interface{}{}
+	if got, _ := activity.ContentDigest(); got != withoutMetadata {
+		t.Errorf("ContentDigest() with empty metadata = %s, want %s", got, withoutMetadata)
+	}
+}
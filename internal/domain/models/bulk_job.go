@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type BulkAction string
+
+const (
+	// BulkActionDelete moves photos to the trash
+	BulkActionDelete BulkAction = "delete"
+	// BulkActionRestore moves photos out of the trash
+	BulkActionRestore BulkAction = "restore"
+	// BulkActionPurge permanently deletes photos in the trash and their stored files
+	BulkActionPurge BulkAction = "purge"
+	// BulkActionTag adds and removes tags
+	BulkActionTag BulkAction = "tag"
+	// BulkActionAddToAlbum adds photos to an album
+	BulkActionAddToAlbum BulkAction = "add_to_album"
+	// BulkActionRemoveFromAlbum removes photos from an album
+	BulkActionRemoveFromAlbum BulkAction = "remove_from_album"
+)
+
+// IsValid reports whether the action is one of the known bulk actions
+func (a BulkAction) IsValid() bool {
+	switch a {
+	case BulkActionDelete, BulkActionRestore, BulkActionPurge, BulkActionTag,
+		BulkActionAddToAlbum, BulkActionRemoveFromAlbum:
+		return true
+	}
+	return false
+}
+
+// OnTrash reports whether the action applies to photos in the trash rather than to active photos
+func (a BulkAction) OnTrash() bool {
+	return a == BulkActionRestore || a == BulkActionPurge
+}
+
+// BulkJobStatus is the status of a bulk job, one of those every kind of job shares
+type BulkJobStatus = JobStatus
+
+const (
+	// BulkJobQueued jobs wait for a worker
+	BulkJobQueued BulkJobStatus = "queued"
+	// BulkJobRunning jobs are being worked on
+	BulkJobRunning BulkJobStatus = "running"
+	// BulkJobCompleted jobs went through every selected photo; some photos may have failed
+	BulkJobCompleted BulkJobStatus = "completed"
+	// BulkJobFailed jobs stopped before the end, for the reason in Error
+	BulkJobFailed BulkJobStatus = "failed"
+)
+
+// PhotoFilter selects the owner's photos a bulk job applies to. Empty fields
+// match every photo; photos in the trash are only matched by actions on the trash.
+type PhotoFilter struct {
+	AlbumID primitive.ObjectID `bson:"album_id,omitempty" json:"album_id,omitempty"`
+	// Tags are the tags photos must all have
+	Tags           []string   `bson:"tags,omitempty" json:"tags,omitempty"`
+	UploadedAfter  *time.Time `bson:"uploaded_after,omitempty" json:"uploaded_after,omitempty"`
+	UploadedBefore *time.Time `bson:"uploaded_before,omitempty" json:"uploaded_before,omitempty"`
+}
+
+// BulkJob applies one action to many photos in the background. The photos are
+// either listed or selected by a filter that is evaluated as the job runs.
+// Photos are worked on in ID order, and Cursor records the last one reached so
+// that an interrupted job resumes where it stopped.
+type BulkJob struct {
+	Job      `bson:",inline"`
+	Action   BulkAction           `bson:"action" json:"action"`
+	PhotoIDs []primitive.ObjectID `bson:"photo_ids,omitempty" json:"photo_ids,omitempty"`
+	Filter   *PhotoFilter         `bson:"filter,omitempty" json:"filter,omitempty"`
+	// AlbumID is the album photos are added to or removed from
+	AlbumID    primitive.ObjectID `bson:"album_id,omitempty" json:"album_id,omitempty"`
+	AddTags    []string           `bson:"add_tags,omitempty" json:"add_tags,omitempty"`
+	RemoveTags []string           `bson:"remove_tags,omitempty" json:"remove_tags,omitempty"`
+
+	// Total is the number of photos selected when the job was created; filters may match
+	// a different number by the time the job runs
+	Total     int64 `bson:"total" json:"total"`
+	Processed int64 `bson:"processed" json:"processed"`
+	Succeeded int64 `bson:"succeeded" json:"succeeded"`
+	Failed    int64 `bson:"failed" json:"failed"`
+	// Failures describes the first photos that failed
+	Failures []BulkFailure      `bson:"failures,omitempty" json:"failures,omitempty"`
+	Cursor   primitive.ObjectID `bson:"cursor,omitempty" json:"-"`
+}
+
+// IsFinished reports whether the job has stopped for good
+func (j *BulkJob) IsFinished() bool {
+	return j.Status == BulkJobCompleted || j.Status == BulkJobFailed
+}
+
+// BulkFailure is why a bulk job left a photo alone
+type BulkFailure struct {
+	PhotoID primitive.ObjectID `bson:"photo_id" json:"photo_id"`
+	Error   string             `bson:"error" json:"error"`
+}
@@ -0,0 +1,26 @@
+package models
+
+type EditOperationType string
+
+const (
+	EditOperationCrop       EditOperationType = "crop"
+	EditOperationStraighten EditOperationType = "straighten"
+	EditOperationBrightness EditOperationType = "brightness"
+	EditOperationContrast   EditOperationType = "contrast"
+	EditOperationSaturation EditOperationType = "saturation"
+	EditOperationGrayscale  EditOperationType = "grayscale"
+	EditOperationSepia      EditOperationType = "sepia"
+)
+
+// EditOperation is one step of a photo's non-destructive edit recipe. Crop
+// rectangles are fractions of the upright image, so recipes do not depend on
+// the size a photo is rendered at.
+type EditOperation struct {
+	Type   EditOperationType `bson:"type" json:"type"`
+	X      float64           `bson:"x,omitempty" json:"x,omitempty"`
+	Y      float64           `bson:"y,omitempty" json:"y,omitempty"`
+	Width  float64           `bson:"width,omitempty" json:"width,omitempty"`
+	Height float64           `bson:"height,omitempty" json:"height,omitempty"`
+	Angle  float64           `bson:"angle,omitempty" json:"angle,omitempty"`
+	Amount float64           `bson:"amount,omitempty" json:"amount,omitempty"`
+}
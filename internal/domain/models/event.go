@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type EventType string
+
+const (
+	// EventTypePhotoCreated is published when a photo is uploaded
+	EventTypePhotoCreated EventType = "photo.created"
+	// EventTypePhotoUpdated is published when a photo is edited, gets a new version, is restored from
+	// the trash or is added to or removed from an album, and to a user who joins an album it is in
+	EventTypePhotoUpdated EventType = "photo.updated"
+	// EventTypePhotoDeleted is published when a photo is moved to the trash or purged, and to the
+	// users who can no longer see it because of a change to an album
+	EventTypePhotoDeleted EventType = "photo.deleted"
+	// EventTypeAlbumChanged is published when an album, its photos or its members change
+	EventTypeAlbumChanged EventType = "album.changed"
+	// EventTypeExportCompleted is published to its owner when an export job wrote its archive
+	EventTypeExportCompleted EventType = "export.completed"
+	// EventTypeExportFailed is published to its owner when an export job gave up
+	EventTypeExportFailed EventType = "export.failed"
+)
+
+// EventTypes lists every event type
+var EventTypes = []EventType{
+	EventTypePhotoCreated,
+	EventTypePhotoUpdated,
+	EventTypePhotoDeleted,
+	EventTypeAlbumChanged,
+	EventTypeExportCompleted,
+	EventTypeExportFailed,
+}
+
+// IsValid reports whether the type is one of the known event types
+func (t EventType) IsValid() bool {
+	for _, known := range EventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Event tells the users who can see a photo or album that it changed, or the
+// owner of an export job that it finished. Events only name what changed;
+// clients fetch the current state themselves.
+type Event struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Type    EventType          `bson:"type" json:"type"`
+	PhotoID primitive.ObjectID `bson:"photo_id,omitempty" json:"photo_id,omitempty"`
+	AlbumID primitive.ObjectID `bson:"album_id,omitempty" json:"album_id,omitempty"`
+	// ExportID is the export job an export event is about
+	ExportID primitive.ObjectID `bson:"export_id,omitempty" json:"export_id,omitempty"`
+	// URL downloads the archive of a completed export until ExpiresAt, so that the owner
+	// needs nothing else to fetch it. Error is why a failed export gave up.
+	URL       string     `bson:"url,omitempty" json:"url,omitempty"`
+	ExpiresAt *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	Error     string     `bson:"error,omitempty" json:"error,omitempty"`
+	// ActorID is the user who made the change; it is empty for changes made by the system
+	ActorID string `bson:"actor_id,omitempty" json:"actor_id,omitempty"`
+	// Recipients are the IDs of the users the event is delivered to
+	Recipients []string  `bson:"recipients" json:"recipients"`
+	Timestamp  time.Time `bson:"timestamp" json:"timestamp"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExportJobStatus is the status of an export job, one of those every kind of job shares or expired
+type ExportJobStatus = JobStatus
+
+const (
+	// ExportJobQueued jobs wait for a worker
+	ExportJobQueued ExportJobStatus = "queued"
+	// ExportJobRunning jobs are writing their archive
+	ExportJobRunning ExportJobStatus = "running"
+	// ExportJobCompleted jobs wrote their archive, which can be downloaded until it expires
+	ExportJobCompleted ExportJobStatus = "completed"
+	// ExportJobFailed jobs stopped without an archive, for the reason in Error
+	ExportJobFailed ExportJobStatus = "failed"
+	// ExportJobExpired jobs had their archive deleted once it expired
+	ExportJobExpired ExportJobStatus = "expired"
+)
+
+type ExportKind string
+
+const (
+	// ExportKindPhotos jobs archive a selection of photos or an album
+	ExportKindPhotos ExportKind = "photos"
+	// ExportKindAccount jobs archive all the data of their owner's account
+	ExportKindAccount ExportKind = "account"
+)
+
+// ExportRendition selects the size and format photos are exported in instead of
+// their originals. Zero values keep the size and format of the original.
+type ExportRendition struct {
+	Width   int    `bson:"width,omitempty" json:"width,omitempty"`
+	Height  int    `bson:"height,omitempty" json:"height,omitempty"`
+	Fit     string `bson:"fit,omitempty" json:"fit,omitempty"`
+	Format  string `bson:"format,omitempty" json:"format,omitempty"`
+	Quality int    `bson:"quality,omitempty" json:"quality,omitempty"`
+}
+
+// ExportJob writes a ZIP archive to storage in the background, either of photos
+// in an export too large to stream or of a whole account. The photos of a photo
+// export are resolved when the job is created; archives are written from the
+// start again when a job is interrupted.
+type ExportJob struct {
+	Job `bson:",inline"`
+	// Kind is what the job archives; jobs without one are photo exports
+	Kind ExportKind `bson:"kind,omitempty" json:"kind"`
+	// Name is the file name of the archive
+	Name string `bson:"name" json:"name"`
+	// AlbumID is the album that was exported, if the photos were not selected one by one
+	AlbumID   primitive.ObjectID   `bson:"album_id,omitempty" json:"album_id,omitempty"`
+	PhotoIDs  []primitive.ObjectID `bson:"photo_ids,omitempty" json:"-"`
+	Rendition *ExportRendition     `bson:"rendition,omitempty" json:"rendition,omitempty"`
+	Manifest  bool                 `bson:"manifest" json:"manifest"`
+
+	Total int64 `bson:"total" json:"total"`
+	// Processed counts the photos reached so far, and Failed those whose file was left out of the archive
+	Processed int64 `bson:"processed" json:"processed"`
+	Failed    int64 `bson:"failed" json:"failed"`
+	// Failures describes the first photos left out
+	Failures []BulkFailure `bson:"failures,omitempty" json:"failures,omitempty"`
+	// StorageKey is where the archive is stored until it expires
+	StorageKey string `bson:"storage_key,omitempty" json:"-"`
+	Size       int64  `bson:"size,omitempty" json:"size,omitempty"`
+	// ExpiresAt is when the archive of a completed job is deleted
+	ExpiresAt *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+}
+
+// IsAccountExport reports whether the job archives a whole account
+func (j *ExportJob) IsAccountExport() bool {
+	return j.Kind == ExportKindAccount
+}
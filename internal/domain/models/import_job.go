@@ -0,0 +1,118 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ImportSource string
+
+const (
+	// ImportSourceGoogleTakeout archives come from Google Takeout, with a JSON sidecar per photo
+	ImportSourceGoogleTakeout ImportSource = "google_takeout"
+	// ImportSourceApplePhotos archives are exported from Apple Photos, with an XMP sidecar per photo
+	ImportSourceApplePhotos ImportSource = "apple_photos"
+)
+
+// IsValid reports whether the source is one of the known import sources
+func (s ImportSource) IsValid() bool {
+	return s == ImportSourceGoogleTakeout || s == ImportSourceApplePhotos
+}
+
+// ImportJobStatus is the status of an import job, one of those every kind of job shares
+type ImportJobStatus = JobStatus
+
+const (
+	// ImportJobQueued jobs wait for a worker
+	ImportJobQueued ImportJobStatus = "queued"
+	// ImportJobRunning jobs are importing the files of their archive
+	ImportJobRunning ImportJobStatus = "running"
+	// ImportJobCompleted jobs went through every file of their archive
+	ImportJobCompleted ImportJobStatus = "completed"
+	// ImportJobFailed jobs stopped before the end of their archive, for the reason in Error
+	ImportJobFailed ImportJobStatus = "failed"
+)
+
+// ImportCounts tallies the outcomes of the files of an import
+type ImportCounts struct {
+	// Total is the number of files found, not counting sidecars
+	Total      int64 `bson:"total" json:"total"`
+	Processed  int64 `bson:"processed" json:"processed"`
+	Imported   int64 `bson:"imported" json:"imported"`
+	Duplicates int64 `bson:"duplicates" json:"duplicates"`
+	Skipped    int64 `bson:"skipped" json:"skipped"`
+	Failed     int64 `bson:"failed" json:"failed"`
+}
+
+// Add counts the outcome of one more file
+func (c *ImportCounts) Add(status ImportFileStatus) {
+	c.Processed++
+	switch status {
+	case ImportFileImported:
+		c.Imported++
+	case ImportFileDuplicate:
+		c.Duplicates++
+	case ImportFileSkipped:
+		c.Skipped++
+	case ImportFileFailed:
+		c.Failed++
+	}
+}
+
+// ImportAlbum is an album created by an import for a folder of its archive
+type ImportAlbum struct {
+	Folder  string             `bson:"folder" json:"folder"`
+	AlbumID primitive.ObjectID `bson:"album_id" json:"album_id"`
+}
+
+// ImportJob imports the photos of an archive exported from another photo library
+// in the background. The outcome of each file is kept as an ImportFile; interrupted
+// jobs continue with the files that have none.
+type ImportJob struct {
+	Job    `bson:",inline"`
+	Source ImportSource `bson:"source" json:"source"`
+	// Name is the file name the archive was uploaded with
+	Name string `bson:"name" json:"name"`
+	// StorageKey is where the archive is stored until the job finishes
+	StorageKey string `bson:"storage_key,omitempty" json:"-"`
+	Size       int64  `bson:"size" json:"size"`
+
+	ImportCounts `bson:",inline"`
+	// Albums are the albums created so far, so that an interrupted job reuses them
+	Albums []ImportAlbum `bson:"albums,omitempty" json:"albums,omitempty"`
+}
+
+type ImportFileStatus string
+
+const (
+	// ImportFileImported files were uploaded as a new photo
+	ImportFileImported ImportFileStatus = "imported"
+	// ImportFileDuplicate files were already stored as a photo of the owner, which is kept as it is
+	ImportFileDuplicate ImportFileStatus = "duplicate"
+	// ImportFileSkipped files are not photos that can be uploaded, such as videos
+	ImportFileSkipped ImportFileStatus = "skipped"
+	// ImportFileFailed files could not be imported, for the reason in Error
+	ImportFileFailed ImportFileStatus = "failed"
+)
+
+// IsValid reports whether the status is one of the known file outcomes
+func (s ImportFileStatus) IsValid() bool {
+	return s == ImportFileImported || s == ImportFileDuplicate || s == ImportFileSkipped || s == ImportFileFailed
+}
+
+// ImportFile is the outcome of importing one file of an archive
+type ImportFile struct {
+	ID    primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	JobID primitive.ObjectID `bson:"job_id" json:"job_id"`
+	// Path is the path of the file inside the archive
+	Path   string           `bson:"path" json:"path"`
+	Status ImportFileStatus `bson:"status" json:"status"`
+	// PhotoID is the photo the file was imported as, or the photo it duplicates
+	PhotoID primitive.ObjectID `bson:"photo_id,omitempty" json:"photo_id,omitempty"`
+	// Sidecar is the path of the metadata file matched to the file, if any
+	Sidecar string `bson:"sidecar,omitempty" json:"sidecar,omitempty"`
+	// Error is why the file failed or was skipped, or why an imported file's sidecar could not be read
+	Error     string    `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
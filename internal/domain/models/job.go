@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type JobStatus string
+
+const (
+	// JobQueued jobs wait for a worker
+	JobQueued JobStatus = "queued"
+	// JobRunning jobs are being worked on
+	JobRunning JobStatus = "running"
+	// JobCompleted jobs did all their work
+	JobCompleted JobStatus = "completed"
+	// JobFailed jobs stopped before the end, for the reason in Error
+	JobFailed JobStatus = "failed"
+)
+
+// Job is what background jobs of every kind share: who they run for and how far they
+// are through the queue. Workers claim a job for a lease that they renew while they save
+// progress, and a job whose lease ran out is picked up again by the next worker.
+type Job struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OwnerID string             `bson:"owner_id" json:"owner_id"`
+	Status  JobStatus          `bson:"status" json:"status"`
+	Error   string             `bson:"error,omitempty" json:"error,omitempty"`
+	// Attempts counts how often a worker picked the job up
+	Attempts int `bson:"attempts" json:"-"`
+	// LeaseUntil is when a running job is given up on by its worker and may be picked up again
+	LeaseUntil *time.Time `bson:"lease_until,omitempty" json:"-"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+	StartedAt  *time.Time `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	FinishedAt *time.Time `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+}
+
+// Base returns the part of a job that every kind shares
+func (j *Job) Base() *Job {
+	return j
+}
+
+// Finish marks the job as completed at now, or as failed with the reason when there is one
+func (j *Job) Finish(reason string, now time.Time) {
+	j.Status = JobCompleted
+	if reason != "" {
+		j.Status = JobFailed
+		j.Error = reason
+	}
+	j.FinishedAt = &now
+	j.LeaseUntil = nil
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OperationType string
+
+const (
+	OperationTypeUpload OperationType = "upload"
+	OperationTypeDelete OperationType = "delete"
+	// OperationTypeUploadVersion stores a new version of an existing photo
+	OperationTypeUploadVersion OperationType = "upload_version"
+	// OperationTypeDeleteVersion removes a previous version of a photo
+	OperationTypeDeleteVersion OperationType = "delete_version"
+)
+
+// PendingOperation records the intent to change a photo in both storage and
+// the database. It is written before either side is touched and removed once
+// both sides agree, so a crash in between can be completed or rolled back.
+type PendingOperation struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Type       OperationType      `bson:"type" json:"type"`
+	PhotoID    primitive.ObjectID `bson:"photo_id" json:"photo_id"`
+	StorageKey string             `bson:"storage_key" json:"storage_key"`
+	// VersionKeys are the files of a deleted photo's previous versions
+	VersionKeys []string  `bson:"version_keys,omitempty" json:"version_keys,omitempty"`
+	Attempts    int       `bson:"attempts" json:"attempts"`
+	LastError   string    `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `bson:"updated_at" json:"updated_at"`
+}
@@ -7,12 +7,99 @@ import (
 )
 
 type Photo struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Name        string             `bson:"name" json:"name"`
-	Description string             `bson:"description" json:"description"`
-	Size        int64              `bson:"size" json:"size"`
-	ContentType string             `bson:"content_type" json:"content_type"`
-	S3Key       string             `bson:"s3_key" json:"s3_key"`
-	UploadedAt  time.Time          `bson:"uploaded_at" json:"uploaded_at"`
-	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+	ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	OwnerID     string               `bson:"owner_id,omitempty" json:"owner_id,omitempty"`
+	Name        string               `bson:"name" json:"name"`
+	Description string               `bson:"description" json:"description"`
+	Size        int64                `bson:"size" json:"size"`
+	ContentType string               `bson:"content_type" json:"content_type"`
+	ContentHash string               `bson:"content_hash,omitempty" json:"content_hash,omitempty"`
+	S3Key       string               `bson:"s3_key" json:"s3_key"`
+	Orientation int                  `bson:"orientation,omitempty" json:"orientation,omitempty"`
+	Edits       []EditOperation      `bson:"edits,omitempty" json:"edits,omitempty"`
+	Version     int                  `bson:"version,omitempty" json:"version,omitempty"`
+	Versions    []PhotoVersion       `bson:"versions,omitempty" json:"versions,omitempty"`
+	AlbumIDs    []primitive.ObjectID `bson:"album_ids,omitempty" json:"album_ids,omitempty"`
+	Tags        []string             `bson:"tags,omitempty" json:"tags,omitempty"`
+	TakenAt     *time.Time           `bson:"taken_at,omitempty" json:"taken_at,omitempty"`
+	Location    *GeoLocation         `bson:"location,omitempty" json:"location,omitempty"`
+	UploadedAt  time.Time            `bson:"uploaded_at" json:"uploaded_at"`
+	UpdatedAt   time.Time            `bson:"updated_at" json:"updated_at"`
+	DeletedAt   *time.Time           `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+}
+
+// GeoLocation is where a photo was taken, in WGS 84 degrees, with the altitude in meters
+type GeoLocation struct {
+	Latitude  float64 `bson:"latitude" json:"latitude"`
+	Longitude float64 `bson:"longitude" json:"longitude"`
+	Altitude  float64 `bson:"altitude,omitempty" json:"altitude,omitempty"`
+}
+
+// InAlbum reports whether the photo has been added to the album
+func (p *Photo) InAlbum(albumID primitive.ObjectID) bool {
+	for _, id := range p.AlbumIDs {
+		if id == albumID {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTrashed reports whether the photo has been moved to the trash
+func (p *Photo) IsTrashed() bool {
+	return p.DeletedAt != nil
+}
+
+// History returns the photo's versions, oldest first. Photos uploaded before
+// versions were tracked report their current file as version 1.
+func (p *Photo) History() []PhotoVersion {
+	if len(p.Versions) > 0 {
+		return p.Versions
+	}
+	return []PhotoVersion{{
+		Number:      1,
+		S3Key:       p.S3Key,
+		Size:        p.Size,
+		ContentType: p.ContentType,
+		Hash:        p.ContentHash,
+		Orientation: p.Orientation,
+		Author:      p.OwnerID,
+		CreatedAt:   p.UploadedAt,
+	}}
+}
+
+// HasContent reports whether the photo's current file or one of its kept versions
+// has the hex SHA-256 hash and the size
+func (p *Photo) HasContent(hash string, size int64) bool {
+	if p.ContentHash == hash && p.Size == size {
+		return true
+	}
+	for _, version := range p.Versions {
+		if version.Hash == hash && version.Size == size {
+			return true
+		}
+	}
+	return false
+}
+
+// FindVersion returns the version with the given number, if it is still kept
+func (p *Photo) FindVersion(number int) (*PhotoVersion, bool) {
+	history := p.History()
+	for i := range history {
+		if history[i].Number == number {
+			return &history[i], true
+		}
+	}
+	return nil, false
+}
+
+// StorageKeys returns the keys of every stored file of the photo, current version first
+func (p *Photo) StorageKeys() []string {
+	keys := []string{p.S3Key}
+	for _, version := range p.Versions {
+		if version.S3Key != p.S3Key {
+			keys = append(keys, version.S3Key)
+		}
+	}
+	return keys
 }
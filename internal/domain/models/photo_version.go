@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// PhotoVersion is one stored revision of a photo's file. The photo's current
+// file is always its latest version. RevertedFrom is set on versions created
+// by reverting, to the number of the version they restored.
+type PhotoVersion struct {
+	Number       int       `bson:"number" json:"number"`
+	S3Key        string    `bson:"s3_key" json:"s3_key"`
+	Size         int64     `bson:"size" json:"size"`
+	ContentType  string    `bson:"content_type" json:"content_type"`
+	Hash         string    `bson:"hash,omitempty" json:"hash,omitempty"`
+	Orientation  int       `bson:"orientation,omitempty" json:"orientation,omitempty"`
+	Author       string    `bson:"author,omitempty" json:"author,omitempty"`
+	RevertedFrom int       `bson:"reverted_from,omitempty" json:"reverted_from,omitempty"`
+	CreatedAt    time.Time `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+type ReconcileAction string
+
+const (
+	// ReconcileActionReport only reports mismatches
+	ReconcileActionReport ReconcileAction = "report"
+	// ReconcileActionQuarantine moves orphan objects under the quarantine prefix
+	ReconcileActionQuarantine ReconcileAction = "quarantine"
+	// ReconcileActionDelete deletes orphan objects
+	ReconcileActionDelete ReconcileAction = "delete"
+)
+
+// OrphanObject is a stored object that no photo record references
+type OrphanObject struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+	// Resolution is the key the object was moved to, "deleted", or empty when it was left in place
+	Resolution string `json:"resolution,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// DanglingRecord is a photo version whose stored object is missing
+type DanglingRecord struct {
+	PhotoID string `json:"photo_id"`
+	Name    string `json:"name"`
+	Key     string `json:"key"`
+	Version int    `json:"version"`
+	Size    int64  `json:"size"`
+	Trashed bool   `json:"trashed"`
+}
+
+// ReconciliationReport summarizes a comparison between storage and the photos collection
+type ReconciliationReport struct {
+	Action         ReconcileAction  `json:"action"`
+	StartedAt      time.Time        `json:"started_at"`
+	FinishedAt     time.Time        `json:"finished_at"`
+	ObjectsScanned int              `json:"objects_scanned"`
+	RecordsScanned int              `json:"records_scanned"`
+	SkippedRecent  int              `json:"skipped_recent"`
+	Orphans        []OrphanObject   `json:"orphans"`
+	OrphanBytes    int64            `json:"orphan_bytes"`
+	Dangling       []DanglingRecord `json:"dangling"`
+	DanglingBytes  int64            `json:"dangling_bytes"`
+}
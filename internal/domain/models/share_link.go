@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ShareTargetType string
+
+const (
+	ShareTargetPhoto ShareTargetType = "photo"
+	ShareTargetAlbum ShareTargetType = "album"
+)
+
+// ShareLink grants anyone holding its token access to a photo or album. Only
+// hashes of the token and the optional password are stored.
+type ShareLink struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OwnerID       string             `bson:"owner_id" json:"owner_id"`
+	TargetType    ShareTargetType    `bson:"target_type" json:"target_type"`
+	TargetID      primitive.ObjectID `bson:"target_id" json:"target_id"`
+	TokenHash     string             `bson:"token_hash" json:"-"`
+	PasswordHash  string             `bson:"password_hash,omitempty" json:"-"`
+	AllowDownload bool               `bson:"allow_download" json:"allow_download"`
+	ExpiresAt     *time.Time         `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	ViewCount     int64              `bson:"view_count" json:"view_count"`
+	LastViewedAt  *time.Time         `bson:"last_viewed_at,omitempty" json:"last_viewed_at,omitempty"`
+	RevokedAt     *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// IsActive reports whether the link can still be opened at the given time
+func (l *ShareLink) IsActive(now time.Time) bool {
+	return l.RevokedAt == nil && (l.ExpiresAt == nil || now.Before(*l.ExpiresAt))
+}
+
+// HasPassword reports whether the link is password protected
+func (l *ShareLink) HasPassword() bool {
+	return l.PasswordHash != ""
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// UsageCounter is the size and number of stored files of one kind
+type UsageCounter struct {
+	Bytes int64 `bson:"bytes" json:"bytes"`
+	Files int64 `bson:"files" json:"files"`
+}
+
+// StorageUsage is what a user stores. Originals are the current files of their
+// photos, versions the files of previous versions and renditions the cached
+// variants produced from their photos. Photos in the trash count until purged.
+type StorageUsage struct {
+	OwnerID    string       `bson:"_id" json:"owner_id"`
+	Photos     int64        `bson:"photos" json:"photos"`
+	Originals  UsageCounter `bson:"originals" json:"originals"`
+	Versions   UsageCounter `bson:"versions" json:"versions"`
+	Renditions UsageCounter `bson:"renditions" json:"renditions"`
+	UpdatedAt  time.Time    `bson:"updated_at" json:"updated_at"`
+	// VerifiedAt is when the counters were last recomputed from the photo records.
+	// Counters that were never verified are recomputed before they are used.
+	VerifiedAt *time.Time `bson:"verified_at,omitempty" json:"verified_at,omitempty"`
+}
+
+// QuotaBytes returns the bytes counted against the quota. Renditions can be
+// produced again at any time, so they are not counted.
+func (u *StorageUsage) QuotaBytes() int64 {
+	return u.Originals.Bytes + u.Versions.Bytes
+}
+
+// Negate returns the counters with their signs flipped, for removing usage
+func (u StorageUsage) Negate() StorageUsage {
+	u.Photos = -u.Photos
+	u.Originals = UsageCounter{Bytes: -u.Originals.Bytes, Files: -u.Originals.Files}
+	u.Versions = UsageCounter{Bytes: -u.Versions.Bytes, Files: -u.Versions.Files}
+	u.Renditions = UsageCounter{Bytes: -u.Renditions.Bytes, Files: -u.Renditions.Files}
+	return u
+}
+
+// Quota limits what a user may store. Zero means no limit.
+type Quota struct {
+	MaxBytes  int64 `bson:"max_bytes" json:"max_bytes"`
+	MaxPhotos int64 `bson:"max_photos" json:"max_photos"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StreamTicket lets a browser open one event stream without putting its API token in
+// the URL. Tickets are short-lived, used up on first use and only stored as a hash.
+type StreamTicket struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"user_id"`
+	TicketHash string             `bson:"ticket_hash" json:"-"`
+	ExpiresAt  time.Time          `bson:"expires_at" json:"expires_at"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SyncKind string
+
+const (
+	SyncKindPhoto SyncKind = "photo"
+	SyncKindAlbum SyncKind = "album"
+)
+
+// SyncChange records that a photo or album changed for one user. Each user's
+// changes are numbered from 1 without gaps, in the order they were recorded,
+// so sync clients can ask for everything after the last number they saw.
+type SyncChange struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    string             `bson:"user_id" json:"user_id"`
+	Sequence  int64              `bson:"seq" json:"seq"`
+	Kind      SyncKind           `bson:"kind" json:"kind"`
+	EntityID  primitive.ObjectID `bson:"entity_id" json:"entity_id"`
+	Timestamp time.Time          `bson:"timestamp" json:"timestamp"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AnonymousUsername names the account that requests without an API token act as while
+// tokens are not required. It owns the photos uploaded before accounts existed.
+const AnonymousUsername = "anonymous"
+
+type User struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Username  string             `bson:"username" json:"username"`
+	Email     string             `bson:"email" json:"email"`
+	TokenHash string             `bson:"token_hash" json:"-"`
+	// Plan names the quota plan of the user; empty means the default plan
+	Plan string `bson:"plan,omitempty" json:"plan,omitempty"`
+	// Quota replaces the plan's quota for this user when set
+	Quota     *Quota    `bson:"quota,omitempty" json:"quota,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
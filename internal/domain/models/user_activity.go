@@ -13,13 +13,79 @@ const (
 	ActivityTypeDownload ActivityType = "download"
 	ActivityTypeDelete   ActivityType = "delete"
 	ActivityTypeView     ActivityType = "view"
+	ActivityTypeIssueURL ActivityType = "issue_url"
+	// ActivityTypeGap records activities lost before they could be written, so
+	// that the audit chain shows where its record is incomplete
+	ActivityTypeGap ActivityType = "gap"
 )
 
+// ActivityTypes lists every activity type
+var ActivityTypes = []ActivityType{
+	ActivityTypeUpload,
+	ActivityTypeDownload,
+	ActivityTypeDelete,
+	ActivityTypeView,
+	ActivityTypeIssueURL,
+	ActivityTypeGap,
+}
+
+// IsValid reports whether the type is one of the known activity types
+func (t ActivityType) IsValid() bool {
+	for _, known := range ActivityTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
 type UserActivity struct {
 	ID        primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
 	UserID    string                 `bson:"user_id" json:"user_id"`
 	PhotoID   primitive.ObjectID     `bson:"photo_id" json:"photo_id"`
 	Type      ActivityType           `bson:"type" json:"type"`
 	Timestamp time.Time              `bson:"timestamp" json:"timestamp"`
+	IP        string                 `bson:"ip,omitempty" json:"ip,omitempty"`
+	UserAgent string                 `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+	RequestID string                 `bson:"request_id,omitempty" json:"request_id,omitempty"`
 	Metadata  map[string]interface{} `bson:"metadata,omitempty" json:"metadata,omitempty"`
+	// Sequence, PrevHash, ContentHash and Hash place the activity in the tamper-evident
+	// audit chain. Activities recorded before the chain existed have none of them.
+	Sequence    int64  `bson:"seq,omitempty" json:"seq,omitempty"`
+	PrevHash    string `bson:"prev_hash,omitempty" json:"prev_hash,omitempty"`
+	ContentHash string `bson:"content_hash,omitempty" json:"content_hash,omitempty"`
+	Hash        string `bson:"hash,omitempty" json:"hash,omitempty"`
+	// PrunedAt is set when retention removed the content of a chain entry. The
+	// entry stays as a stub that keeps its type, timestamp and hashes until no
+	// later entry needs it.
+	PrunedAt *time.Time `bson:"pruned_at,omitempty" json:"pruned_at,omitempty"`
+}
+
+// DailyActivityCount is the number of activities of one type on one calendar day
+type DailyActivityCount struct {
+	// Date is the day as YYYY-MM-DD in the time zone the activities were grouped in
+	Date  string       `bson:"date"`
+	Type  ActivityType `bson:"type"`
+	Count int64        `bson:"count"`
+}
+
+// PhotoActivityCount summarises the activity on one photo
+type PhotoActivityCount struct {
+	PhotoID primitive.ObjectID `bson:"_id"`
+	Count   int64              `bson:"count"`
+	// Users is the number of distinct users behind the activities
+	Users  int64     `bson:"users"`
+	LastAt time.Time `bson:"last_at"`
+}
+
+// UserActivityCount summarises the activity of one user
+type UserActivityCount struct {
+	UserID string `bson:"_id"`
+	// Username is filled in from the user record and is empty for deleted users
+	Username string `bson:"-"`
+	Count    int64  `bson:"count"`
+	// Photos is the number of distinct photos the activities were on
+	Photos  int64     `bson:"photos"`
+	FirstAt time.Time `bson:"first_at"`
+	LastAt  time.Time `bson:"last_at"`
 }
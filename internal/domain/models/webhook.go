@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Webhook is an endpoint that events are posted to. Webhooks registered by a
+// user receive the events delivered to that user; webhooks registered by an
+// administrator have no owner and receive every event.
+type Webhook struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OwnerID string             `bson:"owner_id" json:"owner_id,omitempty"`
+	URL     string             `bson:"url" json:"url"`
+	// Events are the event types posted to the webhook; an empty list means all of them
+	Events      []EventType `bson:"events" json:"events"`
+	Description string      `bson:"description" json:"description"`
+	// Secret is the key deliveries are signed with using HMAC-SHA256
+	Secret string `bson:"secret" json:"-"`
+	// ConsecutiveFailures counts the failed attempts since the last successful one
+	ConsecutiveFailures int `bson:"consecutive_failures" json:"consecutive_failures"`
+	// DisabledAt is set when the webhook was disabled, by its owner or after repeated failures
+	DisabledAt     *time.Time `bson:"disabled_at,omitempty" json:"disabled_at,omitempty"`
+	DisabledReason string     `bson:"disabled_reason,omitempty" json:"disabled_reason,omitempty"`
+	CreatedAt      time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+// Wants reports whether events of the type are posted to the webhook
+func (w *Webhook) Wants(eventType EventType) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, wanted := range w.Events {
+		if wanted == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryPending deliveries are waiting for their next attempt
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliverySucceeded deliveries were accepted by the endpoint
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	// WebhookDeliveryFailed deliveries ran out of attempts or their webhook was disabled
+	WebhookDeliveryFailed WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event posted to one webhook, with every attempt made
+type WebhookDelivery struct {
+	ID        primitive.ObjectID    `bson:"_id,omitempty" json:"id"`
+	WebhookID primitive.ObjectID    `bson:"webhook_id" json:"webhook_id"`
+	EventID   primitive.ObjectID    `bson:"event_id" json:"event_id"`
+	EventType EventType             `bson:"event_type" json:"event_type"`
+	Payload   []byte                `bson:"payload" json:"payload"`
+	Status    WebhookDeliveryStatus `bson:"status" json:"status"`
+	Attempts  []WebhookAttempt      `bson:"attempts,omitempty" json:"attempts,omitempty"`
+	// NextAttemptAt is when a pending delivery is attempted next. A delivery being
+	// attempted has it pushed back, so that other instances leave it alone.
+	NextAttemptAt time.Time `bson:"next_attempt_at" json:"next_attempt_at"`
+	// RedeliveryOf is the delivery this one repeats, when it was redelivered by hand
+	RedeliveryOf *primitive.ObjectID `bson:"redelivery_of,omitempty" json:"redelivery_of,omitempty"`
+	CreatedAt    time.Time           `bson:"created_at" json:"created_at"`
+}
+
+// WebhookAttempt is the outcome of posting a delivery once
+type WebhookAttempt struct {
+	At time.Time `bson:"at" json:"at"`
+	// StatusCode is the HTTP status of the response, or zero when there was none
+	StatusCode int    `bson:"status_code,omitempty" json:"status_code,omitempty"`
+	Error      string `bson:"error,omitempty" json:"error,omitempty"`
+	// Response is the beginning of the response body
+	Response string        `bson:"response,omitempty" json:"response,omitempty"`
+	Duration time.Duration `bson:"duration" json:"duration"`
+}
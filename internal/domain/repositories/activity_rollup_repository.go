@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+
+	"photocloud/internal/domain/models"
+)
+
+// ActivityRollupRepository defines the interface for daily activity aggregates
+type ActivityRollupRepository interface {
+	// Add adds the counts of rollups to the stored rollups of the same day, type, user and
+	// photo, creating those that do not exist yet
+	Add(ctx context.Context, rollups []models.ActivityRollup) error
+}
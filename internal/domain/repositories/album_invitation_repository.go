@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"photocloud/internal/domain/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AlbumInvitationRepository defines the interface for album invitation data operations
+type AlbumInvitationRepository interface {
+	// Create creates a new invitation
+	Create(ctx context.Context, invitation *models.AlbumInvitation) error
+
+	// GetByID retrieves an invitation by its ID
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.AlbumInvitation, error)
+
+	// ListPendingByAlbum retrieves the pending invitations of an album, newest first
+	ListPendingByAlbum(ctx context.Context, albumID primitive.ObjectID) ([]models.AlbumInvitation, error)
+
+	// ListPendingForUser retrieves the pending invitations addressed to a user ID, username or email, newest first
+	ListPendingForUser(ctx context.Context, userID, username, email string) ([]models.AlbumInvitation, error)
+
+	// FindPending retrieves the pending invitation of an album addressed to a user ID, username or email, if any
+	FindPending(ctx context.Context, albumID primitive.ObjectID, userID, username, email string) (*models.AlbumInvitation, error)
+
+	// Respond moves a pending invitation to a final status. It returns mongo.ErrNoDocuments
+	// if the invitation is no longer pending.
+	Respond(ctx context.Context, id primitive.ObjectID, status models.InvitationStatus, respondedAt time.Time) error
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"context"
+
+	"photocloud/internal/domain/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AlbumRepository defines the interface for album data operations
+type AlbumRepository interface {
+	// Create creates a new album
+	Create(ctx context.Context, album *models.Album) error
+
+	// GetByID retrieves an album by its ID
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Album, error)
+
+	// Update updates the name, description and update time of an album; members are left untouched
+	Update(ctx context.Context, album *models.Album) error
+
+	// Delete deletes an album by its ID
+	Delete(ctx context.Context, id primitive.ObjectID) error
+
+	// ListByOwner retrieves the albums of an owner with pagination, newest first
+	ListByOwner(ctx context.Context, ownerID string, page, limit int) ([]models.Album, error)
+
+	// ListByMember retrieves the albums shared with a user with pagination, newest first
+	ListByMember(ctx context.Context, userID string, page, limit int) ([]models.Album, error)
+
+	// ListAccessible retrieves the albums among ids that the user owns or is a member of
+	ListAccessible(ctx context.Context, ids []primitive.ObjectID, userID string) ([]models.Album, error)
+
+	// SetMember adds a member to an album, or changes the role of an existing member
+	SetMember(ctx context.Context, id primitive.ObjectID, member models.AlbumMember) error
+
+	// RemoveMember removes a member from an album
+	RemoveMember(ctx context.Context, id primitive.ObjectID, userID string) error
+}
@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+
+	"photocloud/internal/domain/models"
+)
+
+// AuditCheckpointRepository defines the interface for signed audit chain checkpoints
+type AuditCheckpointRepository interface {
+	// Create stores a checkpoint
+	Create(ctx context.Context, checkpoint *models.AuditCheckpoint) error
+
+	// GetLatest retrieves the checkpoint with the highest sequence number, or nil
+	GetLatest(ctx context.Context) (*models.AuditCheckpoint, error)
+
+	// GetLatestPruned retrieves the checkpoint with the highest sequence number among those
+	// made when retention deleted the beginning of the chain, or nil
+	GetLatestPruned(ctx context.Context) (*models.AuditCheckpoint, error)
+
+	// List retrieves every checkpoint in sequence order
+	List(ctx context.Context) ([]models.AuditCheckpoint, error)
+}
@@ -0,0 +1,30 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"photocloud/internal/domain/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BulkJobRepository defines the interface for bulk job data operations
+type BulkJobRepository interface {
+	// Create creates a new bulk job
+	Create(ctx context.Context, job *models.BulkJob) error
+
+	// GetByID retrieves a bulk job by its ID
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.BulkJob, error)
+
+	// ListByOwner lists the bulk jobs of an owner, newest first
+	ListByOwner(ctx context.Context, ownerID string, page, limit int) ([]models.BulkJob, error)
+
+	// ClaimNext marks the oldest queued job, or a running job whose lease ran out by now, as
+	// running until leaseUntil and counts the attempt. It returns nil when there is none.
+	ClaimNext(ctx context.Context, now, leaseUntil time.Time) (*models.BulkJob, error)
+
+	// SaveProgress stores the status, progress, cursor, lease and times of a job, provided
+	// no other worker claimed it since. It returns mongo.ErrNoDocuments otherwise.
+	SaveProgress(ctx context.Context, job *models.BulkJob) error
+}
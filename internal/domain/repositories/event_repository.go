@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+
+	"photocloud/internal/domain/models"
+)
+
+// EventRepository defines the interface for events shared between API instances
+type EventRepository interface {
+	// Create stores an event so that every watching instance receives it
+	Create(ctx context.Context, event *models.Event) error
+
+	// Watch calls handle with each event stored after the resume token, or from now on when
+	// the token is empty, until the context is cancelled or the stream fails. handle also
+	// receives the token to resume after that event.
+	Watch(ctx context.Context, resumeAfter []byte, handle func(event *models.Event, resumeToken []byte)) error
+}
@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"photocloud/internal/domain/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExportJobRepository defines the interface for export job data operations
+type ExportJobRepository interface {
+	// Create creates a new export job
+	Create(ctx context.Context, job *models.ExportJob) error
+
+	// GetByID retrieves an export job by its ID
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.ExportJob, error)
+
+	// ListByOwner lists the export jobs of an owner, newest first, without their photo lists
+	ListByOwner(ctx context.Context, ownerID string, page, limit int) ([]models.ExportJob, error)
+
+	// GetPending retrieves a queued or running job of the kind of an owner, or nil when there is none
+	GetPending(ctx context.Context, ownerID string, kind models.ExportKind) (*models.ExportJob, error)
+
+	// ClaimNext marks the oldest queued job, or a running job whose lease ran out by now, as
+	// running until leaseUntil and counts the attempt. It returns nil when there is none.
+	ClaimNext(ctx context.Context, now, leaseUntil time.Time) (*models.ExportJob, error)
+
+	// SaveProgress stores the status, total, progress, archive, lease and times of a job, provided
+	// no other worker claimed it since. It returns mongo.ErrNoDocuments otherwise.
+	SaveProgress(ctx context.Context, job *models.ExportJob) error
+
+	// ListExpired retrieves up to limit completed jobs whose archive expired by now
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]models.ExportJob, error)
+}
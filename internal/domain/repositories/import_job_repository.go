@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"photocloud/internal/domain/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ImportJobRepository defines the interface for import job data operations
+type ImportJobRepository interface {
+	// Create creates a new import job
+	Create(ctx context.Context, job *models.ImportJob) error
+
+	// GetByID retrieves an import job by its ID
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.ImportJob, error)
+
+	// ListByOwner lists the import jobs of an owner, newest first
+	ListByOwner(ctx context.Context, ownerID string, page, limit int) ([]models.ImportJob, error)
+
+	// ClaimNext marks the oldest queued job, or a running job whose lease ran out by now, as
+	// running until leaseUntil and counts the attempt. It returns nil when there is none.
+	ClaimNext(ctx context.Context, now, leaseUntil time.Time) (*models.ImportJob, error)
+
+	// SaveProgress stores the status, counts, albums, archive, lease and times of a job, provided
+	// no other worker claimed it since. It returns mongo.ErrNoDocuments otherwise.
+	SaveProgress(ctx context.Context, job *models.ImportJob) error
+}
+
+// ImportFileRepository defines the interface for the file outcomes of import jobs
+type ImportFileRepository interface {
+	// CreateMany records file outcomes. Outcomes already recorded for the same path of the
+	// same job are left as they are.
+	CreateMany(ctx context.Context, files []models.ImportFile) error
+
+	// ListByJob lists the file outcomes of a job in the order they were recorded, only those
+	// with the status unless it is empty
+	ListByJob(ctx context.Context, jobID primitive.ObjectID, status models.ImportFileStatus, page, limit int) ([]models.ImportFile, error)
+
+	// ListPaths retrieves the paths of every file with a recorded outcome of a job
+	ListPaths(ctx context.Context, jobID primitive.ObjectID) ([]string, error)
+
+	// CountByStatus counts the recorded file outcomes of a job by status
+	CountByStatus(ctx context.Context, jobID primitive.ObjectID) (map[models.ImportFileStatus]int64, error)
+}
@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"photocloud/internal/domain/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PendingOperationRepository defines the interface for the pending operation journal
+type PendingOperationRepository interface {
+	// Create records a new pending operation
+	Create(ctx context.Context, op *models.PendingOperation) error
+
+	// Delete removes a finished operation from the journal
+	Delete(ctx context.Context, id primitive.ObjectID) error
+
+	// RecordFailure increments the attempt counter and stores the last error of an operation
+	RecordFailure(ctx context.Context, id primitive.ObjectID, message string) error
+
+	// ListStale retrieves up to limit operations created before the cutoff, oldest first
+	ListStale(ctx context.Context, cutoff time.Time, limit int) ([]models.PendingOperation, error)
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"photocloud/internal/domain/models"
 
@@ -13,18 +14,107 @@ type PhotoRepository interface {
 	// Create creates a new photo record
 	Create(ctx context.Context, photo *models.Photo) error
 
-	// GetByID retrieves a photo by its ID
+	// GetByID retrieves a photo by its ID, including photos in the trash
 	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Photo, error)
 
-	// Update updates an existing photo
-	Update(ctx context.Context, photo *models.Photo) error
+	// GetByIDs retrieves the photos with the given IDs that exist, including photos in the trash
+	GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Photo, error)
 
 	// Delete deletes a photo by its ID
 	Delete(ctx context.Context, id primitive.ObjectID) error
 
-	// List retrieves photos with pagination
-	List(ctx context.Context, page, limit int) ([]models.Photo, error)
+	// List retrieves photos of the owner that are not in the trash with pagination.
+	// An empty owner ID lists photos of every owner.
+	List(ctx context.Context, ownerID string, page, limit int) ([]models.Photo, error)
 
-	// Count returns the total number of photos
+	// Count returns the total number of photos that are not in the trash
 	Count(ctx context.Context) (int64, error)
+
+	// MoveToTrash marks a photo as deleted at the given time
+	MoveToTrash(ctx context.Context, id primitive.ObjectID, deletedAt time.Time) error
+
+	// Restore removes the deleted mark from a photo in the trash
+	Restore(ctx context.Context, id primitive.ObjectID) error
+
+	// MoveManyToTrash marks the photos that are not in the trash yet as deleted at the given
+	// time and returns how many were marked
+	MoveManyToTrash(ctx context.Context, ids []primitive.ObjectID, deletedAt time.Time) (int64, error)
+
+	// RestoreMany removes the deleted mark from the photos that are in the trash and returns
+	// how many were restored
+	RestoreMany(ctx context.Context, ids []primitive.ObjectID, updatedAt time.Time) (int64, error)
+
+	// UpdateTags adds and removes tags on the photos, in one bulk write
+	UpdateTags(ctx context.Context, ids []primitive.ObjectID, add, remove []string, updatedAt time.Time) error
+
+	// ListByFilter retrieves up to limit photos of the owner matching the filter with an ID
+	// after afterID, in ID order. Photos in the trash are matched instead of the others when
+	// trashed is set.
+	ListByFilter(ctx context.Context, ownerID string, filter models.PhotoFilter, trashed bool, afterID primitive.ObjectID, limit int) ([]models.Photo, error)
+
+	// CountByFilter counts the photos of the owner matching the filter, like ListByFilter
+	CountByFilter(ctx context.Context, ownerID string, filter models.PhotoFilter, trashed bool) (int64, error)
+
+	// ListTrashed retrieves photos of the owner in the trash with pagination, most recently
+	// deleted first. An empty owner ID lists photos of every owner.
+	ListTrashed(ctx context.Context, ownerID string, page, limit int) ([]models.Photo, error)
+
+	// ListTrashedBefore retrieves up to limit photos that were moved to the trash before the cutoff
+	ListTrashedBefore(ctx context.Context, cutoff time.Time, limit int) ([]models.Photo, error)
+
+	// SetEdits replaces the edit recipe of a photo, removing it when edits is empty
+	SetEdits(ctx context.Context, id primitive.ObjectID, edits []models.EditOperation, updatedAt time.Time) error
+
+	// SetOrientation stores the orientation and edit recipe of a photo, and the orientation of
+	// its current version, provided the photo was not changed since its updated time was
+	// previousUpdatedAt. It returns mongo.ErrNoDocuments otherwise.
+	SetOrientation(ctx context.Context, photo *models.Photo, previousUpdatedAt time.Time) error
+
+	// AggregateUsage computes the photo count and the original and version counters of the
+	// storage usage of the given owners, or of every owner when none are given. Renditions
+	// are not recorded on photos and are left at zero.
+	AggregateUsage(ctx context.Context, ownerIDs ...string) ([]models.StorageUsage, error)
+
+	// GetOwners retrieves the owner of each of the photos that exist, by photo ID
+	GetOwners(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]string, error)
+
+	// ListStorageKeys retrieves every photo, including photos in the trash, with only
+	// the fields needed to match it against storage (ID, name, size, S3 key, versions, deleted at)
+	ListStorageKeys(ctx context.Context) ([]models.Photo, error)
+
+	// UpdateVersions stores the photo's version list and current file, provided the
+	// stored current version number still equals previousVersion
+	UpdateVersions(ctx context.Context, photo *models.Photo, previousVersion int) error
+
+	// RemoveVersion removes a version other than the current one from a photo and reports
+	// whether it was removed
+	RemoveVersion(ctx context.Context, id primitive.ObjectID, key string) (bool, error)
+
+	// ListWithPrunableVersions retrieves up to limit photos keeping more than keep previous
+	// versions, or previous versions created before the cutoff. A negative keep or a zero
+	// cutoff disables that condition.
+	ListWithPrunableVersions(ctx context.Context, keep int, cutoff time.Time, limit int) ([]models.Photo, error)
+
+	// AddToAlbum adds the photos to an album, skipping photos of other owners unless ownerID
+	// is empty, and returns how many photos were added
+	AddToAlbum(ctx context.Context, albumID primitive.ObjectID, photoIDs []primitive.ObjectID, ownerID string) (int64, error)
+
+	// RemoveFromAlbum removes the photos from an album, skipping photos of other owners unless
+	// ownerID is empty, and returns how many were removed
+	RemoveFromAlbum(ctx context.Context, albumID primitive.ObjectID, photoIDs []primitive.ObjectID, ownerID string) (int64, error)
+
+	// RemoveAlbum removes an album from every photo in it
+	RemoveAlbum(ctx context.Context, albumID primitive.ObjectID) error
+
+	// ListByAlbum retrieves the photos of an album that are not in the trash with pagination, newest first
+	ListByAlbum(ctx context.Context, albumID primitive.ObjectID, page, limit int) ([]models.Photo, error)
+
+	// ListByContentHash retrieves the photos of the owner, including photos in the trash, whose
+	// current file or a kept version has any of the content hashes, with only their content hash,
+	// size, the hash and size of their versions and deleted at
+	ListByContentHash(ctx context.Context, ownerID string, hashes []string) ([]models.Photo, error)
+
+	// ClaimUnowned assigns every photo without an owner, and every photo of fromOwnerID
+	// when it is not empty, to the owner
+	ClaimUnowned(ctx context.Context, ownerID, fromOwnerID string) (int64, error)
 }
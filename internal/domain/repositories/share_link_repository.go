@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"photocloud/internal/domain/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ShareLinkRepository defines the interface for share link data operations
+type ShareLinkRepository interface {
+	// Create creates a new share link
+	Create(ctx context.Context, link *models.ShareLink) error
+
+	// GetByID retrieves a share link by its ID
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.ShareLink, error)
+
+	// GetByTokenHash retrieves the share link with the token hash
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.ShareLink, error)
+
+	// ListByOwner retrieves the share links of an owner with pagination, newest first
+	ListByOwner(ctx context.Context, ownerID string, page, limit int) ([]models.ShareLink, error)
+
+	// Revoke marks a share link as revoked at the given time
+	Revoke(ctx context.Context, id primitive.ObjectID, revokedAt time.Time) error
+
+	// RecordView increments the view counter of a share link
+	RecordView(ctx context.Context, id primitive.ObjectID, viewedAt time.Time) error
+}
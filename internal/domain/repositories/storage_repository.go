@@ -2,20 +2,55 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"io"
+	"time"
 )
 
+// ErrFileNotFound is returned when a key does not exist in storage
+var ErrFileNotFound = errors.New("file not found in storage")
+
+// FileInfo describes a file held in storage
+type FileInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+}
+
+// ByteRange selects an inclusive range of bytes within a file. An End below
+// zero reads through to the end of the file.
+type ByteRange struct {
+	Start int64
+	End   int64
+}
+
 // StorageRepository defines the interface for storage operations (S3)
 type StorageRepository interface {
 	// UploadFile uploads a file to storage and returns the file key
 	UploadFile(ctx context.Context, key string, content io.Reader, contentType string) error
 
-	// DownloadFile downloads a file from storage
-	DownloadFile(ctx context.Context, key string) (io.ReadCloser, error)
+	// UploadStream uploads a file of unknown size as it is read, in parts, without holding the
+	// whole file. Nothing is stored under the key unless the content is read to the end.
+	UploadStream(ctx context.Context, key string, content io.Reader, contentType string) error
+
+	// DownloadFile downloads a file, or only the given byte range of it when byteRange is not nil.
+	// The returned info describes the whole file, not just the range.
+	DownloadFile(ctx context.Context, key string, byteRange *ByteRange) (io.ReadCloser, *FileInfo, error)
+
+	// StatFile retrieves a file's metadata without downloading it
+	StatFile(ctx context.Context, key string) (*FileInfo, error)
 
 	// DeleteFile deletes a file from storage
 	DeleteFile(ctx context.Context, key string) error
 
 	// GetFileURL gets a presigned URL for the file
 	GetFileURL(ctx context.Context, key string, expiryMinutes int) (string, error)
+
+	// ListFiles lists every file whose key starts with the prefix
+	ListFiles(ctx context.Context, prefix string) ([]FileInfo, error)
+
+	// CopyFile copies a file to a new key within storage
+	CopyFile(ctx context.Context, srcKey, dstKey string) error
 }
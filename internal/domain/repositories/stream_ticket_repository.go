@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"photocloud/internal/domain/models"
+)
+
+// StreamTicketRepository defines the interface for event stream ticket data operations
+type StreamTicketRepository interface {
+	// Create stores a new ticket
+	Create(ctx context.Context, ticket *models.StreamTicket) error
+
+	// Consume deletes the ticket with the hash and returns it, or nil if there is none
+	// or it expired before now
+	Consume(ctx context.Context, ticketHash string, now time.Time) (*models.StreamTicket, error)
+}
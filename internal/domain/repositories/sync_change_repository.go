@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+
+	"photocloud/internal/domain/models"
+)
+
+// SyncChangeRepository defines the interface for the per-user change feeds of sync clients
+type SyncChangeRepository interface {
+	// Create stores a change. It fails with a duplicate key error when the user's
+	// sequence number is already taken.
+	Create(ctx context.Context, change *models.SyncChange) error
+
+	// GetLatest retrieves the newest change of a user, or nil if there is none
+	GetLatest(ctx context.Context, userID string) (*models.SyncChange, error)
+
+	// ListAfter lists the changes of a user after a sequence number, oldest first
+	ListAfter(ctx context.Context, userID string, afterSeq int64, limit int) ([]models.SyncChange, error)
+
+	// DeleteUpTo deletes the changes of a user up to and including a sequence number
+	DeleteUpTo(ctx context.Context, userID string, seq int64) error
+}
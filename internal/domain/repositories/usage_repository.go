@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"photocloud/internal/domain/models"
+)
+
+// UsageRepository defines the interface for storage usage data operations
+type UsageRepository interface {
+	// Get retrieves the usage of an owner, or nil if nothing was recorded yet
+	Get(ctx context.Context, ownerID string) (*models.StorageUsage, error)
+
+	// Add adds the counters of delta, which may be negative, to the usage of an owner
+	Add(ctx context.Context, ownerID string, delta models.StorageUsage) error
+
+	// Reserve adds the counters of delta to the usage of an owner in one step, provided the
+	// result stays within the quota. It reports false, changing nothing, if it would not.
+	// The usage must have been recorded before.
+	Reserve(ctx context.Context, ownerID string, delta models.StorageUsage, quota models.Quota) (bool, error)
+
+	// Set replaces the usage of an owner with verified counters
+	Set(ctx context.Context, usage *models.StorageUsage) error
+
+	// List retrieves the usage of every owner
+	List(ctx context.Context) ([]models.StorageUsage, error)
+
+	// Invalidate marks the usage of an owner as unverified, so it is recomputed before its next use
+	Invalidate(ctx context.Context, ownerID string, at time.Time) error
+}
@@ -9,6 +9,23 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ActivityFilter selects the activities that aggregations count. Zero fields match every activity.
+type ActivityFilter struct {
+	// Start and End bound the activity timestamps, both inclusive
+	Start   time.Time
+	End     time.Time
+	Types   []models.ActivityType
+	UserID  string
+	PhotoID primitive.ObjectID
+}
+
+// ActivityExpiry holds the time before which activities expire. Types without an
+// entry of their own use Default; zero times never expire.
+type ActivityExpiry struct {
+	Default time.Time
+	Types   map[models.ActivityType]time.Time
+}
+
 type UserActivityRepository interface {
 	// Create creates a new activity record
 	Create(ctx context.Context, activity *models.UserActivity) error
@@ -19,6 +36,10 @@ type UserActivityRepository interface {
 	// GetUserActivities retrieves activities for a specific user with pagination
 	GetUserActivities(ctx context.Context, userID string, page, limit int) ([]models.UserActivity, error)
 
+	// ListUserActivities retrieves up to limit activities of a user with an ID after afterID,
+	// in ID order, which is the order they were recorded in
+	ListUserActivities(ctx context.Context, userID string, afterID primitive.ObjectID, limit int) ([]models.UserActivity, error)
+
 	// GetPhotoActivities retrieves activities for a specific photo with pagination
 	GetPhotoActivities(ctx context.Context, photoID primitive.ObjectID, page, limit int) ([]models.UserActivity, error)
 
@@ -27,4 +48,38 @@ type UserActivityRepository interface {
 
 	// Count returns the total number of activities
 	Count(ctx context.Context) (int64, error)
+
+	// GetChainHead retrieves the activity with the highest audit chain sequence number, or nil
+	GetChainHead(ctx context.Context) (*models.UserActivity, error)
+
+	// ListChain retrieves up to limit activities of the audit chain after a sequence number, in chain order
+	ListChain(ctx context.Context, afterSequence int64, limit int) ([]models.UserActivity, error)
+
+	// ListExpired retrieves up to limit unpruned activities that have expired, oldest first
+	ListExpired(ctx context.Context, expiry ActivityExpiry, limit int) ([]models.UserActivity, error)
+
+	// Prune removes the content of activities. Entries of the audit chain stay as stubs
+	// that keep their type, timestamp and hashes; activities outside the chain are deleted.
+	Prune(ctx context.Context, ids []primitive.ObjectID, at time.Time) (int64, error)
+
+	// GetFirstUnpruned retrieves the oldest audit chain entry that has not been pruned, or nil
+	GetFirstUnpruned(ctx context.Context) (*models.UserActivity, error)
+
+	// DeletePrunedBefore deletes the pruned audit chain entries before a sequence number
+	DeletePrunedBefore(ctx context.Context, sequence int64) (int64, error)
+
+	// SetExpiry makes the database delete activities older than ttl by itself, or stops
+	// it from doing so when ttl is zero
+	SetExpiry(ctx context.Context, ttl time.Duration) error
+
+	// CountByDay counts the matching activities per calendar day and type, in date order.
+	// Days are taken in the named IANA time zone, or UTC when it is empty.
+	CountByDay(ctx context.Context, filter ActivityFilter, timezone string) ([]models.DailyActivityCount, error)
+
+	// TopPhotos returns the photos with the most matching activities, most active first
+	TopPhotos(ctx context.Context, filter ActivityFilter, limit int) ([]models.PhotoActivityCount, error)
+
+	// ActiveUsers returns the users with the most matching activities, most active first.
+	// Activities without a user, such as those through share links, are left out.
+	ActiveUsers(ctx context.Context, filter ActivityFilter, limit int) ([]models.UserActivityCount, error)
 }
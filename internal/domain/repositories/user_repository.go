@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"context"
+
+	"photocloud/internal/domain/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserRepository defines the interface for user data operations
+type UserRepository interface {
+	// Create creates a new user
+	Create(ctx context.Context, user *models.User) error
+
+	// GetByID retrieves a user by ID
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+
+	// GetByUsername retrieves a user by username
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+
+	// GetByEmail retrieves a user by email address
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+
+	// GetByTokenHash retrieves the user owning an API token hash
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.User, error)
+
+	// SetTokenHash replaces the hash of a user's API token
+	SetTokenHash(ctx context.Context, id primitive.ObjectID, tokenHash string) error
+
+	// SetQuota changes the plan of a user and the quota that replaces the plan's quota; nil removes it
+	SetQuota(ctx context.Context, id primitive.ObjectID, plan string, quota *models.Quota) error
+}
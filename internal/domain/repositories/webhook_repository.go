@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"photocloud/internal/domain/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WebhookRepository defines the interface for webhook data operations
+type WebhookRepository interface {
+	// Create creates a new webhook
+	Create(ctx context.Context, webhook *models.Webhook) error
+
+	// GetByID retrieves a webhook by its ID
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Webhook, error)
+
+	// Update updates the URL, event filter, description, failure count, disabled state and
+	// update time of a webhook
+	Update(ctx context.Context, webhook *models.Webhook) error
+
+	// Delete deletes a webhook
+	Delete(ctx context.Context, id primitive.ObjectID) error
+
+	// ListByOwner lists the webhooks of an owner, newest first; the empty owner lists system webhooks
+	ListByOwner(ctx context.Context, ownerID string, page, limit int) ([]models.Webhook, error)
+
+	// ListForEvent lists the enabled webhooks of the owners that want events of the type
+	ListForEvent(ctx context.Context, eventType models.EventType, ownerIDs []string) ([]models.Webhook, error)
+
+	// RecordSuccess resets the failure count of a webhook
+	RecordSuccess(ctx context.Context, id primitive.ObjectID) error
+
+	// RecordFailure counts a failed attempt and disables the webhook with the reason once
+	// disableAfter attempts in a row have failed. It returns whether the webhook was disabled.
+	RecordFailure(ctx context.Context, id primitive.ObjectID, disableAfter int, reason string, now time.Time) (bool, error)
+}
+
+// WebhookDeliveryRepository defines the interface for webhook deliveries and their attempts
+type WebhookDeliveryRepository interface {
+	// CreateMany creates new deliveries
+	CreateMany(ctx context.Context, deliveries []models.WebhookDelivery) error
+
+	// GetByID retrieves a delivery by its ID
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.WebhookDelivery, error)
+
+	// ListByWebhook lists the deliveries of a webhook, newest first
+	ListByWebhook(ctx context.Context, webhookID primitive.ObjectID, page, limit int) ([]models.WebhookDelivery, error)
+
+	// ClaimDue returns a pending delivery due by now, oldest first, after moving its next
+	// attempt to leaseUntil so that no one else attempts it meanwhile. It returns nil when
+	// nothing is due.
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time) (*models.WebhookDelivery, error)
+
+	// RecordAttempt adds an attempt to a delivery and sets its status and next attempt
+	RecordAttempt(ctx context.Context, id primitive.ObjectID, attempt models.WebhookAttempt, status models.WebhookDeliveryStatus, nextAttemptAt time.Time) error
+
+	// DeleteByWebhook deletes the deliveries of a webhook
+	DeleteByWebhook(ctx context.Context, webhookID primitive.ObjectID) error
+}
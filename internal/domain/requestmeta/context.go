@@ -0,0 +1,25 @@
+// Package requestmeta carries details of the HTTP request through request contexts.
+package requestmeta
+
+import "context"
+
+// Metadata describes the request an operation was made in
+type Metadata struct {
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+type metadataKey struct{}
+
+// WithMetadata returns a context carrying the request metadata
+func WithMetadata(ctx context.Context, metadata Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, metadata)
+}
+
+// FromContext returns the request metadata. Contexts outside HTTP requests,
+// such as background workers and the CLI, have none.
+func FromContext(ctx context.Context) (Metadata, bool) {
+	metadata, ok := ctx.Value(metadataKey{}).(Metadata)
+	return metadata, ok
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// permission is what the caller may do with a photo or an album. Each level
+// includes the ones below it.
+type permission int
+
+const (
+	permNone permission = iota
+	// permView allows seeing the resource and downloading its content
+	permView
+	// permContribute allows adding one's own photos to an album and removing them again
+	permContribute
+	// permEdit allows changing the resource without deleting it
+	permEdit
+	// permOwn allows everything, including deleting and sharing the resource
+	permOwn
+)
+
+// rolePermissions maps album roles to the permission they grant on the album
+var rolePermissions = map[models.AlbumRole]permission{
+	models.AlbumRoleViewer:      permView,
+	models.AlbumRoleContributor: permContribute,
+	models.AlbumRoleEditor:      permEdit,
+}
+
+// accessChecker loads photos and albums on behalf of the caller and checks what
+// they may do with them. Contexts without a user belong to the system and may do
+// everything.
+type accessChecker struct {
+	photoRepo repositories.PhotoRepository
+	albumRepo repositories.AlbumRepository
+}
+
+// activePhoto loads a photo that is not in the trash and on which the caller has at least need
+func (a accessChecker) activePhoto(ctx context.Context, id primitive.ObjectID, need permission) (*models.Photo, error) {
+	photo, err := a.photoRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if photo == nil || photo.IsTrashed() {
+		return nil, ErrPhotoNotFound
+	}
+	if err := a.checkPhoto(ctx, photo, need); err != nil {
+		return nil, err
+	}
+	return photo, nil
+}
+
+// checkPhoto returns ErrPhotoNotFound if the caller cannot see the photo and
+// ErrForbidden if they can but have less than need
+func (a accessChecker) checkPhoto(ctx context.Context, photo *models.Photo, need permission) error {
+	granted, err := a.photoPermission(ctx, photo)
+	if err != nil {
+		return err
+	}
+	return checkPermission(granted, need, ErrPhotoNotFound)
+}
+
+// photoPermission returns the caller's permission on a photo. Owners have every
+// permission; album members may view the photos of the album, and editors of
+// the album may also edit them.
+func (a accessChecker) photoPermission(ctx context.Context, photo *models.Photo) (permission, error) {
+	user, ok := auth.UserFromContext(ctx)
+	if !ok || photo.OwnerID == user.ID.Hex() {
+		return permOwn, nil
+	}
+	if len(photo.AlbumIDs) == 0 {
+		return permNone, nil
+	}
+
+	albums, err := a.albumRepo.ListAccessible(ctx, photo.AlbumIDs, user.ID.Hex())
+	if err != nil {
+		return permNone, fmt.Errorf("failed to load albums: %w", err)
+	}
+
+	granted := permNone
+	for i := range albums {
+		derived := permView
+		if albumPermission(ctx, &albums[i]) >= permEdit {
+			derived = permEdit
+		}
+		if derived > granted {
+			granted = derived
+		}
+	}
+	return granted, nil
+}
+
+// album loads an album on which the caller has at least need
+func (a accessChecker) album(ctx context.Context, id primitive.ObjectID, need permission) (*models.Album, error) {
+	album, err := a.albumRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if album == nil {
+		return nil, ErrAlbumNotFound
+	}
+	if err := checkPermission(albumPermission(ctx, album), need, ErrAlbumNotFound); err != nil {
+		return nil, err
+	}
+	return album, nil
+}
+
+// albumPermission returns the caller's permission on an album
+func albumPermission(ctx context.Context, album *models.Album) permission {
+	user, ok := auth.UserFromContext(ctx)
+	if !ok || album.OwnerID == user.ID.Hex() {
+		return permOwn
+	}
+	if member, ok := album.Member(user.ID.Hex()); ok {
+		return rolePermissions[member.Role]
+	}
+	return permNone
+}
+
+// checkPermission hides resources the caller cannot see behind notFound
+func checkPermission(granted, need permission, notFound error) error {
+	if granted == permNone {
+		return notFound
+	}
+	if granted < need {
+		return ErrForbidden
+	}
+	return nil
+}
@@ -0,0 +1,335 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// accountExportFormat is the version of the account archive layout, bumped on incompatible changes
+	accountExportFormat = 1
+
+	accountPhotosDir   = "photos/"
+	accountTrashDir    = "trash/"
+	accountReadmeName  = "README.txt"
+	accountFileName    = "account.json"
+	accountAlbumsName  = "albums.json"
+	accountSharesName  = "share_links.json"
+	accountActivityLog = "activity.jsonl"
+)
+
+// accountReadme describes the layout of account archives to whoever opens one
+const accountReadme = `This archive holds the data of a photocloud account.
+
+account.json       The account: ID, username, email, plan, quota and creation
+                   time.
+photos/            The original of every photo, as last uploaded, next to a
+                   JSON sidecar named after it with ".json" added.
+trash/             Photos in the trash, laid out like photos/.
+albums.json        The albums the account owns, with their members, and the
+                   albums shared with it, with its own membership.
+share_links.json   The share links the account created. Tokens and passwords
+                   are only stored as hashes and are not included.
+activity.jsonl     The activity recorded for the account, one JSON object per
+                   line, oldest first.
+
+Each sidecar holds the photo's ID, name, description, content type, size,
+SHA-256 hash, tags, the IDs of the albums it is in, its edit recipe, the
+history of its versions, when and where it was taken if known, and its
+upload, update and trash times. Edits are not applied to the exported
+originals; the recipe lists them in the order they are applied. Earlier
+versions are listed, but only the current file is included. When a photo's
+file could not be read, its sidecar has an "error" and no "file".
+
+All times are in UTC, in RFC 3339 format.
+`
+
+// accountExportInfo is account.json
+type accountExportInfo struct {
+	Format     int          `json:"format"`
+	ExportedAt time.Time    `json:"exported_at"`
+	User       *models.User `json:"user"`
+}
+
+// accountPhoto is the JSON sidecar of a photo in an account archive
+type accountPhoto struct {
+	ID string `json:"id"`
+	// File is the name of the photo's entry in the archive
+	File        string                 `json:"file,omitempty"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	ContentType string                 `json:"content_type"`
+	Size        int64                  `json:"size"`
+	SHA256      string                 `json:"sha256,omitempty"`
+	Orientation int                    `json:"orientation,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+	AlbumIDs    []primitive.ObjectID   `json:"album_ids,omitempty"`
+	Edits       []models.EditOperation `json:"edits,omitempty"`
+	Versions    []accountPhotoVersion  `json:"versions"`
+	TakenAt     *time.Time             `json:"taken_at,omitempty"`
+	Location    *models.GeoLocation    `json:"location,omitempty"`
+	UploadedAt  time.Time              `json:"uploaded_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+	DeletedAt   *time.Time             `json:"deleted_at,omitempty"`
+	// Error tells why the photo's file is not in the archive
+	Error string `json:"error,omitempty"`
+}
+
+type accountPhotoVersion struct {
+	Number       int       `json:"number"`
+	Size         int64     `json:"size"`
+	ContentType  string    `json:"content_type"`
+	SHA256       string    `json:"sha256,omitempty"`
+	Author       string    `json:"author,omitempty"`
+	RevertedFrom int       `json:"reverted_from,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (s *exportService) ExportAccount(ctx context.Context) (*models.ExportJob, error) {
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+	userID := user.ID.Hex()
+
+	pending, err := s.jobRepo.GetPending(ctx, userID, models.ExportKindAccount)
+	if err != nil {
+		return nil, err
+	}
+	if pending != nil {
+		return nil, fmt.Errorf("%w: an account export is already in progress", ErrConflict)
+	}
+
+	total, err := s.countAccountPhotos(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	job := &models.ExportJob{
+		Job:   models.Job{OwnerID: userID, Status: models.ExportJobQueued, CreatedAt: now},
+		Kind:  models.ExportKindAccount,
+		Name:  safeFileName(fmt.Sprintf("photocloud-%s-%s", user.Username, now.UTC().Format("2006-01-02")), "photocloud") + ".zip",
+		Total: total,
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// countAccountPhotos counts the photos of a user, including those in the trash
+func (s *exportService) countAccountPhotos(ctx context.Context, userID string) (int64, error) {
+	active, err := s.photoRepo.CountByFilter(ctx, userID, models.PhotoFilter{}, false)
+	if err != nil {
+		return 0, err
+	}
+	trashed, err := s.photoRepo.CountByFilter(ctx, userID, models.PhotoFilter{}, true)
+	if err != nil {
+		return 0, err
+	}
+	return active + trashed, nil
+}
+
+// writeAccountArchive writes all the data of the job's owner into an archive, saving
+// progress after every batch of photos and activities
+func (s *exportService) writeAccountArchive(ctx context.Context, job *models.ExportJob, w io.Writer) error {
+	user, _ := auth.UserFromContext(ctx)
+	exportedAt := time.Now().UTC()
+	archive := s.newArchiveWriter(w, nil, nil, false)
+
+	var err error
+	if job.Total, err = s.countAccountPhotos(ctx, job.OwnerID); err != nil {
+		return err
+	}
+
+	readme, err := archive.create(accountReadmeName, exportedAt)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(readme, accountReadme); err != nil {
+		return err
+	}
+	info := accountExportInfo{Format: accountExportFormat, ExportedAt: exportedAt, User: user}
+	if err := archive.writeJSON(accountFileName, info, exportedAt); err != nil {
+		return err
+	}
+
+	if err := s.writeAccountPhotos(ctx, job, archive, accountPhotosDir, false); err != nil {
+		return err
+	}
+	if err := s.writeAccountPhotos(ctx, job, archive, accountTrashDir, true); err != nil {
+		return err
+	}
+	if err := s.writeAccountAlbums(ctx, job.OwnerID, archive, exportedAt); err != nil {
+		return err
+	}
+	if err := s.writeAccountShareLinks(ctx, job.OwnerID, archive, exportedAt); err != nil {
+		return err
+	}
+	if err := s.writeAccountActivity(ctx, job, archive, exportedAt); err != nil {
+		return err
+	}
+	return archive.close()
+}
+
+// writeAccountPhotos writes the photos of the job's owner, either those in the trash or
+// the others, into a directory of the archive, each with its sidecar
+func (s *exportService) writeAccountPhotos(ctx context.Context, job *models.ExportJob, archive *archiveWriter, dir string, trashed bool) error {
+	afterID := primitive.NilObjectID
+	for {
+		photos, err := s.photoRepo.ListByFilter(ctx, job.OwnerID, models.PhotoFilter{}, trashed, afterID, exportBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for i := range photos {
+			photo := &photos[i]
+			file, skipped, err := archive.add(ctx, dir, photo)
+			if err != nil {
+				return err
+			}
+
+			sidecar := toAccountPhoto(photo)
+			sidecarName := dir + safeFileName(photo.Name, photo.ID.Hex())
+			if skipped != nil {
+				sidecar.Error = skipped.Error()
+			} else {
+				sidecar.File, sidecarName = file, file
+			}
+			if err := archive.writeJSON(archive.entryName(sidecarName+".json"), sidecar, photo.UpdatedAt); err != nil {
+				return err
+			}
+
+			job.Processed++
+			if skipped != nil {
+				job.Failed++
+				if len(job.Failures) < maxExportFailures {
+					job.Failures = append(job.Failures, models.BulkFailure{PhotoID: photo.ID, Error: skipped.Error()})
+				}
+			}
+		}
+
+		if err := s.jobs.extendLease(ctx, job); err != nil {
+			return err
+		}
+		if len(photos) < exportBatchSize {
+			return nil
+		}
+		afterID = photos[len(photos)-1].ID
+	}
+}
+
+// writeAccountAlbums writes the albums a user owns, followed by those shared with them
+func (s *exportService) writeAccountAlbums(ctx context.Context, userID string, archive *archiveWriter, exportedAt time.Time) error {
+	albums := []models.Album{}
+	for _, list := range []func(context.Context, string, int, int) ([]models.Album, error){s.albumRepo.ListByOwner, s.albumRepo.ListByMember} {
+		for page := 1; ; page++ {
+			batch, err := list(ctx, userID, page, exportPageSize)
+			if err != nil {
+				return err
+			}
+			albums = append(albums, batch...)
+			if len(batch) < exportPageSize {
+				break
+			}
+		}
+	}
+
+	for i := range albums {
+		if albums[i].OwnerID != userID {
+			// Who else an album was shared with is the business of its owner
+			member, _ := albums[i].Member(userID)
+			albums[i].Members = nil
+			if member != nil {
+				albums[i].Members = []models.AlbumMember{*member}
+			}
+		}
+	}
+	return archive.writeJSON(accountAlbumsName, albums, exportedAt)
+}
+
+// writeAccountShareLinks writes the share links a user created, which never include their secrets
+func (s *exportService) writeAccountShareLinks(ctx context.Context, userID string, archive *archiveWriter, exportedAt time.Time) error {
+	links := []models.ShareLink{}
+	for page := 1; ; page++ {
+		batch, err := s.shareRepo.ListByOwner(ctx, userID, page, exportPageSize)
+		if err != nil {
+			return err
+		}
+		links = append(links, batch...)
+		if len(batch) < exportPageSize {
+			return archive.writeJSON(accountSharesName, links, exportedAt)
+		}
+	}
+}
+
+// writeAccountActivity writes the activity of the job's owner as JSON lines, oldest first
+func (s *exportService) writeAccountActivity(ctx context.Context, job *models.ExportJob, archive *archiveWriter, exportedAt time.Time) error {
+	entry, err := archive.create(accountActivityLog, exportedAt)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(entry)
+
+	afterID := primitive.NilObjectID
+	for {
+		activities, err := s.activityRepo.ListUserActivities(ctx, job.OwnerID, afterID, exportPageSize)
+		if err != nil {
+			return err
+		}
+		for i := range activities {
+			if err := encoder.Encode(&activities[i]); err != nil {
+				return fmt.Errorf("failed to write %s: %w", accountActivityLog, err)
+			}
+		}
+
+		if err := s.jobs.extendLease(ctx, job); err != nil {
+			return err
+		}
+		if len(activities) < exportPageSize {
+			return nil
+		}
+		afterID = activities[len(activities)-1].ID
+	}
+}
+
+// toAccountPhoto describes a photo in its sidecar, leaving out where its files are stored
+func toAccountPhoto(photo *models.Photo) accountPhoto {
+	sidecar := accountPhoto{
+		ID:          photo.ID.Hex(),
+		Name:        photo.Name,
+		Description: photo.Description,
+		ContentType: photo.ContentType,
+		Size:        photo.Size,
+		SHA256:      photo.ContentHash,
+		Orientation: photo.Orientation,
+		Tags:        photo.Tags,
+		AlbumIDs:    photo.AlbumIDs,
+		Edits:       photo.Edits,
+		TakenAt:     photo.TakenAt,
+		Location:    photo.Location,
+		UploadedAt:  photo.UploadedAt,
+		UpdatedAt:   photo.UpdatedAt,
+		DeletedAt:   photo.DeletedAt,
+	}
+	for _, version := range photo.History() {
+		sidecar.Versions = append(sidecar.Versions, accountPhotoVersion{
+			Number:       version.Number,
+			Size:         version.Size,
+			ContentType:  version.ContentType,
+			SHA256:       version.Hash,
+			Author:       version.Author,
+			RevertedFrom: version.RevertedFrom,
+			CreatedAt:    version.CreatedAt,
+		})
+	}
+	return sidecar
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/requestmeta"
+	"photocloud/internal/imaging"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ActivityRecorder accepts user activity for writing. Record must return
+// immediately; a failure to write an activity never fails the operation.
+type ActivityRecorder interface {
+	Record(activity *models.UserActivity)
+}
+
+// activityPhotoService records uploads, views, URL issues, downloads and
+// deletes of photos as user activity. Other operations pass through.
+type activityPhotoService struct {
+	PhotoService
+	recorder ActivityRecorder
+}
+
+// NewActivityPhotoService wraps a photo service so that its operations are recorded as user activity
+func NewActivityPhotoService(photoService PhotoService, recorder ActivityRecorder) PhotoService {
+	return &activityPhotoService{
+		PhotoService: photoService,
+		recorder:     recorder,
+	}
+}
+
+func (s *activityPhotoService) UploadPhoto(ctx context.Context, name, description string, content io.Reader, contentType string, size int64) (*models.Photo, error) {
+	photo, err := s.PhotoService.UploadPhoto(ctx, name, description, content, contentType, size)
+	if err == nil {
+		recordActivity(ctx, s.recorder, models.ActivityTypeUpload, photo.ID, map[string]interface{}{"size": size})
+	}
+	return photo, err
+}
+
+func (s *activityPhotoService) ImportPhoto(ctx context.Context, name, description string, content io.Reader, contentType string, size int64, metadata PhotoMetadata) (*models.Photo, error) {
+	photo, err := s.PhotoService.ImportPhoto(ctx, name, description, content, contentType, size, metadata)
+	if err == nil {
+		recordActivity(ctx, s.recorder, models.ActivityTypeUpload, photo.ID, map[string]interface{}{"size": size, "imported": true})
+	}
+	return photo, err
+}
+
+func (s *activityPhotoService) GetPhoto(ctx context.Context, id primitive.ObjectID) (*models.Photo, error) {
+	photo, err := s.PhotoService.GetPhoto(ctx, id)
+	if err == nil {
+		recordActivity(ctx, s.recorder, models.ActivityTypeView, id, nil)
+	}
+	return photo, err
+}
+
+func (s *activityPhotoService) GetPhotoContent(ctx context.Context, id primitive.ObjectID) (*FileContent, error) {
+	content, err := s.PhotoService.GetPhotoContent(ctx, id)
+	if err == nil {
+		recordActivity(ctx, s.recorder, models.ActivityTypeDownload, id, map[string]interface{}{"original": true})
+	}
+	return content, err
+}
+
+func (s *activityPhotoService) GetPhotoURL(ctx context.Context, id primitive.ObjectID) (string, error) {
+	url, err := s.PhotoService.GetPhotoURL(ctx, id)
+	if err == nil {
+		recordActivity(ctx, s.recorder, models.ActivityTypeIssueURL, id, nil)
+	}
+	return url, err
+}
+
+// GetPhotoWithURL records a single view: the URL comes with the photo and is not
+// counted as issued on its own
+func (s *activityPhotoService) GetPhotoWithURL(ctx context.Context, id primitive.ObjectID) (*models.Photo, string, error) {
+	photo, url, err := s.PhotoService.GetPhotoWithURL(ctx, id)
+	if err == nil {
+		recordActivity(ctx, s.recorder, models.ActivityTypeView, id, nil)
+	}
+	return photo, url, err
+}
+
+func (s *activityPhotoService) DeletePhoto(ctx context.Context, id primitive.ObjectID) error {
+	err := s.PhotoService.DeletePhoto(ctx, id)
+	if err == nil {
+		recordActivity(ctx, s.recorder, models.ActivityTypeDelete, id, nil)
+	}
+	return err
+}
+
+func (s *activityPhotoService) PurgePhoto(ctx context.Context, id primitive.ObjectID) error {
+	err := s.PhotoService.PurgePhoto(ctx, id)
+	if err == nil {
+		recordActivity(ctx, s.recorder, models.ActivityTypeDelete, id, map[string]interface{}{"permanent": true})
+	}
+	return err
+}
+
+func (s *activityPhotoService) TrashPhotos(ctx context.Context, ids []primitive.ObjectID) (*PhotoBatch, error) {
+	batch, err := s.PhotoService.TrashPhotos(ctx, ids)
+	if err == nil {
+		for _, photo := range batch.Changed {
+			recordActivity(ctx, s.recorder, models.ActivityTypeDelete, photo.ID, map[string]interface{}{"bulk": true})
+		}
+	}
+	return batch, err
+}
+
+func (s *activityPhotoService) PurgePhotos(ctx context.Context, ids []primitive.ObjectID) (*PhotoBatch, error) {
+	batch, err := s.PhotoService.PurgePhotos(ctx, ids)
+	if err == nil {
+		for _, photo := range batch.Changed {
+			recordActivity(ctx, s.recorder, models.ActivityTypeDelete, photo.ID, map[string]interface{}{"permanent": true, "bulk": true})
+		}
+	}
+	return batch, err
+}
+
+func (s *activityPhotoService) UploadVersion(ctx context.Context, id primitive.ObjectID, content io.Reader, contentType string, size int64) (*models.Photo, error) {
+	photo, err := s.PhotoService.UploadVersion(ctx, id, content, contentType, size)
+	if err == nil {
+		recordActivity(ctx, s.recorder, models.ActivityTypeUpload, id, map[string]interface{}{"size": size, "version": photo.Version})
+	}
+	return photo, err
+}
+
+func (s *activityPhotoService) GetVersionContent(ctx context.Context, id primitive.ObjectID, number int) (*FileContent, error) {
+	content, err := s.PhotoService.GetVersionContent(ctx, id, number)
+	if err == nil {
+		recordActivity(ctx, s.recorder, models.ActivityTypeDownload, id, map[string]interface{}{"version": number})
+	}
+	return content, err
+}
+
+// activityTransformService records downloads of rendered photos and views of
+// transformed variants as user activity. Other operations pass through.
+type activityTransformService struct {
+	TransformService
+	recorder ActivityRecorder
+}
+
+// NewActivityTransformService wraps a transform service so that served photos are recorded as user activity
+func NewActivityTransformService(transformService TransformService, recorder ActivityRecorder) TransformService {
+	return &activityTransformService{
+		TransformService: transformService,
+		recorder:         recorder,
+	}
+}
+
+func (s *activityTransformService) TransformPhoto(ctx context.Context, id primitive.ObjectID, opts imaging.Options, signature string, expires time.Time, accept string) (*FileContent, error) {
+	content, err := s.TransformService.TransformPhoto(ctx, id, opts, signature, expires, accept)
+	if err == nil {
+		recordActivity(ctx, s.recorder, models.ActivityTypeView, id, map[string]interface{}{"transform": opts.Canonical()})
+	}
+	return content, err
+}
+
+func (s *activityTransformService) RenderPhoto(ctx context.Context, id primitive.ObjectID, accept string) (*FileContent, error) {
+	content, err := s.TransformService.RenderPhoto(ctx, id, accept)
+	// Without a rendition the original is served, which the photo service records
+	if err == nil && content != nil {
+		recordActivity(ctx, s.recorder, models.ActivityTypeDownload, id, map[string]interface{}{"content_type": content.Info.ContentType})
+	}
+	return content, err
+}
+
+// recordActivity hands an activity of the caller to the recorder, with the
+// details of the request it was made in
+func recordActivity(ctx context.Context, recorder ActivityRecorder, activityType models.ActivityType, photoID primitive.ObjectID, metadata map[string]interface{}) {
+	activity := &models.UserActivity{
+		UserID:    auth.UserID(ctx),
+		PhotoID:   photoID,
+		Type:      activityType,
+		Timestamp: time.Now(),
+		Metadata:  metadata,
+	}
+	if request, ok := requestmeta.FromContext(ctx); ok {
+		activity.IP = request.IP
+		activity.UserAgent = request.UserAgent
+		activity.RequestID = request.RequestID
+	}
+	recorder.Record(activity)
+}
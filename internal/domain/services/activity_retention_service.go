@@ -0,0 +1,197 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"photocloud/config"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// activityPurgeBatchSize is the number of expired activities archived and removed at a time
+	activityPurgeBatchSize = 1000
+	// activityArchivePrefix is where archives of expired activity are stored
+	activityArchivePrefix = "archive/activity/"
+)
+
+// ActivityRetentionService removes user activity that has outlived its retention period
+type ActivityRetentionService interface {
+	// PurgeExpired removes the activity that expired by now, after adding it to the daily
+	// rollups and archiving it to storage when configured, and returns how much was removed.
+	// Audit chain entries keep a stub until a signed checkpoint records their removal.
+	PurgeExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+type activityRetentionService struct {
+	activityRepo repositories.UserActivityRepository
+	rollupRepo   repositories.ActivityRollupRepository
+	storageRepo  repositories.StorageRepository
+	auditService AuditService
+	retention    config.ActivityRetention
+}
+
+// NewActivityRetentionService creates an activity retention service
+func NewActivityRetentionService(activityRepo repositories.UserActivityRepository, rollupRepo repositories.ActivityRollupRepository, storageRepo repositories.StorageRepository, auditService AuditService, retention config.ActivityRetention) ActivityRetentionService {
+	return &activityRetentionService{
+		activityRepo: activityRepo,
+		rollupRepo:   rollupRepo,
+		storageRepo:  storageRepo,
+		auditService: auditService,
+		retention:    retention,
+	}
+}
+
+func (s *activityRetentionService) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	expiry := repositories.ActivityExpiry{Types: make(map[models.ActivityType]time.Time, len(s.retention.Types))}
+	if s.retention.Default > 0 {
+		expiry.Default = now.Add(-s.retention.Default)
+	}
+	for activityType, period := range s.retention.Types {
+		var cutoff time.Time
+		if period > 0 {
+			cutoff = now.Add(-period)
+		}
+		expiry.Types[models.ActivityType(activityType)] = cutoff
+	}
+
+	purged := 0
+	for {
+		activities, err := s.activityRepo.ListExpired(ctx, expiry, activityPurgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+		if len(activities) == 0 {
+			break
+		}
+
+		// Nothing is removed unless it was archived and rolled up first
+		if s.retention.Archive {
+			if err := s.archive(ctx, activities, now); err != nil {
+				return purged, err
+			}
+		}
+		if s.retention.Rollup {
+			if err := s.rollupRepo.Add(ctx, rollUp(activities)); err != nil {
+				return purged, fmt.Errorf("failed to roll up expired activity: %w", err)
+			}
+		}
+
+		ids := make([]primitive.ObjectID, len(activities))
+		for i, activity := range activities {
+			ids[i] = activity.ID
+		}
+		removed, err := s.activityRepo.Prune(ctx, ids, now)
+		purged += int(removed)
+		if err != nil {
+			return purged, fmt.Errorf("failed to remove expired activity: %w", err)
+		}
+		if removed == 0 || len(activities) < activityPurgeBatchSize {
+			break
+		}
+	}
+
+	if err := s.deletePrunedPrefix(ctx); err != nil {
+		return purged, err
+	}
+	return purged, nil
+}
+
+// deletePrunedPrefix deletes the pruned chain entries that no unpruned entry
+// links back to. The head of the chain is kept so that new entries can link to it,
+// and the audit service records the new start of the chain in a signed checkpoint.
+func (s *activityRetentionService) deletePrunedPrefix(ctx context.Context) error {
+	head, err := s.activityRepo.GetChainHead(ctx)
+	if err != nil || head == nil {
+		return err
+	}
+	boundary := head.Sequence
+
+	first, err := s.activityRepo.GetFirstUnpruned(ctx)
+	if err != nil {
+		return err
+	}
+	if first != nil && first.Sequence < boundary {
+		boundary = first.Sequence
+	}
+
+	if _, err := s.auditService.Trim(ctx, boundary); err != nil {
+		return fmt.Errorf("failed to delete pruned audit entries: %w", err)
+	}
+	return nil
+}
+
+// archive stores activities as one gzipped JSON Lines file. The key is derived from the
+// activities, so archiving the same batch again replaces the earlier file.
+func (s *activityRetentionService) archive(ctx context.Context, activities []models.UserActivity, now time.Time) error {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(writer)
+	for i := range activities {
+		if err := encoder.Encode(&activities[i]); err != nil {
+			return fmt.Errorf("failed to encode activity archive: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to compress activity archive: %w", err)
+	}
+
+	key := fmt.Sprintf("%s%s/%s-%s.jsonl.gz", activityArchivePrefix, now.UTC().Format("2006/01/02"),
+		activities[0].ID.Hex(), activities[len(activities)-1].ID.Hex())
+	if err := s.storageRepo.UploadFile(ctx, key, &buf, "application/gzip"); err != nil {
+		return fmt.Errorf("failed to store activity archive %s: %w", key, err)
+	}
+	return nil
+}
+
+// rollUp counts activities per UTC day, type, user and photo
+func rollUp(activities []models.UserActivity) []models.ActivityRollup {
+	type rollupKey struct {
+		date         string
+		activityType models.ActivityType
+		userID       string
+		photoID      primitive.ObjectID
+	}
+
+	index := make(map[rollupKey]int)
+	var rollups []models.ActivityRollup
+	for _, activity := range activities {
+		key := rollupKey{
+			date:         activity.Timestamp.UTC().Format("2006-01-02"),
+			activityType: activity.Type,
+			userID:       activity.UserID,
+			photoID:      activity.PhotoID,
+		}
+
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(rollups)
+			rollups = append(rollups, models.ActivityRollup{
+				Date:    key.date,
+				Type:    activity.Type,
+				UserID:  activity.UserID,
+				PhotoID: activity.PhotoID,
+				FirstAt: activity.Timestamp,
+				LastAt:  activity.Timestamp,
+			})
+			i = len(rollups) - 1
+		}
+
+		rollup := &rollups[i]
+		rollup.Count++
+		if activity.Timestamp.Before(rollup.FirstAt) {
+			rollup.FirstAt = activity.Timestamp
+		}
+		if activity.Timestamp.After(rollup.LastAt) {
+			rollup.LastAt = activity.Timestamp
+		}
+	}
+	return rollups
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxDailyActivityDays bounds the number of days counted by one daily activity query
+const maxDailyActivityDays = 366
+
+// DailyActivity is the number of activities of each type on one calendar day
+type DailyActivity struct {
+	// Date is the day as YYYY-MM-DD
+	Date   string
+	Counts map[models.ActivityType]int64
+}
+
+// ActivityService reads recorded user activity
+type ActivityService interface {
+	// ListMyActivity lists the caller's own activity, newest first
+	ListMyActivity(ctx context.Context, page, limit int) ([]models.UserActivity, error)
+	// ListPhotoActivity lists the activity on a photo, newest first. Only the owner may
+	// read it, and the IP and user agent of other users are left out.
+	ListPhotoActivity(ctx context.Context, photoID primitive.ObjectID, page, limit int) ([]models.UserActivity, error)
+	// ListActivity lists every user's activity between start and end, newest first. It is
+	// reserved for the system.
+	ListActivity(ctx context.Context, start, end time.Time, page, limit int) ([]models.UserActivity, error)
+
+	// DailyActivity counts the matching activities per day in the named time zone, including
+	// days without activity. Users may count their own activity or the activity on their photos.
+	DailyActivity(ctx context.Context, filter repositories.ActivityFilter, timezone string) ([]DailyActivity, error)
+	// TopPhotos returns the photos with the most matching activities. It is reserved for the system.
+	TopPhotos(ctx context.Context, filter repositories.ActivityFilter, limit int) ([]models.PhotoActivityCount, error)
+	// ActiveUsers returns the users with the most matching activities. It is reserved for the system.
+	ActiveUsers(ctx context.Context, filter repositories.ActivityFilter, limit int) ([]models.UserActivityCount, error)
+}
+
+type activityService struct {
+	activityRepo repositories.UserActivityRepository
+	userRepo     repositories.UserRepository
+	access       accessChecker
+}
+
+// NewActivityService creates an activity service
+func NewActivityService(activityRepo repositories.UserActivityRepository, photoRepo repositories.PhotoRepository, albumRepo repositories.AlbumRepository, userRepo repositories.UserRepository) ActivityService {
+	return &activityService{
+		activityRepo: activityRepo,
+		userRepo:     userRepo,
+		access:       accessChecker{photoRepo: photoRepo, albumRepo: albumRepo},
+	}
+}
+
+func (s *activityService) ListMyActivity(ctx context.Context, page, limit int) ([]models.UserActivity, error) {
+	userID := auth.UserID(ctx)
+	if userID == "" {
+		return nil, ErrUnauthorized
+	}
+	return s.activityRepo.GetUserActivities(ctx, userID, page, limit)
+}
+
+func (s *activityService) ListPhotoActivity(ctx context.Context, photoID primitive.ObjectID, page, limit int) ([]models.UserActivity, error) {
+	if err := s.checkPhotoOwner(ctx, photoID); err != nil {
+		return nil, err
+	}
+
+	activities, err := s.activityRepo.GetPhotoActivities(ctx, photoID, page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Owners see who used their photo, but not where from
+	if userID := auth.UserID(ctx); userID != "" {
+		for i := range activities {
+			if activities[i].UserID != userID {
+				activities[i].IP = ""
+				activities[i].UserAgent = ""
+			}
+		}
+	}
+	return activities, nil
+}
+
+func (s *activityService) ListActivity(ctx context.Context, start, end time.Time, page, limit int) ([]models.UserActivity, error) {
+	if err := requireSystem(ctx); err != nil {
+		return nil, err
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("%w: end is before start", ErrInvalidArgument)
+	}
+	return s.activityRepo.GetActivitiesByTimeRange(ctx, start, end, page, limit)
+}
+
+func (s *activityService) DailyActivity(ctx context.Context, filter repositories.ActivityFilter, timezone string) ([]DailyActivity, error) {
+	if err := validateActivityFilter(filter); err != nil {
+		return nil, err
+	}
+	if filter.Start.IsZero() || filter.End.IsZero() {
+		return nil, fmt.Errorf("%w: start and end are required", ErrInvalidArgument)
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil || timezone == "Local" {
+		return nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidArgument, timezone)
+	}
+	days := calendarDays(filter.Start, filter.End, location)
+	if len(days) > maxDailyActivityDays {
+		return nil, fmt.Errorf("%w: at most %d days can be counted at once", ErrInvalidArgument, maxDailyActivityDays)
+	}
+
+	// Users count their own activity unless they ask about one of their photos
+	if userID := auth.UserID(ctx); userID != "" {
+		if !filter.PhotoID.IsZero() {
+			if err := s.checkPhotoOwner(ctx, filter.PhotoID); err != nil {
+				return nil, err
+			}
+		} else if filter.UserID == "" {
+			filter.UserID = userID
+		} else if filter.UserID != userID {
+			return nil, ErrForbidden
+		}
+	}
+
+	counts, err := s.activityRepo.CountByDay(ctx, filter, location.String())
+	if err != nil {
+		return nil, err
+	}
+
+	types := filter.Types
+	if len(types) == 0 {
+		types = models.ActivityTypes
+	}
+	byDate := make(map[string]map[models.ActivityType]int64, len(days))
+	report := make([]DailyActivity, len(days))
+	for i, date := range days {
+		report[i] = DailyActivity{Date: date, Counts: make(map[models.ActivityType]int64, len(types))}
+		for _, activityType := range types {
+			report[i].Counts[activityType] = 0
+		}
+		byDate[date] = report[i].Counts
+	}
+	for _, count := range counts {
+		if dayCounts, ok := byDate[count.Date]; ok {
+			dayCounts[count.Type] += count.Count
+		}
+	}
+	return report, nil
+}
+
+func (s *activityService) TopPhotos(ctx context.Context, filter repositories.ActivityFilter, limit int) ([]models.PhotoActivityCount, error) {
+	if err := requireSystem(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateActivityFilter(filter); err != nil {
+		return nil, err
+	}
+	return s.activityRepo.TopPhotos(ctx, filter, limit)
+}
+
+func (s *activityService) ActiveUsers(ctx context.Context, filter repositories.ActivityFilter, limit int) ([]models.UserActivityCount, error) {
+	if err := requireSystem(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateActivityFilter(filter); err != nil {
+		return nil, err
+	}
+
+	users, err := s.activityRepo.ActiveUsers(ctx, filter, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range users {
+		id, err := primitive.ObjectIDFromHex(users[i].UserID)
+		if err != nil {
+			continue
+		}
+		user, err := s.userRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil {
+			users[i].Username = user.Username
+		}
+	}
+	return users, nil
+}
+
+// checkPhotoOwner checks that the photo exists, in the trash or not, and belongs to the caller
+func (s *activityService) checkPhotoOwner(ctx context.Context, photoID primitive.ObjectID) error {
+	photo, err := s.access.photoRepo.GetByID(ctx, photoID)
+	if err != nil {
+		return err
+	}
+	if photo == nil {
+		return ErrPhotoNotFound
+	}
+	return s.access.checkPhoto(ctx, photo, permOwn)
+}
+
+// requireSystem returns ErrForbidden unless the context belongs to the system
+func requireSystem(ctx context.Context) error {
+	if _, ok := auth.UserFromContext(ctx); ok {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// validateActivityFilter checks the time range and activity types of a filter
+func validateActivityFilter(filter repositories.ActivityFilter) error {
+	if !filter.Start.IsZero() && !filter.End.IsZero() && filter.End.Before(filter.Start) {
+		return fmt.Errorf("%w: end is before start", ErrInvalidArgument)
+	}
+	for _, activityType := range filter.Types {
+		if !activityType.IsValid() {
+			return fmt.Errorf("%w: unknown activity type %q", ErrInvalidArgument, activityType)
+		}
+	}
+	return nil
+}
+
+// calendarDays lists the dates from start to end, inclusive, as YYYY-MM-DD in the location.
+// It stops one day past the daily query limit so that overlong ranges are cheap to reject.
+func calendarDays(start, end time.Time, location *time.Location) []string {
+	first := start.In(location)
+	last := end.In(location).Format("2006-01-02")
+
+	var days []string
+	day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, location)
+	for len(days) <= maxDailyActivityDays {
+		date := day.Format("2006-01-02")
+		days = append(days, date)
+		if date == last {
+			break
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return days
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"testing"
+
+	"photocloud/internal/domain/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestGetPhotoWithURLRecordsOneView(t *testing.T) {
+	ctx, user := userContext()
+	photo := &models.Photo{ID: primitive.NewObjectID(), OwnerID: user.ID.Hex(), S3Key: "photos/a.jpg"}
+	photoRepo := newFakePhotoRepo(photo)
+	storage := newFakeStorageRepo()
+	storage.files[photo.S3Key] = &fakeFile{data: []byte("photo")}
+	recorder := &fakeRecorder{}
+	service := NewActivityPhotoService(&photoService{photoRepo: photoRepo, storageRepo: storage, access: accessChecker{photoRepo: photoRepo}}, recorder)
+
+	got, url, err := service.GetPhotoWithURL(ctx, photo.ID)
+	if err != nil {
+		t.Fatalf("GetPhotoWithURL() error = %v", err)
+	}
+	if got.ID != photo.ID || url == "" {
+		t.Errorf("GetPhotoWithURL() = %s, %q, want the photo and its URL", got.ID, url)
+	}
+	if len(recorder.activities) != 1 || recorder.activities[0].Type != models.ActivityTypeView {
+		t.Fatalf("recorded %d activities, want a single view", len(recorder.activities))
+	}
+	if activity := recorder.activities[0]; activity.PhotoID != photo.ID || activity.UserID != user.ID.Hex() {
+		t.Errorf("recorded a view of %s by %q, want %s by %s", activity.PhotoID, activity.UserID, photo.ID, user.ID.Hex())
+	}
+
+	// Photos the caller cannot see are not recorded
+	if _, _, err := service.GetPhotoWithURL(ctx, primitive.NewObjectID()); err == nil {
+		t.Fatal("GetPhotoWithURL() of an unknown photo succeeded")
+	}
+	if len(recorder.activities) != 1 {
+		t.Errorf("recorded %d activities after a failed request, want 1", len(recorder.activities))
+	}
+}
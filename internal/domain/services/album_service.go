@@ -0,0 +1,425 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AlbumService manages albums, the photos in them and the users they are shared with
+type AlbumService interface {
+	// CreateAlbum creates an album owned by the caller
+	CreateAlbum(ctx context.Context, name, description string) (*models.Album, error)
+	// GetAlbum retrieves an album the caller owns or is a member of
+	GetAlbum(ctx context.Context, id primitive.ObjectID) (*models.Album, error)
+	// ListAlbums lists the caller's albums, newest first
+	ListAlbums(ctx context.Context, page, limit int) ([]models.Album, error)
+	// ListSharedAlbums lists the albums other users have shared with the caller, newest first
+	ListSharedAlbums(ctx context.Context, page, limit int) ([]models.Album, error)
+	// UpdateAlbum changes the name and description of an album; nil values are left unchanged.
+	// Editors may update albums too.
+	UpdateAlbum(ctx context.Context, id primitive.ObjectID, name, description *string) (*models.Album, error)
+	// DeleteAlbum deletes an album. Its photos are kept.
+	DeleteAlbum(ctx context.Context, id primitive.ObjectID) error
+
+	// ListAlbumPhotos lists the photos of an album that are not in the trash, newest first
+	ListAlbumPhotos(ctx context.Context, id primitive.ObjectID, page, limit int) ([]models.Photo, error)
+	// AddPhotos adds photos owned by the caller to an album and returns how many were added.
+	// Contributors and editors may add photos too.
+	AddPhotos(ctx context.Context, id primitive.ObjectID, photoIDs []primitive.ObjectID) (int64, error)
+	// RemovePhotos removes photos from an album and returns how many were removed. Contributors
+	// may only remove their own photos.
+	RemovePhotos(ctx context.Context, id primitive.ObjectID, photoIDs []primitive.ObjectID) (int64, error)
+
+	// InviteMember invites a user, by username or email, to an album with a role
+	InviteMember(ctx context.Context, id primitive.ObjectID, username, email string, role models.AlbumRole) (*models.AlbumInvitation, error)
+	// ListAlbumInvitations lists the pending invitations of an album
+	ListAlbumInvitations(ctx context.Context, id primitive.ObjectID) ([]models.AlbumInvitation, error)
+	// CancelInvitation withdraws a pending invitation of an album
+	CancelInvitation(ctx context.Context, id, invitationID primitive.ObjectID) error
+	// ListMyInvitations lists the pending invitations addressed to the caller
+	ListMyInvitations(ctx context.Context) ([]models.AlbumInvitation, error)
+	// RespondToInvitation accepts or declines an invitation addressed to the caller
+	RespondToInvitation(ctx context.Context, invitationID primitive.ObjectID, accept bool) (*models.AlbumInvitation, error)
+
+	// ListMembers lists the users an album is shared with
+	ListMembers(ctx context.Context, id primitive.ObjectID) ([]models.AlbumMember, error)
+	// UpdateMemberRole changes the role of a member of an album
+	UpdateMemberRole(ctx context.Context, id primitive.ObjectID, userID string, role models.AlbumRole) (*models.AlbumMember, error)
+	// RemoveMember stops sharing an album with a user. Members may remove themselves.
+	// Photos they added stay in the album.
+	RemoveMember(ctx context.Context, id primitive.ObjectID, userID string) error
+}
+
+type albumService struct {
+	albumRepo      repositories.AlbumRepository
+	photoRepo      repositories.PhotoRepository
+	userRepo       repositories.UserRepository
+	invitationRepo repositories.AlbumInvitationRepository
+	access         accessChecker
+}
+
+// NewAlbumService creates an album service
+func NewAlbumService(albumRepo repositories.AlbumRepository, photoRepo repositories.PhotoRepository, userRepo repositories.UserRepository, invitationRepo repositories.AlbumInvitationRepository) AlbumService {
+	return &albumService{
+		albumRepo:      albumRepo,
+		photoRepo:      photoRepo,
+		userRepo:       userRepo,
+		invitationRepo: invitationRepo,
+		access:         accessChecker{photoRepo: photoRepo, albumRepo: albumRepo},
+	}
+}
+
+func (s *albumService) CreateAlbum(ctx context.Context, name, description string) (*models.Album, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: album name is required", ErrInvalidArgument)
+	}
+
+	now := time.Now()
+	album := &models.Album{
+		OwnerID:     auth.UserID(ctx),
+		Name:        name,
+		Description: description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.albumRepo.Create(ctx, album); err != nil {
+		return nil, fmt.Errorf("failed to create album: %w", err)
+	}
+	return album, nil
+}
+
+func (s *albumService) GetAlbum(ctx context.Context, id primitive.ObjectID) (*models.Album, error) {
+	return s.access.album(ctx, id, permView)
+}
+
+func (s *albumService) ListAlbums(ctx context.Context, page, limit int) ([]models.Album, error) {
+	return s.albumRepo.ListByOwner(ctx, auth.UserID(ctx), page, limit)
+}
+
+func (s *albumService) ListSharedAlbums(ctx context.Context, page, limit int) ([]models.Album, error) {
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+	return s.albumRepo.ListByMember(ctx, user.ID.Hex(), page, limit)
+}
+
+func (s *albumService) UpdateAlbum(ctx context.Context, id primitive.ObjectID, name, description *string) (*models.Album, error) {
+	album, err := s.access.album(ctx, id, permEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	if name != nil {
+		album.Name = strings.TrimSpace(*name)
+		if album.Name == "" {
+			return nil, fmt.Errorf("%w: album name is required", ErrInvalidArgument)
+		}
+	}
+	if description != nil {
+		album.Description = *description
+	}
+	album.UpdatedAt = time.Now()
+
+	if err := s.albumRepo.Update(ctx, album); err != nil {
+		return nil, fmt.Errorf("failed to update album: %w", err)
+	}
+	return album, nil
+}
+
+func (s *albumService) DeleteAlbum(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := s.access.album(ctx, id, permOwn); err != nil {
+		return err
+	}
+
+	// The album goes first so that it is never listed with photos half removed
+	if err := s.albumRepo.Delete(ctx, id); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("failed to delete album: %w", err)
+	}
+	if err := s.photoRepo.RemoveAlbum(ctx, id); err != nil {
+		return fmt.Errorf("failed to remove photos from album: %w", err)
+	}
+
+	// Invitations left pending are cancelled when answered, so failures here are harmless
+	if invitations, err := s.invitationRepo.ListPendingByAlbum(ctx, id); err == nil {
+		now := time.Now()
+		for _, invitation := range invitations {
+			_ = s.invitationRepo.Respond(ctx, invitation.ID, models.InvitationStatusCancelled, now)
+		}
+	}
+	return nil
+}
+
+func (s *albumService) ListAlbumPhotos(ctx context.Context, id primitive.ObjectID, page, limit int) ([]models.Photo, error) {
+	if _, err := s.access.album(ctx, id, permView); err != nil {
+		return nil, err
+	}
+
+	return s.photoRepo.ListByAlbum(ctx, id, page, limit)
+}
+
+func (s *albumService) AddPhotos(ctx context.Context, id primitive.ObjectID, photoIDs []primitive.ObjectID) (int64, error) {
+	if len(photoIDs) == 0 {
+		return 0, fmt.Errorf("%w: no photos given", ErrInvalidArgument)
+	}
+	if _, err := s.access.album(ctx, id, permContribute); err != nil {
+		return 0, err
+	}
+
+	added, err := s.photoRepo.AddToAlbum(ctx, id, photoIDs, auth.UserID(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("failed to add photos to album: %w", err)
+	}
+	return added, nil
+}
+
+func (s *albumService) RemovePhotos(ctx context.Context, id primitive.ObjectID, photoIDs []primitive.ObjectID) (int64, error) {
+	if len(photoIDs) == 0 {
+		return 0, fmt.Errorf("%w: no photos given", ErrInvalidArgument)
+	}
+	album, err := s.access.album(ctx, id, permContribute)
+	if err != nil {
+		return 0, err
+	}
+
+	// Contributors only take back what they added
+	ownerID := ""
+	if albumPermission(ctx, album) < permEdit {
+		ownerID = auth.UserID(ctx)
+	}
+
+	removed, err := s.photoRepo.RemoveFromAlbum(ctx, id, photoIDs, ownerID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove photos from album: %w", err)
+	}
+	return removed, nil
+}
+
+func (s *albumService) InviteMember(ctx context.Context, id primitive.ObjectID, username, email string, role models.AlbumRole) (*models.AlbumInvitation, error) {
+	if !role.IsValid() {
+		return nil, fmt.Errorf("%w: unknown role %q", ErrInvalidArgument, role)
+	}
+	username = strings.TrimSpace(username)
+	email = strings.ToLower(strings.TrimSpace(email))
+	if (username == "") == (email == "") {
+		return nil, fmt.Errorf("%w: exactly one of username and email is required", ErrInvalidArgument)
+	}
+	// Every client without a token acts as the anonymous account, so sharing with it would share with everyone
+	if strings.EqualFold(username, models.AnonymousUsername) {
+		return nil, fmt.Errorf("%w: albums cannot be shared with the anonymous account", ErrInvalidArgument)
+	}
+
+	album, err := s.access.album(ctx, id, permOwn)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unknown usernames and emails are invited like known ones, so that inviting
+	// cannot be used to find out who has an account
+	var invitee *models.User
+	if username != "" {
+		invitee, err = s.userRepo.GetByUsername(ctx, username)
+	} else {
+		invitee, err = s.userRepo.GetByEmail(ctx, email)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+
+	invitation := &models.AlbumInvitation{
+		AlbumID:   album.ID,
+		AlbumName: album.Name,
+		InviterID: auth.UserID(ctx),
+		Username:  username,
+		Email:     email,
+		Role:      role,
+		Status:    models.InvitationStatusPending,
+		CreatedAt: time.Now(),
+	}
+	if invitee != nil {
+		inviteeID := invitee.ID.Hex()
+		if inviteeID == album.OwnerID {
+			return nil, fmt.Errorf("%w: the owner cannot be invited to their own album", ErrInvalidArgument)
+		}
+		if _, ok := album.Member(inviteeID); ok {
+			return nil, fmt.Errorf("%w: the album is already shared with this user", ErrConflict)
+		}
+		invitation.InviteeID = inviteeID
+	}
+
+	pending, err := s.invitationRepo.FindPending(ctx, album.ID, invitation.InviteeID, invitation.Username, invitation.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up invitations: %w", err)
+	}
+	if pending != nil {
+		return nil, fmt.Errorf("%w: the user already has a pending invitation", ErrConflict)
+	}
+
+	if err := s.invitationRepo.Create(ctx, invitation); err != nil {
+		return nil, fmt.Errorf("failed to create invitation: %w", err)
+	}
+	return invitation, nil
+}
+
+func (s *albumService) ListAlbumInvitations(ctx context.Context, id primitive.ObjectID) ([]models.AlbumInvitation, error) {
+	if _, err := s.access.album(ctx, id, permOwn); err != nil {
+		return nil, err
+	}
+
+	return s.invitationRepo.ListPendingByAlbum(ctx, id)
+}
+
+func (s *albumService) CancelInvitation(ctx context.Context, id, invitationID primitive.ObjectID) error {
+	if _, err := s.access.album(ctx, id, permOwn); err != nil {
+		return err
+	}
+
+	invitation, err := s.invitationRepo.GetByID(ctx, invitationID)
+	if err != nil {
+		return err
+	}
+	if invitation == nil || invitation.AlbumID != id {
+		return ErrInvitationNotFound
+	}
+
+	err = s.invitationRepo.Respond(ctx, invitationID, models.InvitationStatusCancelled, time.Now())
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrInvitationNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to cancel invitation: %w", err)
+	}
+	return nil
+}
+
+func (s *albumService) ListMyInvitations(ctx context.Context) ([]models.AlbumInvitation, error) {
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+	return s.invitationRepo.ListPendingForUser(ctx, user.ID.Hex(), user.Username, user.Email)
+}
+
+// RespondToInvitation marks the invitation answered before granting the role,
+// so that an invitation cancelled in the meantime never grants access.
+func (s *albumService) RespondToInvitation(ctx context.Context, invitationID primitive.ObjectID, accept bool) (*models.AlbumInvitation, error) {
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	invitation, err := s.invitationRepo.GetByID(ctx, invitationID)
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil || !invitation.IsFor(user) || invitation.Status != models.InvitationStatusPending {
+		return nil, ErrInvitationNotFound
+	}
+
+	album, err := s.albumRepo.GetByID(ctx, invitation.AlbumID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := models.InvitationStatusDeclined
+	if accept {
+		status = models.InvitationStatusAccepted
+	}
+	if album == nil {
+		status = models.InvitationStatusCancelled
+	}
+
+	now := time.Now()
+	err = s.invitationRepo.Respond(ctx, invitationID, status, now)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvitationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to answer invitation: %w", err)
+	}
+	if album == nil {
+		return nil, ErrAlbumNotFound
+	}
+
+	invitation.Status = status
+	invitation.RespondedAt = &now
+	if !accept {
+		return invitation, nil
+	}
+
+	member := models.AlbumMember{
+		UserID:   user.ID.Hex(),
+		Username: user.Username,
+		Role:     invitation.Role,
+		AddedAt:  now,
+	}
+	if err := s.albumRepo.SetMember(ctx, album.ID, member); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrAlbumNotFound
+		}
+		return nil, fmt.Errorf("failed to add album member: %w", err)
+	}
+	return invitation, nil
+}
+
+func (s *albumService) ListMembers(ctx context.Context, id primitive.ObjectID) ([]models.AlbumMember, error) {
+	album, err := s.access.album(ctx, id, permView)
+	if err != nil {
+		return nil, err
+	}
+
+	return album.Members, nil
+}
+
+func (s *albumService) UpdateMemberRole(ctx context.Context, id primitive.ObjectID, userID string, role models.AlbumRole) (*models.AlbumMember, error) {
+	if !role.IsValid() {
+		return nil, fmt.Errorf("%w: unknown role %q", ErrInvalidArgument, role)
+	}
+
+	album, err := s.access.album(ctx, id, permOwn)
+	if err != nil {
+		return nil, err
+	}
+	member, ok := album.Member(userID)
+	if !ok {
+		return nil, ErrMemberNotFound
+	}
+
+	member.Role = role
+	if err := s.albumRepo.SetMember(ctx, id, *member); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrAlbumNotFound
+		}
+		return nil, fmt.Errorf("failed to update album member: %w", err)
+	}
+	return member, nil
+}
+
+func (s *albumService) RemoveMember(ctx context.Context, id primitive.ObjectID, userID string) error {
+	album, err := s.access.album(ctx, id, permView)
+	if err != nil {
+		return err
+	}
+	if albumPermission(ctx, album) < permOwn && userID != auth.UserID(ctx) {
+		return ErrForbidden
+	}
+	if _, ok := album.Member(userID); !ok {
+		return ErrMemberNotFound
+	}
+
+	if err := s.albumRepo.RemoveMember(ctx, id, userID); err != nil {
+		return fmt.Errorf("failed to remove album member: %w", err)
+	}
+	return nil
+}
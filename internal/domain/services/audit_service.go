@@ -0,0 +1,385 @@
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"photocloud/config"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// auditAppendAttempts bounds how often an append is retried when other writers extend the chain first
+	auditAppendAttempts = 10
+	// auditBatchSize is the number of chain entries read at a time while walking the chain
+	auditBatchSize = 1000
+)
+
+// AuditService keeps user activity in an append-only hash chain. Each entry
+// carries the hash of the one before it, and the head of the chain is signed
+// periodically, so that changing, removing or reordering entries is detected.
+type AuditService interface {
+	// Append links an activity to the end of the chain and stores it
+	Append(ctx context.Context, activity *models.UserActivity) error
+
+	// Checkpoint checks the entries added since the last checkpoint and signs the head of the
+	// chain. It returns nil if nothing was added since.
+	Checkpoint(ctx context.Context) (*models.AuditCheckpoint, error)
+
+	// Verify walks the whole chain and reports the first broken link. Checkpoint signatures are
+	// checked with publicKey; checkpoints are only compared with the chain when it is nil.
+	Verify(ctx context.Context, publicKey ed25519.PublicKey) (*models.AuditReport, error)
+
+	// Trim deletes the pruned entries before a sequence number, after signing a checkpoint
+	// that records where the chain now starts. Without a signing key nothing is deleted.
+	Trim(ctx context.Context, before int64) (int64, error)
+
+	// PublicKey returns the key checkpoints are signed with, or nil when they are disabled
+	PublicKey() ed25519.PublicKey
+}
+
+type auditService struct {
+	activityRepo   repositories.UserActivityRepository
+	checkpointRepo repositories.AuditCheckpointRepository
+	signingKey     ed25519.PrivateKey
+	// retention tells when an entry may have been pruned
+	retention config.ActivityRetention
+
+	// mu serialises appends from this process; the unique sequence index
+	// serialises them across processes
+	mu       sync.Mutex
+	loaded   bool
+	headSeq  int64
+	headHash string
+}
+
+// NewAuditService creates an audit service. The Ed25519 signing key is derived
+// from secret; checkpoints are disabled when it is empty. Pruned entries are only
+// accepted once their retention period has passed.
+func NewAuditService(activityRepo repositories.UserActivityRepository, checkpointRepo repositories.AuditCheckpointRepository, secret []byte, retention config.ActivityRetention) AuditService {
+	s := &auditService{
+		activityRepo:   activityRepo,
+		checkpointRepo: checkpointRepo,
+		retention:      retention,
+	}
+	if len(secret) > 0 {
+		seed := sha256.Sum256(secret)
+		s.signingKey = ed25519.NewKeyFromSeed(seed[:])
+	}
+	return s
+}
+
+// AuditKeyID returns the short identifier recorded with checkpoints signed by a key
+func AuditKeyID(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:8])
+}
+
+func (s *auditService) PublicKey() ed25519.PublicKey {
+	if s.signingKey == nil {
+		return nil
+	}
+	return s.signingKey.Public().(ed25519.PublicKey)
+}
+
+func (s *auditService) Append(ctx context.Context, activity *models.UserActivity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if activity.ID.IsZero() {
+		activity.ID = primitive.NewObjectID()
+	}
+	// The hash covers the timestamp as the database stores it
+	activity.Timestamp = activity.Timestamp.UTC().Truncate(time.Millisecond)
+	contentHash, err := activity.ContentDigest()
+	if err != nil {
+		return err
+	}
+	activity.ContentHash = contentHash
+
+	for attempt := 0; attempt < auditAppendAttempts; attempt++ {
+		if !s.loaded {
+			head, err := s.activityRepo.GetChainHead(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to read the audit chain head: %w", err)
+			}
+			s.headSeq, s.headHash = 0, ""
+			if head != nil {
+				s.headSeq, s.headHash = head.Sequence, head.Hash
+			}
+			s.loaded = true
+		}
+
+		activity.Sequence = s.headSeq + 1
+		activity.PrevHash = s.headHash
+		activity.Hash = activity.ChainHash()
+
+		err := s.activityRepo.Create(ctx, activity)
+		if err == nil {
+			s.headSeq, s.headHash = activity.Sequence, activity.Hash
+			return nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+		// Another process took this position, so continue after its entry
+		s.loaded = false
+	}
+	return fmt.Errorf("%w: the audit chain kept moving while appending", ErrConflict)
+}
+
+func (s *auditService) Checkpoint(ctx context.Context) (*models.AuditCheckpoint, error) {
+	if s.signingKey == nil {
+		return nil, ErrCheckpointsDisabled
+	}
+
+	latest, err := s.checkpointRepo.GetLatest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Entries are only deleted up to a checkpoint, so the chain continues after the latest one
+	var after int64
+	var afterHash string
+	if latest != nil {
+		after, afterHash = latest.Sequence, latest.Hash
+	}
+
+	// Only entries that are intact are signed, so that a checkpoint never vouches for tampering
+	var head *models.UserActivity
+	broken, err := s.walkChain(ctx, after, afterHash, 0, func(entry *models.UserActivity) *models.AuditBreak {
+		head = entry
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if broken != nil {
+		return nil, fmt.Errorf("refusing to sign a broken audit chain: entry %d: %s", broken.Sequence, broken.Reason)
+	}
+	if head == nil {
+		return nil, nil
+	}
+
+	checkpoint := &models.AuditCheckpoint{
+		Sequence:  head.Sequence,
+		Hash:      head.Hash,
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+		KeyID:     AuditKeyID(s.PublicKey()),
+	}
+	checkpoint.Signature = ed25519.Sign(s.signingKey, checkpoint.SignedPayload())
+	if err := s.checkpointRepo.Create(ctx, checkpoint); err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+func (s *auditService) Verify(ctx context.Context, publicKey ed25519.PublicKey) (*models.AuditReport, error) {
+	report := &models.AuditReport{}
+	if publicKey != nil {
+		report.KeyID = AuditKeyID(publicKey)
+	}
+
+	checkpoints, err := s.checkpointRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Checkpoints with an invalid signature are a break of their own and are not compared with the chain.
+	// The chain starts after the newest checkpoint recording that retention deleted its beginning; with a
+	// key, only a verified checkpoint can record that.
+	bySequence := make(map[int64][]models.AuditCheckpoint)
+	var start int64
+	var startHash string
+	for _, checkpoint := range checkpoints {
+		switch {
+		case publicKey == nil || checkpoint.KeyID != report.KeyID:
+			report.Unverified++
+		case !ed25519.Verify(publicKey, checkpoint.SignedPayload(), checkpoint.Signature):
+			report.NoteBreak(checkpoint.Sequence, "", fmt.Sprintf("the checkpoint created at %s has an invalid signature",
+				checkpoint.CreatedAt.UTC().Format(time.RFC3339)))
+			continue
+		default:
+			report.Checkpoints++
+		}
+		bySequence[checkpoint.Sequence] = append(bySequence[checkpoint.Sequence], checkpoint)
+
+		trusted := publicKey == nil || checkpoint.KeyID == report.KeyID
+		if checkpoint.PrefixPruned && trusted && checkpoint.Sequence > start {
+			start, startHash = checkpoint.Sequence, checkpoint.Hash
+		}
+	}
+
+	broken, err := s.walkChain(ctx, start, startHash, 0, func(entry *models.UserActivity) *models.AuditBreak {
+		if report.Entries == 0 {
+			report.FirstSequence = entry.Sequence
+		}
+		for _, checkpoint := range bySequence[entry.Sequence] {
+			if checkpoint.Hash != entry.Hash {
+				return &models.AuditBreak{Sequence: entry.Sequence, ActivityID: entry.ID.Hex(),
+					Reason: fmt.Sprintf("the hash does not match the checkpoint created at %s", checkpoint.CreatedAt.UTC().Format(time.RFC3339))}
+			}
+		}
+		report.Entries++
+		if entry.PrunedAt != nil {
+			report.Pruned++
+		}
+		report.LastSequence = entry.Sequence
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if broken != nil {
+		report.NoteBreak(broken.Sequence, broken.ActivityID, broken.Reason)
+		return report, nil
+	}
+
+	// Entries at the end of the chain cannot be removed unnoticed once a checkpoint covers them
+	for _, checkpoint := range checkpoints {
+		if checkpoint.Sequence > report.LastSequence {
+			report.NoteBreak(report.LastSequence+1, "", fmt.Sprintf("entries up to %d are covered by a checkpoint but missing", checkpoint.Sequence))
+			break
+		}
+	}
+	return report, nil
+}
+
+func (s *auditService) Trim(ctx context.Context, before int64) (int64, error) {
+	if s.signingKey == nil {
+		return 0, nil
+	}
+
+	start, startHash := int64(0), ""
+	pruned, err := s.checkpointRepo.GetLatestPruned(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if pruned != nil {
+		start, startHash = pruned.Sequence, pruned.Hash
+	}
+
+	if before > start+1 {
+		// Every entry to be deleted must be an intact stub, and the last one is signed so that
+		// the chain can only start right after it
+		var last *models.UserActivity
+		broken, err := s.walkChain(ctx, start, startHash, before-1, func(entry *models.UserActivity) *models.AuditBreak {
+			if entry.PrunedAt == nil {
+				return &models.AuditBreak{Sequence: entry.Sequence, ActivityID: entry.ID.Hex(),
+					Reason: "the entry has not been pruned"}
+			}
+			last = entry
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+		if broken != nil {
+			return 0, fmt.Errorf("refusing to delete the beginning of a broken audit chain: entry %d: %s", broken.Sequence, broken.Reason)
+		}
+		if last == nil || last.Sequence != before-1 {
+			return 0, fmt.Errorf("refusing to delete the beginning of the audit chain: entry %d is missing", before-1)
+		}
+
+		checkpoint := &models.AuditCheckpoint{
+			Sequence:     last.Sequence,
+			Hash:         last.Hash,
+			CreatedAt:    time.Now().UTC().Truncate(time.Millisecond),
+			PrefixPruned: true,
+			KeyID:        AuditKeyID(s.PublicKey()),
+		}
+		checkpoint.Signature = ed25519.Sign(s.signingKey, checkpoint.SignedPayload())
+		if err := s.checkpointRepo.Create(ctx, checkpoint); err != nil {
+			return 0, err
+		}
+		start = checkpoint.Sequence
+	}
+
+	// Stubs left behind by an earlier trim that stopped after signing are deleted as well
+	return s.activityRepo.DeletePrunedBefore(ctx, start+1)
+}
+
+// retentionPeriod returns how long activities of a type are kept, or zero when they are kept forever
+func (s *auditService) retentionPeriod(activityType models.ActivityType) time.Duration {
+	if period, ok := s.retention.Types[string(activityType)]; ok {
+		return period
+	}
+	return s.retention.Default
+}
+
+// walkChain reads the entries after the given sequence number in chain order and checks
+// that each one links to the one before and matches its hashes. The first entry must follow
+// the given hash. Pruned entries must have outlived their retention period. The walk ends
+// after the entry at last, or at the end of the chain when last is zero. visit is called
+// with every intact entry and may report a break itself.
+func (s *auditService) walkChain(ctx context.Context, after int64, afterHash string, last int64, visit func(*models.UserActivity) *models.AuditBreak) (*models.AuditBreak, error) {
+	prevSeq, prevHash := after, afterHash
+	now := time.Now()
+
+	for {
+		limit := auditBatchSize
+		if last > 0 {
+			if prevSeq >= last {
+				return nil, nil
+			}
+			if remaining := last - prevSeq; remaining < int64(limit) {
+				limit = int(remaining)
+			}
+		}
+		entries, err := s.activityRepo.ListChain(ctx, prevSeq, limit)
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) == 0 {
+			return nil, nil
+		}
+
+		for i := range entries {
+			entry := &entries[i]
+
+			switch {
+			case entry.Sequence != prevSeq+1:
+				reason := fmt.Sprintf("entries %d to %d are missing", prevSeq+1, entry.Sequence-1)
+				if entry.Sequence == prevSeq+2 {
+					reason = fmt.Sprintf("entry %d is missing", prevSeq+1)
+				}
+				return &models.AuditBreak{Sequence: prevSeq + 1, Reason: reason}, nil
+			case entry.PrevHash != prevHash:
+				return &models.AuditBreak{Sequence: entry.Sequence, ActivityID: entry.ID.Hex(),
+					Reason: fmt.Sprintf("the previous hash does not match entry %d", prevSeq)}, nil
+			case entry.ChainHash() != entry.Hash:
+				return &models.AuditBreak{Sequence: entry.Sequence, ActivityID: entry.ID.Hex(),
+					Reason: "the hash does not match the recorded activity"}, nil
+			}
+
+			// Pruned entries no longer have the content their content hash covers, but the type
+			// and timestamp their link covers must show that they were due to be pruned
+			if entry.PrunedAt == nil {
+				contentHash, err := entry.ContentDigest()
+				if err != nil {
+					return nil, err
+				}
+				if contentHash != entry.ContentHash {
+					return &models.AuditBreak{Sequence: entry.Sequence, ActivityID: entry.ID.Hex(),
+						Reason: "the content hash does not match the recorded activity"}, nil
+				}
+			} else if period := s.retentionPeriod(entry.Type); period <= 0 || entry.Timestamp.After(now.Add(-period)) {
+				return &models.AuditBreak{Sequence: entry.Sequence, ActivityID: entry.ID.Hex(),
+					Reason: fmt.Sprintf("the %s entry was pruned before its retention period ended", entry.Type)}, nil
+			}
+			if broken := visit(entry); broken != nil {
+				return broken, nil
+			}
+
+			prevSeq, prevHash = entry.Sequence, entry.Hash
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// maxBulkJobPhotos bounds the number of photos a bulk job may list
+	maxBulkJobPhotos = 10000
+	// bulkJobBatchSize is the number of photos changed at a time
+	bulkJobBatchSize = 100
+	// maxBulkJobFailures bounds the number of failed photos described on a job
+	maxBulkJobFailures = 100
+)
+
+// BulkRequest describes an action on many photos. Photos are either listed
+// by ID or selected by a filter on the caller's photos.
+type BulkRequest struct {
+	Action   models.BulkAction
+	PhotoIDs []primitive.ObjectID
+	Filter   *models.PhotoFilter
+	// AlbumID is the album of add_to_album and remove_from_album
+	AlbumID primitive.ObjectID
+	// AddTags and RemoveTags are the tags of the tag action
+	AddTags    []string
+	RemoveTags []string
+}
+
+// BulkService runs actions on many photos as background jobs
+type BulkService interface {
+	// CreateJob checks a bulk request and queues it as a job of the caller
+	CreateJob(ctx context.Context, req BulkRequest) (*models.BulkJob, error)
+	// GetJob returns a job of the caller with its progress
+	GetJob(ctx context.Context, id primitive.ObjectID) (*models.BulkJob, error)
+	// ListJobs lists the caller's jobs, newest first, without their photo lists
+	ListJobs(ctx context.Context, page, limit int) ([]models.BulkJob, error)
+
+	// RunNext claims the oldest queued job, or one whose worker stopped, and works through it on
+	// behalf of its owner. It reports whether there was a job.
+	RunNext(ctx context.Context) (bool, error)
+}
+
+type bulkService struct {
+	jobRepo      repositories.BulkJobRepository
+	photoRepo    repositories.PhotoRepository
+	userRepo     repositories.UserRepository
+	photoService PhotoService
+	albumService AlbumService
+	access       accessChecker
+	jobs         *jobRunner[models.BulkJob, *models.BulkJob]
+}
+
+// NewBulkService creates a bulk service. Photos are changed through the photo and album
+// services, so that bulk changes are published and recorded like single ones.
+func NewBulkService(jobRepo repositories.BulkJobRepository, photoRepo repositories.PhotoRepository, albumRepo repositories.AlbumRepository, userRepo repositories.UserRepository, photoService PhotoService, albumService AlbumService) BulkService {
+	s := &bulkService{
+		jobRepo:      jobRepo,
+		photoRepo:    photoRepo,
+		userRepo:     userRepo,
+		photoService: photoService,
+		albumService: albumService,
+		access:       accessChecker{photoRepo: photoRepo, albumRepo: albumRepo},
+	}
+	s.jobs = newJobRunner("bulk", jobRepo, s.run, nil)
+	return s
+}
+
+func (s *bulkService) CreateJob(ctx context.Context, req BulkRequest) (*models.BulkJob, error) {
+	userID := auth.UserID(ctx)
+	if userID == "" {
+		return nil, ErrUnauthorized
+	}
+	if !req.Action.IsValid() {
+		return nil, fmt.Errorf("%w: unknown action %q", ErrInvalidArgument, req.Action)
+	}
+	if (len(req.PhotoIDs) > 0) == (req.Filter != nil) {
+		return nil, fmt.Errorf("%w: exactly one of photo IDs and a filter is required", ErrInvalidArgument)
+	}
+	if len(req.PhotoIDs) > maxBulkJobPhotos {
+		return nil, fmt.Errorf("%w: at most %d photos can be listed; use a filter for more", ErrInvalidArgument, maxBulkJobPhotos)
+	}
+
+	job := &models.BulkJob{
+		Job:    models.Job{OwnerID: userID, Status: models.BulkJobQueued, CreatedAt: time.Now()},
+		Action: req.Action,
+	}
+
+	switch req.Action {
+	case models.BulkActionAddToAlbum, models.BulkActionRemoveFromAlbum:
+		if req.AlbumID.IsZero() {
+			return nil, fmt.Errorf("%w: an album is required", ErrInvalidArgument)
+		}
+		if _, err := s.access.album(ctx, req.AlbumID, permContribute); err != nil {
+			return nil, err
+		}
+		job.AlbumID = req.AlbumID
+	case models.BulkActionTag:
+		var err error
+		if job.AddTags, err = normalizeTags(req.AddTags); err != nil {
+			return nil, err
+		}
+		if job.RemoveTags, err = normalizeTags(req.RemoveTags); err != nil {
+			return nil, err
+		}
+		if len(job.AddTags) == 0 && len(job.RemoveTags) == 0 {
+			return nil, fmt.Errorf("%w: no tags to add or remove", ErrInvalidArgument)
+		}
+	}
+
+	if req.Filter != nil {
+		filter := *req.Filter
+		if filter.UploadedAfter != nil && filter.UploadedBefore != nil && !filter.UploadedAfter.Before(*filter.UploadedBefore) {
+			return nil, fmt.Errorf("%w: uploaded_after must be before uploaded_before", ErrInvalidArgument)
+		}
+		var err error
+		if filter.Tags, err = normalizeTags(filter.Tags); err != nil {
+			return nil, err
+		}
+		if job.Total, err = s.photoRepo.CountByFilter(ctx, userID, filter, req.Action.OnTrash()); err != nil {
+			return nil, err
+		}
+		job.Filter = &filter
+	} else {
+		// Photos are worked on in ID order, so the cursor tells how far a job got
+		job.PhotoIDs = slices.Clone(req.PhotoIDs)
+		slices.SortFunc(job.PhotoIDs, compareObjectIDs)
+		job.PhotoIDs = slices.Compact(job.PhotoIDs)
+		job.Total = int64(len(job.PhotoIDs))
+	}
+
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (s *bulkService) GetJob(ctx context.Context, id primitive.ObjectID) (*models.BulkJob, error) {
+	job, err := s.jobRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrBulkJobNotFound
+	}
+	if userID := auth.UserID(ctx); userID != "" && job.OwnerID != userID {
+		return nil, ErrBulkJobNotFound
+	}
+	return job, nil
+}
+
+func (s *bulkService) ListJobs(ctx context.Context, page, limit int) ([]models.BulkJob, error) {
+	userID := auth.UserID(ctx)
+	if userID == "" {
+		return nil, ErrUnauthorized
+	}
+	return s.jobRepo.ListByOwner(ctx, userID, page, limit)
+}
+
+func (s *bulkService) RunNext(ctx context.Context) (bool, error) {
+	return s.jobs.runNext(ctx)
+}
+
+// run works through the photos of a job in batches, saving progress after each one
+func (s *bulkService) run(ctx context.Context, job *models.BulkJob) error {
+	ctx, err := ownerContext(ctx, s.userRepo, job.OwnerID)
+	if err != nil {
+		return err
+	}
+
+	for {
+		ids, err := s.nextBatch(ctx, job)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		batch, err := s.apply(ctx, job, ids)
+		if err != nil {
+			return err
+		}
+
+		job.Processed += int64(len(ids))
+		job.Succeeded += int64(len(ids) - len(batch.Failed))
+		job.Failed += int64(len(batch.Failed))
+		// Failures are described in photo order
+		for _, id := range ids {
+			if err, ok := batch.Failed[id]; ok && len(job.Failures) < maxBulkJobFailures {
+				job.Failures = append(job.Failures, models.BulkFailure{PhotoID: id, Error: err.Error()})
+			}
+		}
+		job.Cursor = ids[len(ids)-1]
+		if err := s.jobs.extendLease(ctx, job); err != nil {
+			return err
+		}
+	}
+}
+
+// nextBatch returns the IDs of the next photos of a job after its cursor
+func (s *bulkService) nextBatch(ctx context.Context, job *models.BulkJob) ([]primitive.ObjectID, error) {
+	if job.Filter == nil {
+		start := sort.Search(len(job.PhotoIDs), func(i int) bool {
+			return compareObjectIDs(job.PhotoIDs[i], job.Cursor) > 0
+		})
+		end := min(start+bulkJobBatchSize, len(job.PhotoIDs))
+		return job.PhotoIDs[start:end], nil
+	}
+
+	photos, err := s.photoRepo.ListByFilter(ctx, job.OwnerID, *job.Filter, job.Action.OnTrash(), job.Cursor, bulkJobBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	return photoIDs(photos), nil
+}
+
+// apply runs the action of a job on a batch of photos
+func (s *bulkService) apply(ctx context.Context, job *models.BulkJob, ids []primitive.ObjectID) (*PhotoBatch, error) {
+	switch job.Action {
+	case models.BulkActionDelete:
+		return s.photoService.TrashPhotos(ctx, ids)
+	case models.BulkActionRestore:
+		return s.photoService.RestorePhotos(ctx, ids)
+	case models.BulkActionPurge:
+		return s.photoService.PurgePhotos(ctx, ids)
+	case models.BulkActionTag:
+		return s.photoService.TagPhotos(ctx, ids, job.AddTags, job.RemoveTags)
+	case models.BulkActionAddToAlbum, models.BulkActionRemoveFromAlbum:
+		return s.changeAlbum(ctx, job, ids)
+	}
+	return nil, fmt.Errorf("%w: unknown action %q", ErrInvalidArgument, job.Action)
+}
+
+// changeAlbum adds photos to or removes them from the album of a job. Only the
+// caller's own photos can be added; contributors can only remove their own photos.
+// Photos already added or removed count as changed, so that batches can be repeated.
+func (s *bulkService) changeAlbum(ctx context.Context, job *models.BulkJob, ids []primitive.ObjectID) (*PhotoBatch, error) {
+	album, err := s.access.album(ctx, job.AlbumID, permContribute)
+	if err != nil {
+		return nil, err
+	}
+	photos, err := s.photoRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	userID := auth.UserID(ctx)
+	ownOnly := job.Action == models.BulkActionAddToAlbum || albumPermission(ctx, album) < permEdit
+	batch := &PhotoBatch{Failed: make(map[primitive.ObjectID]error, len(ids))}
+	for _, id := range ids {
+		batch.Failed[id] = ErrPhotoNotFound
+	}
+	for _, photo := range photos {
+		if photo.IsTrashed() {
+			continue
+		}
+		if err := s.access.checkPhoto(ctx, &photo, permView); err != nil {
+			batch.Failed[photo.ID] = err
+			continue
+		}
+		if ownOnly && photo.OwnerID != userID {
+			batch.Failed[photo.ID] = ErrForbidden
+			continue
+		}
+		delete(batch.Failed, photo.ID)
+		batch.Changed = append(batch.Changed, photo)
+	}
+	if len(batch.Changed) == 0 {
+		return batch, nil
+	}
+
+	if job.Action == models.BulkActionAddToAlbum {
+		_, err = s.albumService.AddPhotos(ctx, job.AlbumID, photoIDs(batch.Changed))
+	} else {
+		_, err = s.albumService.RemovePhotos(ctx, job.AlbumID, photoIDs(batch.Changed))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+func compareObjectIDs(a, b primitive.ObjectID) int {
+	return bytes.Compare(a[:], b[:])
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"photocloud/internal/domain/models"
+	"photocloud/internal/imaging"
+)
+
+// toImagingEdits converts a stored edit recipe to imaging operations
+func toImagingEdits(ops []models.EditOperation) []imaging.Edit {
+	edits := make([]imaging.Edit, len(ops))
+	for i, op := range ops {
+		edits[i] = imaging.Edit{
+			Kind:   imaging.EditKind(op.Type),
+			X:      op.X,
+			Y:      op.Y,
+			Width:  op.Width,
+			Height: op.Height,
+			Angle:  op.Angle,
+			Amount: op.Amount,
+		}
+	}
+	return edits
+}
+
+// fromImagingEdits converts imaging operations back to a stored edit recipe
+func fromImagingEdits(edits []imaging.Edit) []models.EditOperation {
+	ops := make([]models.EditOperation, len(edits))
+	for i, edit := range edits {
+		ops[i] = models.EditOperation{
+			Type:   models.EditOperationType(edit.Kind),
+			X:      edit.X,
+			Y:      edit.Y,
+			Width:  edit.Width,
+			Height: edit.Height,
+			Angle:  edit.Angle,
+			Amount: edit.Amount,
+		}
+	}
+	return ops
+}
@@ -0,0 +1,84 @@
+package services
+
+import "errors"
+
+var (
+	// ErrPhotoNotFound is returned when a photo does not exist or is not visible to the caller
+	ErrPhotoNotFound = errors.New("photo not found")
+
+	// ErrPhotoNotInTrash is returned when a trash operation targets a photo that is not in the trash
+	ErrPhotoNotInTrash = errors.New("photo is not in the trash")
+
+	// ErrInvalidArgument is returned when a request parameter is not acceptable
+	ErrInvalidArgument = errors.New("invalid argument")
+
+	// ErrInvalidSignature is returned when a signed URL does not match its parameters
+	ErrInvalidSignature = errors.New("invalid signature")
+
+	// ErrTransformsDisabled is returned when image transforms are used without a signing key
+	ErrTransformsDisabled = errors.New("image transforms are not configured")
+
+	// ErrCheckpointsDisabled is returned when an audit checkpoint is requested without a signing key
+	ErrCheckpointsDisabled = errors.New("audit checkpoints are not configured")
+
+	// ErrAlbumNotFound is returned when an album does not exist or is not visible to the caller
+	ErrAlbumNotFound = errors.New("album not found")
+
+	// ErrInvitationNotFound is returned when an album invitation does not exist, is not addressed to
+	// the caller or has already been answered
+	ErrInvitationNotFound = errors.New("invitation not found")
+
+	// ErrMemberNotFound is returned when an album is not shared with the given user
+	ErrMemberNotFound = errors.New("album member not found")
+
+	// ErrShareNotFound is returned when a share link does not exist
+	ErrShareNotFound = errors.New("share link not found")
+
+	// ErrShareExpired is returned when a share link has expired or been revoked
+	ErrShareExpired = errors.New("share link has expired or been revoked")
+
+	// ErrSyncTokenExpired is returned when the changes after a sync token are no longer kept
+	ErrSyncTokenExpired = errors.New("sync token has expired, list everything again")
+
+	// ErrPasswordRequired is returned when a password-protected share link is opened without the right password
+	ErrPasswordRequired = errors.New("share link requires a valid password")
+
+	// ErrForbidden is returned when the caller may see a resource but not perform the operation
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrVersionNotFound is returned when a photo version does not exist or has been pruned
+	ErrVersionNotFound = errors.New("photo version not found")
+
+	// ErrWebhookNotFound is returned when a webhook does not exist or belongs to someone else
+	ErrWebhookNotFound = errors.New("webhook not found")
+
+	// ErrDeliveryNotFound is returned when a webhook delivery does not exist or has expired
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+
+	// ErrBulkJobNotFound is returned when a bulk job does not exist, belongs to someone else or has expired
+	ErrBulkJobNotFound = errors.New("bulk job not found")
+
+	// ErrExportNotFound is returned when an export job does not exist, belongs to someone else or has expired
+	ErrExportNotFound = errors.New("export not found")
+
+	// ErrImportNotFound is returned when an import job does not exist, belongs to someone else or has expired
+	ErrImportNotFound = errors.New("import not found")
+
+	// ErrConflict is returned when a change collides with a concurrent change or existing data
+	ErrConflict = errors.New("conflict")
+
+	// ErrUnauthorized is returned when a request does not carry valid credentials
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrQuotaExceeded is returned when storing more would exceed the owner's storage quota
+	ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+	// ErrUploadTooLarge is returned when a single upload is larger than the owner's whole storage quota
+	ErrUploadTooLarge = errors.New("upload is larger than the storage quota")
+
+	// ErrArchiveTooLarge is returned when an archive uploaded for import exceeds the size limit
+	ErrArchiveTooLarge = errors.New("archive is too large")
+
+	// ErrBusy is returned when a bounded worker pool has no free slot in time
+	ErrBusy = errors.New("server is busy, retry later")
+)
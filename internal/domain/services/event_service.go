@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+)
+
+// eventSubscriptionBuffer is how many events may wait for a subscriber before it is dropped
+const eventSubscriptionBuffer = 64
+
+// EventPublisher accepts events for delivery to their recipients. Publish is called
+// in the path of the operation that made the event and must not wait on recipients;
+// a failure to deliver an event never fails the operation.
+type EventPublisher interface {
+	Publish(event *models.Event)
+}
+
+// eventPublishers hands every event to several publishers in turn
+type eventPublishers []EventPublisher
+
+// NewEventFanout returns a publisher that hands every event to each of publishers
+func NewEventFanout(publishers ...EventPublisher) EventPublisher {
+	return eventPublishers(publishers)
+}
+
+func (p eventPublishers) Publish(event *models.Event) {
+	for _, publisher := range p {
+		publisher.Publish(event)
+	}
+}
+
+// EventService delivers the events published on this instance to the users
+// subscribed to it. It publishes directly to its subscribers; an event relay
+// in front of it shares events with other instances.
+type EventService interface {
+	EventPublisher
+
+	// Subscribe follows the events published to the caller from now on
+	Subscribe(ctx context.Context) (*EventSubscription, error)
+}
+
+// EventSubscription receives the events published to one user
+type EventSubscription struct {
+	userID  string
+	events  chan *models.Event
+	service *eventService
+}
+
+// Events returns the channel events arrive on. It is closed when the subscription
+// is closed, or when the subscriber fell so far behind that events were lost.
+func (s *EventSubscription) Events() <-chan *models.Event {
+	return s.events
+}
+
+// Close stops the subscription
+func (s *EventSubscription) Close() {
+	s.service.remove(s)
+}
+
+type eventService struct {
+	mu            sync.RWMutex
+	subscriptions map[string]map[*EventSubscription]struct{}
+}
+
+// NewEventService creates an event service without subscribers
+func NewEventService() EventService {
+	return &eventService{
+		subscriptions: make(map[string]map[*EventSubscription]struct{}),
+	}
+}
+
+func (s *eventService) Subscribe(ctx context.Context) (*EventSubscription, error) {
+	userID := auth.UserID(ctx)
+	if userID == "" {
+		return nil, ErrUnauthorized
+	}
+
+	subscription := &EventSubscription{
+		userID:  userID,
+		events:  make(chan *models.Event, eventSubscriptionBuffer),
+		service: s,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subscriptions[userID] == nil {
+		s.subscriptions[userID] = make(map[*EventSubscription]struct{})
+	}
+	s.subscriptions[userID][subscription] = struct{}{}
+	return subscription, nil
+}
+
+func (s *eventService) Publish(event *models.Event) {
+	var lagging []*EventSubscription
+
+	s.mu.RLock()
+	for _, userID := range event.Recipients {
+		for subscription := range s.subscriptions[userID] {
+			select {
+			case subscription.events <- event:
+			default:
+				lagging = append(lagging, subscription)
+			}
+		}
+	}
+	s.mu.RUnlock()
+
+	// Subscribers that missed an event are dropped so that they reconnect and catch up
+	for _, subscription := range lagging {
+		s.remove(subscription)
+	}
+}
+
+// remove forgets a subscription and closes its channel, unless that already happened
+func (s *eventService) remove(subscription *EventSubscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscriptions := s.subscriptions[subscription.userID]
+	if _, ok := subscriptions[subscription]; !ok {
+		return
+	}
+	delete(subscriptions, subscription)
+	if len(subscriptions) == 0 {
+		delete(s.subscriptions, subscription.userID)
+	}
+	close(subscription.events)
+}
@@ -0,0 +1,390 @@
+package services
+
+import (
+	"context"
+	"io"
+	"slices"
+	"time"
+
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+	"photocloud/internal/imaging"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// eventAlbumPageSize is how many photos of an album are loaded at once to publish their changes
+const eventAlbumPageSize = 500
+
+// eventPhotoService publishes uploads, changes and deletes of photos to the
+// users who can see them. Other operations pass through.
+type eventPhotoService struct {
+	PhotoService
+	photoRepo  repositories.PhotoRepository
+	recipients eventRecipients
+	publisher  EventPublisher
+}
+
+// NewEventPhotoService wraps a photo service so that changes to photos are published as events
+func NewEventPhotoService(photoService PhotoService, photoRepo repositories.PhotoRepository, albumRepo repositories.AlbumRepository, publisher EventPublisher) PhotoService {
+	return &eventPhotoService{
+		PhotoService: photoService,
+		photoRepo:    photoRepo,
+		recipients:   eventRecipients{albumRepo: albumRepo},
+		publisher:    publisher,
+	}
+}
+
+func (s *eventPhotoService) UploadPhoto(ctx context.Context, name, description string, content io.Reader, contentType string, size int64) (*models.Photo, error) {
+	photo, err := s.PhotoService.UploadPhoto(ctx, name, description, content, contentType, size)
+	if err == nil {
+		s.publish(ctx, models.EventTypePhotoCreated, photo)
+	}
+	return photo, err
+}
+
+func (s *eventPhotoService) ImportPhoto(ctx context.Context, name, description string, content io.Reader, contentType string, size int64, metadata PhotoMetadata) (*models.Photo, error) {
+	photo, err := s.PhotoService.ImportPhoto(ctx, name, description, content, contentType, size, metadata)
+	if err == nil {
+		s.publish(ctx, models.EventTypePhotoCreated, photo)
+	}
+	return photo, err
+}
+
+func (s *eventPhotoService) DeletePhoto(ctx context.Context, id primitive.ObjectID) error {
+	err := s.PhotoService.DeletePhoto(ctx, id)
+	if err == nil {
+		// The photo is still readable in the trash
+		if photo, _ := s.photoRepo.GetByID(ctx, id); photo != nil {
+			s.publish(ctx, models.EventTypePhotoDeleted, photo)
+		}
+	}
+	return err
+}
+
+func (s *eventPhotoService) RestorePhoto(ctx context.Context, id primitive.ObjectID) (*models.Photo, error) {
+	photo, err := s.PhotoService.RestorePhoto(ctx, id)
+	if err == nil {
+		s.publish(ctx, models.EventTypePhotoUpdated, photo)
+	}
+	return photo, err
+}
+
+func (s *eventPhotoService) PurgePhoto(ctx context.Context, id primitive.ObjectID) error {
+	// The recipients are looked up before the photo is gone
+	photo, _ := s.photoRepo.GetByID(ctx, id)
+	err := s.PhotoService.PurgePhoto(ctx, id)
+	if err == nil && photo != nil {
+		s.publish(ctx, models.EventTypePhotoDeleted, photo)
+	}
+	return err
+}
+
+func (s *eventPhotoService) RotatePhoto(ctx context.Context, id primitive.ObjectID, degrees int, flip imaging.Flip) (*models.Photo, error) {
+	photo, err := s.PhotoService.RotatePhoto(ctx, id, degrees, flip)
+	if err == nil {
+		s.publish(ctx, models.EventTypePhotoUpdated, photo)
+	}
+	return photo, err
+}
+
+func (s *eventPhotoService) UpdateEdits(ctx context.Context, id primitive.ObjectID, ops []models.EditOperation) (*models.Photo, error) {
+	photo, err := s.PhotoService.UpdateEdits(ctx, id, ops)
+	if err == nil {
+		s.publish(ctx, models.EventTypePhotoUpdated, photo)
+	}
+	return photo, err
+}
+
+func (s *eventPhotoService) UploadVersion(ctx context.Context, id primitive.ObjectID, content io.Reader, contentType string, size int64) (*models.Photo, error) {
+	photo, err := s.PhotoService.UploadVersion(ctx, id, content, contentType, size)
+	if err == nil {
+		s.publish(ctx, models.EventTypePhotoUpdated, photo)
+	}
+	return photo, err
+}
+
+func (s *eventPhotoService) RevertPhoto(ctx context.Context, id primitive.ObjectID, number int) (*models.Photo, error) {
+	photo, err := s.PhotoService.RevertPhoto(ctx, id, number)
+	if err == nil {
+		s.publish(ctx, models.EventTypePhotoUpdated, photo)
+	}
+	return photo, err
+}
+
+func (s *eventPhotoService) TrashPhotos(ctx context.Context, ids []primitive.ObjectID) (*PhotoBatch, error) {
+	batch, err := s.PhotoService.TrashPhotos(ctx, ids)
+	s.publishBatch(ctx, models.EventTypePhotoDeleted, batch, err)
+	return batch, err
+}
+
+func (s *eventPhotoService) RestorePhotos(ctx context.Context, ids []primitive.ObjectID) (*PhotoBatch, error) {
+	batch, err := s.PhotoService.RestorePhotos(ctx, ids)
+	s.publishBatch(ctx, models.EventTypePhotoUpdated, batch, err)
+	return batch, err
+}
+
+func (s *eventPhotoService) PurgePhotos(ctx context.Context, ids []primitive.ObjectID) (*PhotoBatch, error) {
+	batch, err := s.PhotoService.PurgePhotos(ctx, ids)
+	s.publishBatch(ctx, models.EventTypePhotoDeleted, batch, err)
+	return batch, err
+}
+
+func (s *eventPhotoService) TagPhotos(ctx context.Context, ids []primitive.ObjectID, add, remove []string) (*PhotoBatch, error) {
+	batch, err := s.PhotoService.TagPhotos(ctx, ids, add, remove)
+	s.publishBatch(ctx, models.EventTypePhotoUpdated, batch, err)
+	return batch, err
+}
+
+// publishBatch publishes an event for each photo a batch operation changed
+func (s *eventPhotoService) publishBatch(ctx context.Context, eventType models.EventType, batch *PhotoBatch, err error) {
+	if err != nil {
+		return
+	}
+	for i := range batch.Changed {
+		s.publish(ctx, eventType, &batch.Changed[i])
+	}
+}
+
+func (s *eventPhotoService) publish(ctx context.Context, eventType models.EventType, photo *models.Photo) {
+	publishEvent(ctx, s.publisher, eventType, photo.ID, primitive.NilObjectID, s.recipients.ofPhoto(ctx, photo))
+}
+
+// eventAlbumService publishes changes to albums, their photos and their
+// members to the users who can see them. Photos that become visible or
+// invisible to a user through an album are published to that user as well.
+// Other operations pass through.
+type eventAlbumService struct {
+	AlbumService
+	photoRepo  repositories.PhotoRepository
+	albumRepo  repositories.AlbumRepository
+	recipients eventRecipients
+	publisher  EventPublisher
+}
+
+// NewEventAlbumService wraps an album service so that changes to albums are published as events
+func NewEventAlbumService(albumService AlbumService, photoRepo repositories.PhotoRepository, albumRepo repositories.AlbumRepository, publisher EventPublisher) AlbumService {
+	return &eventAlbumService{
+		AlbumService: albumService,
+		photoRepo:    photoRepo,
+		albumRepo:    albumRepo,
+		recipients:   eventRecipients{albumRepo: albumRepo},
+		publisher:    publisher,
+	}
+}
+
+func (s *eventAlbumService) CreateAlbum(ctx context.Context, name, description string) (*models.Album, error) {
+	album, err := s.AlbumService.CreateAlbum(ctx, name, description)
+	if err == nil {
+		s.publish(ctx, album)
+	}
+	return album, err
+}
+
+func (s *eventAlbumService) UpdateAlbum(ctx context.Context, id primitive.ObjectID, name, description *string) (*models.Album, error) {
+	album, err := s.AlbumService.UpdateAlbum(ctx, id, name, description)
+	if err == nil {
+		s.publish(ctx, album)
+	}
+	return album, err
+}
+
+func (s *eventAlbumService) DeleteAlbum(ctx context.Context, id primitive.ObjectID) error {
+	// The members and photos are looked up before the album is gone
+	album, _ := s.albumRepo.GetByID(ctx, id)
+	var photos []models.Photo
+	if album != nil {
+		photos = s.albumPhotos(ctx, id)
+	}
+	err := s.AlbumService.DeleteAlbum(ctx, id)
+	if err == nil && album != nil {
+		s.publish(ctx, album)
+		s.publishPhotos(ctx, album, photos)
+	}
+	return err
+}
+
+func (s *eventAlbumService) AddPhotos(ctx context.Context, id primitive.ObjectID, photoIDs []primitive.ObjectID) (int64, error) {
+	added, err := s.AlbumService.AddPhotos(ctx, id, photoIDs)
+	if err == nil && added > 0 {
+		s.publishPhotoChanges(ctx, id, photoIDs, true)
+	}
+	return added, err
+}
+
+func (s *eventAlbumService) RemovePhotos(ctx context.Context, id primitive.ObjectID, photoIDs []primitive.ObjectID) (int64, error) {
+	removed, err := s.AlbumService.RemovePhotos(ctx, id, photoIDs)
+	if err == nil && removed > 0 {
+		s.publishPhotoChanges(ctx, id, photoIDs, false)
+	}
+	return removed, err
+}
+
+func (s *eventAlbumService) RespondToInvitation(ctx context.Context, invitationID primitive.ObjectID, accept bool) (*models.AlbumInvitation, error) {
+	invitation, err := s.AlbumService.RespondToInvitation(ctx, invitationID, accept)
+	if err == nil && accept {
+		s.publishByID(ctx, invitation.AlbumID)
+		// The new member is told about the photos they can see now
+		recipients := []string{auth.UserID(ctx)}
+		photos := s.albumPhotos(ctx, invitation.AlbumID)
+		for i := range photos {
+			publishEvent(ctx, s.publisher, models.EventTypePhotoUpdated, photos[i].ID, primitive.NilObjectID, recipients)
+		}
+	}
+	return invitation, err
+}
+
+func (s *eventAlbumService) UpdateMemberRole(ctx context.Context, id primitive.ObjectID, userID string, role models.AlbumRole) (*models.AlbumMember, error) {
+	member, err := s.AlbumService.UpdateMemberRole(ctx, id, userID, role)
+	if err == nil {
+		s.publishByID(ctx, id)
+	}
+	return member, err
+}
+
+func (s *eventAlbumService) RemoveMember(ctx context.Context, id primitive.ObjectID, userID string) error {
+	// The removed member is told too, so the album is looked up before they leave
+	album, _ := s.albumRepo.GetByID(ctx, id)
+	err := s.AlbumService.RemoveMember(ctx, id, userID)
+	if err == nil && album != nil {
+		s.publish(ctx, album)
+		// They are told about the photos they can no longer see, unless they own them
+		// or see them through another album
+		recipients := []string{userID}
+		photos := s.albumPhotos(ctx, id)
+		for i := range photos {
+			if !slices.Contains(s.recipients.ofPhoto(ctx, &photos[i]), userID) {
+				publishEvent(ctx, s.publisher, models.EventTypePhotoDeleted, photos[i].ID, primitive.NilObjectID, recipients)
+			}
+		}
+	}
+	return err
+}
+
+func (s *eventAlbumService) publishByID(ctx context.Context, id primitive.ObjectID) *models.Album {
+	album, _ := s.albumRepo.GetByID(ctx, id)
+	if album != nil {
+		s.publish(ctx, album)
+	}
+	return album
+}
+
+// publishPhotoChanges publishes the album and the photos that were added to it, or
+// removed from it
+func (s *eventAlbumService) publishPhotoChanges(ctx context.Context, id primitive.ObjectID, photoIDs []primitive.ObjectID, added bool) {
+	album := s.publishByID(ctx, id)
+	if album == nil {
+		return
+	}
+	photos, err := s.photoRepo.GetByIDs(ctx, photoIDs)
+	if err != nil {
+		return
+	}
+	// Photos that could not be added or removed need no event
+	changed := photos[:0]
+	for _, photo := range photos {
+		if photo.InAlbum(id) == added {
+			changed = append(changed, photo)
+		}
+	}
+	s.publishPhotos(ctx, album, changed)
+}
+
+// publishPhotos publishes photos whose albums changed to the users who can see them,
+// and deletes them for the owner and members of the album who no longer can. Photos
+// in the trash were deleted for everyone already.
+func (s *eventAlbumService) publishPhotos(ctx context.Context, album *models.Album, photos []models.Photo) {
+	members := albumRecipients(album, nil)
+	for i := range photos {
+		photo := &photos[i]
+		if photo.IsTrashed() {
+			continue
+		}
+		recipients := s.recipients.ofPhoto(ctx, photo)
+		publishEvent(ctx, s.publisher, models.EventTypePhotoUpdated, photo.ID, primitive.NilObjectID, recipients)
+
+		var lost []string
+		for _, userID := range members {
+			if !slices.Contains(recipients, userID) {
+				lost = append(lost, userID)
+			}
+		}
+		publishEvent(ctx, s.publisher, models.EventTypePhotoDeleted, photo.ID, primitive.NilObjectID, lost)
+	}
+}
+
+// albumPhotos loads the photos of an album that are not in the trash. Events are
+// published on a best-effort basis, so the photos listed before a failure are returned.
+func (s *eventAlbumService) albumPhotos(ctx context.Context, id primitive.ObjectID) []models.Photo {
+	var photos []models.Photo
+	for page := 1; ; page++ {
+		batch, err := s.photoRepo.ListByAlbum(ctx, id, page, eventAlbumPageSize)
+		photos = append(photos, batch...)
+		if err != nil || len(batch) < eventAlbumPageSize {
+			return photos
+		}
+	}
+}
+
+func (s *eventAlbumService) publish(ctx context.Context, album *models.Album) {
+	publishEvent(ctx, s.publisher, models.EventTypeAlbumChanged, primitive.NilObjectID, album.ID, albumRecipients(album, nil))
+}
+
+// eventRecipients finds the users an event about a photo is delivered to
+type eventRecipients struct {
+	albumRepo repositories.AlbumRepository
+}
+
+// ofPhoto returns the owner of a photo and the owners and members of the albums it is in.
+// Albums that cannot be read are skipped, so that the owner is told in any case.
+func (r eventRecipients) ofPhoto(ctx context.Context, photo *models.Photo) []string {
+	var recipients []string
+	if photo.OwnerID != "" {
+		recipients = append(recipients, photo.OwnerID)
+	}
+	for _, albumID := range photo.AlbumIDs {
+		album, err := r.albumRepo.GetByID(ctx, albumID)
+		if err != nil || album == nil {
+			continue
+		}
+		recipients = albumRecipients(album, recipients)
+	}
+	return recipients
+}
+
+// albumRecipients adds the owner and members of an album to recipients, skipping duplicates
+func albumRecipients(album *models.Album, recipients []string) []string {
+	seen := make(map[string]bool, len(recipients)+len(album.Members)+1)
+	for _, userID := range recipients {
+		seen[userID] = true
+	}
+	add := func(userID string) {
+		if userID != "" && !seen[userID] {
+			seen[userID] = true
+			recipients = append(recipients, userID)
+		}
+	}
+
+	add(album.OwnerID)
+	for _, member := range album.Members {
+		add(member.UserID)
+	}
+	return recipients
+}
+
+// publishEvent hands an event made by the caller to the publisher
+func publishEvent(ctx context.Context, publisher EventPublisher, eventType models.EventType, photoID, albumID primitive.ObjectID, recipients []string) {
+	if len(recipients) == 0 {
+		return
+	}
+	publisher.Publish(&models.Event{
+		ID:         primitive.NewObjectID(),
+		Type:       eventType,
+		PhotoID:    photoID,
+		AlbumID:    albumID,
+		ActorID:    auth.UserID(ctx),
+		Recipients: recipients,
+		Timestamp:  time.Now(),
+	})
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"testing"
+
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memberAlbumService changes the albums and photos of fake repositories the way
+// the album service does, without checking permissions
+type memberAlbumService struct {
+	AlbumService
+	photos      *fakePhotoRepo
+	albums      *fakeAlbumRepo
+	invitations map[primitive.ObjectID]*models.AlbumInvitation
+}
+
+func (s *memberAlbumService) AddPhotos(ctx context.Context, id primitive.ObjectID, photoIDs []primitive.ObjectID) (int64, error) {
+	var added int64
+	for _, photoID := range photoIDs {
+		if photo := s.photos.photos[photoID]; !photo.InAlbum(id) {
+			photo.AlbumIDs = append(photo.AlbumIDs, id)
+			added++
+		}
+	}
+	return added, nil
+}
+
+func (s *memberAlbumService) RemovePhotos(ctx context.Context, id primitive.ObjectID, photoIDs []primitive.ObjectID) (int64, error) {
+	var removed int64
+	for _, photoID := range photoIDs {
+		photo := s.photos.photos[photoID]
+		if index := slices.Index(photo.AlbumIDs, id); index >= 0 {
+			photo.AlbumIDs = slices.Delete(photo.AlbumIDs, index, index+1)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func (s *memberAlbumService) RespondToInvitation(ctx context.Context, invitationID primitive.ObjectID, accept bool) (*models.AlbumInvitation, error) {
+	invitation := s.invitations[invitationID]
+	album := s.albums.albums[invitation.AlbumID]
+	album.Members = append(album.Members, models.AlbumMember{UserID: auth.UserID(ctx), Role: invitation.Role})
+	return invitation, nil
+}
+
+func (s *memberAlbumService) RemoveMember(ctx context.Context, id primitive.ObjectID, userID string) error {
+	album := s.albums.albums[id]
+	album.Members = slices.DeleteFunc(album.Members, func(member models.AlbumMember) bool { return member.UserID == userID })
+	return nil
+}
+
+func (s *memberAlbumService) DeleteAlbum(ctx context.Context, id primitive.ObjectID) error {
+	delete(s.albums.albums, id)
+	for _, photo := range s.photos.photos {
+		photo.AlbumIDs = slices.DeleteFunc(photo.AlbumIDs, func(albumID primitive.ObjectID) bool { return albumID == id })
+	}
+	return nil
+}
+
+// syncPublisher records the changes of every event in the change feeds
+type syncPublisher struct {
+	t    *testing.T
+	sync SyncService
+}
+
+func (p syncPublisher) Publish(event *models.Event) {
+	if err := p.sync.RecordChanges(context.Background(), event); err != nil {
+		p.t.Fatalf("RecordChanges() error = %v", err)
+	}
+}
+
+// syncFeed follows the change feed of one user
+type syncFeed struct {
+	ctx   context.Context
+	token string
+}
+
+// changes returns the photos and albums that changed since the last call, and whether
+// each is a tombstone
+func (f *syncFeed) changes(t *testing.T, sync SyncService) map[primitive.ObjectID]bool {
+	t.Helper()
+	batch, err := sync.ListChanges(f.ctx, f.token, 100)
+	if err != nil {
+		t.Fatalf("ListChanges() error = %v", err)
+	}
+	f.token = batch.Next
+	changes := make(map[primitive.ObjectID]bool, len(batch.Entries))
+	for _, entry := range batch.Entries {
+		changes[entry.ID] = entry.Deleted
+	}
+	return changes
+}
+
+func TestAlbumChangesReachMemberFeeds(t *testing.T) {
+	ownerCtx, owner := userContext()
+	memberCtx, member := userContext()
+	inviteeCtx, _ := userContext()
+
+	album := &models.Album{ID: primitive.NewObjectID(), OwnerID: owner.ID.Hex(), Members: []models.AlbumMember{{UserID: member.ID.Hex(), Role: models.AlbumRoleViewer}}}
+	beach := &models.Photo{ID: primitive.NewObjectID(), OwnerID: owner.ID.Hex()}
+	forest := &models.Photo{ID: primitive.NewObjectID(), OwnerID: owner.ID.Hex()}
+	// The member's own photo stays theirs when they leave the album
+	mine := &models.Photo{ID: primitive.NewObjectID(), OwnerID: member.ID.Hex()}
+	invitation := &models.AlbumInvitation{ID: primitive.NewObjectID(), AlbumID: album.ID, Role: models.AlbumRoleViewer}
+
+	photoRepo := newFakePhotoRepo(beach, forest, mine)
+	albumRepo := newFakeAlbumRepo(album)
+	sync := NewSyncService(newFakeSyncChangeRepo(), photoRepo, albumRepo)
+	albums := NewEventAlbumService(&memberAlbumService{
+		photos:      photoRepo,
+		albums:      albumRepo,
+		invitations: map[primitive.ObjectID]*models.AlbumInvitation{invitation.ID: invitation},
+	}, photoRepo, albumRepo, syncPublisher{t: t, sync: sync})
+
+	feeds := map[string]*syncFeed{"owner": {ctx: ownerCtx}, "member": {ctx: memberCtx}, "invitee": {ctx: inviteeCtx}}
+	for _, feed := range feeds {
+		feed.changes(t, sync)
+	}
+
+	steps := []struct {
+		name  string
+		do    func() error
+		feeds map[string]map[primitive.ObjectID]bool
+	}{
+		{
+			name: "photos added",
+			do: func() error {
+				_, err := albums.AddPhotos(ownerCtx, album.ID, []primitive.ObjectID{beach.ID, forest.ID, mine.ID})
+				return err
+			},
+			feeds: map[string]map[primitive.ObjectID]bool{
+				"owner":   {album.ID: false, beach.ID: false, forest.ID: false, mine.ID: false},
+				"member":  {album.ID: false, beach.ID: false, forest.ID: false, mine.ID: false},
+				"invitee": {},
+			},
+		},
+		{
+			name: "invitation accepted",
+			do: func() error {
+				_, err := albums.RespondToInvitation(inviteeCtx, invitation.ID, true)
+				return err
+			},
+			feeds: map[string]map[primitive.ObjectID]bool{
+				"owner":   {album.ID: false},
+				"member":  {album.ID: false},
+				"invitee": {album.ID: false, beach.ID: false, forest.ID: false, mine.ID: false},
+			},
+		},
+		{
+			name: "photo removed",
+			do: func() error {
+				_, err := albums.RemovePhotos(ownerCtx, album.ID, []primitive.ObjectID{forest.ID})
+				return err
+			},
+			feeds: map[string]map[primitive.ObjectID]bool{
+				"owner":   {album.ID: false, forest.ID: false},
+				"member":  {album.ID: false, forest.ID: true},
+				"invitee": {album.ID: false, forest.ID: true},
+			},
+		},
+		{
+			name: "member removed",
+			do:   func() error { return albums.RemoveMember(ownerCtx, album.ID, member.ID.Hex()) },
+			feeds: map[string]map[primitive.ObjectID]bool{
+				"owner":   {album.ID: false},
+				"member":  {album.ID: true, beach.ID: true},
+				"invitee": {album.ID: false},
+			},
+		},
+		{
+			name: "album deleted",
+			do:   func() error { return albums.DeleteAlbum(ownerCtx, album.ID) },
+			feeds: map[string]map[primitive.ObjectID]bool{
+				"owner":   {album.ID: true, beach.ID: false, mine.ID: true},
+				"member":  {mine.ID: false},
+				"invitee": {album.ID: true, beach.ID: true, mine.ID: true},
+			},
+		},
+	}
+
+	for _, step := range steps {
+		if err := step.do(); err != nil {
+			t.Fatalf("%s: error = %v", step.name, err)
+		}
+		for name, want := range step.feeds {
+			if got := feeds[name].changes(t, sync); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: %s's changes = %v, want %v", step.name, name, got, want)
+			}
+		}
+	}
+}
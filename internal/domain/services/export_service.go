@@ -0,0 +1,657 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+
+	"photocloud/config"
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+	"photocloud/internal/imaging"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// maxExportPhotos bounds the number of photos in one export
+	maxExportPhotos = 10000
+	// exportPageSize is the number of album photos listed at a time
+	exportPageSize = 1000
+	// exportBatchSize is the number of photos an export job loads at a time
+	exportBatchSize = 100
+	// maxExportFailures bounds the number of left out photos described on a job
+	maxExportFailures = 100
+	// exportKeyPrefix is where the archives of export jobs are stored
+	exportKeyPrefix = "exports/"
+	// exportManifestName is the name of the metadata file inside archives
+	exportManifestName = "manifest.json"
+)
+
+// ExportRequest describes a ZIP export of photos, either listed by ID or taken from an album
+type ExportRequest struct {
+	PhotoIDs []primitive.ObjectID
+	AlbumID  primitive.ObjectID
+	// Rendition selects the size and format photos are exported in; originals are exported without it
+	Rendition *models.ExportRendition
+	// Manifest adds a manifest.json with the metadata of the photos to the archive
+	Manifest bool
+	// Async writes the archive to storage even when it is small enough to stream
+	Async bool
+}
+
+// Archive is a ZIP export ready to be streamed. Photos are read from storage as
+// the archive is written, so it is never held in memory or on disk as a whole.
+type Archive struct {
+	// Name is the file name of the archive
+	Name string
+	// Size is the combined size of the photos' originals
+	Size int64
+
+	ctx     context.Context
+	service *exportService
+	album   *models.Album
+	photos  []models.Photo
+	request ExportRequest
+}
+
+// WriteZip writes the archive. Photos deleted since the export was requested, or whose
+// files cannot be read, are left out. When an error is returned the archive is incomplete.
+func (a *Archive) WriteZip(w io.Writer) error {
+	archive := a.service.newArchiveWriter(w, a.album, a.request.Rendition, a.request.Manifest)
+	for i := range a.photos {
+		if _, _, err := archive.add(a.ctx, "", &a.photos[i]); err != nil {
+			return err
+		}
+	}
+	return archive.close()
+}
+
+// ExportService packs photos into ZIP archives, streamed directly or written to storage by background jobs
+type ExportService interface {
+	// Export checks an export request and resolves its photos. Exports whose originals add up to
+	// at most the streaming limit are returned as an archive to write out; larger ones, and those
+	// asked to run in the background, are queued as a job of the caller instead.
+	Export(ctx context.Context, req ExportRequest) (*Archive, *models.ExportJob, error)
+	// GetJob returns an export job of the caller and, once it completed, a link to its archive
+	// that stays valid until the archive expires
+	GetJob(ctx context.Context, id primitive.ObjectID) (*models.ExportJob, string, error)
+	// ListJobs lists the caller's export jobs, newest first, without their photo lists
+	ListJobs(ctx context.Context, page, limit int) ([]models.ExportJob, error)
+	// ExportAccount queues a job that archives all the data of the caller's account. A user
+	// has one account export queued or running at a time.
+	ExportAccount(ctx context.Context) (*models.ExportJob, error)
+
+	// RunNext claims the oldest queued job, or one whose worker stopped, and writes its archive to
+	// storage on behalf of its owner, who is told when the job finishes. It reports whether there
+	// was a job.
+	RunNext(ctx context.Context) (bool, error)
+	// DeleteExpired deletes the archives that expired by now and returns how many were deleted
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+type exportService struct {
+	jobRepo          repositories.ExportJobRepository
+	photoRepo        repositories.PhotoRepository
+	albumRepo        repositories.AlbumRepository
+	userRepo         repositories.UserRepository
+	shareRepo        repositories.ShareLinkRepository
+	activityRepo     repositories.UserActivityRepository
+	storageRepo      repositories.StorageRepository
+	transformService TransformService
+	publisher        EventPublisher
+	access           accessChecker
+	cfg              config.ExportConfig
+	maxDimension     int
+	jobs             *jobRunner[models.ExportJob, *models.ExportJob]
+}
+
+// NewExportService creates an export service. Renditions are produced through the transform
+// service and are at most maxDimension pixels wide and high. Finished jobs are announced
+// to their owner through the publisher.
+func NewExportService(jobRepo repositories.ExportJobRepository, photoRepo repositories.PhotoRepository, albumRepo repositories.AlbumRepository, userRepo repositories.UserRepository, shareRepo repositories.ShareLinkRepository, activityRepo repositories.UserActivityRepository, storageRepo repositories.StorageRepository, transformService TransformService, publisher EventPublisher, cfg config.ExportConfig, maxDimension int) ExportService {
+	s := &exportService{
+		jobRepo:          jobRepo,
+		photoRepo:        photoRepo,
+		albumRepo:        albumRepo,
+		userRepo:         userRepo,
+		shareRepo:        shareRepo,
+		activityRepo:     activityRepo,
+		storageRepo:      storageRepo,
+		transformService: transformService,
+		publisher:        publisher,
+		access:           accessChecker{photoRepo: photoRepo, albumRepo: albumRepo},
+		cfg:              cfg,
+		maxDimension:     maxDimension,
+	}
+	s.jobs = newJobRunner("export", jobRepo, s.run, s.finish)
+	return s
+}
+
+func (s *exportService) Export(ctx context.Context, req ExportRequest) (*Archive, *models.ExportJob, error) {
+	userID := auth.UserID(ctx)
+	if userID == "" {
+		return nil, nil, ErrUnauthorized
+	}
+	if (len(req.PhotoIDs) > 0) == !req.AlbumID.IsZero() {
+		return nil, nil, fmt.Errorf("%w: exactly one of photo IDs and an album is required", ErrInvalidArgument)
+	}
+	if len(req.PhotoIDs) > maxExportPhotos {
+		return nil, nil, fmt.Errorf("%w: at most %d photos can be exported at once", ErrInvalidArgument, maxExportPhotos)
+	}
+	if req.Rendition != nil {
+		rendition := *req.Rendition
+		rendition.Format = strings.ToLower(rendition.Format)
+		if rendition.Format == "jpg" {
+			rendition.Format = string(imaging.FormatJPEG)
+		}
+		if err := toImagingOptions(rendition).Validate(s.maxDimension); err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+		}
+		req.Rendition = &rendition
+	}
+
+	archive := &Archive{Name: "photos", ctx: ctx, service: s, request: req}
+	var err error
+	if req.AlbumID.IsZero() {
+		archive.photos, err = s.selectedPhotos(ctx, req.PhotoIDs)
+	} else if archive.album, err = s.access.album(ctx, req.AlbumID, permView); err == nil {
+		archive.Name = archive.album.Name
+		archive.photos, err = s.albumPhotos(ctx, req.AlbumID)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	archive.Name = safeFileName(archive.Name, "photos") + ".zip"
+	for _, photo := range archive.photos {
+		archive.Size += photo.Size
+	}
+
+	if !req.Async && archive.Size <= s.cfg.MaxStreamBytes {
+		return archive, nil, nil
+	}
+
+	job := &models.ExportJob{
+		Job:       models.Job{OwnerID: userID, Status: models.ExportJobQueued, CreatedAt: time.Now()},
+		Kind:      models.ExportKindPhotos,
+		Name:      archive.Name,
+		AlbumID:   req.AlbumID,
+		PhotoIDs:  photoIDs(archive.photos),
+		Rendition: req.Rendition,
+		Manifest:  req.Manifest,
+		Total:     int64(len(archive.photos)),
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, nil, err
+	}
+	return nil, job, nil
+}
+
+func (s *exportService) GetJob(ctx context.Context, id primitive.ObjectID) (*models.ExportJob, string, error) {
+	job, err := s.jobRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if job == nil {
+		return nil, "", ErrExportNotFound
+	}
+	if userID := auth.UserID(ctx); userID != "" && job.OwnerID != userID {
+		return nil, "", ErrExportNotFound
+	}
+
+	if job.Status != models.ExportJobCompleted || job.ExpiresAt == nil {
+		return job, "", nil
+	}
+	remaining := time.Until(*job.ExpiresAt)
+	if remaining <= 0 {
+		// The archive is about to be deleted
+		return job, "", nil
+	}
+	url, err := s.storageRepo.GetFileURL(ctx, job.StorageKey, max(1, int(remaining/time.Minute)))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create download URL: %w", err)
+	}
+	return job, url, nil
+}
+
+func (s *exportService) ListJobs(ctx context.Context, page, limit int) ([]models.ExportJob, error) {
+	userID := auth.UserID(ctx)
+	if userID == "" {
+		return nil, ErrUnauthorized
+	}
+	return s.jobRepo.ListByOwner(ctx, userID, page, limit)
+}
+
+func (s *exportService) RunNext(ctx context.Context) (bool, error) {
+	return s.jobs.runNext(ctx)
+}
+
+func (s *exportService) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	deleted := 0
+	for {
+		jobs, err := s.jobRepo.ListExpired(ctx, now, exportBatchSize)
+		if err != nil {
+			return deleted, err
+		}
+
+		for i := range jobs {
+			job := &jobs[i]
+			if err := s.storageRepo.DeleteFile(ctx, job.StorageKey); err != nil && !errors.Is(err, repositories.ErrFileNotFound) {
+				return deleted, fmt.Errorf("failed to delete the archive of export job %s: %w", job.ID.Hex(), err)
+			}
+			job.Status = models.ExportJobExpired
+			job.StorageKey = ""
+			if err := s.jobRepo.SaveProgress(ctx, job); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+				return deleted, err
+			}
+			deleted++
+		}
+		if len(jobs) < exportBatchSize {
+			return deleted, nil
+		}
+	}
+}
+
+// run writes the archive of a job to storage while it is being produced. Interrupted
+// jobs start over, replacing whatever an earlier attempt stored.
+func (s *exportService) run(ctx context.Context, job *models.ExportJob) error {
+	ctx, err := ownerContext(ctx, s.userRepo, job.OwnerID)
+	if err != nil {
+		return err
+	}
+	write := func(w io.Writer) error {
+		return s.writeAccountArchive(ctx, job, w)
+	}
+	if !job.IsAccountExport() {
+		var album *models.Album
+		if !job.AlbumID.IsZero() {
+			if album, err = s.access.album(ctx, job.AlbumID, permView); err != nil {
+				return err
+			}
+		}
+		write = func(w io.Writer) error {
+			return s.writeJobArchive(ctx, job, album, w)
+		}
+	}
+	job.Processed, job.Failed, job.Failures = 0, 0, nil
+
+	key := fmt.Sprintf("%s%s/%s", exportKeyPrefix, job.ID.Hex(), job.Name)
+	reader, writer := io.Pipe()
+	uploaded := make(chan error, 1)
+	go func() {
+		err := s.storageRepo.UploadStream(ctx, key, reader, "application/zip")
+		// A failed upload makes further writes to the archive fail too
+		reader.CloseWithError(err)
+		uploaded <- err
+	}()
+
+	err = write(writer)
+	writer.CloseWithError(err)
+	if uploadErr := <-uploaded; err == nil && uploadErr != nil {
+		err = fmt.Errorf("failed to store the archive: %w", uploadErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	info, err := s.storageRepo.StatFile(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to read the stored archive: %w", err)
+	}
+	job.StorageKey, job.Size = key, info.Size
+	return nil
+}
+
+// writeJobArchive writes the photos of a job into an archive in batches, checking that the
+// owner can still see each one and saving progress after every batch
+func (s *exportService) writeJobArchive(ctx context.Context, job *models.ExportJob, album *models.Album, w io.Writer) error {
+	archive := s.newArchiveWriter(w, album, job.Rendition, job.Manifest)
+	for start := 0; start < len(job.PhotoIDs); start += exportBatchSize {
+		ids := job.PhotoIDs[start:min(start+exportBatchSize, len(job.PhotoIDs))]
+		photos, err := s.photoRepo.GetByIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+		byID := make(map[primitive.ObjectID]*models.Photo, len(photos))
+		for i := range photos {
+			byID[photos[i].ID] = &photos[i]
+		}
+
+		for _, id := range ids {
+			var skipped error
+			photo, ok := byID[id]
+			if ok && !photo.IsTrashed() {
+				if err := s.access.checkPhoto(ctx, photo, permView); err != nil {
+					if !errors.Is(err, ErrPhotoNotFound) && !errors.Is(err, ErrForbidden) {
+						return err
+					}
+					skipped = archive.skip(id, photo.Name, err)
+				} else if _, skipped, err = archive.add(ctx, "", photo); err != nil {
+					return err
+				}
+			} else {
+				skipped = archive.skip(id, "", ErrPhotoNotFound)
+			}
+
+			job.Processed++
+			if skipped != nil {
+				job.Failed++
+				if len(job.Failures) < maxExportFailures {
+					job.Failures = append(job.Failures, models.BulkFailure{PhotoID: id, Error: skipped.Error()})
+				}
+			}
+		}
+
+		if err := s.jobs.extendLease(ctx, job); err != nil {
+			return err
+		}
+	}
+	return archive.close()
+}
+
+// finish marks a job as completed, starting the expiry of its archive, or as failed with the
+// reason when there is one, and tells its owner
+func (s *exportService) finish(ctx context.Context, job *models.ExportJob, reason string) error {
+	if reason == "" {
+		expiresAt := time.Now().Add(s.cfg.LinkExpiry)
+		job.ExpiresAt = &expiresAt
+	}
+	if saved, err := s.jobs.saveFinished(ctx, job, reason); !saved {
+		return err
+	}
+
+	event := &models.Event{
+		ID:         primitive.NewObjectID(),
+		Type:       models.EventTypeExportCompleted,
+		ExportID:   job.ID,
+		Recipients: []string{job.OwnerID},
+		Timestamp:  *job.FinishedAt,
+	}
+	if job.Status == models.ExportJobFailed {
+		event.Type, event.Error = models.EventTypeExportFailed, job.Error
+	} else if url, err := s.storageRepo.GetFileURL(ctx, job.StorageKey, max(1, int(s.cfg.LinkExpiry/time.Minute))); err == nil {
+		// Without a link the owner still finds it on the job
+		event.URL, event.ExpiresAt = url, job.ExpiresAt
+	}
+	s.publisher.Publish(event)
+	return nil
+}
+
+// selectedPhotos loads the listed photos in the order given, leaving out repeated IDs.
+// Every photo must be visible to the caller and not in the trash.
+func (s *exportService) selectedPhotos(ctx context.Context, ids []primitive.ObjectID) ([]models.Photo, error) {
+	found, err := s.photoRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]*models.Photo, len(found))
+	for i := range found {
+		byID[found[i].ID] = &found[i]
+	}
+
+	photos := make([]models.Photo, 0, len(ids))
+	seen := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		photo, ok := byID[id]
+		if !ok || photo.IsTrashed() {
+			return nil, fmt.Errorf("%w: %s", ErrPhotoNotFound, id.Hex())
+		}
+		if err := s.access.checkPhoto(ctx, photo, permView); err != nil {
+			return nil, fmt.Errorf("%w: %s", err, id.Hex())
+		}
+		photos = append(photos, *photo)
+	}
+	return photos, nil
+}
+
+// albumPhotos loads the photos of an album that are not in the trash, newest first
+func (s *exportService) albumPhotos(ctx context.Context, albumID primitive.ObjectID) ([]models.Photo, error) {
+	var photos []models.Photo
+	for page := 1; ; page++ {
+		batch, err := s.photoRepo.ListByAlbum(ctx, albumID, page, exportPageSize)
+		if err != nil {
+			return nil, err
+		}
+		photos = append(photos, batch...)
+		if len(photos) > maxExportPhotos {
+			return nil, fmt.Errorf("%w: albums with more than %d photos cannot be exported at once", ErrInvalidArgument, maxExportPhotos)
+		}
+		if len(batch) < exportPageSize {
+			return photos, nil
+		}
+	}
+}
+
+// exportManifest describes the photos of an archive
+type exportManifest struct {
+	ExportedAt time.Time               `json:"exported_at"`
+	Album      *exportManifestAlbum    `json:"album,omitempty"`
+	Rendition  *models.ExportRendition `json:"rendition,omitempty"`
+	Photos     []exportManifestPhoto   `json:"photos"`
+	// Skipped lists the photos left out of the archive, and why
+	Skipped []exportManifestSkip `json:"skipped,omitempty"`
+}
+
+type exportManifestAlbum struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type exportManifestPhoto struct {
+	// File is the name of the photo's entry in the archive
+	File        string `json:"file"`
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Size is the size of the exported file
+	Size int64 `json:"size"`
+	// SHA256 is the content hash of the original, which is only given when the original was exported
+	SHA256     string    `json:"sha256,omitempty"`
+	Tags       []string  `json:"tags,omitempty"`
+	UploadedAt time.Time `json:"uploaded_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type exportManifestSkip struct {
+	ID    string `json:"id"`
+	Name  string `json:"name,omitempty"`
+	Error string `json:"error"`
+}
+
+// archiveWriter writes photos into a ZIP archive, giving every entry a unique name
+// and collecting the manifest as it goes
+type archiveWriter struct {
+	service   *exportService
+	zip       *zip.Writer
+	rendition *imaging.Options
+	names     map[string]bool
+	// manifest is nil when the archive has none
+	manifest *exportManifest
+}
+
+func (s *exportService) newArchiveWriter(w io.Writer, album *models.Album, rendition *models.ExportRendition, withManifest bool) *archiveWriter {
+	archive := &archiveWriter{
+		service: s,
+		zip:     zip.NewWriter(w),
+		names:   make(map[string]bool),
+	}
+	if rendition != nil {
+		opts := toImagingOptions(*rendition)
+		archive.rendition = &opts
+	}
+	if withManifest {
+		archive.names[exportManifestName] = true
+		archive.manifest = &exportManifest{
+			ExportedAt: time.Now().UTC(),
+			Rendition:  rendition,
+			Photos:     []exportManifestPhoto{},
+		}
+		if album != nil {
+			archive.manifest.Album = &exportManifestAlbum{ID: album.ID.Hex(), Name: album.Name, Description: album.Description}
+		}
+	}
+	return archive
+}
+
+// add writes a photo into a directory of the archive, the top level when dir is empty, and
+// returns the name of its entry. A photo that can no longer be exported is left out and
+// described in the manifest; add then returns why as skipped, without an error.
+func (a *archiveWriter) add(ctx context.Context, dir string, photo *models.Photo) (name string, skipped error, err error) {
+	body, name, err := a.open(ctx, photo)
+	if err != nil {
+		if errors.Is(err, ErrPhotoNotFound) || errors.Is(err, repositories.ErrFileNotFound) || errors.Is(err, ErrInvalidArgument) {
+			return "", a.skip(photo.ID, photo.Name, err), nil
+		}
+		return "", nil, fmt.Errorf("failed to read photo %s: %w", photo.ID.Hex(), err)
+	}
+	defer body.Close()
+
+	// Photos are compressed already, so they are stored as they are
+	header := &zip.FileHeader{
+		Name:     a.entryName(dir + safeFileName(name, photo.ID.Hex())),
+		Method:   zip.Store,
+		Modified: photo.UploadedAt,
+	}
+	entry, err := a.zip.CreateHeader(header)
+	if err != nil {
+		return "", nil, err
+	}
+	written, err := io.Copy(entry, body)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to add photo %s to the archive: %w", photo.ID.Hex(), err)
+	}
+
+	if a.manifest != nil {
+		entry := exportManifestPhoto{
+			File:        header.Name,
+			ID:          photo.ID.Hex(),
+			Name:        photo.Name,
+			Description: photo.Description,
+			Size:        written,
+			Tags:        photo.Tags,
+			UploadedAt:  photo.UploadedAt,
+			UpdatedAt:   photo.UpdatedAt,
+		}
+		if a.rendition == nil {
+			entry.SHA256 = photo.ContentHash
+		}
+		a.manifest.Photos = append(a.manifest.Photos, entry)
+	}
+	return header.Name, nil, nil
+}
+
+// open opens the file a photo is exported as and returns the name it is exported under:
+// the original, or the rendition produced and cached by the transform service
+func (a *archiveWriter) open(ctx context.Context, photo *models.Photo) (io.ReadCloser, string, error) {
+	key, name := photo.S3Key, photo.Name
+	if a.rendition != nil {
+		var err error
+		if key, err = a.service.transformService.PrepareVariant(ctx, photo.ID, *a.rendition); err != nil {
+			return nil, "", err
+		}
+		name = strings.TrimSuffix(name, path.Ext(name)) + path.Ext(key)
+	}
+
+	body, _, err := a.service.storageRepo.DownloadFile(ctx, key, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, name, nil
+}
+
+// skip describes a photo left out of the archive in the manifest and returns the reason
+func (a *archiveWriter) skip(id primitive.ObjectID, name string, reason error) error {
+	if a.manifest != nil {
+		a.manifest.Skipped = append(a.manifest.Skipped, exportManifestSkip{ID: id.Hex(), Name: name, Error: reason.Error()})
+	}
+	return reason
+}
+
+// entryName returns the name unless an earlier entry has it, ignoring case; repeated
+// names are numbered like "beach (2).jpg"
+func (a *archiveWriter) entryName(name string) string {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	candidate := name
+	for n := 2; a.names[strings.ToLower(candidate)]; n++ {
+		candidate = fmt.Sprintf("%s (%d)%s", base, n, ext)
+	}
+	a.names[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+// create starts a compressed entry of the archive with a name no photo takes
+func (a *archiveWriter) create(name string, modified time.Time) (io.Writer, error) {
+	a.names[strings.ToLower(name)] = true
+	return a.zip.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+}
+
+// writeJSON adds an entry holding a value as indented JSON
+func (a *archiveWriter) writeJSON(name string, value any, modified time.Time) error {
+	entry, err := a.create(name, modified)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// close adds the manifest, if any, and finishes the archive
+func (a *archiveWriter) close() error {
+	if a.manifest != nil {
+		if err := a.writeJSON(exportManifestName, a.manifest, a.manifest.ExportedAt); err != nil {
+			return err
+		}
+	}
+	return a.zip.Close()
+}
+
+// safeFileName turns a name into one that is safe to use as a file name on any system,
+// without directories, or returns the fallback when nothing is left of it
+func safeFileName(name, fallback string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, " .")
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+// toImagingOptions converts an export rendition to transform options
+func toImagingOptions(rendition models.ExportRendition) imaging.Options {
+	return imaging.Options{
+		Width:   rendition.Width,
+		Height:  rendition.Height,
+		Fit:     imaging.Fit(rendition.Fit),
+		Format:  imaging.Format(rendition.Format),
+		Quality: rendition.Quality,
+	}
+}
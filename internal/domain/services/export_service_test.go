@@ -0,0 +1,94 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEntryName(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest bool
+		names    []string
+		want     []string
+	}{
+		{
+			name:  "unique names",
+			names: []string{"beach.jpg", "forest.jpg"},
+			want:  []string{"beach.jpg", "forest.jpg"},
+		},
+		{
+			name:  "repeated names are numbered",
+			names: []string{"beach.jpg", "beach.jpg", "beach.jpg"},
+			want:  []string{"beach.jpg", "beach (2).jpg", "beach (3).jpg"},
+		},
+		{
+			name:  "case is ignored",
+			names: []string{"Beach.jpg", "beach.JPG"},
+			want:  []string{"Beach.jpg", "beach (2).JPG"},
+		},
+		{
+			name:  "numbered name already taken",
+			names: []string{"beach.jpg", "beach (2).jpg", "beach.jpg"},
+			want:  []string{"beach.jpg", "beach (2).jpg", "beach (3).jpg"},
+		},
+		{
+			name:  "without extension",
+			names: []string{"README", "README"},
+			want:  []string{"README", "README (2)"},
+		},
+		{
+			name:  "only the last extension",
+			names: []string{"photos.tar.gz", "photos.tar.gz"},
+			want:  []string{"photos.tar.gz", "photos.tar (2).gz"},
+		},
+		{
+			name:  "same name in other directories",
+			names: []string{"Trip/beach.jpg", "Home/beach.jpg", "Trip/beach.jpg"},
+			want:  []string{"Trip/beach.jpg", "Home/beach.jpg", "Trip/beach (2).jpg"},
+		},
+		{
+			name:     "manifest name is reserved",
+			manifest: true,
+			names:    []string{"Manifest.json"},
+			want:     []string{"Manifest (2).json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := (&exportService{}).newArchiveWriter(nil, nil, nil, tt.manifest)
+			got := make([]string, len(tt.names))
+			for i, name := range tt.names {
+				got[i] = archive.entryName(name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("entryName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSafeFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "beach.jpg", want: "beach.jpg"},
+		{name: "../../etc/passwd", want: "_.._etc_passwd"},
+		{name: `C:\photos\beach.jpg`, want: "C__photos_beach.jpg"},
+		{name: "what?*.jpg", want: "what__.jpg"},
+		{name: "line\nbreak.jpg", want: "line_break.jpg"},
+		{name: " beach.jpg. ", want: "beach.jpg"},
+		{name: "...", want: "fallback"},
+		{name: "", want: "fallback"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := safeFileName(tt.name, "fallback"); got != tt.want {
+				t.Errorf("safeFileName(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,459 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"slices"
+	"time"
+
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The fakes keep their data in maps and implement the repository methods the tests
+// reach; the embedded interface panics on any other method.
+
+type fakePhotoRepo struct {
+	repositories.PhotoRepository
+	photos map[primitive.ObjectID]*models.Photo
+	// createErr fails every Create when set
+	createErr error
+}
+
+func newFakePhotoRepo(photos ...*models.Photo) *fakePhotoRepo {
+	repo := &fakePhotoRepo{photos: make(map[primitive.ObjectID]*models.Photo)}
+	for _, photo := range photos {
+		repo.photos[photo.ID] = photo
+	}
+	return repo
+}
+
+func (r *fakePhotoRepo) Create(ctx context.Context, photo *models.Photo) error {
+	if r.createErr != nil {
+		return r.createErr
+	}
+	r.photos[photo.ID] = photo
+	return nil
+}
+
+func (r *fakePhotoRepo) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Photo, error) {
+	photo, ok := r.photos[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *photo
+	copied.AlbumIDs = slices.Clone(photo.AlbumIDs)
+	return &copied, nil
+}
+
+func (r *fakePhotoRepo) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Photo, error) {
+	var photos []models.Photo
+	for _, id := range ids {
+		if photo, ok := r.photos[id]; ok {
+			photos = append(photos, *photo)
+		}
+	}
+	return photos, nil
+}
+
+func (r *fakePhotoRepo) ListByAlbum(ctx context.Context, albumID primitive.ObjectID, page, limit int) ([]models.Photo, error) {
+	var photos []models.Photo
+	for _, photo := range r.photos {
+		if photo.InAlbum(albumID) && !photo.IsTrashed() {
+			photos = append(photos, *photo)
+		}
+	}
+	start, end := min((page-1)*limit, len(photos)), min(page*limit, len(photos))
+	return photos[start:end], nil
+}
+
+func (r *fakePhotoRepo) Restore(ctx context.Context, id primitive.ObjectID) error {
+	r.photos[id].DeletedAt = nil
+	return nil
+}
+
+func (r *fakePhotoRepo) RestoreMany(ctx context.Context, ids []primitive.ObjectID, updatedAt time.Time) (int64, error) {
+	for _, id := range ids {
+		r.photos[id].DeletedAt = nil
+	}
+	return int64(len(ids)), nil
+}
+
+func (r *fakePhotoRepo) AggregateUsage(ctx context.Context, ownerIDs ...string) ([]models.StorageUsage, error) {
+	usage := make(map[string]*models.StorageUsage)
+	for _, photo := range r.photos {
+		if len(ownerIDs) > 0 && !slices.Contains(ownerIDs, photo.OwnerID) {
+			continue
+		}
+		if usage[photo.OwnerID] == nil {
+			usage[photo.OwnerID] = &models.StorageUsage{OwnerID: photo.OwnerID}
+		}
+		addUsage(usage[photo.OwnerID], photoUsage(photo))
+	}
+	var result []models.StorageUsage
+	for _, owner := range usage {
+		result = append(result, *owner)
+	}
+	return result, nil
+}
+
+func (r *fakePhotoRepo) ClaimUnowned(ctx context.Context, ownerID, fromOwnerID string) (int64, error) {
+	var claimed int64
+	for _, photo := range r.photos {
+		if photo.OwnerID == "" || (fromOwnerID != "" && photo.OwnerID == fromOwnerID) {
+			photo.OwnerID = ownerID
+			claimed++
+		}
+	}
+	return claimed, nil
+}
+
+type fakeUserRepo struct {
+	repositories.UserRepository
+	users map[primitive.ObjectID]*models.User
+}
+
+func newFakeUserRepo(users ...*models.User) *fakeUserRepo {
+	repo := &fakeUserRepo{users: make(map[primitive.ObjectID]*models.User)}
+	for _, user := range users {
+		repo.users[user.ID] = user
+	}
+	return repo
+}
+
+func (r *fakeUserRepo) Create(ctx context.Context, user *models.User) error {
+	user.ID = primitive.NewObjectID()
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *fakeUserRepo) GetByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	return r.find(func(user *models.User) bool { return user.ID == id }), nil
+}
+
+func (r *fakeUserRepo) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	return r.find(func(user *models.User) bool { return user.Username == username }), nil
+}
+
+func (r *fakeUserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.find(func(user *models.User) bool { return user.Email == email }), nil
+}
+
+func (r *fakeUserRepo) GetByTokenHash(ctx context.Context, tokenHash string) (*models.User, error) {
+	return r.find(func(user *models.User) bool { return user.TokenHash == tokenHash }), nil
+}
+
+func (r *fakeUserRepo) SetTokenHash(ctx context.Context, id primitive.ObjectID, tokenHash string) error {
+	r.users[id].TokenHash = tokenHash
+	return nil
+}
+
+func (r *fakeUserRepo) find(match func(*models.User) bool) *models.User {
+	for _, user := range r.users {
+		if match(user) {
+			copied := *user
+			return &copied
+		}
+	}
+	return nil
+}
+
+type fakeUsageRepo struct {
+	repositories.UsageRepository
+	usage       map[string]*models.StorageUsage
+	invalidated []string
+}
+
+func newFakeUsageRepo() *fakeUsageRepo {
+	return &fakeUsageRepo{usage: make(map[string]*models.StorageUsage)}
+}
+
+func (r *fakeUsageRepo) Get(ctx context.Context, ownerID string) (*models.StorageUsage, error) {
+	usage, ok := r.usage[ownerID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *usage
+	return &copied, nil
+}
+
+func (r *fakeUsageRepo) Add(ctx context.Context, ownerID string, delta models.StorageUsage) error {
+	if r.usage[ownerID] == nil {
+		r.usage[ownerID] = &models.StorageUsage{OwnerID: ownerID}
+	}
+	addUsage(r.usage[ownerID], delta)
+	return nil
+}
+
+func (r *fakeUsageRepo) Reserve(ctx context.Context, ownerID string, delta models.StorageUsage, quota models.Quota) (bool, error) {
+	usage, ok := r.usage[ownerID]
+	if !ok {
+		return false, nil
+	}
+	if bytes := delta.QuotaBytes(); quota.MaxBytes > 0 && bytes > 0 && usage.QuotaBytes()+bytes > quota.MaxBytes {
+		return false, nil
+	}
+	if quota.MaxPhotos > 0 && delta.Photos > 0 && usage.Photos+delta.Photos > quota.MaxPhotos {
+		return false, nil
+	}
+	addUsage(usage, delta)
+	return true, nil
+}
+
+func (r *fakeUsageRepo) Set(ctx context.Context, usage *models.StorageUsage) error {
+	copied := *usage
+	r.usage[usage.OwnerID] = &copied
+	return nil
+}
+
+func (r *fakeUsageRepo) Invalidate(ctx context.Context, ownerID string, at time.Time) error {
+	r.invalidated = append(r.invalidated, ownerID)
+	if usage, ok := r.usage[ownerID]; ok {
+		usage.VerifiedAt = nil
+	}
+	return nil
+}
+
+// addUsage adds the counters of delta to usage
+func addUsage(usage *models.StorageUsage, delta models.StorageUsage) {
+	usage.Photos += delta.Photos
+	usage.Originals.Bytes += delta.Originals.Bytes
+	usage.Originals.Files += delta.Originals.Files
+	usage.Versions.Bytes += delta.Versions.Bytes
+	usage.Versions.Files += delta.Versions.Files
+	usage.Renditions.Bytes += delta.Renditions.Bytes
+	usage.Renditions.Files += delta.Renditions.Files
+}
+
+type fakeOperationRepo struct {
+	repositories.PendingOperationRepository
+	ops map[primitive.ObjectID]*models.PendingOperation
+}
+
+func newFakeOperationRepo() *fakeOperationRepo {
+	return &fakeOperationRepo{ops: make(map[primitive.ObjectID]*models.PendingOperation)}
+}
+
+func (r *fakeOperationRepo) Create(ctx context.Context, op *models.PendingOperation) error {
+	op.ID = primitive.NewObjectID()
+	r.ops[op.ID] = op
+	return nil
+}
+
+func (r *fakeOperationRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	delete(r.ops, id)
+	return nil
+}
+
+func (r *fakeOperationRepo) RecordFailure(ctx context.Context, id primitive.ObjectID, message string) error {
+	r.ops[id].Attempts++
+	r.ops[id].LastError = message
+	return nil
+}
+
+type fakeStorageRepo struct {
+	repositories.StorageRepository
+	files map[string]*fakeFile
+	// uploadErr fails every UploadFile when set
+	uploadErr error
+	// downloads records the range of every download, with End -1 for the rest of the file
+	downloads []repositories.ByteRange
+}
+
+type fakeFile struct {
+	data         []byte
+	etag         string
+	lastModified time.Time
+}
+
+func newFakeStorageRepo() *fakeStorageRepo {
+	return &fakeStorageRepo{files: make(map[string]*fakeFile)}
+}
+
+func (r *fakeStorageRepo) info(key string, file *fakeFile) *repositories.FileInfo {
+	return &repositories.FileInfo{Key: key, Size: int64(len(file.data)), ContentType: "image/jpeg", ETag: file.etag, LastModified: file.lastModified}
+}
+
+func (r *fakeStorageRepo) UploadFile(ctx context.Context, key string, content io.Reader, contentType string) error {
+	if r.uploadErr != nil {
+		return r.uploadErr
+	}
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	r.files[key] = &fakeFile{data: data, etag: `"` + key + `"`, lastModified: time.Now()}
+	return nil
+}
+
+func (r *fakeStorageRepo) DownloadFile(ctx context.Context, key string, byteRange *repositories.ByteRange) (io.ReadCloser, *repositories.FileInfo, error) {
+	file, ok := r.files[key]
+	if !ok {
+		return nil, nil, repositories.ErrFileNotFound
+	}
+	selected := repositories.ByteRange{Start: 0, End: -1}
+	if byteRange != nil {
+		selected = *byteRange
+	}
+	r.downloads = append(r.downloads, selected)
+
+	end := int64(len(file.data))
+	if selected.End >= 0 && selected.End+1 < end {
+		end = selected.End + 1
+	}
+	return io.NopCloser(bytes.NewReader(file.data[selected.Start:end])), r.info(key, file), nil
+}
+
+func (r *fakeStorageRepo) StatFile(ctx context.Context, key string) (*repositories.FileInfo, error) {
+	file, ok := r.files[key]
+	if !ok {
+		return nil, repositories.ErrFileNotFound
+	}
+	return r.info(key, file), nil
+}
+
+func (r *fakeStorageRepo) GetFileURL(ctx context.Context, key string, expiryMinutes int) (string, error) {
+	if _, ok := r.files[key]; !ok {
+		return "", repositories.ErrFileNotFound
+	}
+	return "https://storage.example.com/" + key, nil
+}
+
+func (r *fakeStorageRepo) DeleteFile(ctx context.Context, key string) error {
+	delete(r.files, key)
+	return nil
+}
+
+type fakeAlbumRepo struct {
+	repositories.AlbumRepository
+	albums map[primitive.ObjectID]*models.Album
+}
+
+func newFakeAlbumRepo(albums ...*models.Album) *fakeAlbumRepo {
+	repo := &fakeAlbumRepo{albums: make(map[primitive.ObjectID]*models.Album)}
+	for _, album := range albums {
+		repo.albums[album.ID] = album
+	}
+	return repo
+}
+
+func (r *fakeAlbumRepo) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Album, error) {
+	album, ok := r.albums[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *album
+	copied.Members = slices.Clone(album.Members)
+	return &copied, nil
+}
+
+func (r *fakeAlbumRepo) ListAccessible(ctx context.Context, ids []primitive.ObjectID, userID string) ([]models.Album, error) {
+	var albums []models.Album
+	for _, id := range ids {
+		album, ok := r.albums[id]
+		if !ok {
+			continue
+		}
+		if _, member := album.Member(userID); member || album.OwnerID == userID {
+			albums = append(albums, *album)
+		}
+	}
+	return albums, nil
+}
+
+type fakeShareRepo struct {
+	repositories.ShareLinkRepository
+	links map[primitive.ObjectID]*models.ShareLink
+}
+
+func newFakeShareRepo() *fakeShareRepo {
+	return &fakeShareRepo{links: make(map[primitive.ObjectID]*models.ShareLink)}
+}
+
+func (r *fakeShareRepo) Create(ctx context.Context, link *models.ShareLink) error {
+	link.ID = primitive.NewObjectID()
+	r.links[link.ID] = link
+	return nil
+}
+
+func (r *fakeShareRepo) GetByID(ctx context.Context, id primitive.ObjectID) (*models.ShareLink, error) {
+	link, ok := r.links[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *link
+	return &copied, nil
+}
+
+func (r *fakeShareRepo) GetByTokenHash(ctx context.Context, tokenHash string) (*models.ShareLink, error) {
+	for _, link := range r.links {
+		if link.TokenHash == tokenHash {
+			copied := *link
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeShareRepo) Revoke(ctx context.Context, id primitive.ObjectID, revokedAt time.Time) error {
+	r.links[id].RevokedAt = &revokedAt
+	return nil
+}
+
+func (r *fakeShareRepo) RecordView(ctx context.Context, id primitive.ObjectID, viewedAt time.Time) error {
+	r.links[id].ViewCount++
+	return nil
+}
+
+type fakeSyncChangeRepo struct {
+	repositories.SyncChangeRepository
+	changes map[string][]models.SyncChange
+}
+
+func newFakeSyncChangeRepo() *fakeSyncChangeRepo {
+	return &fakeSyncChangeRepo{changes: make(map[string][]models.SyncChange)}
+}
+
+func (r *fakeSyncChangeRepo) Create(ctx context.Context, change *models.SyncChange) error {
+	change.ID = primitive.NewObjectID()
+	r.changes[change.UserID] = append(r.changes[change.UserID], *change)
+	return nil
+}
+
+func (r *fakeSyncChangeRepo) GetLatest(ctx context.Context, userID string) (*models.SyncChange, error) {
+	changes := r.changes[userID]
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	latest := changes[len(changes)-1]
+	return &latest, nil
+}
+
+func (r *fakeSyncChangeRepo) ListAfter(ctx context.Context, userID string, afterSeq int64, limit int) ([]models.SyncChange, error) {
+	var changes []models.SyncChange
+	for _, change := range r.changes[userID] {
+		if change.Sequence > afterSeq && len(changes) < limit {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+// fakeRecorder keeps the recorded activities
+type fakeRecorder struct {
+	activities []*models.UserActivity
+}
+
+func (r *fakeRecorder) Record(activity *models.UserActivity) {
+	r.activities = append(r.activities, activity)
+}
+
+// userContext returns a context authenticated as a new user, and the user
+func userContext() (context.Context, *models.User) {
+	user := &models.User{ID: primitive.NewObjectID(), Username: "user"}
+	return auth.WithUser(context.Background(), user), user
+}
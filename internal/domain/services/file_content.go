@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"photocloud/internal/domain/repositories"
+)
+
+// maxReadAtSkip is the longest distance ReadAt reads past instead of opening a new download
+const maxReadAtSkip = 64 << 10
+
+// FileContent is a seekable view of a stored file. Nothing is downloaded until
+// the first read, and each read after a seek fetches only the bytes from the
+// new offset onwards, so callers such as http.ServeContent can serve byte
+// ranges without transferring the whole file.
+type FileContent struct {
+	// Name is the file name to present to clients
+	Name string
+	// Info describes the stored file, including its size and ETag
+	Info repositories.FileInfo
+
+	ctx         context.Context
+	storageRepo repositories.StorageRepository
+	offset      int64
+	body        io.ReadCloser
+}
+
+func newFileContent(ctx context.Context, storageRepo repositories.StorageRepository, name string, info repositories.FileInfo) *FileContent {
+	return &FileContent{
+		Name:        name,
+		Info:        info,
+		ctx:         ctx,
+		storageRepo: storageRepo,
+	}
+}
+
+// Read reads from the current offset, opening a ranged download if needed
+func (c *FileContent) Read(p []byte) (int, error) {
+	if c.offset >= c.Info.Size {
+		return 0, io.EOF
+	}
+
+	if c.body == nil {
+		body, info, err := c.storageRepo.DownloadFile(c.ctx, c.Info.Key, &repositories.ByteRange{Start: c.offset, End: -1})
+		if err != nil {
+			return 0, err
+		}
+		if info.ETag != c.Info.ETag {
+			body.Close()
+			return 0, fmt.Errorf("%w: file %s changed while it was being read", ErrConflict, c.Info.Key)
+		}
+		c.body = body
+	}
+
+	n, err := c.body.Read(p)
+	c.offset += int64(n)
+	return n, err
+}
+
+// Seek moves the offset for the next read. The open download is dropped only if the offset changes.
+func (c *FileContent) Seek(offset int64, whence int) (int64, error) {
+	var target int64
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target = c.offset + offset
+	case io.SeekEnd:
+		target = c.Info.Size + offset
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if target < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	if target != c.offset {
+		c.closeBody()
+		c.offset = target
+	}
+	return target, nil
+}
+
+// ReadAt reads from an offset by seeking there, so reads that continue where the previous
+// one ended keep using the open download. Short skips forward are read past rather than
+// opening a new download. Unlike most readers it is not safe for concurrent use.
+func (c *FileContent) ReadAt(p []byte, offset int64) (int, error) {
+	if skip := offset - c.offset; c.body != nil && skip > 0 && skip <= maxReadAtSkip {
+		if _, err := io.CopyN(io.Discard, c, skip); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := c.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(c, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Close releases the open download, if any
+func (c *FileContent) Close() error {
+	return c.closeBody()
+}
+
+func (c *FileContent) closeBody() error {
+	if c.body == nil {
+		return nil
+	}
+	err := c.body.Close()
+	c.body = nil
+	return err
+}
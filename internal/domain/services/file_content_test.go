@@ -0,0 +1,199 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"photocloud/internal/domain/repositories"
+)
+
+// newTestContent stores data under a key and returns a view of it as the services open it
+func newTestContent(t *testing.T, data []byte) (*FileContent, *fakeStorageRepo) {
+	t.Helper()
+	storage := newFakeStorageRepo()
+	storage.files["photos/a.jpg"] = &fakeFile{data: data, etag: `"v1"`, lastModified: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	info, err := storage.StatFile(context.Background(), "photos/a.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newFileContent(context.Background(), storage, "a.jpg", *info), storage
+}
+
+func TestFileContentSeekAndRead(t *testing.T) {
+	content, storage := newTestContent(t, []byte("0123456789"))
+
+	if len(storage.downloads) != 0 {
+		t.Fatalf("downloaded %v before the first read", storage.downloads)
+	}
+	if end, err := content.Seek(-4, io.SeekEnd); err != nil || end != 6 {
+		t.Fatalf("Seek(-4, end) = %d, %v, want 6", end, err)
+	}
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(content, buf); err != nil || string(buf) != "67" {
+		t.Fatalf("read %q, %v, want 67", buf, err)
+	}
+	// Reading on continues the open download
+	if _, err := io.ReadFull(content, buf); err != nil || string(buf) != "89" {
+		t.Fatalf("read %q, %v, want 89", buf, err)
+	}
+	if n, err := content.Read(buf); n != 0 || err != io.EOF {
+		t.Fatalf("read past the end = %d, %v, want EOF", n, err)
+	}
+
+	if _, err := content.Seek(2, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := content.Seek(1, io.SeekCurrent); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadFull(content, buf); err != nil || string(buf) != "34" {
+		t.Fatalf("read %q, %v, want 34", buf, err)
+	}
+
+	want := []repositories.ByteRange{{Start: 6, End: -1}, {Start: 3, End: -1}}
+	if !reflect.DeepEqual(storage.downloads, want) {
+		t.Errorf("downloads = %v, want %v", storage.downloads, want)
+	}
+	if _, err := content.Seek(-1, io.SeekStart); err == nil {
+		t.Error("Seek() to a negative position succeeded")
+	}
+}
+
+func TestFileContentReadAt(t *testing.T) {
+	content, storage := newTestContent(t, []byte("0123456789"))
+
+	buf := make([]byte, 3)
+	if n, err := content.ReadAt(buf, 1); n != 3 || err != nil || string(buf) != "123" {
+		t.Fatalf("ReadAt(1) = %d, %v, %q", n, err, buf)
+	}
+	// A short skip forward reads past the gap instead of downloading again
+	if n, err := content.ReadAt(buf, 6); n != 3 || err != nil || string(buf) != "678" {
+		t.Fatalf("ReadAt(6) = %d, %v, %q", n, err, buf)
+	}
+	if n, err := content.ReadAt(buf, 8); n != 2 || err != io.EOF || string(buf[:n]) != "89" {
+		t.Fatalf("ReadAt(8) = %d, %v, %q, want 2 bytes and EOF", n, err, buf[:n])
+	}
+
+	want := []repositories.ByteRange{{Start: 1, End: -1}, {Start: 8, End: -1}}
+	if !reflect.DeepEqual(storage.downloads, want) {
+		t.Errorf("downloads = %v, want %v", storage.downloads, want)
+	}
+}
+
+func TestFileContentChangedWhileRead(t *testing.T) {
+	content, storage := newTestContent(t, []byte("0123456789"))
+
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(content, buf); err != nil {
+		t.Fatal(err)
+	}
+	storage.files["photos/a.jpg"].etag = `"v2"`
+
+	// The open download still serves the version it started on
+	if _, err := io.ReadFull(content, buf); err != nil || string(buf) != "23" {
+		t.Fatalf("read %q, %v, want 23", buf, err)
+	}
+	// A new download after a seek finds another version and must not mix it in
+	if _, err := content.Seek(8, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := content.Read(buf); !errors.Is(err, ErrConflict) {
+		t.Fatalf("read after the file changed: error = %v, want ErrConflict", err)
+	}
+}
+
+func TestServeFileContent(t *testing.T) {
+	data := []byte("0123456789")
+	modified := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		headers     map[string]string
+		wantStatus  int
+		wantBody    string
+		wantLength  string
+		wantParts   []string
+		wantNoFetch bool
+	}{
+		{name: "whole file", wantStatus: http.StatusOK, wantBody: "0123456789", wantLength: "10"},
+		{name: "single range", headers: map[string]string{"Range": "bytes=2-5"}, wantStatus: http.StatusPartialContent, wantBody: "2345", wantLength: "4"},
+		{name: "suffix range", headers: map[string]string{"Range": "bytes=-3"}, wantStatus: http.StatusPartialContent, wantBody: "789", wantLength: "3"},
+		{name: "multiple ranges", headers: map[string]string{"Range": "bytes=0-1,7-8"}, wantStatus: http.StatusPartialContent, wantParts: []string{"01", "78"}},
+		{name: "unsatisfiable range", headers: map[string]string{"Range": "bytes=20-30"}, wantStatus: http.StatusRequestedRangeNotSatisfiable, wantNoFetch: true},
+		{name: "matching ETag", headers: map[string]string{"If-None-Match": `"v1"`}, wantStatus: http.StatusNotModified, wantNoFetch: true},
+		{name: "other ETag", headers: map[string]string{"If-None-Match": `"v0"`}, wantStatus: http.StatusOK, wantBody: "0123456789"},
+		{name: "not modified since", headers: map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, wantStatus: http.StatusNotModified, wantNoFetch: true},
+		{name: "modified since", headers: map[string]string{"If-Modified-Since": modified.Add(-time.Hour).Format(http.TimeFormat)}, wantStatus: http.StatusOK, wantBody: "0123456789"},
+		{name: "range of another version", headers: map[string]string{"Range": "bytes=2-5", "If-Range": `"v0"`}, wantStatus: http.StatusOK, wantBody: "0123456789"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, storage := newTestContent(t, data)
+			request := httptest.NewRequest(http.MethodGet, "/api/v1/photos/1/content", nil)
+			for key, value := range tt.headers {
+				request.Header.Set(key, value)
+			}
+			recorder := httptest.NewRecorder()
+			// The handlers set the ETag and serve the content like this
+			recorder.Header().Set("ETag", content.Info.ETag)
+			http.ServeContent(recorder, request, content.Name, content.Info.LastModified, content)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if tt.wantNoFetch && len(storage.downloads) != 0 {
+				t.Errorf("downloaded %v for a response without a body", storage.downloads)
+			}
+			if tt.wantLength != "" && recorder.Header().Get("Content-Length") != tt.wantLength {
+				t.Errorf("Content-Length = %q, want %q", recorder.Header().Get("Content-Length"), tt.wantLength)
+			}
+			if tt.wantBody != "" && recorder.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", recorder.Body.String(), tt.wantBody)
+			}
+			if tt.wantParts != nil {
+				if got := readParts(t, recorder); !reflect.DeepEqual(got, tt.wantParts) {
+					t.Errorf("parts = %q, want %q", got, tt.wantParts)
+				}
+				if length, _ := strconv.Atoi(recorder.Header().Get("Content-Length")); length != recorder.Body.Len() {
+					t.Errorf("Content-Length = %d, body has %d bytes", length, recorder.Body.Len())
+				}
+			}
+		})
+	}
+}
+
+// readParts returns the bodies of a multipart/byteranges response
+func readParts(t *testing.T, recorder *httptest.ResponseRecorder) []string {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(recorder.Header().Get("Content-Type"))
+	if err != nil || mediaType != "multipart/byteranges" {
+		t.Fatalf("Content-Type = %q, want multipart/byteranges", recorder.Header().Get("Content-Type"))
+	}
+	reader := multipart.NewReader(bytes.NewReader(recorder.Body.Bytes()), params["boundary"])
+	var parts []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return parts
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, string(body))
+	}
+}
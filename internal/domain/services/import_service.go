@@ -0,0 +1,478 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"photocloud/config"
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+	"photocloud/internal/importing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// importBatchSize is the number of files whose outcomes an import records at a time
+	importBatchSize = 50
+	// importKeyPrefix is where the archives of import jobs are stored until they finish
+	importKeyPrefix = "imports/"
+)
+
+// ImportService imports the photos of libraries exported from other services, with the
+// capture dates, descriptions, locations and albums their sidecars and folders tell of.
+// Files already stored as a photo of the caller are not stored again.
+type ImportService interface {
+	// CreateJob stores an uploaded export archive and queues a job of the caller that imports it
+	CreateJob(ctx context.Context, source models.ImportSource, name string, archive io.Reader) (*models.ImportJob, error)
+	// GetJob returns an import job of the caller
+	GetJob(ctx context.Context, id primitive.ObjectID) (*models.ImportJob, error)
+	// ListJobs lists the caller's import jobs, newest first
+	ListJobs(ctx context.Context, page, limit int) ([]models.ImportJob, error)
+	// ListFiles lists the file outcomes of an import job of the caller in the order they were
+	// recorded, only those with the status unless it is empty
+	ListFiles(ctx context.Context, id primitive.ObjectID, status models.ImportFileStatus, page, limit int) ([]models.ImportFile, error)
+	// ImportLocal imports an export the caller can read directly, such as an extracted
+	// archive, while they wait. Each file's outcome is reported as soon as it is known.
+	ImportLocal(ctx context.Context, source models.ImportSource, fsys fs.FS, report func(models.ImportFile)) (*models.ImportCounts, error)
+
+	// RunNext claims the oldest queued job, or one whose worker stopped, and imports the files
+	// of its archive on behalf of its owner, continuing after the files an earlier attempt
+	// recorded. The archive is deleted once the job finishes. It reports whether there was a job.
+	RunNext(ctx context.Context) (bool, error)
+}
+
+type importService struct {
+	jobRepo      repositories.ImportJobRepository
+	fileRepo     repositories.ImportFileRepository
+	photoRepo    repositories.PhotoRepository
+	userRepo     repositories.UserRepository
+	storageRepo  repositories.StorageRepository
+	photoService PhotoService
+	albumService AlbumService
+	cfg          config.ImportConfig
+	jobs         *jobRunner[models.ImportJob, *models.ImportJob]
+}
+
+// NewImportService creates an import service. Photos are uploaded and albums are created
+// through the given services, so imports are recorded and announced like any other change.
+func NewImportService(jobRepo repositories.ImportJobRepository, fileRepo repositories.ImportFileRepository, photoRepo repositories.PhotoRepository, userRepo repositories.UserRepository, storageRepo repositories.StorageRepository, photoService PhotoService, albumService AlbumService, cfg config.ImportConfig) ImportService {
+	s := &importService{
+		jobRepo:      jobRepo,
+		fileRepo:     fileRepo,
+		photoRepo:    photoRepo,
+		userRepo:     userRepo,
+		storageRepo:  storageRepo,
+		photoService: photoService,
+		albumService: albumService,
+		cfg:          cfg,
+	}
+	s.jobs = newJobRunner("import", jobRepo, s.run, s.finish)
+	return s
+}
+
+func (s *importService) CreateJob(ctx context.Context, source models.ImportSource, name string, archive io.Reader) (*models.ImportJob, error) {
+	userID := auth.UserID(ctx)
+	if userID == "" {
+		return nil, ErrUnauthorized
+	}
+	if !source.IsValid() {
+		return nil, fmt.Errorf("%w: unknown import source %q", ErrInvalidArgument, source)
+	}
+
+	job := &models.ImportJob{
+		Job:    models.Job{ID: primitive.NewObjectID(), OwnerID: userID, Status: models.ImportJobQueued},
+		Source: source,
+		Name:   safeFileName(path.Base(name), "archive.zip"),
+	}
+	job.StorageKey = fmt.Sprintf("%s%s/archive.zip", importKeyPrefix, job.ID.Hex())
+
+	// One byte more than allowed is read to tell archives of exactly the limit from larger ones
+	limited := &io.LimitedReader{R: archive, N: s.cfg.MaxArchiveBytes + 1}
+	if err := s.storageRepo.UploadStream(ctx, job.StorageKey, limited, "application/zip"); err != nil {
+		return nil, fmt.Errorf("failed to store the archive: %w", err)
+	}
+	size, err := s.checkArchive(ctx, job.StorageKey, limited.N == 0)
+	if err != nil {
+		_ = s.storageRepo.DeleteFile(context.WithoutCancel(ctx), job.StorageKey)
+		return nil, err
+	}
+
+	job.Size = size
+	job.CreatedAt = time.Now()
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		_ = s.storageRepo.DeleteFile(context.WithoutCancel(ctx), job.StorageKey)
+		return nil, err
+	}
+	return job, nil
+}
+
+// checkArchive checks that a stored archive is within the size limit and can be read as a
+// ZIP archive, and returns its size
+func (s *importService) checkArchive(ctx context.Context, key string, tooLarge bool) (int64, error) {
+	if tooLarge {
+		return 0, fmt.Errorf("%w: archives can be at most %d bytes", ErrArchiveTooLarge, s.cfg.MaxArchiveBytes)
+	}
+	info, err := s.storageRepo.StatFile(ctx, key)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the stored archive: %w", err)
+	}
+	content := newFileContent(ctx, s.storageRepo, path.Base(key), *info)
+	defer content.Close()
+	if _, err := zip.NewReader(content, info.Size); err != nil {
+		return 0, fmt.Errorf("%w: the archive is not a ZIP archive: %v", ErrInvalidArgument, err)
+	}
+	return info.Size, nil
+}
+
+func (s *importService) GetJob(ctx context.Context, id primitive.ObjectID) (*models.ImportJob, error) {
+	job, err := s.jobRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrImportNotFound
+	}
+	if userID := auth.UserID(ctx); userID != "" && job.OwnerID != userID {
+		return nil, ErrImportNotFound
+	}
+	return job, nil
+}
+
+func (s *importService) ListJobs(ctx context.Context, page, limit int) ([]models.ImportJob, error) {
+	userID := auth.UserID(ctx)
+	if userID == "" {
+		return nil, ErrUnauthorized
+	}
+	return s.jobRepo.ListByOwner(ctx, userID, page, limit)
+}
+
+func (s *importService) ListFiles(ctx context.Context, id primitive.ObjectID, status models.ImportFileStatus, page, limit int) ([]models.ImportFile, error) {
+	if status != "" && !status.IsValid() {
+		return nil, fmt.Errorf("%w: unknown file status %q", ErrInvalidArgument, status)
+	}
+	if _, err := s.GetJob(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.fileRepo.ListByJob(ctx, id, status, page, limit)
+}
+
+func (s *importService) ImportLocal(ctx context.Context, source models.ImportSource, fsys fs.FS, report func(models.ImportFile)) (*models.ImportCounts, error) {
+	if auth.UserID(ctx) == "" {
+		return nil, ErrUnauthorized
+	}
+	if !source.IsValid() {
+		return nil, fmt.Errorf("%w: unknown import source %q", ErrInvalidArgument, source)
+	}
+	library, err := importing.Open(fsys, source)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := &models.ImportCounts{Total: int64(len(library.Files))}
+	run := &importRun{
+		service:      s,
+		library:      library,
+		albums:       make(map[string]primitive.ObjectID),
+		albumCreated: func(context.Context, models.ImportAlbum) error { return nil },
+		record: func(_ context.Context, files []models.ImportFile) error {
+			for _, file := range files {
+				counts.Add(file.Status)
+				report(file)
+			}
+			return nil
+		},
+	}
+	return counts, run.importFiles(ctx, library.Files)
+}
+
+func (s *importService) RunNext(ctx context.Context) (bool, error) {
+	return s.jobs.runNext(ctx)
+}
+
+// run imports the files of a job's archive that have no recorded outcome yet, saving
+// progress after every batch. The archive is read from storage in place.
+func (s *importService) run(ctx context.Context, job *models.ImportJob) error {
+	ctx, err := ownerContext(ctx, s.userRepo, job.OwnerID)
+	if err != nil {
+		return err
+	}
+
+	info, err := s.storageRepo.StatFile(ctx, job.StorageKey)
+	if errors.Is(err, repositories.ErrFileNotFound) {
+		return fmt.Errorf("%w: the archive is no longer stored", ErrInvalidArgument)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read the stored archive: %w", err)
+	}
+	content := newFileContent(ctx, s.storageRepo, job.Name, *info)
+	defer content.Close()
+	archive, err := zip.NewReader(content, info.Size)
+	if err != nil {
+		return fmt.Errorf("%w: the archive is not a ZIP archive: %v", ErrInvalidArgument, err)
+	}
+	library, err := importing.Open(archive, job.Source)
+	if err != nil {
+		return err
+	}
+
+	// Counts are taken from the recorded outcomes, which an interrupted attempt may have saved
+	// after it last saved the job
+	paths, err := s.fileRepo.ListPaths(ctx, job.ID)
+	if err != nil {
+		return err
+	}
+	recorded, err := s.fileRepo.CountByStatus(ctx, job.ID)
+	if err != nil {
+		return err
+	}
+	job.ImportCounts = models.ImportCounts{
+		Total:      int64(len(library.Files)),
+		Imported:   recorded[models.ImportFileImported],
+		Duplicates: recorded[models.ImportFileDuplicate],
+		Skipped:    recorded[models.ImportFileSkipped],
+		Failed:     recorded[models.ImportFileFailed],
+	}
+	job.Processed = job.Imported + job.Duplicates + job.Skipped + job.Failed
+	if err := s.jobs.extendLease(ctx, job); err != nil {
+		return err
+	}
+
+	done := make(map[string]bool, len(paths))
+	for _, name := range paths {
+		done[name] = true
+	}
+	remaining := make([]importing.File, 0, len(library.Files)-len(done))
+	for _, file := range library.Files {
+		if !done[file.Path] {
+			remaining = append(remaining, file)
+		}
+	}
+
+	albums := make(map[string]primitive.ObjectID, len(job.Albums))
+	for _, album := range job.Albums {
+		albums[album.Folder] = album.AlbumID
+	}
+	run := &importRun{
+		service: s,
+		library: library,
+		albums:  albums,
+		albumCreated: func(ctx context.Context, album models.ImportAlbum) error {
+			job.Albums = append(job.Albums, album)
+			return s.jobs.extendLease(ctx, job)
+		},
+		record: func(ctx context.Context, files []models.ImportFile) error {
+			for i := range files {
+				files[i].JobID = job.ID
+			}
+			if err := s.fileRepo.CreateMany(ctx, files); err != nil {
+				return err
+			}
+			for _, file := range files {
+				job.Add(file.Status)
+			}
+			return s.jobs.extendLease(ctx, job)
+		},
+	}
+	return run.importFiles(ctx, remaining)
+}
+
+// finish marks a job as completed, or as failed with the reason when there is one, and
+// deletes its archive
+func (s *importService) finish(ctx context.Context, job *models.ImportJob, reason string) error {
+	key := job.StorageKey
+	job.StorageKey = ""
+	if saved, err := s.jobs.saveFinished(ctx, job, reason); !saved {
+		return err
+	}
+	if err := s.storageRepo.DeleteFile(ctx, key); err != nil && !errors.Is(err, repositories.ErrFileNotFound) {
+		return fmt.Errorf("failed to delete the archive of import job %s: %w", job.ID.Hex(), err)
+	}
+	return nil
+}
+
+// importRun imports files of a library on behalf of the caller, a batch at a time
+type importRun struct {
+	service *importService
+	library *importing.Library
+	// albums maps the folders seen so far to the albums created for them, or to a zero ID
+	// for folders that stand for no album
+	albums map[string]primitive.ObjectID
+	// albumCreated is called with every album created, before photos are added to it
+	albumCreated func(ctx context.Context, album models.ImportAlbum) error
+	// record is called with the outcomes of every batch, once its photos are in their albums
+	record func(ctx context.Context, files []models.ImportFile) error
+}
+
+func (r *importRun) importFiles(ctx context.Context, files []importing.File) error {
+	for start := 0; start < len(files); start += importBatchSize {
+		batch := files[start:min(start+importBatchSize, len(files))]
+		outcomes := make([]models.ImportFile, 0, len(batch))
+		additions := make(map[primitive.ObjectID][]primitive.ObjectID)
+		for _, file := range batch {
+			outcome, err := r.importFile(ctx, file)
+			if err != nil {
+				return err
+			}
+			outcomes = append(outcomes, *outcome)
+			if outcome.PhotoID.IsZero() {
+				continue
+			}
+
+			// Duplicates join the album too, as Takeout repeats album photos in the folders by year
+			albumID, err := r.album(ctx, file.Folder())
+			if err != nil {
+				return err
+			}
+			if !albumID.IsZero() {
+				additions[albumID] = append(additions[albumID], outcome.PhotoID)
+			}
+		}
+
+		for albumID, photoIDs := range additions {
+			// Albums the caller deleted since they were created are not brought back
+			if _, err := r.service.albumService.AddPhotos(ctx, albumID, photoIDs); err != nil && !errors.Is(err, ErrAlbumNotFound) {
+				return fmt.Errorf("failed to add photos to album %s: %w", albumID.Hex(), err)
+			}
+		}
+		if err := r.record(ctx, outcomes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importFile uploads a file as a photo unless it cannot be one or is stored already. Failures
+// specific to the file are returned as its outcome; the error is for failures to go on at all.
+func (r *importRun) importFile(ctx context.Context, file importing.File) (*models.ImportFile, error) {
+	outcome := &models.ImportFile{
+		Path:      file.Path,
+		Sidecar:   file.Sidecar,
+		CreatedAt: time.Now(),
+	}
+	outcomeOf := func(status models.ImportFileStatus, err error) (*models.ImportFile, error) {
+		outcome.Status = status
+		if err != nil {
+			outcome.Error = err.Error()
+		}
+		return outcome, nil
+	}
+
+	contentType, ok := PhotoContentType(file.Path)
+	if !ok {
+		return outcomeOf(models.ImportFileSkipped, errors.New("not a supported photo format"))
+	}
+	data, err := r.read(file)
+	if err != nil {
+		return outcomeOf(models.ImportFileFailed, fmt.Errorf("failed to read the file: %w", err))
+	}
+	if data == nil {
+		return outcomeOf(models.ImportFileSkipped, fmt.Errorf("larger than the upload limit of %d bytes", r.service.cfg.MaxFileBytes))
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	stored, err := r.service.photoRepo.ListByContentHash(ctx, auth.UserID(ctx), []string{hash})
+	if err != nil {
+		return nil, err
+	}
+	for _, photo := range stored {
+		// Photos in the trash are on their way out, so the file is imported again
+		if photo.HasContent(hash, int64(len(data))) && !photo.IsTrashed() {
+			outcome.PhotoID = photo.ID
+			return outcomeOf(models.ImportFileDuplicate, nil)
+		}
+	}
+
+	metadata, sidecarErr := r.library.Metadata(file)
+	if metadata == nil {
+		metadata = &importing.Metadata{}
+	}
+	name := path.Base(file.Path)
+	if metadata.Title != "" {
+		name = metadata.Title
+	}
+	photo, err := r.service.photoService.ImportPhoto(ctx, name, metadata.Description, bytes.NewReader(data), contentType, int64(len(data)), PhotoMetadata{
+		TakenAt:  metadata.TakenAt,
+		Location: metadata.Location,
+		Tags:     importTags(metadata.Tags),
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return outcomeOf(models.ImportFileFailed, err)
+	}
+	outcome.PhotoID = photo.ID
+	return outcomeOf(models.ImportFileImported, sidecarErr)
+}
+
+// read reads a file of the library. It returns nil for files larger than photos can be.
+func (r *importRun) read(file importing.File) ([]byte, error) {
+	f, err := r.library.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil && info.Size() > r.service.cfg.MaxFileBytes {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(f, r.service.cfg.MaxFileBytes+1))
+	if err != nil || int64(len(data)) > r.service.cfg.MaxFileBytes {
+		return nil, err
+	}
+	return data, nil
+}
+
+// album returns the album a folder stands for, creating it when a photo of the folder
+// is first imported. It returns a zero ID for folders that stand for no album.
+func (r *importRun) album(ctx context.Context, folder string) (primitive.ObjectID, error) {
+	if albumID, ok := r.albums[folder]; ok {
+		return albumID, nil
+	}
+	album, err := r.library.Album(folder)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("failed to read the album of %s: %w", folder, err)
+	}
+
+	var albumID primitive.ObjectID
+	if album != nil {
+		created, err := r.service.albumService.CreateAlbum(ctx, album.Title, album.Description)
+		if err != nil {
+			return primitive.NilObjectID, err
+		}
+		albumID = created.ID
+		if err := r.albumCreated(ctx, models.ImportAlbum{Folder: folder, AlbumID: albumID}); err != nil {
+			return primitive.NilObjectID, err
+		}
+	}
+	r.albums[folder] = albumID
+	return albumID, nil
+}
+
+// importTags keeps the tags of a sidecar that photos can have, so that a photo is not left
+// out for its keywords
+func importTags(tags []string) []string {
+	kept := make([]string, 0, min(len(tags), maxTagsPerChange))
+	for _, tag := range tags {
+		if len(kept) == maxTagsPerChange {
+			break
+		}
+		if tag = strings.TrimSpace(tag); tag != "" && len(tag) <= maxTagLength {
+			kept = append(kept, tag)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// jobLease is how long a worker may go without saving progress before its job is picked up again
+	jobLease = 5 * time.Minute
+	// maxJobAttempts bounds how often an interrupted job is picked up again
+	maxJobAttempts = 5
+)
+
+// leasedJob is a pointer to a kind of background job
+type leasedJob[J any] interface {
+	*J
+	Base() *models.Job
+}
+
+// jobQueue is the repository of a kind of background job
+type jobQueue[P any] interface {
+	ClaimNext(ctx context.Context, now, leaseUntil time.Time) (P, error)
+	SaveProgress(ctx context.Context, job P) error
+}
+
+// jobRunner claims background jobs of one kind from their queue and runs them, keeping
+// the lease of a job while it runs and finishing it when it stops
+type jobRunner[J any, P leasedJob[J]] struct {
+	kind  string
+	queue jobQueue[P]
+	run   func(ctx context.Context, job P) error
+	// finish ends a job with the reason it failed, if any; jobs without more to do on the
+	// way out are finished with saveFinished
+	finish func(ctx context.Context, job P, reason string) error
+}
+
+// newJobRunner creates a runner of the jobs of a kind, such as "bulk". A nil finish
+// saves finished jobs as they are.
+func newJobRunner[J any, P leasedJob[J]](kind string, queue jobQueue[P], run func(context.Context, P) error, finish func(context.Context, P, string) error) *jobRunner[J, P] {
+	r := &jobRunner[J, P]{kind: kind, queue: queue, run: run, finish: finish}
+	if r.finish == nil {
+		r.finish = func(ctx context.Context, job P, reason string) error {
+			_, err := r.saveFinished(ctx, job, reason)
+			return err
+		}
+	}
+	return r
+}
+
+// runNext claims the oldest queued job, or one whose worker stopped, and runs it. It
+// reports whether there was a job.
+func (r *jobRunner[J, P]) runNext(ctx context.Context) (bool, error) {
+	now := time.Now()
+	job, err := r.queue.ClaimNext(ctx, now, now.Add(jobLease))
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	base := job.Base()
+	if base.Attempts > maxJobAttempts {
+		return true, r.finish(ctx, job, fmt.Sprintf("gave up after %d interrupted attempts", maxJobAttempts))
+	}
+	if base.StartedAt == nil {
+		base.StartedAt = &now
+	}
+
+	if err := r.run(ctx, job); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// Another worker took the job over after the lease ran out
+			return true, nil
+		}
+		// Failures of the job as a whole end it; anything else is retried once the lease runs out
+		if isPermanentJobError(err) {
+			return true, r.finish(ctx, job, err.Error())
+		}
+		return true, fmt.Errorf("%s job %s: %w", r.kind, base.ID.Hex(), err)
+	}
+	return true, r.finish(ctx, job, "")
+}
+
+// extendLease saves the progress of a job and keeps it for another lease
+func (r *jobRunner[J, P]) extendLease(ctx context.Context, job P) error {
+	leaseUntil := time.Now().Add(jobLease)
+	job.Base().LeaseUntil = &leaseUntil
+	if err := r.queue.SaveProgress(ctx, job); err != nil {
+		return fmt.Errorf("failed to save progress: %w", err)
+	}
+	return nil
+}
+
+// saveFinished marks a job as completed, or as failed with the reason when there is one,
+// and saves it. It reports false when another worker took the job over, and finishes it.
+func (r *jobRunner[J, P]) saveFinished(ctx context.Context, job P, reason string) (bool, error) {
+	job.Base().Finish(reason, time.Now())
+	err := r.queue.SaveProgress(ctx, job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ownerContext returns a context acting on behalf of the owner of a background job
+func ownerContext(ctx context.Context, userRepo repositories.UserRepository, ownerID string) (context.Context, error) {
+	id, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: the job has no valid owner", ErrInvalidArgument)
+	}
+	owner, err := userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if owner == nil {
+		return nil, fmt.Errorf("%w: the owner of the job no longer exists", ErrForbidden)
+	}
+	return auth.WithUser(ctx, owner), nil
+}
+
+// isPermanentJobError reports whether a background job cannot succeed by running it again
+func isPermanentJobError(err error) bool {
+	return errors.Is(err, ErrAlbumNotFound) || errors.Is(err, ErrForbidden) || errors.Is(err, ErrInvalidArgument)
+}
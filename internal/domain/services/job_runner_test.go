@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"photocloud/internal/domain/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeJobQueue hands out one job and keeps what was saved of it
+type fakeJobQueue struct {
+	job   *models.BulkJob
+	saved []models.BulkJob
+}
+
+func (q *fakeJobQueue) ClaimNext(ctx context.Context, now, leaseUntil time.Time) (*models.BulkJob, error) {
+	if q.job == nil {
+		return nil, nil
+	}
+	job := q.job
+	q.job = nil
+	job.Status, job.LeaseUntil = models.JobRunning, &leaseUntil
+	job.Attempts++
+	return job, nil
+}
+
+func (q *fakeJobQueue) SaveProgress(ctx context.Context, job *models.BulkJob) error {
+	q.saved = append(q.saved, *job)
+	return nil
+}
+
+func TestJobRunnerRunNext(t *testing.T) {
+	tests := []struct {
+		name       string
+		attempts   int
+		runErr     error
+		wantRun    bool
+		wantErr    bool
+		wantStatus models.JobStatus
+		wantError  string
+	}{
+		{name: "completed", wantRun: true, wantStatus: models.JobCompleted},
+		{name: "permanent failure", runErr: fmt.Errorf("%w: bad album", ErrInvalidArgument), wantRun: true, wantStatus: models.JobFailed, wantError: "invalid argument: bad album"},
+		{name: "temporary failure", runErr: errors.New("connection reset"), wantRun: true, wantErr: true},
+		{name: "taken over", runErr: mongo.ErrNoDocuments, wantRun: true},
+		{name: "too many attempts", attempts: maxJobAttempts, wantStatus: models.JobFailed, wantError: fmt.Sprintf("gave up after %d interrupted attempts", maxJobAttempts)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := &fakeJobQueue{job: &models.BulkJob{Job: models.Job{ID: primitive.NewObjectID(), Status: models.JobQueued, Attempts: tt.attempts}}}
+			var ran bool
+			runner := newJobRunner("bulk", queue, func(ctx context.Context, job *models.BulkJob) error {
+				ran = true
+				if job.StartedAt == nil {
+					t.Error("job runs without a start time")
+				}
+				return tt.runErr
+			}, nil)
+
+			claimed, err := runner.runNext(context.Background())
+			if !claimed {
+				t.Fatal("runNext() found no job")
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runNext() error = %v, want error %v", err, tt.wantErr)
+			}
+			if ran != tt.wantRun {
+				t.Errorf("job ran = %v, want %v", ran, tt.wantRun)
+			}
+
+			if tt.wantStatus == "" {
+				if len(queue.saved) != 0 {
+					t.Errorf("saved the job as %s, want it left to the next worker", queue.saved[0].Status)
+				}
+				return
+			}
+			if len(queue.saved) != 1 {
+				t.Fatalf("saved the job %d times, want once", len(queue.saved))
+			}
+			saved := queue.saved[0]
+			if saved.Status != tt.wantStatus || saved.Error != tt.wantError || saved.FinishedAt == nil || saved.LeaseUntil != nil {
+				t.Errorf("saved job = %s %q, finished at %v, lease until %v; want %s %q, finished without a lease", saved.Status, saved.Error, saved.FinishedAt, saved.LeaseUntil, tt.wantStatus, tt.wantError)
+			}
+		})
+	}
+
+	runner := newJobRunner("bulk", &fakeJobQueue{}, func(context.Context, *models.BulkJob) error { return nil }, nil)
+	if claimed, err := runner.runNext(context.Background()); claimed || err != nil {
+		t.Errorf("runNext() on an empty queue = %v, %v, want false", claimed, err)
+	}
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"photocloud/internal/domain/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// maxPhotoBatch bounds the number of photos changed by one batch operation
+	maxPhotoBatch = 500
+	// maxTagLength bounds the length of a single tag
+	maxTagLength = 64
+	// maxTagsPerChange bounds the number of tags added or removed at once
+	maxTagsPerChange = 50
+)
+
+// PhotoBatch is the outcome of changing several photos at once. Photos that
+// were not changed are in Failed with the reason.
+type PhotoBatch struct {
+	// Changed are the changed photos, as they are now
+	Changed []models.Photo
+	Failed  map[primitive.ObjectID]error
+}
+
+func (s *photoService) TrashPhotos(ctx context.Context, ids []primitive.ObjectID) (*PhotoBatch, error) {
+	batch, err := s.loadBatch(ctx, ids, func(photo *models.Photo) error {
+		if photo.IsTrashed() {
+			return ErrPhotoNotFound
+		}
+		return s.access.checkPhoto(ctx, photo, permOwn)
+	})
+	if err != nil || len(batch.Changed) == 0 {
+		return batch, err
+	}
+
+	now := time.Now()
+	if _, err := s.photoRepo.MoveManyToTrash(ctx, photoIDs(batch.Changed), now); err != nil {
+		return nil, fmt.Errorf("failed to move photos to trash: %w", err)
+	}
+	for i := range batch.Changed {
+		batch.Changed[i].DeletedAt = &now
+		batch.Changed[i].UpdatedAt = now
+	}
+	return batch, nil
+}
+
+func (s *photoService) RestorePhotos(ctx context.Context, ids []primitive.ObjectID) (*PhotoBatch, error) {
+	batch, err := s.loadBatch(ctx, ids, s.checkTrashed(ctx))
+	if err != nil || len(batch.Changed) == 0 {
+		return batch, err
+	}
+
+	now := time.Now()
+	if _, err := s.photoRepo.RestoreMany(ctx, photoIDs(batch.Changed), now); err != nil {
+		return nil, fmt.Errorf("failed to restore photos: %w", err)
+	}
+	for i := range batch.Changed {
+		batch.Changed[i].DeletedAt = nil
+		batch.Changed[i].UpdatedAt = now
+	}
+	return batch, nil
+}
+
+func (s *photoService) PurgePhotos(ctx context.Context, ids []primitive.ObjectID) (*PhotoBatch, error) {
+	batch, err := s.loadBatch(ctx, ids, s.checkTrashed(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	// Each photo's files are deleted under its own journal entry, so failures stay separate
+	purged := batch.Changed[:0]
+	for _, photo := range batch.Changed {
+		if err := s.purge(ctx, &photo); err != nil {
+			batch.Failed[photo.ID] = err
+			continue
+		}
+		purged = append(purged, photo)
+	}
+	batch.Changed = purged
+	return batch, nil
+}
+
+func (s *photoService) TagPhotos(ctx context.Context, ids []primitive.ObjectID, add, remove []string) (*PhotoBatch, error) {
+	add, err := normalizeTags(add)
+	if err != nil {
+		return nil, err
+	}
+	remove, err = normalizeTags(remove)
+	if err != nil {
+		return nil, err
+	}
+	if len(add) == 0 && len(remove) == 0 {
+		return nil, fmt.Errorf("%w: no tags to add or remove", ErrInvalidArgument)
+	}
+
+	batch, err := s.loadBatch(ctx, ids, func(photo *models.Photo) error {
+		if photo.IsTrashed() {
+			return ErrPhotoNotFound
+		}
+		return s.access.checkPhoto(ctx, photo, permEdit)
+	})
+	if err != nil || len(batch.Changed) == 0 {
+		return batch, err
+	}
+
+	now := time.Now()
+	if err := s.photoRepo.UpdateTags(ctx, photoIDs(batch.Changed), add, remove, now); err != nil {
+		return nil, fmt.Errorf("failed to update tags: %w", err)
+	}
+	for i := range batch.Changed {
+		photo := &batch.Changed[i]
+		for _, tag := range add {
+			if !slices.Contains(photo.Tags, tag) {
+				photo.Tags = append(photo.Tags, tag)
+			}
+		}
+		photo.Tags = slices.DeleteFunc(photo.Tags, func(tag string) bool {
+			return slices.Contains(remove, tag)
+		})
+		photo.UpdatedAt = now
+	}
+	return batch, nil
+}
+
+// loadBatch loads the photos and sorts them into those that pass check, as
+// Changed, and those that do not exist or fail it, as Failed
+func (s *photoService) loadBatch(ctx context.Context, ids []primitive.ObjectID, check func(*models.Photo) error) (*PhotoBatch, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: no photos given", ErrInvalidArgument)
+	}
+	if len(ids) > maxPhotoBatch {
+		return nil, fmt.Errorf("%w: at most %d photos can be changed at once", ErrInvalidArgument, maxPhotoBatch)
+	}
+
+	photos, err := s.photoRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[primitive.ObjectID]bool, len(photos))
+
+	batch := &PhotoBatch{Failed: make(map[primitive.ObjectID]error)}
+	for _, photo := range photos {
+		found[photo.ID] = true
+		if err := check(&photo); err != nil {
+			batch.Failed[photo.ID] = err
+			continue
+		}
+		batch.Changed = append(batch.Changed, photo)
+	}
+	for _, id := range ids {
+		if !found[id] {
+			batch.Failed[id] = ErrPhotoNotFound
+		}
+	}
+	return batch, nil
+}
+
+// checkTrashed returns a check that passes photos in the trash owned by the caller, like getTrashedPhoto
+func (s *photoService) checkTrashed(ctx context.Context) func(*models.Photo) error {
+	return func(photo *models.Photo) error {
+		return checkTrashed(ctx, photo)
+	}
+}
+
+// normalizeTags trims tags, drops empty and repeated ones and checks their number and length
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) > maxTagsPerChange {
+		return nil, fmt.Errorf("%w: at most %d tags can be changed at once", ErrInvalidArgument, maxTagsPerChange)
+	}
+
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || slices.Contains(normalized, tag) {
+			continue
+		}
+		if len(tag) > maxTagLength {
+			return nil, fmt.Errorf("%w: tags are at most %d bytes long", ErrInvalidArgument, maxTagLength)
+		}
+		normalized = append(normalized, tag)
+	}
+	return normalized, nil
+}
+
+func photoIDs(photos []models.Photo) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, len(photos))
+	for i := range photos {
+		ids[i] = photos[i].ID
+	}
+	return ids
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"photocloud/internal/domain/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// recoveryBatchSize bounds how many pending operations are loaded per recovery round
+const recoveryBatchSize = 100
+
+// beginOperation journals the intent to change a photo in storage and the database.
+// Deletes list the files of the photo's previous versions as version keys.
+func (s *photoService) beginOperation(ctx context.Context, opType models.OperationType, photoID primitive.ObjectID, key string, versionKeys ...string) (*models.PendingOperation, error) {
+	now := time.Now()
+	op := &models.PendingOperation{
+		Type:        opType,
+		PhotoID:     photoID,
+		StorageKey:  key,
+		VersionKeys: versionKeys,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := s.opRepo.Create(ctx, op); err != nil {
+		return nil, fmt.Errorf("failed to record pending %s: %w", opType, err)
+	}
+	return op, nil
+}
+
+// finishOperation removes a completed operation from the journal. A failure is
+// harmless: recovery will find both sides consistent and remove it later.
+func (s *photoService) finishOperation(ctx context.Context, op *models.PendingOperation) {
+	_ = s.opRepo.Delete(context.WithoutCancel(ctx), op.ID)
+}
+
+// failOperation records why an operation could not be resolved and leaves it for recovery
+func (s *photoService) failOperation(ctx context.Context, op *models.PendingOperation, err error) error {
+	_ = s.opRepo.RecordFailure(context.WithoutCancel(ctx), op.ID, err.Error())
+	return err
+}
+
+// resolveUpload settles an interrupted upload. If the photo record was written
+// the upload is complete, otherwise the stored file is removed.
+func (s *photoService) resolveUpload(ctx context.Context, op *models.PendingOperation) error {
+	photo, err := s.photoRepo.GetByID(ctx, op.PhotoID)
+	if err != nil {
+		return s.failOperation(ctx, op, fmt.Errorf("failed to check photo record: %w", err))
+	}
+
+	if photo == nil {
+		if err := s.storageRepo.DeleteFile(ctx, op.StorageKey); err != nil {
+			return s.failOperation(ctx, op, fmt.Errorf("failed to delete file from storage: %w", err))
+		}
+	}
+
+	s.finishOperation(ctx, op)
+	return nil
+}
+
+// resolveVersionUpload settles an interrupted version upload. If the photo
+// references the new file the upload is complete, otherwise the file is removed.
+func (s *photoService) resolveVersionUpload(ctx context.Context, op *models.PendingOperation) error {
+	photo, err := s.photoRepo.GetByID(ctx, op.PhotoID)
+	if err != nil {
+		return s.failOperation(ctx, op, fmt.Errorf("failed to check photo record: %w", err))
+	}
+
+	if photo == nil || !slices.Contains(photo.StorageKeys(), op.StorageKey) {
+		if err := s.storageRepo.DeleteFile(ctx, op.StorageKey); err != nil {
+			return s.failOperation(ctx, op, fmt.Errorf("failed to delete file from storage: %w", err))
+		}
+	}
+
+	s.finishOperation(ctx, op)
+	return nil
+}
+
+// completeVersionDelete rolls a version delete forward: the version is removed
+// from the record, then its file from storage
+func (s *photoService) completeVersionDelete(ctx context.Context, op *models.PendingOperation) error {
+	photo, err := s.photoRepo.GetByID(ctx, op.PhotoID)
+	if err != nil {
+		return s.failOperation(ctx, op, fmt.Errorf("failed to check photo record: %w", err))
+	}
+
+	// The current file is never deleted this way
+	if photo != nil && photo.S3Key == op.StorageKey {
+		s.finishOperation(ctx, op)
+		return nil
+	}
+
+	removed, err := s.photoRepo.RemoveVersion(ctx, op.PhotoID, op.StorageKey)
+	if err != nil {
+		return s.failOperation(ctx, op, fmt.Errorf("failed to remove version from photo record: %w", err))
+	}
+	if removed && photo != nil {
+		for _, version := range photo.Versions {
+			if version.S3Key == op.StorageKey {
+				s.usage.record(ctx, photo.OwnerID, models.StorageUsage{
+					Versions: models.UsageCounter{Bytes: -version.Size, Files: -1},
+				})
+			}
+		}
+	}
+
+	if err := s.storageRepo.DeleteFile(ctx, op.StorageKey); err != nil {
+		return s.failOperation(ctx, op, fmt.Errorf("failed to delete file from storage: %w", err))
+	}
+
+	s.finishOperation(ctx, op)
+	return nil
+}
+
+// completeDelete rolls a delete forward: the record goes first so no photo is
+// ever visible without its file, then the file and its variants are removed.
+func (s *photoService) completeDelete(ctx context.Context, op *models.PendingOperation) error {
+	// The record is loaded for its owner's usage; after a crash it may already be gone
+	photo, err := s.photoRepo.GetByID(ctx, op.PhotoID)
+	if err != nil {
+		return s.failOperation(ctx, op, fmt.Errorf("failed to check photo record: %w", err))
+	}
+	if photo == nil {
+		photo = &models.Photo{ID: op.PhotoID}
+	}
+
+	// Delete from database
+	err = s.photoRepo.Delete(ctx, op.PhotoID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return s.failOperation(ctx, op, fmt.Errorf("failed to delete photo record: %w", err))
+	}
+	if err == nil {
+		s.usage.record(ctx, photo.OwnerID, photoUsage(photo).Negate())
+	}
+
+	// Delete from S3
+	for _, key := range append([]string{op.StorageKey}, op.VersionKeys...) {
+		if err := s.storageRepo.DeleteFile(ctx, key); err != nil {
+			return s.failOperation(ctx, op, fmt.Errorf("failed to delete file from storage: %w", err))
+		}
+	}
+
+	// Delete derived variants
+	if err := deleteVariants(ctx, s.storageRepo, s.usage, photo); err != nil {
+		return s.failOperation(ctx, op, err)
+	}
+
+	s.finishOperation(ctx, op)
+	return nil
+}
+
+// resolveOperation completes or rolls back a single journaled operation
+func (s *photoService) resolveOperation(ctx context.Context, op *models.PendingOperation) error {
+	switch op.Type {
+	case models.OperationTypeUpload:
+		return s.resolveUpload(ctx, op)
+	case models.OperationTypeDelete:
+		return s.completeDelete(ctx, op)
+	case models.OperationTypeUploadVersion:
+		return s.resolveVersionUpload(ctx, op)
+	case models.OperationTypeDeleteVersion:
+		return s.completeVersionDelete(ctx, op)
+	default:
+		return s.failOperation(ctx, op, fmt.Errorf("unknown operation type %q", op.Type))
+	}
+}
+
+func (s *photoService) RecoverPendingOperations(ctx context.Context, cutoff time.Time) (int, error) {
+	recovered := 0
+	var errs []error
+	for {
+		ops, err := s.opRepo.ListStale(ctx, cutoff, recoveryBatchSize)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list pending operations: %w", err))
+			return recovered, errors.Join(errs...)
+		}
+
+		failed := 0
+		for i := range ops {
+			if err := s.resolveOperation(ctx, &ops[i]); err != nil {
+				errs = append(errs, fmt.Errorf("%s of photo %s: %w", ops[i].Type, ops[i].PhotoID.Hex(), err))
+				failed++
+				continue
+			}
+			recovered++
+		}
+
+		// Stop once the journal is drained or a whole batch keeps failing
+		if len(ops) < recoveryBatchSize || failed == len(ops) {
+			return recovered, errors.Join(errs...)
+		}
+	}
+}
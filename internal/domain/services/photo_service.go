@@ -1,122 +1,602 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"photocloud/config"
+	"photocloud/internal/domain/auth"
 	"photocloud/internal/domain/models"
 	"photocloud/internal/domain/repositories"
+	"photocloud/internal/imaging"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+const (
+	// purgeBatchSize bounds how many trashed photos are loaded per purge round
+	purgeBatchSize = 100
+
+	// maxUploadCheckBatch bounds the number of files checked at once before uploading
+	maxUploadCheckBatch = 1000
+
+	// exifHeadSize is how much of a JPEG upload is inspected for EXIF metadata.
+	// The EXIF segment is limited to 64KB and comes first in the file.
+	exifHeadSize = 128 * 1024
+)
+
+// FileDigest identifies a file by the hex SHA-256 of its content and its size
+type FileDigest struct {
+	SHA256 string
+	Size   int64
+}
+
+// PhotoMetadata is what the library a photo is imported from knows about it. Zero
+// fields are unknown.
+type PhotoMetadata struct {
+	TakenAt  *time.Time
+	Location *models.GeoLocation
+	Tags     []string
+}
+
+// UploadedFile tells whether a file was already uploaded by the caller. PhotoID
+// is zero when it was not.
+type UploadedFile struct {
+	FileDigest
+	PhotoID primitive.ObjectID
+	Trashed bool
+}
+
 type PhotoService interface {
 	UploadPhoto(ctx context.Context, name, description string, content io.Reader, contentType string, size int64) (*models.Photo, error)
+	// ImportPhoto uploads a photo brought over from another library, keeping what that library
+	// knew about when and where it was taken and how it was tagged
+	ImportPhoto(ctx context.Context, name, description string, content io.Reader, contentType string, size int64, metadata PhotoMetadata) (*models.Photo, error)
+	// CheckUploaded tells, for each file in order, whether the caller already has a photo
+	// whose current file or a kept version has the same hash and size, so that backup clients
+	// can skip it. Photos in the trash count as uploaded and are reported as trashed.
+	CheckUploaded(ctx context.Context, files []FileDigest) ([]UploadedFile, error)
 	GetPhoto(ctx context.Context, id primitive.ObjectID) (*models.Photo, error)
-	GetPhotoContent(ctx context.Context, id primitive.ObjectID) (io.ReadCloser, error)
+	GetPhotoContent(ctx context.Context, id primitive.ObjectID) (*FileContent, error)
 	DeletePhoto(ctx context.Context, id primitive.ObjectID) error
 	ListPhotos(ctx context.Context, page, limit int) ([]models.Photo, error)
 	GetPhotoURL(ctx context.Context, id primitive.ObjectID) (string, error)
+	// GetPhotoWithURL returns a photo together with a presigned URL of its file
+	GetPhotoWithURL(ctx context.Context, id primitive.ObjectID) (*models.Photo, string, error)
+
+	// ListTrash lists photos in the trash, most recently deleted first
+	ListTrash(ctx context.Context, page, limit int) ([]models.Photo, error)
+	// RestorePhoto moves a photo out of the trash
+	RestorePhoto(ctx context.Context, id primitive.ObjectID) (*models.Photo, error)
+	// PurgePhoto permanently deletes a photo that is in the trash
+	PurgePhoto(ctx context.Context, id primitive.ObjectID) error
+	// TrashPhotos moves the caller's photos to the trash
+	TrashPhotos(ctx context.Context, ids []primitive.ObjectID) (*PhotoBatch, error)
+	// RestorePhotos moves the caller's photos out of the trash
+	RestorePhotos(ctx context.Context, ids []primitive.ObjectID) (*PhotoBatch, error)
+	// PurgePhotos permanently deletes the caller's photos in the trash and their stored files
+	PurgePhotos(ctx context.Context, ids []primitive.ObjectID) (*PhotoBatch, error)
+	// TagPhotos adds and then removes tags on photos the caller may edit
+	TagPhotos(ctx context.Context, ids []primitive.ObjectID, add, remove []string) (*PhotoBatch, error)
+	// PurgeExpiredPhotos permanently deletes photos moved to the trash before the cutoff
+	PurgeExpiredPhotos(ctx context.Context, cutoff time.Time) (int, error)
+
+	// RecoverPendingOperations completes or rolls back operations journaled before the cutoff
+	RecoverPendingOperations(ctx context.Context, cutoff time.Time) (int, error)
+
+	// RotatePhoto rotates a photo clockwise by a multiple of 90 degrees and then flips it,
+	// by changing its orientation rather than re-encoding the original
+	RotatePhoto(ctx context.Context, id primitive.ObjectID, degrees int, flip imaging.Flip) (*models.Photo, error)
+
+	// UpdateEdits replaces the photo's edit recipe; an empty recipe resets the photo to its original
+	UpdateEdits(ctx context.Context, id primitive.ObjectID, ops []models.EditOperation) (*models.Photo, error)
+
+	// UploadVersion replaces the content of a photo, keeping the previous content as a version
+	UploadVersion(ctx context.Context, id primitive.ObjectID, content io.Reader, contentType string, size int64) (*models.Photo, error)
+	// ListVersions lists the kept versions of a photo, oldest first
+	ListVersions(ctx context.Context, id primitive.ObjectID) ([]models.PhotoVersion, error)
+	// GetVersionContent returns a seekable view of the stored file of a photo version
+	GetVersionContent(ctx context.Context, id primitive.ObjectID, number int) (*FileContent, error)
+	// RevertPhoto makes a copy of a previous version the photo's new current version
+	RevertPhoto(ctx context.Context, id primitive.ObjectID, number int) (*models.Photo, error)
+	// PruneVersions removes previous versions beyond the keep most recent ones or created
+	// before the cutoff. A negative keep or a zero cutoff disables that limit.
+	PruneVersions(ctx context.Context, keep int, cutoff time.Time) (int, error)
 }
 
 type photoService struct {
 	photoRepo   repositories.PhotoRepository
 	storageRepo repositories.StorageRepository
+	opRepo      repositories.PendingOperationRepository
+	access      accessChecker
+	usage       usageTracker
 }
 
-func NewPhotoService(photoRepo repositories.PhotoRepository, storageRepo repositories.StorageRepository) PhotoService {
+func NewPhotoService(photoRepo repositories.PhotoRepository, albumRepo repositories.AlbumRepository, userRepo repositories.UserRepository, usageRepo repositories.UsageRepository, storageRepo repositories.StorageRepository, opRepo repositories.PendingOperationRepository, quotas config.QuotaConfig) PhotoService {
 	return &photoService{
 		photoRepo:   photoRepo,
 		storageRepo: storageRepo,
+		access:      accessChecker{photoRepo: photoRepo, albumRepo: albumRepo},
+		usage:       usageTracker{usageRepo: usageRepo, photoRepo: photoRepo, userRepo: userRepo, quotas: quotas},
+		opRepo:      opRepo,
 	}
 }
 
 func (s *photoService) UploadPhoto(ctx context.Context, name, description string, content io.Reader, contentType string, size int64) (*models.Photo, error) {
-	// Create a unique S3 key
-	s3Key := fmt.Sprintf("photos/%s/%s%s",
-		time.Now().Format("2006/01/02"),
-		primitive.NewObjectID().Hex(),
-		filepath.Ext(name))
+	return s.ImportPhoto(ctx, name, description, content, contentType, size, PhotoMetadata{})
+}
 
-	// Upload to S3
-	if err := s.storageRepo.UploadFile(ctx, s3Key, content, contentType); err != nil {
-		return nil, fmt.Errorf("failed to upload file to storage: %w", err)
+func (s *photoService) ImportPhoto(ctx context.Context, name, description string, content io.Reader, contentType string, size int64, metadata PhotoMetadata) (*models.Photo, error) {
+	tags, err := normalizeTags(metadata.Tags)
+	if err != nil {
+		return nil, err
+	}
+	upload, err := inspectUpload(content, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read photo metadata: %w", err)
 	}
+	defer upload.Close()
 
-	// Create photo record
+	now := time.Now()
 	photo := &models.Photo{
+		ID:          primitive.NewObjectID(),
+		OwnerID:     auth.UserID(ctx),
 		Name:        name,
 		Description: description,
 		Size:        size,
 		ContentType: contentType,
-		S3Key:       s3Key,
-		UploadedAt:  time.Now(),
-		UpdatedAt:   time.Now(),
+		ContentHash: upload.Hash,
+		Orientation: upload.Orientation,
+		Version:     1,
+		Tags:        tags,
+		TakenAt:     metadata.TakenAt,
+		Location:    metadata.Location,
+		UploadedAt:  now,
+		UpdatedAt:   now,
+	}
+
+	// Create a unique S3 key
+	photo.S3Key = fmt.Sprintf("photos/%s/%s%s",
+		now.Format("2006/01/02"),
+		photo.ID.Hex(),
+		filepath.Ext(name))
+
+	photo.Versions = []models.PhotoVersion{{
+		Number:      1,
+		S3Key:       photo.S3Key,
+		Size:        size,
+		ContentType: contentType,
+		Hash:        upload.Hash,
+		Orientation: upload.Orientation,
+		Author:      photo.OwnerID,
+		CreatedAt:   now,
+	}}
+
+	release, err := s.usage.reserve(ctx, photo.OwnerID, photoUsage(photo))
+	if err != nil {
+		return nil, err
+	}
+
+	// Journal the upload before touching storage so a crash can be rolled back
+	op, err := s.beginOperation(ctx, models.OperationTypeUpload, photo.ID, photo.S3Key)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	// Upload to S3
+	if err := s.storageRepo.UploadFile(ctx, photo.S3Key, upload.Body, contentType); err != nil {
+		_ = s.resolveUpload(context.WithoutCancel(ctx), op)
+		release()
+		return nil, fmt.Errorf("failed to upload file to storage: %w", err)
 	}
 
+	// Create photo record
 	if err := s.photoRepo.Create(ctx, photo); err != nil {
-		// Try to cleanup the uploaded file if database insert fails
-		_ = s.storageRepo.DeleteFile(ctx, s3Key)
+		_ = s.resolveUpload(context.WithoutCancel(ctx), op)
+		release()
 		return nil, fmt.Errorf("failed to create photo record: %w", err)
 	}
 
+	s.finishOperation(ctx, op)
 	return photo, nil
 }
 
+func (s *photoService) CheckUploaded(ctx context.Context, files []FileDigest) ([]UploadedFile, error) {
+	userID := auth.UserID(ctx)
+	if userID == "" {
+		return nil, ErrUnauthorized
+	}
+	if len(files) > maxUploadCheckBatch {
+		return nil, fmt.Errorf("%w: at most %d files can be checked at once", ErrInvalidArgument, maxUploadCheckBatch)
+	}
+
+	results := make([]UploadedFile, len(files))
+	hashes := make([]string, 0, len(files))
+	for i, file := range files {
+		hash := strings.ToLower(file.SHA256)
+		if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("%w: %q is not a hex SHA-256 hash", ErrInvalidArgument, file.SHA256)
+		}
+		if file.Size < 0 {
+			return nil, fmt.Errorf("%w: the size of %s is negative", ErrInvalidArgument, file.SHA256)
+		}
+		results[i] = UploadedFile{FileDigest: FileDigest{SHA256: hash, Size: file.Size}}
+		hashes = append(hashes, hash)
+	}
+	if len(hashes) == 0 {
+		return results, nil
+	}
+
+	photos, err := s.photoRepo.ListByContentHash(ctx, userID, hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Photos outside the trash are preferred when the same file was uploaded again
+	for i := range results {
+		for j := range photos {
+			photo := &photos[j]
+			if !photo.HasContent(results[i].SHA256, results[i].Size) {
+				continue
+			}
+			if results[i].PhotoID.IsZero() || (results[i].Trashed && !photo.IsTrashed()) {
+				results[i].PhotoID = photo.ID
+				results[i].Trashed = photo.IsTrashed()
+			}
+		}
+	}
+	return results, nil
+}
+
 func (s *photoService) GetPhoto(ctx context.Context, id primitive.ObjectID) (*models.Photo, error) {
-	return s.photoRepo.GetByID(ctx, id)
+	return s.getActivePhoto(ctx, id, permView)
 }
 
-func (s *photoService) GetPhotoContent(ctx context.Context, id primitive.ObjectID) (io.ReadCloser, error) {
-	photo, err := s.photoRepo.GetByID(ctx, id)
+// GetPhotoContent returns a seekable view of the photo's stored file. Only its
+// metadata is fetched here; the bytes are downloaded as the content is read.
+func (s *photoService) GetPhotoContent(ctx context.Context, id primitive.ObjectID) (*FileContent, error) {
+	photo, err := s.getActivePhoto(ctx, id, permView)
 	if err != nil {
 		return nil, err
 	}
-	if photo == nil {
-		return nil, fmt.Errorf("photo not found")
+
+	info, err := s.storageRepo.StatFile(ctx, photo.S3Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file metadata: %w", err)
+	}
+	if info.ContentType == "" {
+		info.ContentType = photo.ContentType
 	}
 
-	return s.storageRepo.DownloadFile(ctx, photo.S3Key)
+	return newFileContent(ctx, s.storageRepo, photo.Name, *info), nil
 }
 
+// DeletePhoto moves a photo to the trash. The stored file is kept until the
+// photo is purged, either explicitly or by the trash purger.
 func (s *photoService) DeletePhoto(ctx context.Context, id primitive.ObjectID) error {
-	photo, err := s.photoRepo.GetByID(ctx, id)
+	if _, err := s.getActivePhoto(ctx, id, permOwn); err != nil {
+		return err
+	}
+
+	if err := s.photoRepo.MoveToTrash(ctx, id, time.Now()); err != nil {
+		return fmt.Errorf("failed to move photo to trash: %w", err)
+	}
+
+	return nil
+}
+
+func (s *photoService) ListPhotos(ctx context.Context, page, limit int) ([]models.Photo, error) {
+	return s.photoRepo.List(ctx, auth.UserID(ctx), page, limit)
+}
+
+func (s *photoService) GetPhotoURL(ctx context.Context, id primitive.ObjectID) (string, error) {
+	photo, err := s.getActivePhoto(ctx, id, permView)
+	if err != nil {
+		return "", err
+	}
+	return s.photoURL(ctx, photo)
+}
+
+func (s *photoService) GetPhotoWithURL(ctx context.Context, id primitive.ObjectID) (*models.Photo, string, error) {
+	photo, err := s.getActivePhoto(ctx, id, permView)
+	if err != nil {
+		return nil, "", err
+	}
+	url, err := s.photoURL(ctx, photo)
+	if err != nil {
+		return nil, "", err
+	}
+	return photo, url, nil
+}
+
+// photoURL returns a presigned URL of a photo's file that expires in 15 minutes
+func (s *photoService) photoURL(ctx context.Context, photo *models.Photo) (string, error) {
+	return s.storageRepo.GetFileURL(ctx, photo.S3Key, 15)
+}
+
+func (s *photoService) ListTrash(ctx context.Context, page, limit int) ([]models.Photo, error) {
+	userID := auth.UserID(ctx)
+	if userID == "" {
+		return nil, ErrUnauthorized
+	}
+	return s.photoRepo.ListTrashed(ctx, userID, page, limit)
+}
+
+func (s *photoService) RestorePhoto(ctx context.Context, id primitive.ObjectID) (*models.Photo, error) {
+	if _, err := s.getTrashedPhoto(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if err := s.photoRepo.Restore(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed to restore photo: %w", err)
+	}
+
+	return s.getActivePhoto(ctx, id, permOwn)
+}
+
+func (s *photoService) PurgePhoto(ctx context.Context, id primitive.ObjectID) error {
+	photo, err := s.getTrashedPhoto(ctx, id)
 	if err != nil {
 		return err
 	}
-	if photo == nil {
-		return fmt.Errorf("photo not found")
+
+	return s.purge(ctx, photo)
+}
+
+func (s *photoService) PurgeExpiredPhotos(ctx context.Context, cutoff time.Time) (int, error) {
+	purged := 0
+	var errs []error
+	for {
+		photos, err := s.photoRepo.ListTrashedBefore(ctx, cutoff, purgeBatchSize)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list expired photos: %w", err))
+			return purged, errors.Join(errs...)
+		}
+
+		failed := 0
+		for i := range photos {
+			if err := s.purge(ctx, &photos[i]); err != nil {
+				errs = append(errs, fmt.Errorf("photo %s: %w", photos[i].ID.Hex(), err))
+				failed++
+				continue
+			}
+			purged++
+		}
+
+		// Stop once the backlog is drained or a whole batch keeps failing,
+		// otherwise the same failing photos would be retried forever
+		if len(photos) < purgeBatchSize || failed == len(photos) {
+			return purged, errors.Join(errs...)
+		}
 	}
+}
 
-	// Delete from S3
-	if err := s.storageRepo.DeleteFile(ctx, photo.S3Key); err != nil {
-		return fmt.Errorf("failed to delete file from storage: %w", err)
+// purge permanently deletes a photo's record and stored files
+func (s *photoService) purge(ctx context.Context, photo *models.Photo) error {
+	op, err := s.beginOperation(ctx, models.OperationTypeDelete, photo.ID, photo.S3Key, photo.StorageKeys()[1:]...)
+	if err != nil {
+		return err
 	}
 
-	// Delete from database
-	if err := s.photoRepo.Delete(ctx, id); err != nil {
-		return fmt.Errorf("failed to delete photo record: %w", err)
+	return s.completeDelete(ctx, op)
+}
+
+func (s *photoService) RotatePhoto(ctx context.Context, id primitive.ObjectID, degrees int, flip imaging.Flip) (*models.Photo, error) {
+	if degrees%90 != 0 {
+		return nil, fmt.Errorf("%w: rotation must be a multiple of 90 degrees", ErrInvalidArgument)
+	}
+	if flip != imaging.FlipNone && flip != imaging.FlipHorizontal && flip != imaging.FlipVertical {
+		return nil, fmt.Errorf("%w: unknown flip %q", ErrInvalidArgument, flip)
 	}
 
-	return nil
+	photo, err := s.getActivePhoto(ctx, id, permEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	// JPEG originals carry their orientation in EXIF, which can be rewritten in place
+	var original []byte
+	current := photo.Orientation
+	if photo.ContentType == "image/jpeg" {
+		body, _, err := s.storageRepo.DownloadFile(ctx, photo.S3Key, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download original: %w", err)
+		}
+		original, err = io.ReadAll(body)
+		body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to download original: %w", err)
+		}
+
+		// Photos uploaded before orientation was recorded
+		if current == 0 {
+			current, _ = imaging.ReadOrientation(original)
+		}
+	}
+
+	next := imaging.Reorient(current, degrees, flip)
+	if original != nil && imaging.SetOrientation(original, next) {
+		// The patched file becomes a new version so the previous bytes are kept
+		sum := sha256.Sum256(original)
+		version := models.PhotoVersion{
+			Size:        int64(len(original)),
+			ContentType: photo.ContentType,
+			Hash:        hex.EncodeToString(sum[:]),
+			Orientation: next,
+		}
+		err = s.addVersion(ctx, photo, version, func(key string) error {
+			return s.storageRepo.UploadFile(ctx, key, bytes.NewReader(original), photo.ContentType)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	previousUpdatedAt := photo.UpdatedAt
+	photo.Orientation = next
+	if len(photo.Versions) > 0 {
+		// A revert to the current version should restore the new orientation
+		photo.Versions[len(photo.Versions)-1].Orientation = next
+	}
+	if len(photo.Edits) > 0 {
+		// Keep crops over the same content now that the image is turned
+		photo.Edits = fromImagingEdits(imaging.ReorientEdits(toImagingEdits(photo.Edits), degrees, flip))
+	}
+	photo.UpdatedAt = time.Now()
+	if err := s.photoRepo.SetOrientation(ctx, photo, previousUpdatedAt); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: the photo was changed by another request", ErrConflict)
+		}
+		return nil, fmt.Errorf("failed to update photo record: %w", err)
+	}
+
+	// Renditions were produced with the old orientation
+	if err := deleteVariants(ctx, s.storageRepo, s.usage, photo); err != nil {
+		return nil, fmt.Errorf("failed to invalidate renditions: %w", err)
+	}
+
+	return photo, nil
 }
 
-func (s *photoService) ListPhotos(ctx context.Context, page, limit int) ([]models.Photo, error) {
-	return s.photoRepo.List(ctx, page, limit)
+func (s *photoService) UpdateEdits(ctx context.Context, id primitive.ObjectID, ops []models.EditOperation) (*models.Photo, error) {
+	if err := imaging.ValidateEdits(toImagingEdits(ops)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+
+	photo, err := s.getActivePhoto(ctx, id, permEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	photo.Edits = ops
+	photo.UpdatedAt = time.Now()
+	if err := s.photoRepo.SetEdits(ctx, id, ops, photo.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("failed to update edits: %w", err)
+	}
+
+	// Variants are keyed by recipe, so the old ones are unreachable
+	if err := deleteVariants(ctx, s.storageRepo, s.usage, photo); err != nil {
+		return nil, fmt.Errorf("failed to invalidate renditions: %w", err)
+	}
+
+	return photo, nil
 }
 
-func (s *photoService) GetPhotoURL(ctx context.Context, id primitive.ObjectID) (string, error) {
+// inspectedUpload is an upload read once for its hash and EXIF orientation
+type inspectedUpload struct {
+	// Body yields the complete content again, and can seek as storage needs
+	Body        io.ReadSeeker
+	Hash        string
+	Orientation int
+
+	// spool is the temporary copy of a body that cannot seek
+	spool *os.File
+}
+
+// Close removes the temporary copy of the upload, if one was made
+func (u *inspectedUpload) Close() error {
+	if u.spool == nil {
+		return nil
+	}
+	u.spool.Close()
+	return os.Remove(u.spool.Name())
+}
+
+// inspectUpload hashes an upload and reads its EXIF orientation. Storage needs a
+// seekable body, so uploads that cannot seek are copied to a temporary file, which
+// Close removes.
+func inspectUpload(content io.Reader, contentType string) (*inspectedUpload, error) {
+	upload := &inspectedUpload{Orientation: 1}
+	hash := sha256.New()
+
+	if body, ok := content.(io.ReadSeeker); ok {
+		upload.Body = body
+		if _, err := io.Copy(hash, body); err != nil {
+			return nil, err
+		}
+	} else {
+		spool, err := os.CreateTemp("", "photocloud-upload-*")
+		if err != nil {
+			return nil, err
+		}
+		upload.Body = spool
+		upload.spool = spool
+		if _, err := io.Copy(io.MultiWriter(spool, hash), content); err != nil {
+			upload.Close()
+			return nil, err
+		}
+	}
+	upload.Hash = hex.EncodeToString(hash.Sum(nil))
+
+	if _, err := upload.Body.Seek(0, io.SeekStart); err != nil {
+		upload.Close()
+		return nil, err
+	}
+	if contentType == "image/jpeg" {
+		head := make([]byte, exifHeadSize)
+		n, err := io.ReadFull(upload.Body, head)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			upload.Close()
+			return nil, err
+		}
+		if parsed, ok := imaging.ReadOrientation(head[:n]); ok {
+			upload.Orientation = parsed
+		}
+		if _, err := upload.Body.Seek(0, io.SeekStart); err != nil {
+			upload.Close()
+			return nil, err
+		}
+	}
+
+	return upload, nil
+}
+
+// getActivePhoto loads a photo that exists, is not in the trash and on which the caller has at least need
+func (s *photoService) getActivePhoto(ctx context.Context, id primitive.ObjectID, need permission) (*models.Photo, error) {
+	return s.access.activePhoto(ctx, id, need)
+}
+
+// getTrashedPhoto loads a photo that exists, is in the trash and is owned by the caller
+func (s *photoService) getTrashedPhoto(ctx context.Context, id primitive.ObjectID) (*models.Photo, error) {
 	photo, err := s.photoRepo.GetByID(ctx, id)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	if photo == nil {
-		return "", fmt.Errorf("photo not found")
+		return nil, ErrPhotoNotFound
 	}
+	if err := checkTrashed(ctx, photo); err != nil {
+		return nil, err
+	}
+	return photo, nil
+}
 
-	// Get a presigned URL that expires in 15 minutes
-	return s.storageRepo.GetFileURL(ctx, photo.S3Key, 15)
+// checkTrashed passes photos in the trash owned by the caller. Album members only see
+// photos outside the trash, and contexts without a user only pass when they act for
+// the system, so a route that forgot to authenticate cannot restore or purge anything.
+func checkTrashed(ctx context.Context, photo *models.Photo) error {
+	if !auth.IsSystem(ctx) {
+		userID := auth.UserID(ctx)
+		if userID == "" {
+			return ErrUnauthorized
+		}
+		if photo.OwnerID != userID {
+			return ErrPhotoNotFound
+		}
+	}
+	if !photo.IsTrashed() {
+		return ErrPhotoNotInTrash
+	}
+	return nil
 }
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRestorePhotoChecksOwnership(t *testing.T) {
+	ownerCtx, owner := userContext()
+	strangerCtx, _ := userContext()
+	deletedAt := time.Now()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		trashed bool
+		wantErr error
+	}{
+		{name: "owner", ctx: ownerCtx, trashed: true},
+		{name: "system", ctx: auth.WithSystem(context.Background()), trashed: true},
+		{name: "other user", ctx: strangerCtx, trashed: true, wantErr: ErrPhotoNotFound},
+		{name: "no user", ctx: context.Background(), trashed: true, wantErr: ErrUnauthorized},
+		{name: "system flag under a user", ctx: auth.WithSystem(strangerCtx), trashed: true, wantErr: ErrPhotoNotFound},
+		{name: "not in the trash", ctx: ownerCtx, wantErr: ErrPhotoNotInTrash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			photo := &models.Photo{ID: primitive.NewObjectID(), OwnerID: owner.ID.Hex()}
+			if tt.trashed {
+				photo.DeletedAt = &deletedAt
+			}
+			repo := newFakePhotoRepo(photo)
+			service := &photoService{photoRepo: repo, access: accessChecker{photoRepo: repo}}
+
+			_, err := service.RestorePhoto(tt.ctx, photo.ID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RestorePhoto() error = %v, want %v", err, tt.wantErr)
+			}
+			if restored := repo.photos[photo.ID].DeletedAt == nil; tt.trashed && restored != (tt.wantErr == nil) {
+				t.Errorf("photo restored = %v, want %v", restored, tt.wantErr == nil)
+			}
+
+			batch, err := service.RestorePhotos(tt.ctx, []primitive.ObjectID{photo.ID})
+			if err != nil {
+				t.Fatalf("RestorePhotos() error = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(batch.Failed[photo.ID], tt.wantErr) {
+				t.Errorf("RestorePhotos() failed with %v, want %v", batch.Failed[photo.ID], tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestListTrashRequiresUser(t *testing.T) {
+	service := &photoService{photoRepo: newFakePhotoRepo()}
+	for _, ctx := range []context.Context{context.Background(), auth.WithSystem(context.Background())} {
+		if _, err := service.ListTrash(ctx, 1, 20); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("ListTrash() error = %v, want ErrUnauthorized", err)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/imaging"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (s *photoService) UploadVersion(ctx context.Context, id primitive.ObjectID, content io.Reader, contentType string, size int64) (*models.Photo, error) {
+	photo, err := s.getActivePhoto(ctx, id, permEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	upload, err := inspectUpload(content, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read photo metadata: %w", err)
+	}
+	defer upload.Close()
+
+	version := models.PhotoVersion{
+		Size:        size,
+		ContentType: contentType,
+		Hash:        upload.Hash,
+		Orientation: upload.Orientation,
+	}
+	err = s.addVersion(ctx, photo, version, func(key string) error {
+		return s.storageRepo.UploadFile(ctx, key, upload.Body, contentType)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return photo, nil
+}
+
+func (s *photoService) ListVersions(ctx context.Context, id primitive.ObjectID) ([]models.PhotoVersion, error) {
+	photo, err := s.getActivePhoto(ctx, id, permView)
+	if err != nil {
+		return nil, err
+	}
+
+	return photo.History(), nil
+}
+
+func (s *photoService) GetVersionContent(ctx context.Context, id primitive.ObjectID, number int) (*FileContent, error) {
+	photo, err := s.getActivePhoto(ctx, id, permView)
+	if err != nil {
+		return nil, err
+	}
+
+	version, ok := photo.FindVersion(number)
+	if !ok {
+		return nil, ErrVersionNotFound
+	}
+
+	info, err := s.storageRepo.StatFile(ctx, version.S3Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file metadata: %w", err)
+	}
+	if info.ContentType == "" {
+		info.ContentType = version.ContentType
+	}
+
+	name := fmt.Sprintf("%s (v%d)%s", strings.TrimSuffix(photo.Name, filepath.Ext(photo.Name)), number, filepath.Ext(version.S3Key))
+	return newFileContent(ctx, s.storageRepo, name, *info), nil
+}
+
+// RevertPhoto copies the stored file of the version rather than pointing back
+// at it, so the history stays linear and pruning never removes the current file.
+func (s *photoService) RevertPhoto(ctx context.Context, id primitive.ObjectID, number int) (*models.Photo, error) {
+	photo, err := s.getActivePhoto(ctx, id, permEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	source, ok := photo.FindVersion(number)
+	if !ok {
+		return nil, ErrVersionNotFound
+	}
+	if source.S3Key == photo.S3Key {
+		// Already the current version
+		return photo, nil
+	}
+
+	version := models.PhotoVersion{
+		Size:         source.Size,
+		ContentType:  source.ContentType,
+		Hash:         source.Hash,
+		Orientation:  source.Orientation,
+		RevertedFrom: source.Number,
+	}
+	err = s.addVersion(ctx, photo, version, func(key string) error {
+		return s.storageRepo.CopyFile(ctx, source.S3Key, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return photo, nil
+}
+
+func (s *photoService) PruneVersions(ctx context.Context, keep int, cutoff time.Time) (int, error) {
+	if keep < 0 && cutoff.IsZero() {
+		return 0, nil
+	}
+
+	pruned := 0
+	var errs []error
+	for {
+		photos, err := s.photoRepo.ListWithPrunableVersions(ctx, keep, cutoff, purgeBatchSize)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list photos with prunable versions: %w", err))
+			return pruned, errors.Join(errs...)
+		}
+
+		failed := 0
+		for i := range photos {
+			for _, version := range prunableVersions(&photos[i], keep, cutoff) {
+				if err := s.pruneVersion(ctx, &photos[i], version); err != nil {
+					errs = append(errs, fmt.Errorf("photo %s version %d: %w", photos[i].ID.Hex(), version.Number, err))
+					failed++
+					break
+				}
+				pruned++
+			}
+		}
+
+		// Stop once the backlog is drained or a whole batch keeps failing
+		if len(photos) < purgeBatchSize || failed == len(photos) {
+			return pruned, errors.Join(errs...)
+		}
+	}
+}
+
+// pruneVersion permanently deletes a previous version of a photo
+func (s *photoService) pruneVersion(ctx context.Context, photo *models.Photo, version models.PhotoVersion) error {
+	op, err := s.beginOperation(ctx, models.OperationTypeDeleteVersion, photo.ID, version.S3Key)
+	if err != nil {
+		return err
+	}
+
+	return s.completeVersionDelete(ctx, op)
+}
+
+// prunableVersions returns the previous versions of a photo beyond the keep
+// most recent ones or created before the cutoff
+func prunableVersions(photo *models.Photo, keep int, cutoff time.Time) []models.PhotoVersion {
+	history := photo.History()
+	previous := history[:len(history)-1]
+
+	var prunable []models.PhotoVersion
+	for i, version := range previous {
+		tooMany := keep >= 0 && i < len(previous)-keep
+		tooOld := !cutoff.IsZero() && version.CreatedAt.Before(cutoff)
+		if tooMany || tooOld {
+			prunable = append(prunable, version)
+		}
+	}
+	return prunable
+}
+
+// addVersion makes a new version the photo's current file. store writes the
+// version's file under the key chosen for it. On success the photo is updated
+// in place and its cached variants are invalidated.
+func (s *photoService) addVersion(ctx context.Context, photo *models.Photo, version models.PhotoVersion, store func(key string) error) error {
+	// The previous current file is kept as a version
+	release, err := s.usage.reserve(ctx, photo.OwnerID, models.StorageUsage{
+		Originals: models.UsageCounter{Bytes: version.Size - photo.Size},
+		Versions:  models.UsageCounter{Bytes: photo.Size, Files: 1},
+	})
+	if err != nil {
+		return err
+	}
+
+	history := photo.History()
+	previous := photo.Version
+	now := time.Now()
+
+	version.Number = history[len(history)-1].Number + 1
+	version.Author = auth.UserID(ctx)
+	version.CreatedAt = now
+	// Concurrent uploads may pick the same number, so the key carries a unique
+	// suffix; otherwise the losing upload could overwrite the winner's file
+	version.S3Key = fmt.Sprintf("photos/%s/%s-v%d-%s%s",
+		now.Format("2006/01/02"),
+		photo.ID.Hex(),
+		version.Number,
+		primitive.NewObjectID().Hex(),
+		versionExtension(photo, version.ContentType))
+
+	// Journal the upload before touching storage so a crash can be rolled back
+	op, err := s.beginOperation(ctx, models.OperationTypeUploadVersion, photo.ID, version.S3Key)
+	if err != nil {
+		release()
+		return err
+	}
+
+	if err := store(version.S3Key); err != nil {
+		_ = s.resolveVersionUpload(context.WithoutCancel(ctx), op)
+		release()
+		return fmt.Errorf("failed to store version: %w", err)
+	}
+
+	updated := *photo
+	updated.Versions = append(slices.Clone(history), version)
+	updated.Version = version.Number
+	updated.S3Key = version.S3Key
+	updated.Size = version.Size
+	updated.ContentType = version.ContentType
+	updated.ContentHash = version.Hash
+	updated.Orientation = version.Orientation
+	updated.UpdatedAt = now
+
+	if err := s.photoRepo.UpdateVersions(ctx, &updated, previous); err != nil {
+		_ = s.resolveVersionUpload(context.WithoutCancel(ctx), op)
+		release()
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("%w: the photo was changed by another request", ErrConflict)
+		}
+		return fmt.Errorf("failed to update photo record: %w", err)
+	}
+
+	s.finishOperation(ctx, op)
+	*photo = updated
+
+	// Renditions were produced from the previous version
+	if err := deleteVariants(ctx, s.storageRepo, s.usage, photo); err != nil {
+		return fmt.Errorf("failed to invalidate renditions: %w", err)
+	}
+	return nil
+}
+
+// versionExtension returns the file extension for a version's content type,
+// falling back to the extension of the photo's current file
+func versionExtension(photo *models.Photo, contentType string) string {
+	if format, ok := imaging.FormatFromContentType(contentType); ok {
+		return imaging.Extension(format)
+	}
+	return filepath.Ext(photo.S3Key)
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+)
+
+const (
+	// photoKeyPrefix is the storage prefix holding photo originals
+	photoKeyPrefix = "photos/"
+	// quarantinePrefix is where quarantined orphan objects are moved to
+	quarantinePrefix = "quarantine/"
+)
+
+// ReconciliationService compares the storage backend with the photos collection
+type ReconciliationService interface {
+	// Reconcile reports orphan objects and dangling records, applying the action to orphans
+	Reconcile(ctx context.Context, action models.ReconcileAction) (*models.ReconciliationReport, error)
+}
+
+type reconciliationService struct {
+	photoRepo   repositories.PhotoRepository
+	storageRepo repositories.StorageRepository
+	gracePeriod time.Duration
+}
+
+// NewReconciliationService creates a reconciliation service. Objects modified
+// within the grace period are skipped because their upload may still be in flight.
+func NewReconciliationService(photoRepo repositories.PhotoRepository, storageRepo repositories.StorageRepository, gracePeriod time.Duration) ReconciliationService {
+	return &reconciliationService{
+		photoRepo:   photoRepo,
+		storageRepo: storageRepo,
+		gracePeriod: gracePeriod,
+	}
+}
+
+func (s *reconciliationService) Reconcile(ctx context.Context, action models.ReconcileAction) (*models.ReconciliationReport, error) {
+	switch action {
+	case "":
+		action = models.ReconcileActionReport
+	case models.ReconcileActionReport, models.ReconcileActionQuarantine, models.ReconcileActionDelete:
+	default:
+		return nil, fmt.Errorf("%w: unknown reconcile action %q", ErrInvalidArgument, action)
+	}
+
+	report := &models.ReconciliationReport{
+		Action:    action,
+		StartedAt: time.Now(),
+		Orphans:   []models.OrphanObject{},
+		Dangling:  []models.DanglingRecord{},
+	}
+
+	// Records are listed before objects: uploads write storage first, so any
+	// object created in between is recent and falls inside the grace period
+	photos, err := s.photoRepo.ListStorageKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list photo records: %w", err)
+	}
+	files, err := s.storageRepo.ListFiles(ctx, photoKeyPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stored files: %w", err)
+	}
+	report.RecordsScanned = len(photos)
+	report.ObjectsScanned = len(files)
+
+	referenced := make(map[string]bool, len(photos))
+	for _, photo := range photos {
+		for _, key := range photo.StorageKeys() {
+			referenced[key] = true
+		}
+	}
+
+	stored := make(map[string]bool, len(files))
+	graceCutoff := report.StartedAt.Add(-s.gracePeriod)
+	for _, file := range files {
+		stored[file.Key] = true
+		if referenced[file.Key] {
+			continue
+		}
+		if file.LastModified.After(graceCutoff) {
+			report.SkippedRecent++
+			continue
+		}
+
+		orphan := models.OrphanObject{
+			Key:          file.Key,
+			Size:         file.Size,
+			LastModified: file.LastModified,
+		}
+		s.resolveOrphan(ctx, action, &orphan)
+		report.Orphans = append(report.Orphans, orphan)
+		report.OrphanBytes += file.Size
+	}
+
+	for _, photo := range photos {
+		for _, version := range photo.History() {
+			if stored[version.S3Key] {
+				continue
+			}
+			report.Dangling = append(report.Dangling, models.DanglingRecord{
+				PhotoID: photo.ID.Hex(),
+				Name:    photo.Name,
+				Key:     version.S3Key,
+				Version: version.Number,
+				Size:    version.Size,
+				Trashed: photo.IsTrashed(),
+			})
+			report.DanglingBytes += version.Size
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// resolveOrphan applies the reconcile action to an orphan object, recording the outcome on it
+func (s *reconciliationService) resolveOrphan(ctx context.Context, action models.ReconcileAction, orphan *models.OrphanObject) {
+	switch action {
+	case models.ReconcileActionQuarantine:
+		target := quarantinePrefix + orphan.Key
+		if err := s.storageRepo.CopyFile(ctx, orphan.Key, target); err != nil {
+			orphan.Error = fmt.Sprintf("failed to copy to quarantine: %v", err)
+			return
+		}
+		if err := s.storageRepo.DeleteFile(ctx, orphan.Key); err != nil {
+			orphan.Error = fmt.Sprintf("copied to quarantine but failed to delete original: %v", err)
+			return
+		}
+		orphan.Resolution = target
+	case models.ReconcileActionDelete:
+		if err := s.storageRepo.DeleteFile(ctx, orphan.Key); err != nil {
+			orphan.Error = fmt.Sprintf("failed to delete: %v", err)
+			return
+		}
+		orphan.Resolution = "deleted"
+	}
+}
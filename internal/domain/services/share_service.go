@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+	"photocloud/internal/imaging"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// shareDisplaySize bounds the rendition served to viewers of share links
+const shareDisplaySize = 2048
+
+// SharedContent is what a share link currently exposes. Album links list one
+// page of the album's photos.
+type SharedContent struct {
+	Link   *models.ShareLink
+	Album  *models.Album
+	Photos []models.Photo
+}
+
+// SharedPhotoURLs are short-lived storage URLs for one photo of a share link.
+// DownloadURL is only set when the link allows downloading originals.
+type SharedPhotoURLs struct {
+	ViewURL     string
+	DownloadURL string
+	ExpiresAt   time.Time
+}
+
+// ShareService manages public share links and resolves them for anonymous viewers
+type ShareService interface {
+	// CreateShare creates a link to a photo or album owned by the caller and returns it with
+	// its token. Only a hash of the token is stored, so it cannot be shown again.
+	CreateShare(ctx context.Context, targetType models.ShareTargetType, targetID primitive.ObjectID, expiresAt *time.Time, password string, allowDownload bool) (*models.ShareLink, string, error)
+	// ListShares lists the caller's share links, newest first
+	ListShares(ctx context.Context, page, limit int) ([]models.ShareLink, error)
+	// RevokeShare permanently disables a share link
+	RevokeShare(ctx context.Context, id primitive.ObjectID) error
+
+	// OpenShare checks the token and password and returns the shared content, counting a view
+	OpenShare(ctx context.Context, token, password string, page, limit int) (*SharedContent, error)
+	// SharedPhotoURLs checks the token and password and mints storage URLs for a shared photo
+	SharedPhotoURLs(ctx context.Context, token, password string, photoID primitive.ObjectID) (*SharedPhotoURLs, error)
+}
+
+type shareService struct {
+	shareRepo        repositories.ShareLinkRepository
+	photoRepo        repositories.PhotoRepository
+	storageRepo      repositories.StorageRepository
+	transformService TransformService
+	access           accessChecker
+	urlExpiryMinutes int
+}
+
+// NewShareService creates a share service. Storage URLs minted for viewers
+// expire after urlExpiryMinutes.
+func NewShareService(shareRepo repositories.ShareLinkRepository, photoRepo repositories.PhotoRepository, albumRepo repositories.AlbumRepository, storageRepo repositories.StorageRepository, transformService TransformService, urlExpiryMinutes int) ShareService {
+	return &shareService{
+		shareRepo:        shareRepo,
+		photoRepo:        photoRepo,
+		storageRepo:      storageRepo,
+		transformService: transformService,
+		access:           accessChecker{photoRepo: photoRepo, albumRepo: albumRepo},
+		urlExpiryMinutes: urlExpiryMinutes,
+	}
+}
+
+func (s *shareService) CreateShare(ctx context.Context, targetType models.ShareTargetType, targetID primitive.ObjectID, expiresAt *time.Time, password string, allowDownload bool) (*models.ShareLink, string, error) {
+	switch targetType {
+	case models.ShareTargetPhoto:
+		if _, err := s.access.activePhoto(ctx, targetID, permOwn); err != nil {
+			return nil, "", err
+		}
+	case models.ShareTargetAlbum:
+		if _, err := s.access.album(ctx, targetID, permOwn); err != nil {
+			return nil, "", err
+		}
+	default:
+		return nil, "", fmt.Errorf("%w: unknown share target %q", ErrInvalidArgument, targetType)
+	}
+
+	now := time.Now()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return nil, "", fmt.Errorf("%w: expiry must be in the future", ErrInvalidArgument)
+	}
+
+	link := &models.ShareLink{
+		OwnerID:       auth.UserID(ctx),
+		TargetType:    targetType,
+		TargetID:      targetID,
+		AllowDownload: allowDownload,
+		ExpiresAt:     expiresAt,
+		CreatedAt:     now,
+	}
+
+	if password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+			return nil, "", fmt.Errorf("%w: password is longer than 72 bytes", ErrInvalidArgument)
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to hash password: %w", err)
+		}
+		link.PasswordHash = string(hash)
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate share token: %w", err)
+	}
+	link.TokenHash = hashToken(token)
+
+	if err := s.shareRepo.Create(ctx, link); err != nil {
+		return nil, "", fmt.Errorf("failed to create share link: %w", err)
+	}
+	return link, token, nil
+}
+
+func (s *shareService) ListShares(ctx context.Context, page, limit int) ([]models.ShareLink, error) {
+	return s.shareRepo.ListByOwner(ctx, auth.UserID(ctx), page, limit)
+}
+
+func (s *shareService) RevokeShare(ctx context.Context, id primitive.ObjectID) error {
+	link, err := s.shareRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if link == nil {
+		return ErrShareNotFound
+	}
+	if user, ok := auth.UserFromContext(ctx); ok && link.OwnerID != user.ID.Hex() {
+		return ErrShareNotFound
+	}
+
+	if err := s.shareRepo.Revoke(ctx, id, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke share link: %w", err)
+	}
+	return nil
+}
+
+func (s *shareService) OpenShare(ctx context.Context, token, password string, page, limit int) (*SharedContent, error) {
+	link, err := s.resolve(ctx, token, password)
+	if err != nil {
+		return nil, err
+	}
+
+	content := &SharedContent{Link: link}
+	switch link.TargetType {
+	case models.ShareTargetPhoto:
+		photo, err := s.access.activePhoto(ctx, link.TargetID, permView)
+		if err != nil {
+			return nil, err
+		}
+		content.Photos = []models.Photo{*photo}
+	case models.ShareTargetAlbum:
+		album, err := s.access.album(ctx, link.TargetID, permView)
+		if err != nil {
+			return nil, err
+		}
+		photos, err := s.photoRepo.ListByAlbum(ctx, album.ID, page, limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list album photos: %w", err)
+		}
+		content.Album = album
+		content.Photos = photos
+	}
+
+	// A failed counter update must not keep the viewer out
+	_ = s.shareRepo.RecordView(context.WithoutCancel(ctx), link.ID, time.Now())
+	return content, nil
+}
+
+func (s *shareService) SharedPhotoURLs(ctx context.Context, token, password string, photoID primitive.ObjectID) (*SharedPhotoURLs, error) {
+	link, err := s.resolve(ctx, token, password)
+	if err != nil {
+		return nil, err
+	}
+
+	photo, err := s.access.activePhoto(ctx, photoID, permView)
+	if err != nil {
+		return nil, err
+	}
+	shared := link.TargetType == models.ShareTargetPhoto && photo.ID == link.TargetID ||
+		link.TargetType == models.ShareTargetAlbum && photo.InAlbum(link.TargetID)
+	if !shared {
+		return nil, ErrPhotoNotFound
+	}
+
+	urls := &SharedPhotoURLs{
+		ExpiresAt: time.Now().Add(time.Duration(s.urlExpiryMinutes) * time.Minute),
+	}
+
+	// Viewers get a bounded rendition with the photo's edits, never the original
+	viewKey, err := s.transformService.PrepareVariant(ctx, photo.ID, imaging.Options{
+		Width:  shareDisplaySize,
+		Height: shareDisplaySize,
+		Fit:    imaging.FitContain,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare photo for viewing: %w", err)
+	}
+	if urls.ViewURL, err = s.storageRepo.GetFileURL(ctx, viewKey, s.urlExpiryMinutes); err != nil {
+		return nil, fmt.Errorf("failed to generate view URL: %w", err)
+	}
+
+	if link.AllowDownload {
+		if urls.DownloadURL, err = s.storageRepo.GetFileURL(ctx, photo.S3Key, s.urlExpiryMinutes); err != nil {
+			return nil, fmt.Errorf("failed to generate download URL: %w", err)
+		}
+	}
+
+	return urls, nil
+}
+
+// resolve loads the share link for a token, checking that it is active and
+// that the password matches
+func (s *shareService) resolve(ctx context.Context, token, password string) (*models.ShareLink, error) {
+	link, err := s.shareRepo.GetByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up share link: %w", err)
+	}
+	if link == nil {
+		return nil, ErrShareNotFound
+	}
+	if !link.IsActive(time.Now()) {
+		return nil, ErrShareExpired
+	}
+	if link.HasPassword() && bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)) != nil {
+		return nil, ErrPasswordRequired
+	}
+	return link, nil
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"photocloud/internal/domain/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// newTestShareService returns a share service over one photo owned by a new user, and the
+// owner's context
+func newTestShareService() (*shareService, *fakeShareRepo, *models.Photo, context.Context) {
+	ctx, owner := userContext()
+	photo := &models.Photo{ID: primitive.NewObjectID(), OwnerID: owner.ID.Hex()}
+	shares := newFakeShareRepo()
+	photoRepo := newFakePhotoRepo(photo)
+	service := NewShareService(shares, photoRepo, newFakeAlbumRepo(), nil, nil, 15).(*shareService)
+	return service, shares, photo, ctx
+}
+
+func TestOpenShareChecksLink(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		expired  bool
+		revoked  bool
+		token    string
+		opened   string
+		wantErr  error
+	}{
+		{name: "no password"},
+		{name: "right password", password: "secret", opened: "secret"},
+		{name: "wrong password", password: "secret", opened: "Secret", wantErr: ErrPasswordRequired},
+		{name: "missing password", password: "secret", wantErr: ErrPasswordRequired},
+		{name: "expired", expired: true, wantErr: ErrShareExpired},
+		{name: "revoked", revoked: true, wantErr: ErrShareExpired},
+		{name: "revoked with the right password", password: "secret", opened: "secret", revoked: true, wantErr: ErrShareExpired},
+		{name: "unknown token", token: "unknown", wantErr: ErrShareNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, shares, photo, ctx := newTestShareService()
+			expiresAt := time.Now().Add(time.Hour)
+			link, token, err := service.CreateShare(ctx, models.ShareTargetPhoto, photo.ID, &expiresAt, tt.password, false)
+			if err != nil {
+				t.Fatalf("CreateShare() error = %v", err)
+			}
+			if tt.expired {
+				past := time.Now().Add(-time.Second)
+				shares.links[link.ID].ExpiresAt = &past
+			}
+			if tt.revoked {
+				if err := service.RevokeShare(ctx, link.ID); err != nil {
+					t.Fatalf("RevokeShare() error = %v", err)
+				}
+			}
+			if tt.token != "" {
+				token = tt.token
+			}
+
+			// Share links are opened without an API token
+			content, err := service.OpenShare(context.Background(), token, tt.opened, 1, 20)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("OpenShare() error = %v, want %v", err, tt.wantErr)
+			}
+			wantViews := int64(0)
+			if err == nil {
+				wantViews = 1
+				if len(content.Photos) != 1 || content.Photos[0].ID != photo.ID {
+					t.Errorf("OpenShare() photos = %v, want the shared photo", content.Photos)
+				}
+			}
+			if views := shares.links[link.ID].ViewCount; views != wantViews {
+				t.Errorf("view count = %d, want %d", views, wantViews)
+			}
+		})
+	}
+}
+
+func TestCreateShareHashesPassword(t *testing.T) {
+	service, shares, photo, ctx := newTestShareService()
+
+	link, token, err := service.CreateShare(ctx, models.ShareTargetPhoto, photo.ID, nil, "secret", true)
+	if err != nil {
+		t.Fatalf("CreateShare() error = %v", err)
+	}
+	stored := shares.links[link.ID]
+	if !strings.HasPrefix(stored.PasswordHash, "$2") || strings.Contains(stored.PasswordHash, "secret") {
+		t.Errorf("stored password hash %q is not a bcrypt hash", stored.PasswordHash)
+	}
+	if stored.TokenHash == token || stored.TokenHash != hashToken(token) {
+		t.Errorf("stored token hash %q does not hash the token", stored.TokenHash)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name      string
+		id        primitive.ObjectID
+		expiresAt *time.Time
+		password  string
+		wantErr   error
+	}{
+		{name: "password too long", id: photo.ID, password: strings.Repeat("x", 73), wantErr: ErrInvalidArgument},
+		{name: "expiry in the past", id: photo.ID, expiresAt: &past, wantErr: ErrInvalidArgument},
+		{name: "unknown photo", id: primitive.NewObjectID(), wantErr: ErrPhotoNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := service.CreateShare(ctx, models.ShareTargetPhoto, tt.id, tt.expiresAt, tt.password, false); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreateShare() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestShareOwnership(t *testing.T) {
+	service, shares, photo, ctx := newTestShareService()
+	strangerCtx, _ := userContext()
+
+	if _, _, err := service.CreateShare(strangerCtx, models.ShareTargetPhoto, photo.ID, nil, "", false); !errors.Is(err, ErrPhotoNotFound) {
+		t.Errorf("CreateShare() of another user's photo error = %v, want ErrPhotoNotFound", err)
+	}
+	link, _, err := service.CreateShare(ctx, models.ShareTargetPhoto, photo.ID, nil, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.RevokeShare(strangerCtx, link.ID); !errors.Is(err, ErrShareNotFound) {
+		t.Errorf("RevokeShare() of another user's link error = %v, want ErrShareNotFound", err)
+	}
+	if shares.links[link.ID].RevokedAt != nil {
+		t.Error("another user revoked the link")
+	}
+}
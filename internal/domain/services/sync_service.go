@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// syncAppendAttempts bounds how often a change is retried when other writers extend the feed first
+	syncAppendAttempts = 10
+	// syncFeedLength is how many changes are kept per user; clients further behind list everything again
+	syncFeedLength = 10000
+	// syncTrimInterval is how many changes are recorded between trimming a feed
+	syncTrimInterval = 100
+	// syncTokenPrefix versions the sync token format
+	syncTokenPrefix = "v1:"
+)
+
+// SyncEntry is the current state of a photo or album that changed. Deleted
+// entries are tombstones: the photo or album is gone, in the trash or no
+// longer visible to the user.
+type SyncEntry struct {
+	Sequence int64
+	Kind     models.SyncKind
+	ID       primitive.ObjectID
+	Deleted  bool
+	Photo    *models.Photo
+	Album    *models.Album
+}
+
+// SyncBatch is a bounded batch of changes and the token to continue after them
+type SyncBatch struct {
+	Entries []SyncEntry
+	Next    string
+	HasMore bool
+}
+
+// SyncService keeps a change feed per user so that sync clients can mirror
+// their library without listing it again
+type SyncService interface {
+	// RecordChanges adds the photo or album an event is about to the feed of each recipient.
+	// Events about neither are not synced.
+	RecordChanges(ctx context.Context, event *models.Event) error
+
+	// ListChanges returns up to limit of the caller's changes after the token, oldest first,
+	// with the current state of each photo or album; only the last change of each is kept.
+	// Without a token it returns no changes and the token of the current position.
+	ListChanges(ctx context.Context, token string, limit int) (*SyncBatch, error)
+}
+
+type syncService struct {
+	changeRepo repositories.SyncChangeRepository
+	access     accessChecker
+}
+
+// NewSyncService creates a sync service
+func NewSyncService(changeRepo repositories.SyncChangeRepository, photoRepo repositories.PhotoRepository, albumRepo repositories.AlbumRepository) SyncService {
+	return &syncService{
+		changeRepo: changeRepo,
+		access:     accessChecker{photoRepo: photoRepo, albumRepo: albumRepo},
+	}
+}
+
+func (s *syncService) RecordChanges(ctx context.Context, event *models.Event) error {
+	if event.PhotoID.IsZero() && event.AlbumID.IsZero() {
+		return nil
+	}
+	change := models.SyncChange{Kind: models.SyncKindPhoto, EntityID: event.PhotoID, Timestamp: syncChangeTimestamp(event)}
+	if event.Type == models.EventTypeAlbumChanged {
+		change.Kind, change.EntityID = models.SyncKindAlbum, event.AlbumID
+	}
+
+	for _, userID := range event.Recipients {
+		change.ID = primitive.NilObjectID
+		change.UserID = userID
+		if err := s.append(ctx, &change); err != nil {
+			return fmt.Errorf("failed to record %s change for user %s: %w", change.Kind, userID, err)
+		}
+	}
+	return nil
+}
+
+// append gives a change the next sequence number of its user's feed. Numbers are
+// only taken after the previous one is stored, so readers never see them out of order.
+func (s *syncService) append(ctx context.Context, change *models.SyncChange) error {
+	for attempt := 0; attempt < syncAppendAttempts; attempt++ {
+		latest, err := s.changeRepo.GetLatest(ctx, change.UserID)
+		if err != nil {
+			return err
+		}
+		change.Sequence = 1
+		if latest != nil {
+			change.Sequence = latest.Sequence + 1
+		}
+
+		err = s.changeRepo.Create(ctx, change)
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if change.Sequence%syncTrimInterval == 0 && change.Sequence > syncFeedLength {
+			// Trimming is caught up with later, so a failure here is harmless
+			_ = s.changeRepo.DeleteUpTo(ctx, change.UserID, change.Sequence-syncFeedLength)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: the change feed kept moving while appending", ErrConflict)
+}
+
+func (s *syncService) ListChanges(ctx context.Context, token string, limit int) (*SyncBatch, error) {
+	userID := auth.UserID(ctx)
+	if userID == "" {
+		return nil, ErrUnauthorized
+	}
+
+	if token == "" {
+		latest, err := s.changeRepo.GetLatest(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		var position int64
+		if latest != nil {
+			position = latest.Sequence
+		}
+		return &SyncBatch{Entries: []SyncEntry{}, Next: encodeSyncToken(position)}, nil
+	}
+
+	since, err := decodeSyncToken(token)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := s.changeRepo.ListAfter(ctx, userID, since, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	// Feeds are numbered without gaps, so a missing next change was trimmed
+	if len(changes) > 0 && changes[0].Sequence != since+1 {
+		return nil, ErrSyncTokenExpired
+	}
+
+	batch := &SyncBatch{Entries: []SyncEntry{}, Next: token}
+	if len(changes) > limit {
+		changes, batch.HasMore = changes[:limit], true
+	}
+	if len(changes) == 0 {
+		return batch, nil
+	}
+	batch.Next = encodeSyncToken(changes[len(changes)-1].Sequence)
+
+	type entityKey struct {
+		kind models.SyncKind
+		id   primitive.ObjectID
+	}
+	last := make(map[entityKey]int, len(changes))
+	for i, change := range changes {
+		last[entityKey{change.Kind, change.EntityID}] = i
+	}
+	for i, change := range changes {
+		if last[entityKey{change.Kind, change.EntityID}] != i {
+			continue
+		}
+		entry, err := s.resolve(ctx, change)
+		if err != nil {
+			return nil, err
+		}
+		batch.Entries = append(batch.Entries, entry)
+	}
+	return batch, nil
+}
+
+// resolve loads the current state of a changed photo or album, as the caller sees it
+func (s *syncService) resolve(ctx context.Context, change models.SyncChange) (SyncEntry, error) {
+	entry := SyncEntry{Sequence: change.Sequence, Kind: change.Kind, ID: change.EntityID}
+
+	var err error
+	switch change.Kind {
+	case models.SyncKindAlbum:
+		entry.Album, err = s.access.album(ctx, change.EntityID, permView)
+		if errors.Is(err, ErrAlbumNotFound) {
+			entry.Deleted, err = true, nil
+		}
+	default:
+		entry.Photo, err = s.access.activePhoto(ctx, change.EntityID, permView)
+		if errors.Is(err, ErrPhotoNotFound) {
+			entry.Deleted, err = true, nil
+		}
+	}
+	return entry, err
+}
+
+func encodeSyncToken(seq int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(syncTokenPrefix + strconv.FormatInt(seq, 10)))
+}
+
+func decodeSyncToken(token string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil {
+		if value, ok := strings.CutPrefix(string(raw), syncTokenPrefix); ok {
+			if seq, err := strconv.ParseInt(value, 10, 64); err == nil && seq >= 0 {
+				return seq, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("%w: malformed sync token", ErrInvalidArgument)
+}
+
+// syncChangeTimestamp is the time recorded with changes made without an event time
+func syncChangeTimestamp(event *models.Event) time.Time {
+	if event.Timestamp.IsZero() {
+		return time.Now()
+	}
+	return event.Timestamp
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecodeSyncToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		want    int64
+		wantErr bool
+	}{
+		{name: "position", token: "djE6NDI", want: 42},
+		{name: "start of the feed", token: "djE6MA", want: 0},
+		{name: "round trip", token: encodeSyncToken(1234567), want: 1234567},
+		{name: "empty", token: "", wantErr: true},
+		{name: "padded base64", token: "djE6NDI=", wantErr: true},
+		{name: "not base64", token: "v1:42", wantErr: true},
+		{name: "without prefix", token: "NDI", wantErr: true},
+		{name: "other version", token: "djI6NDI", wantErr: true},
+		{name: "negative", token: "djE6LTE", wantErr: true},
+		{name: "not a number", token: "djE6YWJj", wantErr: true},
+		{name: "no number", token: "djE6", wantErr: true},
+		{name: "out of range", token: "djE6OTk5OTk5OTk5OTk5OTk5OTk5OTk", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeSyncToken(tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidArgument) {
+					t.Errorf("decodeSyncToken(%q) error = %v, want ErrInvalidArgument", tt.token, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeSyncToken(%q) error = %v", tt.token, err)
+			}
+			if got != tt.want {
+				t.Errorf("decodeSyncToken(%q) = %d, want %d", tt.token, got, tt.want)
+			}
+		})
+	}
+}
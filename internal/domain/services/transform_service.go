@@ -0,0 +1,350 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"photocloud/config"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+	"photocloud/internal/imaging"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// variantKeyPrefix is the storage prefix holding derived images of photos
+	variantKeyPrefix = "variants/"
+
+	// exportQuality is the JPEG quality of exported edited copies
+	exportQuality = 92
+)
+
+// TransformService produces resized and converted variants of photos on demand
+type TransformService interface {
+	// SignTransform returns the signature authorizing a transform of the photo with the options
+	// until the returned expiry. Only callers who can see the photo may sign transforms of it.
+	SignTransform(ctx context.Context, id primitive.ObjectID, opts imaging.Options) (string, time.Time, error)
+
+	// TransformPhoto returns the photo transformed with the options, producing and caching the variant if needed.
+	// The signature must match the options and expiry, and the expiry must not have passed.
+	// Without a requested format, the best format allowed by the Accept header is used.
+	TransformPhoto(ctx context.Context, id primitive.ObjectID, opts imaging.Options, signature string, expires time.Time, accept string) (*FileContent, error)
+
+	// RenderPhoto returns the full-size photo as it should be displayed: with its edits applied
+	// and in the best format allowed by the Accept header. It returns nil if the original can be
+	// served as is.
+	RenderPhoto(ctx context.Context, id primitive.ObjectID, accept string) (*FileContent, error)
+
+	// PrepareVariant produces the variant of the photo with the options if it is not cached yet
+	// and returns its storage key. Without a requested format, the original format is kept.
+	PrepareVariant(ctx context.Context, id primitive.ObjectID, opts imaging.Options) (string, error)
+
+	// ExportPhoto saves the photo with its edits applied as a new photo of the caller, leaving the
+	// source untouched. It needs edit permission on the source.
+	ExportPhoto(ctx context.Context, id primitive.ObjectID, name string) (*models.Photo, error)
+}
+
+type transformService struct {
+	storageRepo  repositories.StorageRepository
+	photoService PhotoService
+	access       accessChecker
+	usage        usageTracker
+	cfg          config.TransformConfig
+	slots        chan struct{}
+}
+
+// NewTransformService creates a transform service. At most cfg.Concurrency
+// transforms run at once; the rest wait up to cfg.QueueTimeout for a slot.
+func NewTransformService(photoRepo repositories.PhotoRepository, albumRepo repositories.AlbumRepository, usageRepo repositories.UsageRepository, storageRepo repositories.StorageRepository, photoService PhotoService, cfg config.TransformConfig) TransformService {
+	return &transformService{
+		storageRepo:  storageRepo,
+		photoService: photoService,
+		access:       accessChecker{photoRepo: photoRepo, albumRepo: albumRepo},
+		usage:        usageTracker{usageRepo: usageRepo},
+		cfg:          cfg,
+		slots:        make(chan struct{}, cfg.Concurrency),
+	}
+}
+
+func (s *transformService) SignTransform(ctx context.Context, id primitive.ObjectID, opts imaging.Options) (string, time.Time, error) {
+	if len(s.cfg.SigningKey) == 0 {
+		return "", time.Time{}, ErrTransformsDisabled
+	}
+	if err := opts.Validate(s.cfg.MaxDimension); err != nil {
+		return "", time.Time{}, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+	if _, err := s.access.activePhoto(ctx, id, permView); err != nil {
+		return "", time.Time{}, err
+	}
+	expires := time.Now().Add(s.cfg.URLLifetime).Truncate(time.Second)
+	return s.sign(id, opts, expires), expires, nil
+}
+
+func (s *transformService) TransformPhoto(ctx context.Context, id primitive.ObjectID, opts imaging.Options, signature string, expires time.Time, accept string) (*FileContent, error) {
+	if len(s.cfg.SigningKey) == 0 {
+		return nil, ErrTransformsDisabled
+	}
+	expected := s.sign(id, opts, expires)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, ErrInvalidSignature
+	}
+	if time.Now().After(expires) {
+		return nil, fmt.Errorf("%w: the URL has expired", ErrInvalidSignature)
+	}
+	if err := opts.Validate(s.cfg.MaxDimension); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+
+	photo, err := s.access.activePhoto(ctx, id, permView)
+	if err != nil {
+		return nil, err
+	}
+
+	fallback, ok := imaging.Negotiate(accept)
+	if !ok {
+		fallback = originalFormat(photo)
+	}
+	opts = opts.WithDefaults(fallback)
+
+	return s.variant(ctx, photo, opts)
+}
+
+func (s *transformService) RenderPhoto(ctx context.Context, id primitive.ObjectID, accept string) (*FileContent, error) {
+	photo, err := s.access.activePhoto(ctx, id, permView)
+	if err != nil {
+		return nil, err
+	}
+
+	format, negotiated := imaging.Negotiate(accept)
+	converts := negotiated && imaging.ContentType(format) != photo.ContentType
+	// GIFs may be animated, which a still conversion would lose
+	if photo.ContentType == "image/gif" && len(photo.Edits) == 0 {
+		converts = false
+	}
+	if !converts && len(photo.Edits) == 0 {
+		return nil, nil
+	}
+
+	if !converts {
+		format = originalFormat(photo)
+	}
+	return s.variant(ctx, photo, imaging.Options{Format: format}.WithDefaults(format))
+}
+
+func (s *transformService) PrepareVariant(ctx context.Context, id primitive.ObjectID, opts imaging.Options) (string, error) {
+	if err := opts.Validate(s.cfg.MaxDimension); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+
+	photo, err := s.access.activePhoto(ctx, id, permView)
+	if err != nil {
+		return "", err
+	}
+
+	format := originalFormat(photo)
+	if !imaging.IsEncodable(format) {
+		format = imaging.FormatJPEG
+	}
+	opts = opts.WithDefaults(format)
+
+	content, err := s.variant(ctx, photo, opts)
+	if err != nil {
+		return "", err
+	}
+	content.Close()
+
+	return variantKey(photo, opts), nil
+}
+
+func (s *transformService) ExportPhoto(ctx context.Context, id primitive.ObjectID, name string) (*models.Photo, error) {
+	// The copy belongs to the caller and counts toward their quota, so album viewers and
+	// contributors cannot take over other members' photos this way
+	photo, err := s.access.activePhoto(ctx, id, permEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	format := originalFormat(photo)
+	if name == "" {
+		name = strings.TrimSuffix(photo.Name, filepath.Ext(photo.Name)) + " (edited)"
+	}
+	name = strings.TrimSuffix(name, filepath.Ext(name)) + imaging.Extension(format)
+
+	if err := s.acquire(ctx); err != nil {
+		return nil, err
+	}
+	encoded, err := s.render(ctx, photo, imaging.Options{Format: format, Quality: exportQuality}.WithDefaults(format))
+	s.release()
+	if err != nil {
+		return nil, err
+	}
+
+	size := int64(encoded.Len())
+	return s.photoService.UploadPhoto(ctx, name, photo.Description, encoded, imaging.ContentType(format), size)
+}
+
+// originalFormat returns the format of a photo's original, or JPEG if it cannot be encoded
+func originalFormat(photo *models.Photo) imaging.Format {
+	if format, ok := imaging.FormatFromContentType(photo.ContentType); ok {
+		return format
+	}
+	return imaging.FormatJPEG
+}
+
+// variant serves a cached variant, producing it first if it does not exist yet
+func (s *transformService) variant(ctx context.Context, photo *models.Photo, opts imaging.Options) (*FileContent, error) {
+	key := variantKey(photo, opts)
+	name := variantName(photo.Name, opts.Format)
+
+	if content, err := s.cachedVariant(ctx, key, name); content != nil || err != nil {
+		return content, err
+	}
+
+	if err := s.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer s.release()
+
+	// Another request may have produced the variant while this one was queued
+	if content, err := s.cachedVariant(ctx, key, name); content != nil || err != nil {
+		return content, err
+	}
+
+	if err := s.produce(ctx, photo, opts, key); err != nil {
+		return nil, err
+	}
+
+	return s.cachedVariant(ctx, key, name)
+}
+
+// cachedVariant returns the stored variant, or nil without an error if it does not exist
+func (s *transformService) cachedVariant(ctx context.Context, key, name string) (*FileContent, error) {
+	info, err := s.storageRepo.StatFile(ctx, key)
+	if errors.Is(err, repositories.ErrFileNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check variant cache: %w", err)
+	}
+	return newFileContent(ctx, s.storageRepo, name, *info), nil
+}
+
+// produce transforms the original and stores the result under the variant key
+func (s *transformService) produce(ctx context.Context, photo *models.Photo, opts imaging.Options, key string) error {
+	encoded, err := s.render(ctx, photo, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := s.storageRepo.UploadFile(ctx, key, bytes.NewReader(encoded.Bytes()), imaging.ContentType(opts.Format)); err != nil {
+		return fmt.Errorf("failed to store variant: %w", err)
+	}
+	s.usage.record(ctx, photo.OwnerID, models.StorageUsage{
+		Renditions: models.UsageCounter{Bytes: int64(encoded.Len()), Files: 1},
+	})
+	return nil
+}
+
+// render decodes the original, turns it upright, applies the edit recipe and
+// the transform options, and encodes the result
+func (s *transformService) render(ctx context.Context, photo *models.Photo, opts imaging.Options) (*bytes.Buffer, error) {
+	body, _, err := s.storageRepo.DownloadFile(ctx, photo.S3Key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download original: %w", err)
+	}
+	original, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to download original: %w", err)
+	}
+
+	img, _, err := imaging.Decode(bytes.NewReader(original), s.cfg.MaxPixels)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+
+	// Renditions are stored upright; photos uploaded before orientation was recorded fall back to EXIF
+	orientation := photo.Orientation
+	if orientation == 0 {
+		orientation, _ = imaging.ReadOrientation(original)
+	}
+	img = imaging.ApplyOrientation(img, orientation)
+	img = imaging.ApplyEdits(img, toImagingEdits(photo.Edits))
+
+	var encoded bytes.Buffer
+	if err := imaging.Encode(ctx, &encoded, imaging.Resize(img, opts), opts.Format, opts.Quality); err != nil {
+		return nil, fmt.Errorf("failed to encode variant: %w", err)
+	}
+	return &encoded, nil
+}
+
+// acquire waits for a free transform slot
+func (s *transformService) acquire(ctx context.Context) error {
+	timer := time.NewTimer(s.cfg.QueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return ErrBusy
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *transformService) release() {
+	<-s.slots
+}
+
+// sign computes the hex HMAC-SHA256 of the photo ID, canonical options and expiry
+func (s *transformService) sign(id primitive.ObjectID, opts imaging.Options, expires time.Time) string {
+	mac := hmac.New(sha256.New, s.cfg.SigningKey)
+	mac.Write([]byte(fmt.Sprintf("%s?%s&exp=%d", id.Hex(), opts.Canonical(), expires.Unix())))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// variantKey returns the storage key of a photo variant, derived from a hash
+// of its resolved options and the photo's edit recipe
+func variantKey(photo *models.Photo, opts imaging.Options) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%+v", opts.Canonical(), photo.Edits)))
+	return fmt.Sprintf("%s%s/%s%s", variantKeyPrefix, photo.ID.Hex(), hex.EncodeToString(sum[:16]), imaging.Extension(opts.Format))
+}
+
+// variantName returns the download name of a variant, based on the photo name
+func variantName(photoName string, format imaging.Format) string {
+	return strings.TrimSuffix(photoName, filepath.Ext(photoName)) + imaging.Extension(format)
+}
+
+// deleteVariants removes every cached variant of a photo and takes them off its owner's usage
+func deleteVariants(ctx context.Context, storageRepo repositories.StorageRepository, usage usageTracker, photo *models.Photo) error {
+	files, err := storageRepo.ListFiles(ctx, variantKeyPrefix+photo.ID.Hex()+"/")
+	if err != nil {
+		return fmt.Errorf("failed to list variants: %w", err)
+	}
+
+	var freed models.UsageCounter
+	defer func() {
+		if freed.Files > 0 {
+			usage.record(ctx, photo.OwnerID, models.StorageUsage{Renditions: freed}.Negate())
+		}
+	}()
+	for _, file := range files {
+		if err := storageRepo.DeleteFile(ctx, file.Key); err != nil {
+			return fmt.Errorf("failed to delete variant %s: %w", file.Key, err)
+		}
+		freed.Bytes += file.Size
+		freed.Files++
+	}
+	return nil
+}
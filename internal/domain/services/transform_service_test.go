@@ -0,0 +1,183 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"testing"
+	"time"
+
+	"photocloud/config"
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/imaging"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// newTestTransformService returns a transform service signing with key over a stored 4x4 PNG photo
+func newTestTransformService(t *testing.T, key string) (*transformService, *models.Photo) {
+	t.Helper()
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, image.NewNRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	photo := &models.Photo{ID: primitive.NewObjectID(), Name: "a.png", S3Key: "photos/a.png", ContentType: "image/png"}
+	storage := newFakeStorageRepo()
+	if err := storage.UploadFile(context.Background(), photo.S3Key, &encoded, photo.ContentType); err != nil {
+		t.Fatal(err)
+	}
+
+	photoRepo := newFakePhotoRepo(photo)
+	return &transformService{
+		storageRepo: storage,
+		access:      accessChecker{photoRepo: photoRepo},
+		cfg: config.TransformConfig{
+			SigningKey:   []byte(key),
+			URLLifetime:  time.Minute,
+			Concurrency:  1,
+			QueueTimeout: time.Second,
+			MaxDimension: 100,
+			MaxPixels:    1 << 20,
+		},
+		slots: make(chan struct{}, 1),
+	}, photo
+}
+
+func TestTransformPhotoSignature(t *testing.T) {
+	opts := imaging.Options{Width: 2, Format: imaging.FormatPNG}
+	service, photo := newTestTransformService(t, "key")
+	signature, expires, err := service.SignTransform(context.Background(), photo.ID, opts)
+	if err != nil {
+		t.Fatalf("SignTransform() error = %v", err)
+	}
+	if lifetime := time.Until(expires); lifetime <= 0 || lifetime > time.Minute {
+		t.Fatalf("SignTransform() expires in %s, want within the URL lifetime", lifetime)
+	}
+	other, _ := newTestTransformService(t, "other key")
+	disabled, _ := newTestTransformService(t, "")
+
+	tests := []struct {
+		name      string
+		service   *transformService
+		id        primitive.ObjectID
+		opts      imaging.Options
+		signature string
+		expires   time.Time
+		wantErr   error
+	}{
+		{name: "valid", service: service, id: photo.ID, opts: opts, signature: signature, expires: expires},
+		{name: "other width", service: service, id: photo.ID, opts: imaging.Options{Width: 3, Format: imaging.FormatPNG}, signature: signature, expires: expires, wantErr: ErrInvalidSignature},
+		{name: "other format", service: service, id: photo.ID, opts: imaging.Options{Width: 2, Format: imaging.FormatJPEG}, signature: signature, expires: expires, wantErr: ErrInvalidSignature},
+		{name: "other photo", service: service, id: primitive.NewObjectID(), opts: opts, signature: signature, expires: expires, wantErr: ErrInvalidSignature},
+		{name: "extended expiry", service: service, id: photo.ID, opts: opts, signature: signature, expires: expires.Add(time.Hour), wantErr: ErrInvalidSignature},
+		{name: "no signature", service: service, id: photo.ID, opts: opts, expires: expires, wantErr: ErrInvalidSignature},
+		{name: "other key", service: other, id: photo.ID, opts: opts, signature: signature, expires: expires, wantErr: ErrInvalidSignature},
+		{name: "transforms disabled", service: disabled, id: photo.ID, opts: opts, signature: signature, expires: expires, wantErr: ErrTransformsDisabled},
+		{
+			name:      "expired",
+			service:   service,
+			id:        photo.ID,
+			opts:      opts,
+			signature: service.sign(photo.ID, opts, time.Now().Add(-time.Second).Truncate(time.Second)),
+			expires:   time.Now().Add(-time.Second).Truncate(time.Second),
+			wantErr:   ErrInvalidSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Signed URLs are opened without an API token
+			content, err := tt.service.TransformPhoto(context.Background(), tt.id, tt.opts, tt.signature, tt.expires, "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("TransformPhoto() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer content.Close()
+			img, err := png.Decode(content)
+			if err != nil {
+				t.Fatalf("variant is not a PNG: %v", err)
+			}
+			if width := img.Bounds().Dx(); width != 2 {
+				t.Errorf("variant is %d pixels wide, want 2", width)
+			}
+		})
+	}
+}
+
+func TestSignTransformRequiresAccess(t *testing.T) {
+	service, photo := newTestTransformService(t, "key")
+	photo.OwnerID = primitive.NewObjectID().Hex()
+	ctx, _ := userContext()
+
+	if _, _, err := service.SignTransform(ctx, photo.ID, imaging.Options{Width: 2}); !errors.Is(err, ErrPhotoNotFound) {
+		t.Errorf("SignTransform() of another user's photo error = %v, want ErrPhotoNotFound", err)
+	}
+	if _, _, err := service.SignTransform(context.Background(), photo.ID, imaging.Options{Width: 1000}); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("SignTransform() beyond the largest dimension error = %v, want ErrInvalidArgument", err)
+	}
+}
+
+// uploadRecorder is a photo service that records uploads instead of storing them
+type uploadRecorder struct {
+	PhotoService
+	uploads []string
+}
+
+func (r *uploadRecorder) UploadPhoto(ctx context.Context, name, description string, content io.Reader, contentType string, size int64) (*models.Photo, error) {
+	r.uploads = append(r.uploads, name)
+	return &models.Photo{ID: primitive.NewObjectID(), Name: name, OwnerID: auth.UserID(ctx)}, nil
+}
+
+func TestExportPhotoRequiresEdit(t *testing.T) {
+	ownerCtx, ownerUser := userContext()
+	owner := ownerUser.ID.Hex()
+	ctx, member := userContext()
+	strangerCtx, _ := userContext()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		role    models.AlbumRole
+		wantErr error
+	}{
+		{name: "owner", ctx: ownerCtx},
+		{name: "editor", ctx: ctx, role: models.AlbumRoleEditor},
+		{name: "contributor", ctx: ctx, role: models.AlbumRoleContributor, wantErr: ErrForbidden},
+		{name: "viewer", ctx: ctx, role: models.AlbumRoleViewer, wantErr: ErrForbidden},
+		{name: "stranger", ctx: strangerCtx, role: models.AlbumRoleEditor, wantErr: ErrPhotoNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, photo := newTestTransformService(t, "key")
+			album := &models.Album{ID: primitive.NewObjectID(), OwnerID: owner}
+			if tt.role != "" {
+				album.Members = []models.AlbumMember{{UserID: member.ID.Hex(), Role: tt.role}}
+			}
+			photo.OwnerID, photo.AlbumIDs = owner, []primitive.ObjectID{album.ID}
+			service.access.albumRepo = newFakeAlbumRepo(album)
+			uploads := &uploadRecorder{}
+			service.photoService = uploads
+
+			exported, err := service.ExportPhoto(tt.ctx, photo.ID, "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ExportPhoto() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(uploads.uploads) != 0 {
+					t.Errorf("ExportPhoto() uploaded %v", uploads.uploads)
+				}
+				return
+			}
+			if exported.Name != "a (edited).png" || exported.OwnerID != auth.UserID(tt.ctx) {
+				t.Errorf("ExportPhoto() = %q of %s, want a (edited).png of the caller", exported.Name, exported.OwnerID)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"path"
+	"strings"
+)
+
+// photoContentTypes maps the extensions of the files photos can be uploaded as to their content type
+var photoContentTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+}
+
+// PhotoContentType returns the content type of a photo file by its extension. It reports
+// false for files that cannot be uploaded as photos.
+func PhotoContentType(fileName string) (string, bool) {
+	contentType, ok := photoContentTypes[strings.ToLower(path.Ext(fileName))]
+	return contentType, ok
+}
+
+// IsPhotoContentType reports whether photos can be uploaded with the content type
+func IsPhotoContentType(contentType string) bool {
+	for _, known := range photoContentTypes {
+		if contentType == known {
+			return true
+		}
+	}
+	return false
+}
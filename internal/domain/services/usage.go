@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"photocloud/config"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// usageTracker keeps the storage usage of owners up to date as files are stored
+// and deleted, and enforces their quotas. Photos without an owner are not tracked.
+type usageTracker struct {
+	usageRepo repositories.UsageRepository
+	photoRepo repositories.PhotoRepository
+	userRepo  repositories.UserRepository
+	quotas    config.QuotaConfig
+}
+
+// record adds delta to the usage of an owner. A failure is harmless: the usage
+// verifier recomputes the counters from the photo records.
+func (t usageTracker) record(ctx context.Context, ownerID string, delta models.StorageUsage) {
+	if ownerID == "" {
+		return
+	}
+	_ = t.usageRepo.Add(context.WithoutCancel(ctx), ownerID, delta)
+}
+
+// reserve adds delta to the usage of an owner before its files are stored, provided
+// the quota leaves room for it, and returns ErrUploadTooLarge or ErrQuotaExceeded
+// otherwise. The check and the addition are one step, so concurrent uploads cannot
+// overshoot the quota together. The returned release takes the reservation back and
+// must be called if storing fails.
+func (t usageTracker) reserve(ctx context.Context, ownerID string, delta models.StorageUsage) (func(), error) {
+	if ownerID == "" {
+		return func() {}, nil
+	}
+	_, quota, err := t.quota(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	bytes := delta.QuotaBytes()
+	if quota.MaxBytes > 0 && bytes > quota.MaxBytes {
+		return nil, fmt.Errorf("%w: %d bytes do not fit in a quota of %d bytes", ErrUploadTooLarge, bytes, quota.MaxBytes)
+	}
+
+	// Makes sure the counters exist and are verified before they are relied on
+	if _, err := t.usage(ctx, ownerID); err != nil {
+		return nil, err
+	}
+	reserved, err := t.usageRepo.Reserve(ctx, ownerID, delta, quota)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve storage usage: %w", err)
+	}
+	if !reserved {
+		usage, err := t.usage(ctx, ownerID)
+		if err != nil {
+			return nil, err
+		}
+		if quota.MaxPhotos > 0 && delta.Photos > 0 && usage.Photos+delta.Photos > quota.MaxPhotos {
+			return nil, fmt.Errorf("%w: %d of %d photos are stored", ErrQuotaExceeded, usage.Photos, quota.MaxPhotos)
+		}
+		return nil, fmt.Errorf("%w: %d of %d bytes are used, %d more do not fit",
+			ErrQuotaExceeded, usage.QuotaBytes(), quota.MaxBytes, bytes)
+	}
+
+	return func() { t.record(ctx, ownerID, delta.Negate()) }, nil
+}
+
+// quota returns the plan and the quota of a user. Users whose account no
+// longer exists have no limits.
+func (t usageTracker) quota(ctx context.Context, ownerID string) (string, models.Quota, error) {
+	id, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return "", models.Quota{}, nil
+	}
+	user, err := t.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return "", models.Quota{}, fmt.Errorf("failed to load quota: %w", err)
+	}
+	if user == nil {
+		return "", models.Quota{}, nil
+	}
+
+	plan := user.Plan
+	if plan == "" {
+		plan = t.quotas.DefaultPlan
+	}
+	if user.Quota != nil {
+		return plan, *user.Quota, nil
+	}
+	limits := t.quotas.Plans[plan]
+	return plan, models.Quota{MaxBytes: limits.MaxBytes, MaxPhotos: limits.MaxPhotos}, nil
+}
+
+// usage returns the usage of an owner. Counters that were never verified, such
+// as those of photos stored before usage was tracked, are recomputed first.
+func (t usageTracker) usage(ctx context.Context, ownerID string) (*models.StorageUsage, error) {
+	stored, err := t.usageRepo.Get(ctx, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load storage usage: %w", err)
+	}
+	if stored != nil && stored.VerifiedAt != nil {
+		return stored, nil
+	}
+
+	computed, err := t.photoRepo.AggregateUsage(ctx, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute storage usage: %w", err)
+	}
+	usage := &models.StorageUsage{OwnerID: ownerID}
+	if len(computed) > 0 {
+		*usage = computed[0]
+	}
+	// Renditions are only counted in storage by the usage verifier
+	if stored != nil {
+		usage.Renditions = stored.Renditions
+	}
+	now := time.Now()
+	usage.UpdatedAt = now
+	usage.VerifiedAt = &now
+
+	if err := t.usageRepo.Set(ctx, usage); err != nil {
+		return nil, fmt.Errorf("failed to store storage usage: %w", err)
+	}
+	return usage, nil
+}
+
+// photoUsage returns what a photo adds to its owner's usage
+func photoUsage(photo *models.Photo) models.StorageUsage {
+	usage := models.StorageUsage{
+		Photos:    1,
+		Originals: models.UsageCounter{Bytes: photo.Size, Files: 1},
+	}
+	for _, version := range photo.Versions {
+		if version.S3Key != photo.S3Key {
+			usage.Versions.Bytes += version.Size
+			usage.Versions.Files++
+		}
+	}
+	return usage
+}
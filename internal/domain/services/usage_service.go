@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"photocloud/config"
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ownerLookupBatchSize bounds how many photo IDs are resolved to owners per query
+const ownerLookupBatchSize = 1000
+
+// UsageReport is a user's storage usage together with their plan and quota
+type UsageReport struct {
+	Usage models.StorageUsage
+	Plan  string
+	Quota models.Quota
+}
+
+// UsageService reports storage usage and keeps it accurate
+type UsageService interface {
+	// GetUsage returns the caller's storage usage and quota
+	GetUsage(ctx context.Context) (*UsageReport, error)
+
+	// VerifyUsage recomputes the usage of every owner from the photo records and the
+	// stored renditions, and returns how many owners' counters had drifted
+	VerifyUsage(ctx context.Context) (int, error)
+}
+
+type usageService struct {
+	usageRepo   repositories.UsageRepository
+	photoRepo   repositories.PhotoRepository
+	storageRepo repositories.StorageRepository
+	usage       usageTracker
+}
+
+// NewUsageService creates a usage service
+func NewUsageService(usageRepo repositories.UsageRepository, photoRepo repositories.PhotoRepository, userRepo repositories.UserRepository, storageRepo repositories.StorageRepository, quotas config.QuotaConfig) UsageService {
+	return &usageService{
+		usageRepo:   usageRepo,
+		photoRepo:   photoRepo,
+		storageRepo: storageRepo,
+		usage:       usageTracker{usageRepo: usageRepo, photoRepo: photoRepo, userRepo: userRepo, quotas: quotas},
+	}
+}
+
+func (s *usageService) GetUsage(ctx context.Context) (*UsageReport, error) {
+	ownerID := auth.UserID(ctx)
+	if ownerID == "" {
+		return nil, ErrUnauthorized
+	}
+
+	usage, err := s.usage.usage(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	plan, quota, err := s.usage.quota(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	return &UsageReport{Usage: *usage, Plan: plan, Quota: quota}, nil
+}
+
+// VerifyUsage replaces the counters of every owner with recomputed ones.
+// Changes recorded while it runs may be overwritten; the next run corrects them.
+func (s *usageService) VerifyUsage(ctx context.Context) (int, error) {
+	computed, err := s.photoRepo.AggregateUsage(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to aggregate photo usage: %w", err)
+	}
+	renditions, err := s.renditionUsage(ctx)
+	if err != nil {
+		return 0, err
+	}
+	stored, err := s.usageRepo.List(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list storage usage: %w", err)
+	}
+
+	// Owners with stored counters but nothing left are reset to zero
+	usages := make(map[string]*models.StorageUsage)
+	for _, usage := range stored {
+		usages[usage.OwnerID] = &models.StorageUsage{OwnerID: usage.OwnerID}
+	}
+	for i := range computed {
+		usages[computed[i].OwnerID] = &computed[i]
+	}
+	for ownerID, counter := range renditions {
+		if usages[ownerID] == nil {
+			usages[ownerID] = &models.StorageUsage{OwnerID: ownerID}
+		}
+		usages[ownerID].Renditions = counter
+	}
+
+	previous := make(map[string]models.StorageUsage, len(stored))
+	for _, usage := range stored {
+		previous[usage.OwnerID] = usage
+	}
+
+	drifted := 0
+	now := time.Now()
+	var errs []error
+	for ownerID, usage := range usages {
+		if ownerID == "" {
+			continue
+		}
+		if old, ok := previous[ownerID]; !ok || !sameCounters(old, *usage) {
+			drifted++
+		}
+
+		usage.UpdatedAt = now
+		usage.VerifiedAt = &now
+		if err := s.usageRepo.Set(ctx, usage); err != nil {
+			errs = append(errs, fmt.Errorf("owner %s: %w", ownerID, err))
+		}
+	}
+	return drifted, errors.Join(errs...)
+}
+
+// renditionUsage sums the stored variants of every photo by the photo's owner
+func (s *usageService) renditionUsage(ctx context.Context) (map[string]models.UsageCounter, error) {
+	files, err := s.storageRepo.ListFiles(ctx, variantKeyPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list variants: %w", err)
+	}
+
+	byPhoto := make(map[primitive.ObjectID]models.UsageCounter)
+	for _, file := range files {
+		// Variant keys are variants/<photo ID>/<hash><ext>
+		hexID, _, _ := strings.Cut(strings.TrimPrefix(file.Key, variantKeyPrefix), "/")
+		id, err := primitive.ObjectIDFromHex(hexID)
+		if err != nil {
+			continue
+		}
+		counter := byPhoto[id]
+		counter.Bytes += file.Size
+		counter.Files++
+		byPhoto[id] = counter
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(byPhoto))
+	for id := range byPhoto {
+		ids = append(ids, id)
+	}
+
+	byOwner := make(map[string]models.UsageCounter)
+	for start := 0; start < len(ids); start += ownerLookupBatchSize {
+		batch := ids[start:min(start+ownerLookupBatchSize, len(ids))]
+		owners, err := s.photoRepo.GetOwners(ctx, batch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up photo owners: %w", err)
+		}
+		// Variants of photos that no longer exist are left to reconciliation
+		for id, ownerID := range owners {
+			counter := byOwner[ownerID]
+			counter.Bytes += byPhoto[id].Bytes
+			counter.Files += byPhoto[id].Files
+			byOwner[ownerID] = counter
+		}
+	}
+	return byOwner, nil
+}
+
+// sameCounters reports whether two usages have the same counters
+func sameCounters(a, b models.StorageUsage) bool {
+	return a.Photos == b.Photos && a.Originals == b.Originals &&
+		a.Versions == b.Versions && a.Renditions == b.Renditions
+}
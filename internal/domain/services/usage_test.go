@@ -0,0 +1,166 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"photocloud/config"
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// newTestUsageTracker returns a usage tracker for a user on the given quota who
+// already stores the given photos
+func newTestUsageTracker(quota models.Quota, stored ...*models.Photo) (usageTracker, *fakeUsageRepo, *fakePhotoRepo, *models.User) {
+	user := &models.User{ID: primitive.NewObjectID(), Username: "user", Quota: &quota}
+	for _, photo := range stored {
+		photo.OwnerID = user.ID.Hex()
+	}
+	usage := newFakeUsageRepo()
+	photoRepo := newFakePhotoRepo(stored...)
+	return usageTracker{
+		usageRepo: usage,
+		photoRepo: photoRepo,
+		userRepo:  newFakeUserRepo(user),
+		quotas:    config.QuotaConfig{DefaultPlan: "free"},
+	}, usage, photoRepo, user
+}
+
+func TestUsageReserve(t *testing.T) {
+	upload := models.StorageUsage{Photos: 1, Originals: models.UsageCounter{Bytes: 40, Files: 1}}
+
+	tests := []struct {
+		name    string
+		quota   models.Quota
+		stored  int64
+		wantErr error
+	}{
+		{name: "no limits", stored: 1000},
+		{name: "fits", quota: models.Quota{MaxBytes: 100, MaxPhotos: 2}, stored: 60},
+		{name: "larger than the quota", quota: models.Quota{MaxBytes: 30}, wantErr: ErrUploadTooLarge},
+		{name: "bytes used up", quota: models.Quota{MaxBytes: 100}, stored: 61, wantErr: ErrQuotaExceeded},
+		{name: "photos used up", quota: models.Quota{MaxPhotos: 1}, stored: 1, wantErr: ErrQuotaExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Usage that was never recorded is computed from the stored photos first
+			tracker, usage, _, user := newTestUsageTracker(tt.quota, &models.Photo{ID: primitive.NewObjectID(), Size: tt.stored})
+			ownerID := user.ID.Hex()
+
+			release, err := tracker.reserve(context.Background(), ownerID, upload)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("reserve() error = %v, want %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrUploadTooLarge) {
+				// Refused from the quota alone, without loading the usage
+				return
+			}
+			recorded := usage.usage[ownerID]
+			if recorded == nil || recorded.VerifiedAt == nil {
+				t.Fatalf("usage %v was not verified before reserving", recorded)
+			}
+			wantBytes, wantPhotos := tt.stored, int64(1)
+			if err == nil {
+				wantBytes, wantPhotos = wantBytes+40, wantPhotos+1
+			}
+			if recorded.QuotaBytes() != wantBytes || recorded.Photos != wantPhotos {
+				t.Errorf("usage after reserve() = %d bytes in %d photos, want %d in %d", recorded.QuotaBytes(), recorded.Photos, wantBytes, wantPhotos)
+			}
+			if err != nil {
+				return
+			}
+
+			release()
+			if recorded.QuotaBytes() != tt.stored || recorded.Photos != 1 || recorded.Originals.Files != 1 {
+				t.Errorf("usage after release = %+v, want the stored photo only", recorded)
+			}
+		})
+	}
+}
+
+func TestUsageReserveWithoutOwner(t *testing.T) {
+	tracker, usage, _, _ := newTestUsageTracker(models.Quota{MaxBytes: 1})
+	ctx := context.Background()
+	delta := models.StorageUsage{Photos: 1, Originals: models.UsageCounter{Bytes: 40, Files: 1}}
+
+	// Photos without an owner and owners without an account have no quota
+	for _, ownerID := range []string{"", primitive.NewObjectID().Hex()} {
+		release, err := tracker.reserve(ctx, ownerID, delta)
+		if err != nil {
+			t.Fatalf("reserve(%q) error = %v", ownerID, err)
+		}
+		release()
+	}
+	if _, ok := usage.usage[""]; ok {
+		t.Error("usage was recorded for photos without an owner")
+	}
+}
+
+func TestUploadReleasesReservation(t *testing.T) {
+	tests := []struct {
+		name       string
+		storageErr error
+		createErr  error
+		wantPhotos int64
+	}{
+		{name: "stored", wantPhotos: 2},
+		{name: "storage fails", storageErr: errors.New("storage unavailable"), wantPhotos: 1},
+		{name: "record fails", createErr: errors.New("database unavailable"), wantPhotos: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker, usage, photoRepo, user := newTestUsageTracker(models.Quota{MaxBytes: 100}, &models.Photo{ID: primitive.NewObjectID(), Size: 50})
+			photoRepo.createErr = tt.createErr
+			storage := newFakeStorageRepo()
+			storage.uploadErr = tt.storageErr
+			ops := newFakeOperationRepo()
+			service := &photoService{photoRepo: photoRepo, storageRepo: storage, opRepo: ops, usage: tracker}
+			ctx := auth.WithUser(context.Background(), user)
+
+			content := []byte("0123456789")
+			_, err := service.UploadPhoto(ctx, "a.jpg", "", bytes.NewReader(content), "image/png", int64(len(content)))
+			if failed := tt.storageErr != nil || tt.createErr != nil; failed != (err != nil) {
+				t.Fatalf("UploadPhoto() error = %v", err)
+			}
+
+			recorded := usage.usage[user.ID.Hex()]
+			wantBytes := 50 + (tt.wantPhotos-1)*int64(len(content))
+			if recorded.Photos != tt.wantPhotos || recorded.QuotaBytes() != wantBytes {
+				t.Errorf("usage after upload = %d bytes in %d photos, want %d in %d", recorded.QuotaBytes(), recorded.Photos, wantBytes, tt.wantPhotos)
+			}
+			if err != nil && len(storage.files) != 0 {
+				t.Errorf("failed upload left %d files in storage", len(storage.files))
+			}
+			if len(ops.ops) != 0 {
+				t.Errorf("upload left %d pending operations", len(ops.ops))
+			}
+		})
+	}
+}
+
+func TestUploadAfterReleaseFits(t *testing.T) {
+	tracker, _, photoRepo, user := newTestUsageTracker(models.Quota{MaxBytes: 15})
+	storage := newFakeStorageRepo()
+	service := &photoService{photoRepo: photoRepo, storageRepo: storage, opRepo: newFakeOperationRepo(), usage: tracker}
+	ctx := auth.WithUser(context.Background(), user)
+	content := []byte("0123456789")
+
+	storage.uploadErr = errors.New("storage unavailable")
+	if _, err := service.UploadPhoto(ctx, "a.jpg", "", bytes.NewReader(content), "image/png", int64(len(content))); err == nil {
+		t.Fatal("UploadPhoto() succeeded with failing storage")
+	}
+	// The failed upload must not hold on to the room it reserved
+	storage.uploadErr = nil
+	if _, err := service.UploadPhoto(ctx, "a.jpg", "", bytes.NewReader(content), "image/png", int64(len(content))); err != nil {
+		t.Fatalf("UploadPhoto() after a failed upload error = %v", err)
+	}
+	if _, err := service.UploadPhoto(ctx, "b.jpg", "", bytes.NewReader(content), "image/png", int64(len(content))); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("UploadPhoto() beyond the quota error = %v, want ErrQuotaExceeded", err)
+	}
+}
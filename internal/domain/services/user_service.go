@@ -0,0 +1,306 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+)
+
+// tokenBytes is the amount of randomness in API and share tokens
+const tokenBytes = 32
+
+// UserService manages user accounts and their API tokens
+type UserService interface {
+	// CreateUser creates an account and returns it with its API token. Only a hash
+	// of the token is stored, so it cannot be shown again.
+	CreateUser(ctx context.Context, username, email string) (*models.User, string, error)
+
+	// Authenticate returns the user owning the API token. Without a token it returns the
+	// anonymous account, unless tokens are required or the account was never set up.
+	Authenticate(ctx context.Context, token string) (*models.User, error)
+
+	// ResetToken replaces the API token of a user and returns the new one, which cannot be
+	// shown again. It also gives the anonymous account a token, for clients that move to
+	// tokens but keep its photos.
+	ResetToken(ctx context.Context, username string) (string, error)
+
+	// EnsureAnonymousUser creates the anonymous account if it does not exist yet and gives
+	// it the photos without an owner. It returns the account and the number of photos claimed.
+	EnsureAnonymousUser(ctx context.Context) (*models.User, int64, error)
+
+	// IssueStreamTicket returns a single-use ticket that opens one event stream of the
+	// caller, and when it expires. Unlike API tokens, tickets may be put in the URL.
+	IssueStreamTicket(ctx context.Context) (string, time.Time, error)
+
+	// AuthenticateStreamTicket uses up a stream ticket and returns the user it was issued to
+	AuthenticateStreamTicket(ctx context.Context, ticket string) (*models.User, error)
+
+	// ClaimUnownedPhotos assigns every photo without an owner, and every photo of the
+	// anonymous account, to the user
+	ClaimUnownedPhotos(ctx context.Context, user *models.User) (int64, error)
+
+	// SetQuota moves a user to a plan, or to the default plan when plan is empty. A non-nil
+	// quota replaces the plan's quota for this user.
+	SetQuota(ctx context.Context, username, plan string, quota *models.Quota) (*models.User, error)
+}
+
+type userService struct {
+	userRepo       repositories.UserRepository
+	photoRepo      repositories.PhotoRepository
+	usageRepo      repositories.UsageRepository
+	ticketRepo     repositories.StreamTicketRepository
+	ticketLifetime time.Duration
+	authRequired   bool
+}
+
+// NewUserService creates a user service. Stream tickets stay valid for ticketLifetime. Unless
+// authRequired is set, requests without an API token act as the anonymous account.
+func NewUserService(userRepo repositories.UserRepository, photoRepo repositories.PhotoRepository, usageRepo repositories.UsageRepository, ticketRepo repositories.StreamTicketRepository, ticketLifetime time.Duration, authRequired bool) UserService {
+	return &userService{
+		userRepo:       userRepo,
+		photoRepo:      photoRepo,
+		usageRepo:      usageRepo,
+		ticketRepo:     ticketRepo,
+		ticketLifetime: ticketLifetime,
+		authRequired:   authRequired,
+	}
+}
+
+func (s *userService) CreateUser(ctx context.Context, username, email string) (*models.User, string, error) {
+	username = strings.TrimSpace(username)
+	email = strings.ToLower(strings.TrimSpace(email))
+	if username == "" || strings.ContainsAny(username, " @/") {
+		return nil, "", fmt.Errorf("%w: username must be non-empty without spaces, @ or /", ErrInvalidArgument)
+	}
+	if strings.EqualFold(username, models.AnonymousUsername) {
+		return nil, "", fmt.Errorf("%w: username %q is reserved", ErrInvalidArgument, username)
+	}
+	if _, err := mail.ParseAddress(email); err != nil {
+		return nil, "", fmt.Errorf("%w: invalid email %q", ErrInvalidArgument, email)
+	}
+
+	if existing, err := s.userRepo.GetByUsername(ctx, username); err != nil {
+		return nil, "", fmt.Errorf("failed to check username: %w", err)
+	} else if existing != nil {
+		return nil, "", fmt.Errorf("%w: username %q is taken", ErrConflict, username)
+	}
+	if existing, err := s.userRepo.GetByEmail(ctx, email); err != nil {
+		return nil, "", fmt.Errorf("failed to check email: %w", err)
+	} else if existing != nil {
+		return nil, "", fmt.Errorf("%w: email %q is already registered", ErrConflict, email)
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate API token: %w", err)
+	}
+
+	user := &models.User{
+		Username:  username,
+		Email:     email,
+		TokenHash: hashToken(token),
+		CreatedAt: time.Now(),
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, "", fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return user, token, nil
+}
+
+func (s *userService) Authenticate(ctx context.Context, token string) (*models.User, error) {
+	if token == "" {
+		if s.authRequired {
+			return nil, ErrUnauthorized
+		}
+		anonymous, err := s.userRepo.GetByUsername(ctx, models.AnonymousUsername)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up the anonymous account: %w", err)
+		}
+		if anonymous == nil {
+			return nil, ErrUnauthorized
+		}
+		return anonymous, nil
+	}
+
+	user, err := s.userRepo.GetByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up token: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUnauthorized
+	}
+	return user, nil
+}
+
+func (s *userService) ResetToken(ctx context.Context, username string) (string, error) {
+	user, err := s.userRepo.GetByUsername(ctx, strings.TrimSpace(username))
+	if err != nil {
+		return "", fmt.Errorf("failed to look up user: %w", err)
+	}
+	if user == nil {
+		return "", fmt.Errorf("%w: no user named %q", ErrInvalidArgument, username)
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate API token: %w", err)
+	}
+	if err := s.userRepo.SetTokenHash(ctx, user.ID, hashToken(token)); err != nil {
+		return "", fmt.Errorf("failed to store API token: %w", err)
+	}
+	return token, nil
+}
+
+func (s *userService) EnsureAnonymousUser(ctx context.Context) (*models.User, int64, error) {
+	user, err := s.userRepo.GetByUsername(ctx, models.AnonymousUsername)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to look up the anonymous account: %w", err)
+	}
+	if user == nil {
+		// Nobody holds a token of the account until one is reset for it
+		token, err := newToken()
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to generate API token: %w", err)
+		}
+		user = &models.User{
+			Username:  models.AnonymousUsername,
+			TokenHash: hashToken(token),
+			CreatedAt: time.Now(),
+		}
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return nil, 0, fmt.Errorf("failed to create the anonymous account: %w", err)
+		}
+	}
+
+	claimed, err := s.claim(ctx, user, "")
+	if err != nil {
+		return nil, 0, err
+	}
+	return user, claimed, nil
+}
+
+func (s *userService) IssueStreamTicket(ctx context.Context) (string, time.Time, error) {
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return "", time.Time{}, ErrUnauthorized
+	}
+
+	ticket, err := newToken()
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate stream ticket: %w", err)
+	}
+	expiresAt := time.Now().Add(s.ticketLifetime).UTC().Truncate(time.Second)
+	record := &models.StreamTicket{
+		UserID:     user.ID,
+		TicketHash: hashToken(ticket),
+		ExpiresAt:  expiresAt,
+	}
+	if err := s.ticketRepo.Create(ctx, record); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to store stream ticket: %w", err)
+	}
+	return ticket, expiresAt, nil
+}
+
+func (s *userService) AuthenticateStreamTicket(ctx context.Context, ticket string) (*models.User, error) {
+	if ticket == "" {
+		return nil, ErrUnauthorized
+	}
+
+	record, err := s.ticketRepo.Consume(ctx, hashToken(ticket), time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up stream ticket: %w", err)
+	}
+	if record == nil {
+		return nil, ErrUnauthorized
+	}
+	user, err := s.userRepo.GetByID(ctx, record.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUnauthorized
+	}
+	return user, nil
+}
+
+func (s *userService) ClaimUnownedPhotos(ctx context.Context, user *models.User) (int64, error) {
+	anonymous, err := s.userRepo.GetByUsername(ctx, models.AnonymousUsername)
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up the anonymous account: %w", err)
+	}
+	fromOwnerID := ""
+	if anonymous != nil && anonymous.ID != user.ID {
+		fromOwnerID = anonymous.ID.Hex()
+	}
+	return s.claim(ctx, user, fromOwnerID)
+}
+
+// claim assigns the photos without an owner, and those of fromOwnerID if given, to the user
+func (s *userService) claim(ctx context.Context, user *models.User, fromOwnerID string) (int64, error) {
+	claimed, err := s.photoRepo.ClaimUnowned(ctx, user.ID.Hex(), fromOwnerID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim photos: %w", err)
+	}
+
+	// The claimed photos were not counted for the user, so the usage of both sides is
+	// recomputed on next use
+	if claimed > 0 {
+		for _, ownerID := range []string{user.ID.Hex(), fromOwnerID} {
+			if ownerID == "" {
+				continue
+			}
+			if err := s.usageRepo.Invalidate(ctx, ownerID, time.Now()); err != nil {
+				return claimed, fmt.Errorf("failed to reset storage usage: %w", err)
+			}
+		}
+	}
+	return claimed, nil
+}
+
+func (s *userService) SetQuota(ctx context.Context, username, plan string, quota *models.Quota) (*models.User, error) {
+	if quota != nil && (quota.MaxBytes < 0 || quota.MaxPhotos < 0) {
+		return nil, fmt.Errorf("%w: quota limits must not be negative", ErrInvalidArgument)
+	}
+
+	user, err := s.userRepo.GetByUsername(ctx, strings.TrimSpace(username))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("%w: no user named %q", ErrInvalidArgument, username)
+	}
+
+	if err := s.userRepo.SetQuota(ctx, user.ID, plan, quota); err != nil {
+		return nil, fmt.Errorf("failed to update quota: %w", err)
+	}
+	user.Plan = plan
+	user.Quota = quota
+	return user, nil
+}
+
+// newToken returns a random URL-safe token
+func newToken() (string, error) {
+	raw := make([]byte, tokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// hashToken returns the stored form of a token. Tokens carry enough randomness
+// that a fast unsalted hash is sufficient.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"photocloud/internal/domain/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTestUserService(authRequired bool, photos ...*models.Photo) (*userService, *fakeUserRepo, *fakeUsageRepo) {
+	users := newFakeUserRepo()
+	usage := newFakeUsageRepo()
+	service := NewUserService(users, newFakePhotoRepo(photos...), usage, nil, time.Minute, authRequired).(*userService)
+	return service, users, usage
+}
+
+func TestAuthenticateWithoutToken(t *testing.T) {
+	ctx := context.Background()
+
+	required, _, _ := newTestUserService(true)
+	if _, _, err := required.EnsureAnonymousUser(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := required.Authenticate(ctx, ""); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Authenticate() without a token when tokens are required error = %v, want ErrUnauthorized", err)
+	}
+
+	optional, _, _ := newTestUserService(false)
+	if _, err := optional.Authenticate(ctx, ""); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Authenticate() without a token before the anonymous account exists error = %v, want ErrUnauthorized", err)
+	}
+	anonymous, _, err := optional.EnsureAnonymousUser(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user, err := optional.Authenticate(ctx, ""); err != nil || user.ID != anonymous.ID {
+		t.Errorf("Authenticate() without a token = %v, %v, want the anonymous account", user, err)
+	}
+	if _, err := optional.Authenticate(ctx, "not-a-token"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Authenticate() with an invalid token error = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestEnsureAnonymousUserClaimsUnownedPhotos(t *testing.T) {
+	ctx := context.Background()
+	owned := &models.Photo{ID: primitive.NewObjectID(), OwnerID: primitive.NewObjectID().Hex()}
+	legacy := &models.Photo{ID: primitive.NewObjectID()}
+	service, users, usage := newTestUserService(false, owned, legacy)
+
+	anonymous, claimed, err := service.EnsureAnonymousUser(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claimed != 1 || legacy.OwnerID != anonymous.ID.Hex() || owned.OwnerID == anonymous.ID.Hex() {
+		t.Errorf("EnsureAnonymousUser() claimed %d photos, legacy owner %q, want only the legacy photo", claimed, legacy.OwnerID)
+	}
+	if !slices.Contains(usage.invalidated, anonymous.ID.Hex()) {
+		t.Errorf("usage of the anonymous account was not recomputed")
+	}
+
+	again, claimed, err := service.EnsureAnonymousUser(ctx)
+	if err != nil || again.ID != anonymous.ID || claimed != 0 || len(users.users) != 1 {
+		t.Errorf("EnsureAnonymousUser() again = %v, %d, %v, want the same account and nothing claimed", again, claimed, err)
+	}
+	// Nobody can authenticate as the account until a token is reset for it
+	if _, err := service.Authenticate(ctx, anonymous.TokenHash); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Authenticate() with the stored hash error = %v, want ErrUnauthorized", err)
+	}
+	token, err := service.ResetToken(ctx, models.AnonymousUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user, err := service.Authenticate(ctx, token); err != nil || user.ID != anonymous.ID {
+		t.Errorf("Authenticate() with the reset token = %v, %v, want the anonymous account", user, err)
+	}
+}
+
+func TestClaimUnownedPhotosTakesAnonymousPhotos(t *testing.T) {
+	ctx := context.Background()
+	legacy := &models.Photo{ID: primitive.NewObjectID()}
+	service, _, usage := newTestUserService(false, legacy)
+	anonymous, _, err := service.EnsureAnonymousUser(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, _, err := service.CreateUser(ctx, "alice", "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claimed, err := service.ClaimUnownedPhotos(ctx, alice)
+	if err != nil || claimed != 1 || legacy.OwnerID != alice.ID.Hex() {
+		t.Fatalf("ClaimUnownedPhotos() = %d, %v, owner %q, want the anonymous account's photo", claimed, err, legacy.OwnerID)
+	}
+	for _, ownerID := range []string{alice.ID.Hex(), anonymous.ID.Hex()} {
+		if !slices.Contains(usage.invalidated, ownerID) {
+			t.Errorf("usage of %s was not recomputed", ownerID)
+		}
+	}
+}
+
+func TestCreateUserReservesAnonymous(t *testing.T) {
+	service, _, _ := newTestUserService(false)
+	for _, username := range []string{"anonymous", "Anonymous"} {
+		if _, _, err := service.CreateUser(context.Background(), username, "a@example.com"); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("CreateUser(%q) error = %v, want ErrInvalidArgument", username, err)
+		}
+	}
+}
@@ -0,0 +1,514 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"photocloud/config"
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// webhookSecretBytes is the length of generated webhook secrets
+	webhookSecretBytes = 32
+	// webhookResponseLimit is how much of a response body is kept with an attempt
+	webhookResponseLimit = 1024
+	// webhookMaxRetryDelay caps the exponential backoff between attempts
+	webhookMaxRetryDelay = 12 * time.Hour
+	// webhookLeaseMargin is added to the timeout while a delivery is claimed, so that
+	// other instances only take it over once the attempt is surely over
+	webhookLeaseMargin = time.Minute
+)
+
+// Headers sent with every webhook delivery
+const (
+	WebhookEventHeader     = "X-PhotoCloud-Event"
+	WebhookDeliveryHeader  = "X-PhotoCloud-Delivery"
+	WebhookTimestampHeader = "X-PhotoCloud-Timestamp"
+	WebhookSignatureHeader = "X-PhotoCloud-Signature"
+)
+
+// WebhookService manages webhooks and posts events to them. Users manage their
+// own webhooks, which receive the events delivered to them; the system manages
+// webhooks that receive every event.
+type WebhookService interface {
+	// CreateWebhook registers an endpoint for the caller. The returned webhook carries the
+	// secret deliveries are signed with; it is generated when secret is empty.
+	CreateWebhook(ctx context.Context, endpoint string, events []models.EventType, description, secret string) (*models.Webhook, error)
+	// GetWebhook retrieves one of the caller's webhooks
+	GetWebhook(ctx context.Context, id primitive.ObjectID) (*models.Webhook, error)
+	// ListWebhooks lists the caller's webhooks, newest first
+	ListWebhooks(ctx context.Context, page, limit int) ([]models.Webhook, error)
+	// UpdateWebhook changes a webhook; nil values are left unchanged. Enabling a disabled
+	// webhook resets its failure count.
+	UpdateWebhook(ctx context.Context, id primitive.ObjectID, endpoint *string, events *[]models.EventType, description *string, enabled *bool) (*models.Webhook, error)
+	// DeleteWebhook deletes a webhook and its delivery log
+	DeleteWebhook(ctx context.Context, id primitive.ObjectID) error
+
+	// ListDeliveries lists the deliveries of a webhook, newest first
+	ListDeliveries(ctx context.Context, webhookID primitive.ObjectID, page, limit int) ([]models.WebhookDelivery, error)
+	// GetDelivery retrieves a delivery of a webhook with all its attempts
+	GetDelivery(ctx context.Context, webhookID, deliveryID primitive.ObjectID) (*models.WebhookDelivery, error)
+	// Redeliver posts the payload of a delivery again as a new delivery
+	Redeliver(ctx context.Context, webhookID, deliveryID primitive.ObjectID) (*models.WebhookDelivery, error)
+
+	// Enqueue creates a delivery of an event for every enabled webhook that wants it and
+	// returns how many were created
+	Enqueue(ctx context.Context, event *models.Event) (int, error)
+	// DeliverNext attempts the delivery that is due next and reports whether there was one.
+	// Failed attempts are retried with exponential backoff until they run out.
+	DeliverNext(ctx context.Context, now time.Time) (bool, error)
+}
+
+type webhookService struct {
+	webhookRepo  repositories.WebhookRepository
+	deliveryRepo repositories.WebhookDeliveryRepository
+	client       *http.Client
+	cfg          config.WebhookConfig
+}
+
+// NewWebhookService creates a webhook service
+func NewWebhookService(webhookRepo repositories.WebhookRepository, deliveryRepo repositories.WebhookDeliveryRepository, cfg config.WebhookConfig) WebhookService {
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivateNetworks {
+		// Checked when connecting, so that names resolving to internal addresses are caught too
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("%s is not a public address", host)
+			}
+			return nil
+		}
+	}
+
+	return &webhookService{
+		webhookRepo:  webhookRepo,
+		deliveryRepo: deliveryRepo,
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: cfg.Timeout},
+			// Endpoints answer themselves; following redirects would post the payload elsewhere
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		cfg: cfg,
+	}
+}
+
+// WebhookSignature returns the value of the signature header of a delivery: the
+// hex-encoded HMAC-SHA256 of the timestamp, a dot and the payload
+func WebhookSignature(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *webhookService) CreateWebhook(ctx context.Context, endpoint string, events []models.EventType, description, secret string) (*models.Webhook, error) {
+	if err := s.validateEndpoint(endpoint); err != nil {
+		return nil, err
+	}
+	events, err := normalizeWebhookEvents(events)
+	if err != nil {
+		return nil, err
+	}
+	if secret == "" {
+		buf := make([]byte, webhookSecretBytes)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		secret = hex.EncodeToString(buf)
+	}
+
+	now := time.Now()
+	webhook := &models.Webhook{
+		OwnerID:     auth.UserID(ctx),
+		URL:         endpoint,
+		Events:      events,
+		Description: description,
+		Secret:      secret,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.webhookRepo.Create(ctx, webhook); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+func (s *webhookService) GetWebhook(ctx context.Context, id primitive.ObjectID) (*models.Webhook, error) {
+	webhook, err := s.webhookRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Webhooks of others are not revealed, and the system only manages its own
+	if webhook == nil || webhook.OwnerID != auth.UserID(ctx) {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+func (s *webhookService) ListWebhooks(ctx context.Context, page, limit int) ([]models.Webhook, error) {
+	return s.webhookRepo.ListByOwner(ctx, auth.UserID(ctx), page, limit)
+}
+
+func (s *webhookService) UpdateWebhook(ctx context.Context, id primitive.ObjectID, endpoint *string, events *[]models.EventType, description *string, enabled *bool) (*models.Webhook, error) {
+	webhook, err := s.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if endpoint != nil {
+		if err := s.validateEndpoint(*endpoint); err != nil {
+			return nil, err
+		}
+		webhook.URL = *endpoint
+	}
+	if events != nil {
+		if webhook.Events, err = normalizeWebhookEvents(*events); err != nil {
+			return nil, err
+		}
+	}
+	if description != nil {
+		webhook.Description = *description
+	}
+	now := time.Now()
+	if enabled != nil {
+		switch {
+		case *enabled && webhook.DisabledAt != nil:
+			webhook.DisabledAt, webhook.DisabledReason = nil, ""
+			webhook.ConsecutiveFailures = 0
+		case !*enabled && webhook.DisabledAt == nil:
+			webhook.DisabledAt, webhook.DisabledReason = &now, "disabled by its owner"
+		}
+	}
+	webhook.UpdatedAt = now
+
+	if err := s.webhookRepo.Update(ctx, webhook); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+func (s *webhookService) DeleteWebhook(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := s.GetWebhook(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.webhookRepo.Delete(ctx, id); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	// Deliveries left behind fail when they come due, so a failure here is harmless
+	_ = s.deliveryRepo.DeleteByWebhook(ctx, id)
+	return nil
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, webhookID primitive.ObjectID, page, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+	return s.deliveryRepo.ListByWebhook(ctx, webhookID, page, limit)
+}
+
+func (s *webhookService) GetDelivery(ctx context.Context, webhookID, deliveryID primitive.ObjectID) (*models.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.deliveryRepo.GetByID(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil || delivery.WebhookID != webhookID {
+		return nil, ErrDeliveryNotFound
+	}
+	return delivery, nil
+}
+
+func (s *webhookService) Redeliver(ctx context.Context, webhookID, deliveryID primitive.ObjectID) (*models.WebhookDelivery, error) {
+	webhook, err := s.GetWebhook(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.DisabledAt != nil {
+		return nil, fmt.Errorf("%w: the webhook is disabled", ErrConflict)
+	}
+	original, err := s.GetDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	redelivery := models.WebhookDelivery{
+		WebhookID:     webhookID,
+		EventID:       original.EventID,
+		EventType:     original.EventType,
+		Payload:       original.Payload,
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: now,
+		RedeliveryOf:  &original.ID,
+		CreatedAt:     now,
+	}
+	deliveries := []models.WebhookDelivery{redelivery}
+	if err := s.deliveryRepo.CreateMany(ctx, deliveries); err != nil {
+		return nil, fmt.Errorf("failed to create redelivery: %w", err)
+	}
+	return &deliveries[0], nil
+}
+
+func (s *webhookService) Enqueue(ctx context.Context, event *models.Event) (int, error) {
+	// System webhooks have no owner and receive every event
+	owners := append([]string{""}, event.Recipients...)
+	webhooks, err := s.webhookRepo.ListForEvent(ctx, event.Type, owners)
+	if err != nil || len(webhooks) == 0 {
+		return 0, err
+	}
+
+	body := webhookPayload{
+		ID:        event.ID.Hex(),
+		Type:      string(event.Type),
+		PhotoID:   hexOrEmpty(event.PhotoID),
+		AlbumID:   hexOrEmpty(event.AlbumID),
+		ExportID:  hexOrEmpty(event.ExportID),
+		Error:     event.Error,
+		ActorID:   event.ActorID,
+		Timestamp: event.Timestamp,
+	}
+	// The download link of an export is only posted to its owner's webhooks
+	systemPayload, err := json.Marshal(body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+	body.URL, body.ExpiresAt = event.URL, event.ExpiresAt
+	ownerPayload, err := json.Marshal(body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	now := time.Now()
+	deliveries := make([]models.WebhookDelivery, len(webhooks))
+	for i, webhook := range webhooks {
+		payload := ownerPayload
+		if webhook.OwnerID == "" {
+			payload = systemPayload
+		}
+		deliveries[i] = models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       payload,
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		}
+	}
+	if err := s.deliveryRepo.CreateMany(ctx, deliveries); err != nil {
+		return 0, fmt.Errorf("failed to create webhook deliveries: %w", err)
+	}
+	return len(deliveries), nil
+}
+
+func (s *webhookService) DeliverNext(ctx context.Context, now time.Time) (bool, error) {
+	delivery, err := s.deliveryRepo.ClaimDue(ctx, now, now.Add(s.cfg.Timeout+webhookLeaseMargin))
+	if err != nil || delivery == nil {
+		return false, err
+	}
+
+	webhook, err := s.webhookRepo.GetByID(ctx, delivery.WebhookID)
+	if err != nil {
+		return true, err
+	}
+	if webhook == nil || webhook.DisabledAt != nil {
+		attempt := models.WebhookAttempt{At: now, Error: "the webhook was deleted or disabled"}
+		return true, s.deliveryRepo.RecordAttempt(ctx, delivery.ID, attempt, models.WebhookDeliveryFailed, now)
+	}
+
+	attempt := s.post(ctx, webhook, delivery)
+	if attempt.StatusCode >= 200 && attempt.StatusCode < 300 {
+		if err := s.deliveryRepo.RecordAttempt(ctx, delivery.ID, attempt, models.WebhookDeliverySucceeded, attempt.At); err != nil {
+			return true, err
+		}
+		return true, s.webhookRepo.RecordSuccess(ctx, webhook.ID)
+	}
+
+	attempts := len(delivery.Attempts) + 1
+	status, next := models.WebhookDeliveryPending, attempt.At.Add(webhookRetryDelay(s.cfg.RetryDelay, attempts))
+	if attempts >= s.cfg.MaxAttempts {
+		status, next = models.WebhookDeliveryFailed, attempt.At
+	}
+	if err := s.deliveryRepo.RecordAttempt(ctx, delivery.ID, attempt, status, next); err != nil {
+		return true, err
+	}
+
+	reason := fmt.Sprintf("disabled after %d failed delivery attempts in a row", s.cfg.DisableAfter)
+	if _, err := s.webhookRepo.RecordFailure(ctx, webhook.ID, s.cfg.DisableAfter, reason, attempt.At); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// post sends a delivery to its webhook once and describes the outcome
+func (s *webhookService) post(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) models.WebhookAttempt {
+	attempt := models.WebhookAttempt{At: time.Now()}
+	timestamp := strconv.FormatInt(attempt.At.Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "PhotoCloud-Webhook/1.0")
+	req.Header.Set(WebhookEventHeader, string(delivery.EventType))
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID.Hex())
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, WebhookSignature(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	attempt.Duration = time.Since(attempt.At)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	attempt.StatusCode = resp.StatusCode
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	attempt.Response = string(body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		attempt.Error = fmt.Sprintf("the endpoint answered %s", resp.Status)
+	}
+	return attempt
+}
+
+// validateEndpoint checks that a webhook URL is an absolute HTTP or HTTPS URL that may be reached
+func (s *webhookService) validateEndpoint(endpoint string) error {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: the webhook URL must be an absolute http or https URL", ErrInvalidArgument)
+	}
+	if ip := net.ParseIP(parsed.Hostname()); ip != nil && !s.cfg.AllowPrivateNetworks && !isPublicIP(ip) {
+		return fmt.Errorf("%w: the webhook URL must not point to a private address", ErrInvalidArgument)
+	}
+	return nil
+}
+
+// webhookPayload is the JSON body posted to webhooks
+type webhookPayload struct {
+	ID        string     `json:"id"`
+	Type      string     `json:"type"`
+	PhotoID   string     `json:"photo_id,omitempty"`
+	AlbumID   string     `json:"album_id,omitempty"`
+	ExportID  string     `json:"export_id,omitempty"`
+	URL       string     `json:"url,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	ActorID   string     `json:"actor_id,omitempty"`
+	Timestamp time.Time  `json:"timestamp"`
+}
+
+// normalizeWebhookEvents checks an event filter and stores "all events" as an empty list
+func normalizeWebhookEvents(events []models.EventType) ([]models.EventType, error) {
+	normalized := make([]models.EventType, 0, len(events))
+	for _, eventType := range events {
+		if !eventType.IsValid() {
+			return nil, fmt.Errorf("%w: unknown event type %q", ErrInvalidArgument, eventType)
+		}
+		normalized = append(normalized, eventType)
+	}
+	return normalized, nil
+}
+
+// webhookRetryDelay returns the wait after the given number of failed attempts
+func webhookRetryDelay(base time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < webhookMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > webhookMaxRetryDelay {
+		delay = webhookMaxRetryDelay
+	}
+	return delay
+}
+
+// nonPublicPrefixes are the address ranges that are not reachable on the internet, or
+// that lead to addresses which may not be, such as IPv6 forms of IPv4 addresses
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // this network
+	netip.MustParsePrefix("10.0.0.0/8"),      // private
+	netip.MustParsePrefix("100.64.0.0/10"),   // shared address space
+	netip.MustParsePrefix("127.0.0.0/8"),     // loopback
+	netip.MustParsePrefix("169.254.0.0/16"),  // link-local
+	netip.MustParsePrefix("172.16.0.0/12"),   // private
+	netip.MustParsePrefix("192.0.0.0/24"),    // protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("192.88.99.0/24"),  // 6to4 relay anycast
+	netip.MustParsePrefix("192.168.0.0/16"),  // private
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("224.0.0.0/4"),     // multicast
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved and broadcast
+	netip.MustParsePrefix("::/96"),           // unspecified, loopback and IPv4-compatible
+	netip.MustParsePrefix("::ffff:0:0:0/96"), // IPv4-translated
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local NAT64
+	netip.MustParsePrefix("100::/64"),        // discard
+	netip.MustParsePrefix("2001::/23"),       // protocol assignments, including Teredo
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+	netip.MustParsePrefix("2002::/16"),       // 6to4
+	netip.MustParsePrefix("fc00::/7"),        // unique local
+	netip.MustParsePrefix("fe80::/10"),       // link-local
+	netip.MustParsePrefix("fec0::/10"),       // site-local
+	netip.MustParsePrefix("ff00::/8"),        // multicast
+}
+
+// isPublicIP reports whether an address is reachable on the internet rather than
+// loopback, private, link-local or otherwise special. IPv4 addresses in IPv6 form
+// are checked as the IPv4 address they stand for.
+func isPublicIP(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+func hexOrEmpty(id primitive.ObjectID) string {
+	if id.IsZero() {
+		return ""
+	}
+	return id.Hex()
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		payload   string
+		want      string
+	}{
+		{
+			name:      "event payload",
+			secret:    "secret",
+			timestamp: "1700000000",
+			payload:   `{"id":"1"}`,
+			want:      "sha256=086f6aff7bd084c98679825129c5a64dbad88c760016d6d2c0fb123f27951d54",
+		},
+		{
+			name:      "other secret and payload",
+			secret:    "s3cr3t",
+			timestamp: "1700000000",
+			payload:   `{"id":"2"}`,
+			want:      "sha256=09a0a442b59fd4ff70ef726e024402e92ab2fded98beb7e25e1376219760f69c",
+		},
+		{
+			name:      "empty secret and payload",
+			secret:    "",
+			timestamp: "0",
+			payload:   "",
+			want:      "sha256=b849d5a581847b281957065739df36df2463d1977ea8d6e1e4e6cf33fadc68c3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WebhookSignature(tt.secret, tt.timestamp, []byte(tt.payload)); got != tt.want {
+				t.Errorf("WebhookSignature() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     time.Duration
+		attempts int
+		want     time.Duration
+	}{
+		{name: "first failure", base: time.Minute, attempts: 1, want: time.Minute},
+		{name: "second failure", base: time.Minute, attempts: 2, want: 2 * time.Minute},
+		{name: "fifth failure", base: time.Minute, attempts: 5, want: 16 * time.Minute},
+		{name: "capped", base: time.Minute, attempts: 20, want: webhookMaxRetryDelay},
+		{name: "many attempts do not overflow", base: time.Minute, attempts: 1000, want: webhookMaxRetryDelay},
+		{name: "base above the cap", base: 24 * time.Hour, attempts: 1, want: webhookMaxRetryDelay},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := webhookRetryDelay(tt.base, tt.attempts); got != tt.want {
+				t.Errorf("webhookRetryDelay(%s, %d) = %s, want %s", tt.base, tt.attempts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		address string
+		want    bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"100.64.0.1", false},
+		{"100.127.255.255", false},
+		{"198.18.0.1", false},
+		{"198.19.255.255", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::", false},
+		{"::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"::ffff:93.184.216.34", true},
+		{"64:ff9b::7f00:1", false},
+		{"2002:7f00:1::", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"ff02::1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			if got := isPublicIP(net.ParseIP(tt.address)); got != tt.want {
+				t.Errorf("isPublicIP(%s) = %v, want %v", tt.address, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"photocloud/internal/domain/dto"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+	"photocloud/internal/domain/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// defaultActivityRange is the period covered when a query gives no start
+	defaultActivityRange = 30 * 24 * time.Hour
+	// defaultRankingSize is the number of photos or users ranked when no limit is given
+	defaultRankingSize = 10
+)
+
+type ActivityHandler struct {
+	activityService services.ActivityService
+}
+
+func NewActivityHandler(activityService services.ActivityService) *ActivityHandler {
+	return &ActivityHandler{
+		activityService: activityService,
+	}
+}
+
+// ListMyActivity handles requests for the caller's own activity feed
+func (h *ActivityHandler) ListMyActivity(c *gin.Context) {
+	page, limit := parsePagination(c)
+
+	activities, err := h.activityService.ListMyActivity(c.Request.Context(), page, limit)
+	if err != nil {
+		respondError(c, err, "Failed to list activity")
+		return
+	}
+
+	c.JSON(http.StatusOK, toActivityListResponse(activities, page, limit))
+}
+
+// ListPhotoActivity handles requests for the activity history of a photo
+func (h *ActivityHandler) ListPhotoActivity(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+	page, limit := parsePagination(c)
+
+	activities, err := h.activityService.ListPhotoActivity(c.Request.Context(), id, page, limit)
+	if err != nil {
+		respondError(c, err, "Failed to list photo activity")
+		return
+	}
+
+	c.JSON(http.StatusOK, toActivityListResponse(activities, page, limit))
+}
+
+// ListActivity handles requests for every user's activity within a time range
+func (h *ActivityHandler) ListActivity(c *gin.Context) {
+	filter, _, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
+	page, limit := parsePagination(c)
+
+	activities, err := h.activityService.ListActivity(c.Request.Context(), filter.Start, filter.End, page, limit)
+	if err != nil {
+		respondError(c, err, "Failed to list activity")
+		return
+	}
+
+	c.JSON(http.StatusOK, toActivityListResponse(activities, page, limit))
+}
+
+// DailyActivity handles requests for the number of activities per day
+func (h *ActivityHandler) DailyActivity(c *gin.Context) {
+	filter, timezone, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
+	h.dailyActivity(c, filter, timezone)
+}
+
+// PhotoDailyActivity handles requests for the number of activities per day on a photo
+func (h *ActivityHandler) PhotoDailyActivity(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+	filter, timezone, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
+	filter.PhotoID = id
+	h.dailyActivity(c, filter, timezone)
+}
+
+func (h *ActivityHandler) dailyActivity(c *gin.Context, filter repositories.ActivityFilter, timezone string) {
+	days, err := h.activityService.DailyActivity(c.Request.Context(), filter, timezone)
+	if err != nil {
+		respondError(c, err, "Failed to count activity")
+		return
+	}
+
+	response := dto.DailyActivityListResponse{
+		Start:    filter.Start,
+		End:      filter.End,
+		Timezone: timezone,
+		Days:     make([]dto.DailyActivityResponse, 0, len(days)),
+	}
+	for _, day := range days {
+		counts := make(map[string]int64, len(day.Counts))
+		var total int64
+		for activityType, count := range day.Counts {
+			counts[string(activityType)] = count
+			total += count
+		}
+		response.Days = append(response.Days, dto.DailyActivityResponse{
+			Date:   day.Date,
+			Counts: counts,
+			Total:  total,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// TopPhotos handles requests for the photos with the most activity
+func (h *ActivityHandler) TopPhotos(c *gin.Context) {
+	filter, _, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
+
+	photos, err := h.activityService.TopPhotos(c.Request.Context(), filter, parseRankingSize(c))
+	if err != nil {
+		respondError(c, err, "Failed to rank photos")
+		return
+	}
+
+	response := dto.TopPhotosResponse{
+		Start:  filter.Start,
+		End:    filter.End,
+		Photos: make([]dto.PhotoActivityCountResponse, 0, len(photos)),
+	}
+	for _, photo := range photos {
+		response.Photos = append(response.Photos, dto.PhotoActivityCountResponse{
+			PhotoID:        photo.PhotoID.Hex(),
+			Count:          photo.Count,
+			Users:          photo.Users,
+			LastActivityAt: photo.LastAt,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ActiveUsers handles requests for the users with the most activity
+func (h *ActivityHandler) ActiveUsers(c *gin.Context) {
+	filter, _, ok := parseActivityFilter(c)
+	if !ok {
+		return
+	}
+
+	users, err := h.activityService.ActiveUsers(c.Request.Context(), filter, parseRankingSize(c))
+	if err != nil {
+		respondError(c, err, "Failed to rank users")
+		return
+	}
+
+	response := dto.ActiveUsersResponse{
+		Start: filter.Start,
+		End:   filter.End,
+		Users: make([]dto.ActiveUserResponse, 0, len(users)),
+	}
+	for _, user := range users {
+		response.Users = append(response.Users, dto.ActiveUserResponse{
+			UserID:          user.UserID,
+			Username:        user.Username,
+			Count:           user.Count,
+			Photos:          user.Photos,
+			FirstActivityAt: user.FirstAt,
+			LastActivityAt:  user.LastAt,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// parseActivityFilter reads the start, end, type, user_id, photo_id and tz query parameters,
+// writing a 400 response if any is invalid. The period defaults to the last 30 days; dates
+// without a time cover the whole day in the time zone, which defaults to UTC.
+func parseActivityFilter(c *gin.Context) (repositories.ActivityFilter, string, bool) {
+	var filter repositories.ActivityFilter
+
+	timezone := c.DefaultQuery("tz", "UTC")
+	location, err := time.LoadLocation(timezone)
+	if err != nil || timezone == "Local" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid tz: %s", timezone)})
+		return filter, "", false
+	}
+
+	if filter.Start, err = parseActivityTime(c.Query("start"), location, false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid start: %v", err)})
+		return filter, "", false
+	}
+	if filter.End, err = parseActivityTime(c.Query("end"), location, true); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid end: %v", err)})
+		return filter, "", false
+	}
+	if filter.End.IsZero() {
+		filter.End = time.Now().UTC()
+	}
+	if filter.Start.IsZero() {
+		filter.Start = filter.End.Add(-defaultActivityRange)
+	}
+
+	if types := c.Query("type"); types != "" {
+		for _, activityType := range strings.Split(types, ",") {
+			filter.Types = append(filter.Types, models.ActivityType(strings.TrimSpace(activityType)))
+		}
+	}
+
+	filter.UserID = c.Query("user_id")
+	if photoID := c.Query("photo_id"); photoID != "" {
+		if filter.PhotoID, err = primitive.ObjectIDFromHex(photoID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid photo_id: %s", photoID)})
+			return filter, "", false
+		}
+	}
+
+	return filter, timezone, true
+}
+
+// parseActivityTime parses an RFC 3339 timestamp or a YYYY-MM-DD date in the location. Dates
+// mean the start of the day, or its last instant when endOfDay is set.
+func parseActivityTime(value string, location *time.Location, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	day, err := time.ParseInLocation("2006-01-02", value, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither an RFC 3339 time nor a YYYY-MM-DD date", value)
+	}
+	if endOfDay {
+		day = day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return day, nil
+}
+
+// parseRankingSize reads the limit query parameter of ranking endpoints
+func parseRankingSize(c *gin.Context) int {
+	if c.Query("limit") == "" {
+		return defaultRankingSize
+	}
+	_, limit := parsePagination(c)
+	return limit
+}
+
+func toActivityListResponse(activities []models.UserActivity, page, limit int) dto.ActivityListResponse {
+	response := dto.ActivityListResponse{
+		Activities: make([]dto.ActivityResponse, 0, len(activities)),
+		Page:       page,
+		Limit:      limit,
+	}
+	for _, activity := range activities {
+		response.Activities = append(response.Activities, dto.ActivityResponse{
+			ID:        activity.ID.Hex(),
+			UserID:    activity.UserID,
+			PhotoID:   activity.PhotoID.Hex(),
+			Type:      string(activity.Type),
+			Timestamp: activity.Timestamp,
+			IP:        activity.IP,
+			UserAgent: activity.UserAgent,
+			RequestID: activity.RequestID,
+			Metadata:  activity.Metadata,
+		})
+	}
+	return response
+}
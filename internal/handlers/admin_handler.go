@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"photocloud/internal/domain/dto"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	reconciliationService services.ReconciliationService
+}
+
+func NewAdminHandler(reconciliationService services.ReconciliationService) *AdminHandler {
+	return &AdminHandler{
+		reconciliationService: reconciliationService,
+	}
+}
+
+// Reconcile handles requests to compare storage with the photos collection
+func (h *AdminHandler) Reconcile(c *gin.Context) {
+	var req dto.ReconcileRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request data: %v", err)})
+			return
+		}
+	}
+
+	report, err := h.reconciliationService.Reconcile(c.Request.Context(), models.ReconcileAction(req.Action))
+	if err != nil {
+		respondError(c, err, "Failed to reconcile storage")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,401 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"photocloud/internal/domain/dto"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AlbumHandler struct {
+	albumService services.AlbumService
+}
+
+func NewAlbumHandler(albumService services.AlbumService) *AlbumHandler {
+	return &AlbumHandler{
+		albumService: albumService,
+	}
+}
+
+// CreateAlbum handles requests to create an album
+func (h *AlbumHandler) CreateAlbum(c *gin.Context) {
+	var req dto.CreateAlbumRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request data: %v", err)})
+		return
+	}
+
+	album, err := h.albumService.CreateAlbum(c.Request.Context(), req.Name, req.Description)
+	if err != nil {
+		respondError(c, err, "Failed to create album")
+		return
+	}
+
+	c.JSON(http.StatusCreated, toAlbumResponse(album))
+}
+
+// ListAlbums handles requests to list the caller's albums
+func (h *AlbumHandler) ListAlbums(c *gin.Context) {
+	page, limit := parsePagination(c)
+
+	albums, err := h.albumService.ListAlbums(c.Request.Context(), page, limit)
+	if err != nil {
+		respondError(c, err, "Failed to list albums")
+		return
+	}
+
+	c.JSON(http.StatusOK, toAlbumListResponse(albums, page, limit))
+}
+
+// ListSharedAlbums handles requests to list the albums shared with the caller
+func (h *AlbumHandler) ListSharedAlbums(c *gin.Context) {
+	page, limit := parsePagination(c)
+
+	albums, err := h.albumService.ListSharedAlbums(c.Request.Context(), page, limit)
+	if err != nil {
+		respondError(c, err, "Failed to list shared albums")
+		return
+	}
+
+	c.JSON(http.StatusOK, toAlbumListResponse(albums, page, limit))
+}
+
+// GetAlbum handles requests for a single album
+func (h *AlbumHandler) GetAlbum(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	album, err := h.albumService.GetAlbum(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "Failed to get album")
+		return
+	}
+
+	c.JSON(http.StatusOK, toAlbumResponse(album))
+}
+
+// UpdateAlbum handles requests to rename an album or change its description
+func (h *AlbumHandler) UpdateAlbum(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateAlbumRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request data: %v", err)})
+		return
+	}
+
+	album, err := h.albumService.UpdateAlbum(c.Request.Context(), id, req.Name, req.Description)
+	if err != nil {
+		respondError(c, err, "Failed to update album")
+		return
+	}
+
+	c.JSON(http.StatusOK, toAlbumResponse(album))
+}
+
+// DeleteAlbum handles requests to delete an album, keeping its photos
+func (h *AlbumHandler) DeleteAlbum(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.albumService.DeleteAlbum(c.Request.Context(), id); err != nil {
+		respondError(c, err, "Failed to delete album")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListAlbumPhotos handles requests to list the photos of an album
+func (h *AlbumHandler) ListAlbumPhotos(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+	page, limit := parsePagination(c)
+
+	photos, err := h.albumService.ListAlbumPhotos(c.Request.Context(), id, page, limit)
+	if err != nil {
+		respondError(c, err, "Failed to list album photos")
+		return
+	}
+
+	c.JSON(http.StatusOK, toPhotoListResponse(photos, page, limit))
+}
+
+// AddPhotos handles requests to add photos to an album
+func (h *AlbumHandler) AddPhotos(c *gin.Context) {
+	id, photoIDs, ok := parseAlbumPhotos(c)
+	if !ok {
+		return
+	}
+
+	added, err := h.albumService.AddPhotos(c.Request.Context(), id, photoIDs)
+	if err != nil {
+		respondError(c, err, "Failed to add photos to album")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"added": added})
+}
+
+// RemovePhotos handles requests to remove photos from an album
+func (h *AlbumHandler) RemovePhotos(c *gin.Context) {
+	id, photoIDs, ok := parseAlbumPhotos(c)
+	if !ok {
+		return
+	}
+
+	removed, err := h.albumService.RemovePhotos(c.Request.Context(), id, photoIDs)
+	if err != nil {
+		respondError(c, err, "Failed to remove photos from album")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"removed": removed})
+}
+
+// ListMembers handles requests to list the users an album is shared with
+func (h *AlbumHandler) ListMembers(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	members, err := h.albumService.ListMembers(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "Failed to list album members")
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.AlbumMemberListResponse{Members: toAlbumMemberResponses(members)})
+}
+
+// UpdateMember handles requests to change the role of an album member
+func (h *AlbumHandler) UpdateMember(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateAlbumMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request data: %v", err)})
+		return
+	}
+
+	member, err := h.albumService.UpdateMemberRole(c.Request.Context(), id, c.Param("user_id"), models.AlbumRole(req.Role))
+	if err != nil {
+		respondError(c, err, "Failed to update album member")
+		return
+	}
+
+	c.JSON(http.StatusOK, toAlbumMemberResponse(*member))
+}
+
+// RemoveMember handles requests to stop sharing an album with a user, or to leave a shared album
+func (h *AlbumHandler) RemoveMember(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.albumService.RemoveMember(c.Request.Context(), id, c.Param("user_id")); err != nil {
+		respondError(c, err, "Failed to remove album member")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// InviteMember handles requests to invite a user to an album
+func (h *AlbumHandler) InviteMember(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req dto.InviteAlbumMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request data: %v", err)})
+		return
+	}
+
+	invitation, err := h.albumService.InviteMember(c.Request.Context(), id, req.Username, req.Email, models.AlbumRole(req.Role))
+	if err != nil {
+		respondError(c, err, "Failed to invite album member")
+		return
+	}
+
+	c.JSON(http.StatusCreated, toAlbumInvitationResponse(invitation))
+}
+
+// ListAlbumInvitations handles requests to list the pending invitations of an album
+func (h *AlbumHandler) ListAlbumInvitations(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	invitations, err := h.albumService.ListAlbumInvitations(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "Failed to list album invitations")
+		return
+	}
+
+	c.JSON(http.StatusOK, toAlbumInvitationListResponse(invitations))
+}
+
+// CancelInvitation handles requests to withdraw a pending album invitation
+func (h *AlbumHandler) CancelInvitation(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+	invitationID, ok := parseObjectID(c, "invitation_id")
+	if !ok {
+		return
+	}
+
+	if err := h.albumService.CancelInvitation(c.Request.Context(), id, invitationID); err != nil {
+		respondError(c, err, "Failed to cancel invitation")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListMyInvitations handles requests to list the pending invitations addressed to the caller
+func (h *AlbumHandler) ListMyInvitations(c *gin.Context) {
+	invitations, err := h.albumService.ListMyInvitations(c.Request.Context())
+	if err != nil {
+		respondError(c, err, "Failed to list invitations")
+		return
+	}
+
+	c.JSON(http.StatusOK, toAlbumInvitationListResponse(invitations))
+}
+
+// AcceptInvitation handles requests to accept an album invitation
+func (h *AlbumHandler) AcceptInvitation(c *gin.Context) {
+	h.respondToInvitation(c, true)
+}
+
+// DeclineInvitation handles requests to decline an album invitation
+func (h *AlbumHandler) DeclineInvitation(c *gin.Context) {
+	h.respondToInvitation(c, false)
+}
+
+func (h *AlbumHandler) respondToInvitation(c *gin.Context, accept bool) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	invitation, err := h.albumService.RespondToInvitation(c.Request.Context(), id, accept)
+	if err != nil {
+		respondError(c, err, "Failed to answer invitation")
+		return
+	}
+
+	c.JSON(http.StatusOK, toAlbumInvitationResponse(invitation))
+}
+
+// parseAlbumPhotos reads the album ID and the photo IDs of the request body,
+// writing a 400 response if either is invalid
+func parseAlbumPhotos(c *gin.Context) (primitive.ObjectID, []primitive.ObjectID, bool) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return primitive.NilObjectID, nil, false
+	}
+
+	var req dto.AlbumPhotosRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request data: %v", err)})
+		return primitive.NilObjectID, nil, false
+	}
+
+	photoIDs, ok := parseObjectIDs(c, req.PhotoIDs)
+	if !ok {
+		return primitive.NilObjectID, nil, false
+	}
+	return id, photoIDs, true
+}
+
+func toAlbumResponse(album *models.Album) dto.AlbumResponse {
+	return dto.AlbumResponse{
+		ID:          album.ID,
+		OwnerID:     album.OwnerID,
+		Name:        album.Name,
+		Description: album.Description,
+		Members:     toAlbumMemberResponses(album.Members),
+		CreatedAt:   album.CreatedAt,
+		UpdatedAt:   album.UpdatedAt,
+	}
+}
+
+func toAlbumListResponse(albums []models.Album, page, limit int) dto.AlbumListResponse {
+	response := dto.AlbumListResponse{
+		Albums: make([]dto.AlbumResponse, 0, len(albums)),
+		Page:   page,
+		Limit:  limit,
+	}
+	for i := range albums {
+		response.Albums = append(response.Albums, toAlbumResponse(&albums[i]))
+	}
+	return response
+}
+
+func toAlbumMemberResponses(members []models.AlbumMember) []dto.AlbumMemberResponse {
+	responses := make([]dto.AlbumMemberResponse, 0, len(members))
+	for _, member := range members {
+		responses = append(responses, toAlbumMemberResponse(member))
+	}
+	return responses
+}
+
+func toAlbumMemberResponse(member models.AlbumMember) dto.AlbumMemberResponse {
+	return dto.AlbumMemberResponse{
+		UserID:   member.UserID,
+		Username: member.Username,
+		Role:     string(member.Role),
+		AddedAt:  member.AddedAt,
+	}
+}
+
+func toAlbumInvitationListResponse(invitations []models.AlbumInvitation) dto.AlbumInvitationListResponse {
+	response := dto.AlbumInvitationListResponse{
+		Invitations: make([]dto.AlbumInvitationResponse, 0, len(invitations)),
+	}
+	for i := range invitations {
+		response.Invitations = append(response.Invitations, toAlbumInvitationResponse(&invitations[i]))
+	}
+	return response
+}
+
+func toAlbumInvitationResponse(invitation *models.AlbumInvitation) dto.AlbumInvitationResponse {
+	return dto.AlbumInvitationResponse{
+		ID:          invitation.ID,
+		AlbumID:     invitation.AlbumID,
+		AlbumName:   invitation.AlbumName,
+		InviterID:   invitation.InviterID,
+		Username:    invitation.Username,
+		Email:       invitation.Email,
+		Role:        string(invitation.Role),
+		Status:      string(invitation.Status),
+		CreatedAt:   invitation.CreatedAt,
+		RespondedAt: invitation.RespondedAt,
+	}
+}
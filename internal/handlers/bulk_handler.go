@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"photocloud/internal/domain/dto"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type BulkHandler struct {
+	bulkService services.BulkService
+}
+
+func NewBulkHandler(bulkService services.BulkService) *BulkHandler {
+	return &BulkHandler{
+		bulkService: bulkService,
+	}
+}
+
+// CreateJob handles requests to run an action on many photos in the background
+func (h *BulkHandler) CreateJob(c *gin.Context) {
+	var req dto.CreateBulkJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request data: %v", err)})
+		return
+	}
+
+	bulk := services.BulkRequest{
+		Action:     models.BulkAction(req.Action),
+		AddTags:    req.AddTags,
+		RemoveTags: req.RemoveTags,
+	}
+	var ok bool
+	if bulk.PhotoIDs, ok = parseObjectIDs(c, req.PhotoIDs); !ok {
+		return
+	}
+	if bulk.AlbumID, ok = parseOptionalObjectID(c, "album_id", req.AlbumID); !ok {
+		return
+	}
+	if req.Filter != nil {
+		bulk.Filter = &models.PhotoFilter{
+			Tags:           req.Filter.Tags,
+			UploadedAfter:  req.Filter.UploadedAfter,
+			UploadedBefore: req.Filter.UploadedBefore,
+		}
+		if bulk.Filter.AlbumID, ok = parseOptionalObjectID(c, "filter.album_id", req.Filter.AlbumID); !ok {
+			return
+		}
+	}
+
+	job, err := h.bulkService.CreateJob(c.Request.Context(), bulk)
+	if err != nil {
+		respondError(c, err, "Failed to create bulk job")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, toBulkJobResponse(job))
+}
+
+// ListJobs handles requests to list the caller's bulk jobs
+func (h *BulkHandler) ListJobs(c *gin.Context) {
+	page, limit := parsePagination(c)
+
+	jobs, err := h.bulkService.ListJobs(c.Request.Context(), page, limit)
+	if err != nil {
+		respondError(c, err, "Failed to list bulk jobs")
+		return
+	}
+
+	response := dto.BulkJobListResponse{
+		Jobs:  make([]dto.BulkJobResponse, 0, len(jobs)),
+		Page:  page,
+		Limit: limit,
+	}
+	for i := range jobs {
+		response.Jobs = append(response.Jobs, toBulkJobResponse(&jobs[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetJob handles requests for the progress of a bulk job
+func (h *BulkHandler) GetJob(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	job, err := h.bulkService.GetJob(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "Failed to get bulk job")
+		return
+	}
+
+	c.JSON(http.StatusOK, toBulkJobResponse(job))
+}
+
+// parseOptionalObjectID reads an ObjectID from a request field that may be empty,
+// writing a 400 response if it is invalid
+func parseOptionalObjectID(c *gin.Context, field, value string) (primitive.ObjectID, bool) {
+	if value == "" {
+		return primitive.NilObjectID, true
+	}
+	id, err := primitive.ObjectIDFromHex(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid %s: %s", field, value)})
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+func toBulkJobResponse(job *models.BulkJob) dto.BulkJobResponse {
+	response := dto.BulkJobResponse{
+		ID:         job.ID.Hex(),
+		Action:     string(job.Action),
+		Status:     string(job.Status),
+		AddTags:    job.AddTags,
+		RemoveTags: job.RemoveTags,
+		Total:      job.Total,
+		Processed:  job.Processed,
+		Succeeded:  job.Succeeded,
+		Failed:     job.Failed,
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+	}
+	if !job.AlbumID.IsZero() {
+		response.AlbumID = job.AlbumID.Hex()
+	}
+	if job.Filter != nil {
+		response.Filter = &dto.PhotoFilterResponse{
+			Tags:           job.Filter.Tags,
+			UploadedAfter:  job.Filter.UploadedAfter,
+			UploadedBefore: job.Filter.UploadedBefore,
+		}
+		if !job.Filter.AlbumID.IsZero() {
+			response.Filter.AlbumID = job.Filter.AlbumID.Hex()
+		}
+	}
+	for _, failure := range job.Failures {
+		response.Failures = append(response.Failures, dto.BulkFailureResponse{
+			PhotoID: failure.PhotoID.Hex(),
+			Error:   failure.Error,
+		})
+	}
+	return response
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"photocloud/internal/domain/dto"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/services"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// sseRetry is how long EventSource clients wait before reconnecting
+	sseRetry = 3 * time.Second
+	// eventHeartbeatInterval is how often idle streams are sent a keep-alive, so that
+	// proxies keep them open and dead connections are noticed
+	eventHeartbeatInterval = 30 * time.Second
+)
+
+type EventHandler struct {
+	eventService services.EventService
+	userService  services.UserService
+}
+
+func NewEventHandler(eventService services.EventService, userService services.UserService) *EventHandler {
+	return &EventHandler{
+		eventService: eventService,
+		userService:  userService,
+	}
+}
+
+// IssueTicket handles requests for a single-use ticket that opens one event stream,
+// for browsers that cannot send the API token in a header
+func (h *EventHandler) IssueTicket(c *gin.Context) {
+	ticket, expires, err := h.userService.IssueStreamTicket(c.Request.Context())
+	if err != nil {
+		respondError(c, err, "Failed to issue stream ticket")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"ticket":     ticket,
+		"expires_at": expires,
+	})
+}
+
+// Stream handles requests for the caller's events as Server-Sent Events. The stream
+// ends when the client falls behind; clients reconnect and refetch what they show.
+func (h *EventHandler) Stream(c *gin.Context) {
+	ctx := c.Request.Context()
+	subscription, err := h.eventService.Subscribe(ctx)
+	if err != nil {
+		respondError(c, err, "Failed to subscribe to events")
+		return
+	}
+	defer subscription.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Proxies must pass events on as they come
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", sseRetry.Milliseconds())
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(toEventResponse(event))
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", event.ID.Hex(), event.Type, data)
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": keep-alive\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+// WebSocket handles requests for the caller's events over a WebSocket, one JSON
+// message per event. Messages from the client are ignored.
+func (h *EventHandler) WebSocket(c *gin.Context) {
+	subscription, err := h.eventService.Subscribe(c.Request.Context())
+	if err != nil {
+		respondError(c, err, "Failed to subscribe to events")
+		return
+	}
+	defer subscription.Close()
+
+	// The API token authorizes the connection, so requests from any origin are accepted
+	server := websocket.Server{Handler: func(conn *websocket.Conn) {
+		h.serveWebSocket(conn, subscription)
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func (h *EventHandler) serveWebSocket(conn *websocket.Conn, subscription *services.EventSubscription) {
+	// Reading answers the client's pings and notices when it goes away
+	gone := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.Discard, conn)
+		close(gone)
+	}()
+
+	heartbeat := time.NewTicker(eventHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-gone:
+			return
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return
+			}
+			if err := websocket.JSON.Send(conn, toEventResponse(event)); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			conn.PayloadType = websocket.PingFrame
+			if _, err := conn.Write(nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func toEventResponse(event *models.Event) dto.EventResponse {
+	response := dto.EventResponse{
+		ID:        event.ID.Hex(),
+		Type:      string(event.Type),
+		URL:       event.URL,
+		ExpiresAt: event.ExpiresAt,
+		Error:     event.Error,
+		ActorID:   event.ActorID,
+		Timestamp: event.Timestamp,
+	}
+	if !event.PhotoID.IsZero() {
+		response.PhotoID = event.PhotoID.Hex()
+	}
+	if !event.AlbumID.IsZero() {
+		response.AlbumID = event.AlbumID.Hex()
+	}
+	if !event.ExportID.IsZero() {
+		response.ExportID = event.ExportID.Hex()
+	}
+	return response
+}
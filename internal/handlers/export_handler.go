@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+
+	"photocloud/internal/domain/dto"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportHandler struct {
+	exportService services.ExportService
+}
+
+func NewExportHandler(exportService services.ExportService) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+	}
+}
+
+// CreateExport handles requests for a ZIP archive of photos. Small exports are streamed
+// in the response; larger ones are queued as a job and answered with 202 Accepted.
+func (h *ExportHandler) CreateExport(c *gin.Context) {
+	var req dto.CreateExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request data: %v", err)})
+		return
+	}
+
+	export := services.ExportRequest{
+		Manifest: req.Manifest,
+		Async:    req.Async,
+	}
+	var ok bool
+	if export.PhotoIDs, ok = parseObjectIDs(c, req.PhotoIDs); !ok {
+		return
+	}
+	if export.AlbumID, ok = parseOptionalObjectID(c, "album_id", req.AlbumID); !ok {
+		return
+	}
+	if req.Rendition != nil {
+		export.Rendition = &models.ExportRendition{
+			Width:   req.Rendition.Width,
+			Height:  req.Rendition.Height,
+			Fit:     req.Rendition.Fit,
+			Format:  req.Rendition.Format,
+			Quality: req.Rendition.Quality,
+		}
+	}
+
+	archive, job, err := h.exportService.Export(c.Request.Context(), export)
+	if err != nil {
+		respondError(c, err, "Failed to export photos")
+		return
+	}
+	if job != nil {
+		c.JSON(http.StatusAccepted, toExportJobResponse(job, ""))
+		return
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "application/zip")
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": archive.Name}))
+	header.Set("Cache-Control", "private, no-store")
+	c.Status(http.StatusOK)
+	if err := archive.WriteZip(c.Writer); err != nil {
+		// The response has started, so the archive is cut short and clients find it incomplete
+		c.Error(fmt.Errorf("failed to write export archive: %w", err))
+	}
+}
+
+// ExportAccount handles requests for an archive of all the caller's data. The archive is
+// written by a job, answered with 202 Accepted, that tells the caller when it finishes.
+func (h *ExportHandler) ExportAccount(c *gin.Context) {
+	job, err := h.exportService.ExportAccount(c.Request.Context())
+	if err != nil {
+		respondError(c, err, "Failed to export account")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, toExportJobResponse(job, ""))
+}
+
+// ListExports handles requests to list the caller's export jobs
+func (h *ExportHandler) ListExports(c *gin.Context) {
+	page, limit := parsePagination(c)
+
+	jobs, err := h.exportService.ListJobs(c.Request.Context(), page, limit)
+	if err != nil {
+		respondError(c, err, "Failed to list exports")
+		return
+	}
+
+	response := dto.ExportJobListResponse{
+		Jobs:  make([]dto.ExportJobResponse, 0, len(jobs)),
+		Page:  page,
+		Limit: limit,
+	}
+	for i := range jobs {
+		response.Jobs = append(response.Jobs, toExportJobResponse(&jobs[i], ""))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetExport handles requests for the progress of an export job and the link to its archive
+func (h *ExportHandler) GetExport(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	job, url, err := h.exportService.GetJob(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "Failed to get export")
+		return
+	}
+
+	c.JSON(http.StatusOK, toExportJobResponse(job, url))
+}
+
+func toExportJobResponse(job *models.ExportJob, url string) dto.ExportJobResponse {
+	response := dto.ExportJobResponse{
+		ID:         job.ID.Hex(),
+		Kind:       string(models.ExportKindPhotos),
+		Name:       job.Name,
+		Manifest:   job.Manifest,
+		Status:     string(job.Status),
+		Total:      job.Total,
+		Processed:  job.Processed,
+		Failed:     job.Failed,
+		Error:      job.Error,
+		Size:       job.Size,
+		URL:        url,
+		CreatedAt:  job.CreatedAt,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+		ExpiresAt:  job.ExpiresAt,
+	}
+	if job.IsAccountExport() {
+		response.Kind = string(models.ExportKindAccount)
+	}
+	if !job.AlbumID.IsZero() {
+		response.AlbumID = job.AlbumID.Hex()
+	}
+	if job.Rendition != nil {
+		response.Rendition = &dto.ExportRenditionResponse{
+			Width:   job.Rendition.Width,
+			Height:  job.Rendition.Height,
+			Fit:     job.Rendition.Fit,
+			Format:  job.Rendition.Format,
+			Quality: job.Rendition.Quality,
+		}
+	}
+	for _, failure := range job.Failures {
+		response.Failures = append(response.Failures, dto.BulkFailureResponse{
+			PhotoID: failure.PhotoID.Hex(),
+			Error:   failure.Error,
+		})
+	}
+	return response
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"photocloud/internal/domain/repositories"
+	"photocloud/internal/domain/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// parsePagination reads the page and limit query parameters, applying defaults and bounds
+func parsePagination(c *gin.Context) (int, int) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	return page, limit
+}
+
+// parseObjectID reads a path parameter as an ObjectID, writing a 400 response if it is invalid
+func parseObjectID(c *gin.Context, param string) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param(param))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid %s: %s", param, c.Param(param))})
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+// parseObjectIDs converts hex IDs from a request body, writing a 400 response if any is invalid
+func parseObjectIDs(c *gin.Context, hexIDs []string) ([]primitive.ObjectID, bool) {
+	ids := make([]primitive.ObjectID, 0, len(hexIDs))
+	for _, hexID := range hexIDs {
+		id, err := primitive.ObjectIDFromHex(hexID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid photo ID: %s", hexID)})
+			return nil, false
+		}
+		ids = append(ids, id)
+	}
+	return ids, true
+}
+
+// openValidatedFile opens the upload accepted by the FileValidator middleware,
+// writing an error response if there is none
+func openValidatedFile(c *gin.Context) (*multipart.FileHeader, multipart.File, bool) {
+	// Get validated file from context
+	fileHeader, exists := c.Get("validatedFile")
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No validated file found"})
+		return nil, nil, false
+	}
+
+	// Type assert the file header
+	file, ok := fileHeader.(*multipart.FileHeader)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid file data"})
+		return nil, nil, false
+	}
+
+	// Open the file
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to open file: %v", err)})
+		return nil, nil, false
+	}
+	return file, src, true
+}
+
+// respondError writes an error response, choosing the status code from known service errors
+func respondError(c *gin.Context, err error, message string) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrPhotoNotFound), errors.Is(err, services.ErrVersionNotFound),
+		errors.Is(err, services.ErrAlbumNotFound), errors.Is(err, services.ErrShareNotFound),
+		errors.Is(err, services.ErrMemberNotFound), errors.Is(err, services.ErrInvitationNotFound),
+		errors.Is(err, services.ErrWebhookNotFound), errors.Is(err, services.ErrDeliveryNotFound),
+		errors.Is(err, services.ErrBulkJobNotFound), errors.Is(err, services.ErrExportNotFound),
+		errors.Is(err, services.ErrImportNotFound), errors.Is(err, repositories.ErrFileNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrShareExpired), errors.Is(err, services.ErrSyncTokenExpired):
+		status = http.StatusGone
+	case errors.Is(err, services.ErrPhotoNotInTrash), errors.Is(err, services.ErrConflict):
+		status = http.StatusConflict
+	case errors.Is(err, services.ErrInvalidArgument):
+		status = http.StatusBadRequest
+	case errors.Is(err, services.ErrUnauthorized), errors.Is(err, services.ErrPasswordRequired):
+		status = http.StatusUnauthorized
+	case errors.Is(err, services.ErrInvalidSignature), errors.Is(err, services.ErrForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, services.ErrUploadTooLarge), errors.Is(err, services.ErrArchiveTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, services.ErrQuotaExceeded):
+		status = http.StatusInsufficientStorage
+	case errors.Is(err, services.ErrTransformsDisabled):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, services.ErrBusy):
+		status = http.StatusServiceUnavailable
+		c.Header("Retry-After", "5")
+	}
+
+	c.JSON(status, gin.H{"error": fmt.Sprintf("%s: %v", message, err)})
+}
+
+// serveFileContent streams stored content with its validators so that range
+// and conditional requests are handled, including multi-range responses
+func serveFileContent(c *gin.Context, content *services.FileContent) {
+	header := c.Writer.Header()
+	header.Set("Content-Type", content.Info.ContentType)
+	header.Set("Cache-Control", "private, no-cache")
+	if content.Info.ETag != "" {
+		header.Set("ETag", content.Info.ETag)
+	}
+	if content.Name != "" {
+		header.Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": content.Name}))
+	}
+
+	http.ServeContent(c.Writer, c.Request, content.Name, content.Info.LastModified, content)
+}
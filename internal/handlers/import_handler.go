@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+
+	"photocloud/internal/domain/dto"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ImportHandler struct {
+	importService services.ImportService
+}
+
+func NewImportHandler(importService services.ImportService) *ImportHandler {
+	return &ImportHandler{
+		importService: importService,
+	}
+}
+
+// CreateImport handles uploads of an export archive to import. The archive is the raw
+// request body, described by the source and name query parameters, and is imported by
+// a job answered with 202 Accepted.
+func (h *ImportHandler) CreateImport(c *gin.Context) {
+	source := models.ImportSource(c.Query("source"))
+	job, err := h.importService.CreateJob(c.Request.Context(), source, c.DefaultQuery("name", "archive.zip"), c.Request.Body)
+	if err != nil {
+		respondError(c, err, "Failed to import archive")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, toImportJobResponse(job))
+}
+
+// ListImports handles requests to list the caller's import jobs
+func (h *ImportHandler) ListImports(c *gin.Context) {
+	page, limit := parsePagination(c)
+
+	jobs, err := h.importService.ListJobs(c.Request.Context(), page, limit)
+	if err != nil {
+		respondError(c, err, "Failed to list imports")
+		return
+	}
+
+	response := dto.ImportJobListResponse{
+		Jobs:  make([]dto.ImportJobResponse, 0, len(jobs)),
+		Page:  page,
+		Limit: limit,
+	}
+	for i := range jobs {
+		response.Jobs = append(response.Jobs, toImportJobResponse(&jobs[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetImport handles requests for the progress of an import job
+func (h *ImportHandler) GetImport(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	job, err := h.importService.GetJob(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "Failed to get import")
+		return
+	}
+
+	c.JSON(http.StatusOK, toImportJobResponse(job))
+}
+
+// ListImportFiles handles requests for the outcomes of the files of an import job,
+// optionally only those with the status given in the query
+func (h *ImportHandler) ListImportFiles(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+	page, limit := parsePagination(c)
+
+	files, err := h.importService.ListFiles(c.Request.Context(), id, models.ImportFileStatus(c.Query("status")), page, limit)
+	if err != nil {
+		respondError(c, err, "Failed to list imported files")
+		return
+	}
+
+	response := dto.ImportFileListResponse{
+		Files: make([]dto.ImportFileResponse, 0, len(files)),
+		Page:  page,
+		Limit: limit,
+	}
+	for _, file := range files {
+		item := dto.ImportFileResponse{
+			Path:    file.Path,
+			Status:  string(file.Status),
+			Sidecar: file.Sidecar,
+			Error:   file.Error,
+		}
+		if !file.PhotoID.IsZero() {
+			item.PhotoID = file.PhotoID.Hex()
+		}
+		response.Files = append(response.Files, item)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func toImportJobResponse(job *models.ImportJob) dto.ImportJobResponse {
+	response := dto.ImportJobResponse{
+		ID:         job.ID.Hex(),
+		Source:     string(job.Source),
+		Name:       job.Name,
+		Size:       job.Size,
+		Status:     string(job.Status),
+		Total:      job.Total,
+		Processed:  job.Processed,
+		Imported:   job.Imported,
+		Duplicates: job.Duplicates,
+		Skipped:    job.Skipped,
+		Failed:     job.Failed,
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+	}
+	for _, album := range job.Albums {
+		response.Albums = append(response.Albums, dto.ImportAlbumResponse{Folder: album.Folder, AlbumID: album.AlbumID.Hex()})
+	}
+	return response
+}
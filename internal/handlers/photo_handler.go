@@ -1,23 +1,27 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
-	"mime/multipart"
 	"net/http"
 
 	"photocloud/internal/domain/dto"
+	"photocloud/internal/domain/models"
 	"photocloud/internal/domain/services"
+	"photocloud/internal/imaging"
 
 	"github.com/gin-gonic/gin"
 )
 
 type PhotoHandler struct {
-	photoService services.PhotoService
+	photoService     services.PhotoService
+	transformService services.TransformService
 }
 
-func NewPhotoHandler(photoService services.PhotoService) *PhotoHandler {
+func NewPhotoHandler(photoService services.PhotoService, transformService services.TransformService) *PhotoHandler {
 	return &PhotoHandler{
-		photoService: photoService,
+		photoService:     photoService,
+		transformService: transformService,
 	}
 }
 
@@ -29,24 +33,8 @@ func (h *PhotoHandler) UploadPhoto(c *gin.Context) {
 		return
 	}
 
-	// Get validated file from context
-	fileHeader, exists := c.Get("validatedFile")
-	if !exists {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No validated file found"})
-		return
-	}
-
-	// Type assert the file header
-	file, ok := fileHeader.(*multipart.FileHeader)
+	file, src, ok := openValidatedFile(c)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid file data"})
-		return
-	}
-
-	// Open the file
-	src, err := file.Open()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to open file: %v", err)})
 		return
 	}
 	defer src.Close()
@@ -61,7 +49,7 @@ func (h *PhotoHandler) UploadPhoto(c *gin.Context) {
 		file.Size,
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to upload photo: %v", err)})
+		respondError(c, err, "Failed to upload photo")
 		return
 	}
 
@@ -72,17 +60,283 @@ func (h *PhotoHandler) UploadPhoto(c *gin.Context) {
 		return
 	}
 
-	// Create response
-	response := dto.PhotoResponse{
+	c.JSON(http.StatusCreated, toPhotoResponse(photo, url))
+}
+
+// CheckUploaded handles requests to find out which files were already uploaded by the caller
+func (h *PhotoHandler) CheckUploaded(c *gin.Context) {
+	var req dto.CheckUploadedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request data: %v", err)})
+		return
+	}
+
+	files := make([]services.FileDigest, len(req.Files))
+	for i, file := range req.Files {
+		files[i] = services.FileDigest{SHA256: file.SHA256, Size: file.Size}
+	}
+
+	uploaded, err := h.photoService.CheckUploaded(c.Request.Context(), files)
+	if err != nil {
+		respondError(c, err, "Failed to check uploaded files")
+		return
+	}
+
+	response := dto.CheckUploadedResponse{Files: make([]dto.UploadedFileResponse, 0, len(uploaded))}
+	for _, file := range uploaded {
+		entry := dto.UploadedFileResponse{
+			SHA256:   file.SHA256,
+			Size:     file.Size,
+			Uploaded: !file.PhotoID.IsZero(),
+			Trashed:  file.Trashed,
+		}
+		if entry.Uploaded {
+			entry.PhotoID = file.PhotoID.Hex()
+		}
+		response.Files = append(response.Files, entry)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ListPhotos handles requests to list photos that are not in the trash
+func (h *PhotoHandler) ListPhotos(c *gin.Context) {
+	page, limit := parsePagination(c)
+
+	photos, err := h.photoService.ListPhotos(c.Request.Context(), page, limit)
+	if err != nil {
+		respondError(c, err, "Failed to list photos")
+		return
+	}
+
+	c.JSON(http.StatusOK, toPhotoListResponse(photos, page, limit))
+}
+
+// GetPhoto handles requests for a single photo's metadata and URL
+func (h *PhotoHandler) GetPhoto(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	photo, url, err := h.photoService.GetPhotoWithURL(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "Failed to get photo")
+		return
+	}
+
+	c.JSON(http.StatusOK, toPhotoResponse(photo, url))
+}
+
+// GetPhotoContent handles photo downloads. Range, If-None-Match and
+// If-Modified-Since requests are answered by http.ServeContent. Unless
+// original=true is given, the photo's edits are applied and it is converted to
+// a better format the client accepts, falling back to the original if
+// rendering is not possible.
+func (h *PhotoHandler) GetPhotoContent(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	if c.Query("original") != "true" {
+		c.Header("Vary", "Accept")
+
+		content, err := h.transformService.RenderPhoto(c.Request.Context(), id, c.GetHeader("Accept"))
+		if errors.Is(err, services.ErrPhotoNotFound) {
+			respondError(c, err, "Failed to get photo content")
+			return
+		}
+		if err == nil && content != nil {
+			defer content.Close()
+			serveFileContent(c, content)
+			return
+		}
+	}
+
+	content, err := h.photoService.GetPhotoContent(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "Failed to get photo content")
+		return
+	}
+	defer content.Close()
+
+	serveFileContent(c, content)
+}
+
+// RotatePhoto handles requests to rotate or flip a photo
+func (h *PhotoHandler) RotatePhoto(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req dto.RotatePhotoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request data: %v", err)})
+		return
+	}
+
+	photo, err := h.photoService.RotatePhoto(c.Request.Context(), id, req.Degrees, imaging.Flip(req.Flip))
+	if err != nil {
+		respondError(c, err, "Failed to rotate photo")
+		return
+	}
+
+	c.JSON(http.StatusOK, toPhotoResponse(photo, ""))
+}
+
+// UpdateEdits handles requests to replace a photo's edit recipe
+func (h *PhotoHandler) UpdateEdits(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateEditsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request data: %v", err)})
+		return
+	}
+
+	photo, err := h.photoService.UpdateEdits(c.Request.Context(), id, req.Operations)
+	if err != nil {
+		respondError(c, err, "Failed to update edits")
+		return
+	}
+
+	c.JSON(http.StatusOK, toPhotoResponse(photo, ""))
+}
+
+// ResetEdits handles requests to discard a photo's edit recipe
+func (h *PhotoHandler) ResetEdits(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	photo, err := h.photoService.UpdateEdits(c.Request.Context(), id, nil)
+	if err != nil {
+		respondError(c, err, "Failed to reset edits")
+		return
+	}
+
+	c.JSON(http.StatusOK, toPhotoResponse(photo, ""))
+}
+
+// ExportPhoto handles requests to save an edited copy of a photo as a new photo
+func (h *PhotoHandler) ExportPhoto(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req dto.ExportPhotoRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request data: %v", err)})
+			return
+		}
+	}
+
+	photo, err := h.transformService.ExportPhoto(c.Request.Context(), id, req.Name)
+	if err != nil {
+		respondError(c, err, "Failed to export photo")
+		return
+	}
+
+	c.JSON(http.StatusCreated, toPhotoResponse(photo, ""))
+}
+
+// DeletePhoto handles requests to move a photo to the trash
+func (h *PhotoHandler) DeletePhoto(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.photoService.DeletePhoto(c.Request.Context(), id); err != nil {
+		respondError(c, err, "Failed to delete photo")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListTrash handles requests to list photos in the trash
+func (h *PhotoHandler) ListTrash(c *gin.Context) {
+	page, limit := parsePagination(c)
+
+	photos, err := h.photoService.ListTrash(c.Request.Context(), page, limit)
+	if err != nil {
+		respondError(c, err, "Failed to list trash")
+		return
+	}
+
+	c.JSON(http.StatusOK, toPhotoListResponse(photos, page, limit))
+}
+
+// RestorePhoto handles requests to move a photo out of the trash
+func (h *PhotoHandler) RestorePhoto(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	photo, err := h.photoService.RestorePhoto(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "Failed to restore photo")
+		return
+	}
+
+	c.JSON(http.StatusOK, toPhotoResponse(photo, ""))
+}
+
+// PurgePhoto handles requests to permanently delete a photo in the trash
+func (h *PhotoHandler) PurgePhoto(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.photoService.PurgePhoto(c.Request.Context(), id); err != nil {
+		respondError(c, err, "Failed to permanently delete photo")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func toPhotoResponse(photo *models.Photo, url string) dto.PhotoResponse {
+	return dto.PhotoResponse{
 		ID:          photo.ID,
+		OwnerID:     photo.OwnerID,
 		Name:        photo.Name,
 		Description: photo.Description,
 		Size:        photo.Size,
 		ContentType: photo.ContentType,
+		ContentHash: photo.ContentHash,
+		Orientation: photo.Orientation,
+		Edits:       photo.Edits,
+		Version:     photo.Version,
+		AlbumIDs:    photo.AlbumIDs,
+		Tags:        photo.Tags,
+		TakenAt:     photo.TakenAt,
+		Location:    photo.Location,
 		URL:         url,
 		UploadedAt:  photo.UploadedAt,
 		UpdatedAt:   photo.UpdatedAt,
+		DeletedAt:   photo.DeletedAt,
 	}
+}
 
-	c.JSON(http.StatusCreated, response)
+func toPhotoListResponse(photos []models.Photo, page, limit int) dto.PhotoListResponse {
+	response := dto.PhotoListResponse{
+		Photos: make([]dto.PhotoResponse, 0, len(photos)),
+		Page:   page,
+		Limit:  limit,
+	}
+	for i := range photos {
+		response.Photos = append(response.Photos, toPhotoResponse(&photos[i], ""))
+	}
+	return response
 }
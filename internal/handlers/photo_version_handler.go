@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"photocloud/internal/domain/dto"
+	"photocloud/internal/domain/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UploadVersion handles requests to replace a photo's content with a new version
+func (h *PhotoHandler) UploadVersion(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	file, src, ok := openValidatedFile(c)
+	if !ok {
+		return
+	}
+	defer src.Close()
+
+	photo, err := h.photoService.UploadVersion(
+		c.Request.Context(),
+		id,
+		src,
+		file.Header.Get("Content-Type"),
+		file.Size,
+	)
+	if err != nil {
+		respondError(c, err, "Failed to upload version")
+		return
+	}
+
+	c.JSON(http.StatusCreated, toPhotoResponse(photo, ""))
+}
+
+// ListVersions handles requests to list the kept versions of a photo
+func (h *PhotoHandler) ListVersions(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	versions, err := h.photoService.ListVersions(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "Failed to list versions")
+		return
+	}
+
+	c.JSON(http.StatusOK, toPhotoVersionListResponse(versions))
+}
+
+// GetVersionContent handles downloads of a specific photo version. Like the
+// original download it answers range and conditional requests.
+func (h *PhotoHandler) GetVersionContent(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+	number, ok := parseVersionNumber(c)
+	if !ok {
+		return
+	}
+
+	content, err := h.photoService.GetVersionContent(c.Request.Context(), id, number)
+	if err != nil {
+		respondError(c, err, "Failed to get version content")
+		return
+	}
+	defer content.Close()
+
+	serveFileContent(c, content)
+}
+
+// RevertVersion handles requests to make a previous version current again
+func (h *PhotoHandler) RevertVersion(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+	number, ok := parseVersionNumber(c)
+	if !ok {
+		return
+	}
+
+	photo, err := h.photoService.RevertPhoto(c.Request.Context(), id, number)
+	if err != nil {
+		respondError(c, err, "Failed to revert photo")
+		return
+	}
+
+	c.JSON(http.StatusOK, toPhotoResponse(photo, ""))
+}
+
+// parseVersionNumber reads the version path parameter, writing a 400 response if it is invalid
+func parseVersionNumber(c *gin.Context) (int, bool) {
+	number, err := strconv.Atoi(c.Param("version"))
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid version: %s", c.Param("version"))})
+		return 0, false
+	}
+	return number, true
+}
+
+func toPhotoVersionListResponse(versions []models.PhotoVersion) dto.PhotoVersionListResponse {
+	response := dto.PhotoVersionListResponse{
+		Versions: make([]dto.PhotoVersionResponse, 0, len(versions)),
+	}
+	for i, version := range versions {
+		response.Versions = append(response.Versions, dto.PhotoVersionResponse{
+			Number:       version.Number,
+			Size:         version.Size,
+			ContentType:  version.ContentType,
+			Hash:         version.Hash,
+			Author:       version.Author,
+			RevertedFrom: version.RevertedFrom,
+			Current:      i == len(versions)-1,
+			CreatedAt:    version.CreatedAt,
+		})
+	}
+	return response
+}
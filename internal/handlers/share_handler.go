@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"photocloud/internal/domain/dto"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// sharePasswordHeader carries the password of a protected share link
+const sharePasswordHeader = "X-Share-Password"
+
+type ShareHandler struct {
+	shareService services.ShareService
+}
+
+func NewShareHandler(shareService services.ShareService) *ShareHandler {
+	return &ShareHandler{
+		shareService: shareService,
+	}
+}
+
+// CreateShare handles requests to create a public link to a photo or album
+func (h *ShareHandler) CreateShare(c *gin.Context) {
+	var req dto.CreateShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request data: %v", err)})
+		return
+	}
+
+	var targetType models.ShareTargetType
+	var targetHex string
+	switch {
+	case req.PhotoID != "" && req.AlbumID == "":
+		targetType, targetHex = models.ShareTargetPhoto, req.PhotoID
+	case req.AlbumID != "" && req.PhotoID == "":
+		targetType, targetHex = models.ShareTargetAlbum, req.AlbumID
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exactly one of photo_id and album_id is required"})
+		return
+	}
+	targetID, err := primitive.ObjectIDFromHex(targetHex)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid %s ID: %s", targetType, targetHex)})
+		return
+	}
+
+	link, token, err := h.shareService.CreateShare(c.Request.Context(), targetType, targetID, req.ExpiresAt, req.Password, req.AllowDownload)
+	if err != nil {
+		respondError(c, err, "Failed to create share link")
+		return
+	}
+
+	response := toShareLinkResponse(link)
+	response.Token = token
+	response.URL = "/api/v1/s/" + token
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListShares handles requests to list the caller's share links
+func (h *ShareHandler) ListShares(c *gin.Context) {
+	page, limit := parsePagination(c)
+
+	links, err := h.shareService.ListShares(c.Request.Context(), page, limit)
+	if err != nil {
+		respondError(c, err, "Failed to list share links")
+		return
+	}
+
+	response := dto.ShareLinkListResponse{
+		Shares: make([]dto.ShareLinkResponse, 0, len(links)),
+		Page:   page,
+		Limit:  limit,
+	}
+	for i := range links {
+		response.Shares = append(response.Shares, toShareLinkResponse(&links[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeShare handles requests to disable a share link
+func (h *ShareHandler) RevokeShare(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.shareService.RevokeShare(c.Request.Context(), id); err != nil {
+		respondError(c, err, "Failed to revoke share link")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// OpenShare handles anonymous requests for the content of a share link
+func (h *ShareHandler) OpenShare(c *gin.Context) {
+	page, limit := parsePagination(c)
+
+	content, err := h.shareService.OpenShare(c.Request.Context(), c.Param("token"), c.GetHeader(sharePasswordHeader), page, limit)
+	if err != nil {
+		respondError(c, err, "Failed to open share link")
+		return
+	}
+
+	response := dto.SharedContentResponse{
+		TargetType:    string(content.Link.TargetType),
+		AllowDownload: content.Link.AllowDownload,
+		ExpiresAt:     content.Link.ExpiresAt,
+		Photos:        make([]dto.SharedPhotoResponse, 0, len(content.Photos)),
+		Page:          page,
+		Limit:         limit,
+	}
+	if content.Album != nil {
+		response.Album = &dto.SharedAlbumResponse{
+			Name:        content.Album.Name,
+			Description: content.Album.Description,
+		}
+	}
+	for _, photo := range content.Photos {
+		response.Photos = append(response.Photos, dto.SharedPhotoResponse{
+			ID:          photo.ID,
+			Name:        photo.Name,
+			Description: photo.Description,
+			ContentType: photo.ContentType,
+			Size:        photo.Size,
+			UploadedAt:  photo.UploadedAt,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetSharedPhotoURLs handles anonymous requests for storage URLs of a shared photo
+func (h *ShareHandler) GetSharedPhotoURLs(c *gin.Context) {
+	photoID, ok := parseObjectID(c, "photo_id")
+	if !ok {
+		return
+	}
+
+	urls, err := h.shareService.SharedPhotoURLs(c.Request.Context(), c.Param("token"), c.GetHeader(sharePasswordHeader), photoID)
+	if err != nil {
+		respondError(c, err, "Failed to get shared photo")
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SharedPhotoURLsResponse{
+		ViewURL:     urls.ViewURL,
+		DownloadURL: urls.DownloadURL,
+		ExpiresAt:   urls.ExpiresAt,
+	})
+}
+
+func toShareLinkResponse(link *models.ShareLink) dto.ShareLinkResponse {
+	return dto.ShareLinkResponse{
+		ID:                link.ID,
+		TargetType:        string(link.TargetType),
+		TargetID:          link.TargetID,
+		AllowDownload:     link.AllowDownload,
+		PasswordProtected: link.HasPassword(),
+		ExpiresAt:         link.ExpiresAt,
+		ViewCount:         link.ViewCount,
+		LastViewedAt:      link.LastViewedAt,
+		RevokedAt:         link.RevokedAt,
+		CreatedAt:         link.CreatedAt,
+	}
+}
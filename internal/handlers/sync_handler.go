@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"photocloud/internal/domain/dto"
+	"photocloud/internal/domain/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultSyncBatchSize is the number of changes returned when no limit is given
+	defaultSyncBatchSize = 100
+	// maxSyncBatchSize bounds the number of changes returned at once
+	maxSyncBatchSize = 500
+)
+
+type SyncHandler struct {
+	syncService services.SyncService
+}
+
+func NewSyncHandler(syncService services.SyncService) *SyncHandler {
+	return &SyncHandler{
+		syncService: syncService,
+	}
+}
+
+// ListChanges handles requests for the caller's changes since a sync token
+func (h *SyncHandler) ListChanges(c *gin.Context) {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultSyncBatchSize
+	}
+	if limit > maxSyncBatchSize {
+		limit = maxSyncBatchSize
+	}
+
+	batch, err := h.syncService.ListChanges(c.Request.Context(), c.Query("since"), limit)
+	if err != nil {
+		respondError(c, err, "Failed to list changes")
+		return
+	}
+
+	response := dto.SyncChangesResponse{
+		Changes: make([]dto.SyncChangeResponse, 0, len(batch.Entries)),
+		Next:    batch.Next,
+		HasMore: batch.HasMore,
+	}
+	for _, entry := range batch.Entries {
+		change := dto.SyncChangeResponse{
+			Sequence: entry.Sequence,
+			Kind:     string(entry.Kind),
+			ID:       entry.ID.Hex(),
+			Deleted:  entry.Deleted,
+		}
+		if entry.Photo != nil {
+			photo := toPhotoResponse(entry.Photo, "")
+			change.Photo = &photo
+		}
+		if entry.Album != nil {
+			album := toAlbumResponse(entry.Album)
+			change.Album = &album
+		}
+		response.Changes = append(response.Changes, change)
+	}
+	c.JSON(http.StatusOK, response)
+}
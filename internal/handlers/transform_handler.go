@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"photocloud/internal/domain/services"
+	"photocloud/internal/imaging"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TransformHandler struct {
+	transformService services.TransformService
+}
+
+func NewTransformHandler(transformService services.TransformService) *TransformHandler {
+	return &TransformHandler{
+		transformService: transformService,
+	}
+}
+
+// TransformPhoto handles requests for a resized or converted photo. The query
+// must carry the signature and expiry issued by SignTransformURL for the same parameters.
+func (h *TransformHandler) TransformPhoto(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	opts, err := imaging.ParseOptions(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid transform parameters: %v", err)})
+		return
+	}
+
+	// Without an explicit format the output depends on the Accept header
+	if opts.Format == "" {
+		c.Header("Vary", "Accept")
+	}
+
+	// A missing or malformed expiry fails the signature check
+	exp, _ := strconv.ParseInt(c.Query("exp"), 10, 64)
+
+	content, err := h.transformService.TransformPhoto(c.Request.Context(), id, opts, c.Query("sig"), time.Unix(exp, 0), c.GetHeader("Accept"))
+	if err != nil {
+		respondError(c, err, "Failed to transform photo")
+		return
+	}
+	defer content.Close()
+
+	serveFileContent(c, content)
+}
+
+// SignTransformURL handles requests for a signed transform URL with the given parameters
+func (h *TransformHandler) SignTransformURL(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	opts, err := imaging.ParseOptions(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid transform parameters: %v", err)})
+		return
+	}
+
+	signature, expires, err := h.transformService.SignTransform(c.Request.Context(), id, opts)
+	if err != nil {
+		respondError(c, err, "Failed to sign transform URL")
+		return
+	}
+
+	query := opts.Query()
+	query.Set("exp", strconv.FormatInt(expires.Unix(), 10))
+	query.Set("sig", signature)
+	c.JSON(http.StatusOK, gin.H{
+		"url":        fmt.Sprintf("/api/v1/photos/%s/transform?%s", id.Hex(), query.Encode()),
+		"expires_at": expires,
+	})
+}
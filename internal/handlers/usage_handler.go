@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+
+	"photocloud/internal/domain/dto"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UsageHandler struct {
+	usageService services.UsageService
+}
+
+func NewUsageHandler(usageService services.UsageService) *UsageHandler {
+	return &UsageHandler{
+		usageService: usageService,
+	}
+}
+
+// GetUsage handles requests for the caller's storage usage and quota
+func (h *UsageHandler) GetUsage(c *gin.Context) {
+	report, err := h.usageService.GetUsage(c.Request.Context())
+	if err != nil {
+		respondError(c, err, "Failed to get storage usage")
+		return
+	}
+
+	usage := report.Usage
+	c.JSON(http.StatusOK, dto.UsageResponse{
+		Plan: report.Plan,
+		Quota: dto.QuotaResponse{
+			MaxBytes:  report.Quota.MaxBytes,
+			MaxPhotos: report.Quota.MaxPhotos,
+		},
+		UsedBytes:  usage.QuotaBytes(),
+		Photos:     usage.Photos,
+		Originals:  toUsageCounterResponse(usage.Originals),
+		Renditions: toUsageCounterResponse(usage.Renditions),
+		Versions:   toUsageCounterResponse(usage.Versions),
+		VerifiedAt: usage.VerifiedAt,
+	})
+}
+
+func toUsageCounterResponse(counter models.UsageCounter) dto.UsageCounterResponse {
+	return dto.UsageCounterResponse{
+		Bytes: counter.Bytes,
+		Files: counter.Files,
+	}
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"photocloud/internal/domain/dto"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhookService services.WebhookService
+}
+
+func NewWebhookHandler(webhookService services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// CreateWebhook handles requests to register a webhook
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req dto.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request data: %v", err)})
+		return
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(c.Request.Context(), req.URL, toEventTypes(req.Events), req.Description, req.Secret)
+	if err != nil {
+		respondError(c, err, "Failed to create webhook")
+		return
+	}
+
+	// The secret is shown once, so that it can be stored by the receiver
+	response := toWebhookResponse(webhook)
+	response.Secret = webhook.Secret
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListWebhooks handles requests to list the caller's webhooks
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	page, limit := parsePagination(c)
+
+	webhooks, err := h.webhookService.ListWebhooks(c.Request.Context(), page, limit)
+	if err != nil {
+		respondError(c, err, "Failed to list webhooks")
+		return
+	}
+
+	response := dto.WebhookListResponse{
+		Webhooks: make([]dto.WebhookResponse, 0, len(webhooks)),
+		Page:     page,
+		Limit:    limit,
+	}
+	for i := range webhooks {
+		response.Webhooks = append(response.Webhooks, toWebhookResponse(&webhooks[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetWebhook handles requests for a single webhook
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.GetWebhook(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, "Failed to get webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, toWebhookResponse(webhook))
+}
+
+// UpdateWebhook handles requests to change, disable or enable a webhook
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request data: %v", err)})
+		return
+	}
+	var events *[]models.EventType
+	if req.Events != nil {
+		eventTypes := toEventTypes(*req.Events)
+		events = &eventTypes
+	}
+
+	webhook, err := h.webhookService.UpdateWebhook(c.Request.Context(), id, req.URL, events, req.Description, req.Enabled)
+	if err != nil {
+		respondError(c, err, "Failed to update webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, toWebhookResponse(webhook))
+}
+
+// DeleteWebhook handles requests to delete a webhook
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(c.Request.Context(), id); err != nil {
+		respondError(c, err, "Failed to delete webhook")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries handles requests for the delivery log of a webhook
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+	page, limit := parsePagination(c)
+
+	deliveries, err := h.webhookService.ListDeliveries(c.Request.Context(), id, page, limit)
+	if err != nil {
+		respondError(c, err, "Failed to list webhook deliveries")
+		return
+	}
+
+	response := dto.WebhookDeliveryListResponse{
+		Deliveries: make([]dto.WebhookDeliveryResponse, 0, len(deliveries)),
+		Page:       page,
+		Limit:      limit,
+	}
+	for i := range deliveries {
+		response.Deliveries = append(response.Deliveries, toWebhookDeliveryResponse(&deliveries[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetDelivery handles requests for a single delivery of a webhook
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := parseObjectID(c, "delivery_id")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.GetDelivery(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		respondError(c, err, "Failed to get webhook delivery")
+		return
+	}
+
+	c.JSON(http.StatusOK, toWebhookDeliveryResponse(delivery))
+}
+
+// Redeliver handles requests to post a delivery again
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, ok := parseObjectID(c, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := parseObjectID(c, "delivery_id")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		respondError(c, err, "Failed to redeliver")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, toWebhookDeliveryResponse(delivery))
+}
+
+func toEventTypes(events []string) []models.EventType {
+	eventTypes := make([]models.EventType, len(events))
+	for i, eventType := range events {
+		eventTypes[i] = models.EventType(eventType)
+	}
+	return eventTypes
+}
+
+func toWebhookResponse(webhook *models.Webhook) dto.WebhookResponse {
+	events := make([]string, len(webhook.Events))
+	for i, eventType := range webhook.Events {
+		events[i] = string(eventType)
+	}
+	return dto.WebhookResponse{
+		ID:                  webhook.ID.Hex(),
+		URL:                 webhook.URL,
+		Events:              events,
+		Description:         webhook.Description,
+		Enabled:             webhook.DisabledAt == nil,
+		ConsecutiveFailures: webhook.ConsecutiveFailures,
+		DisabledAt:          webhook.DisabledAt,
+		DisabledReason:      webhook.DisabledReason,
+		CreatedAt:           webhook.CreatedAt,
+		UpdatedAt:           webhook.UpdatedAt,
+	}
+}
+
+func toWebhookDeliveryResponse(delivery *models.WebhookDelivery) dto.WebhookDeliveryResponse {
+	response := dto.WebhookDeliveryResponse{
+		ID:        delivery.ID.Hex(),
+		WebhookID: delivery.WebhookID.Hex(),
+		EventID:   delivery.EventID.Hex(),
+		EventType: string(delivery.EventType),
+		Status:    string(delivery.Status),
+		Payload:   delivery.Payload,
+		Attempts:  make([]dto.WebhookAttemptResponse, 0, len(delivery.Attempts)),
+		CreatedAt: delivery.CreatedAt,
+	}
+	if delivery.Status == models.WebhookDeliveryPending {
+		nextAttemptAt := delivery.NextAttemptAt
+		response.NextAttemptAt = &nextAttemptAt
+	}
+	if delivery.RedeliveryOf != nil {
+		response.RedeliveryOf = delivery.RedeliveryOf.Hex()
+	}
+	for _, attempt := range delivery.Attempts {
+		response.Attempts = append(response.Attempts, dto.WebhookAttemptResponse{
+			At:         attempt.At,
+			StatusCode: attempt.StatusCode,
+			Error:      attempt.Error,
+			Response:   attempt.Response,
+			DurationMS: attempt.Duration.Milliseconds(),
+		})
+	}
+	return response
+}
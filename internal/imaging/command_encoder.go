@@ -0,0 +1,89 @@
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// commandWaitDelay bounds how long a stopped encoder may keep its output open
+const commandWaitDelay = time.Second
+
+// CommandEncoder returns an encoder that runs an external program, for formats
+// such as WebP and AVIF that cannot be encoded in pure Go. The command line may
+// use the {input}, {output} and {quality} placeholders; the input is a PNG file,
+// for example "cwebp -quiet -q {quality} {input} -o {output}". The program is killed
+// when the context is done or after the timeout, whichever comes first.
+func CommandEncoder(commandLine string, timeout time.Duration) Encoder {
+	return func(ctx context.Context, w io.Writer, img image.Image, quality int) error {
+		dir, err := os.MkdirTemp("", "photocloud-encode-*")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		input := filepath.Join(dir, "input.png")
+		output := filepath.Join(dir, "output")
+		if err := writePNG(input, img); err != nil {
+			return err
+		}
+
+		replacer := strings.NewReplacer(
+			"{input}", input,
+			"{output}", output,
+			"{quality}", strconv.Itoa(quality),
+		)
+		args := strings.Fields(replacer.Replace(commandLine))
+		if len(args) == 0 {
+			return fmt.Errorf("encoder command is empty")
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Stderr = &stderr
+		cmd.WaitDelay = commandWaitDelay
+		if err := cmd.Run(); err != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("%s did not finish within %s", args[0], timeout)
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("%s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+		}
+
+		encoded, err := os.Open(output)
+		if err != nil {
+			return fmt.Errorf("%s produced no output: %w", args[0], err)
+		}
+		defer encoded.Close()
+
+		_, err = io.Copy(w, encoded)
+		return err
+	}
+}
+
+func writePNG(path string, img image.Image) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,238 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+)
+
+// EditKind names an edit operation
+type EditKind string
+
+const (
+	// EditCrop keeps the rectangle X, Y, Width, Height, given as fractions of the image
+	EditCrop EditKind = "crop"
+	// EditStraighten rotates by Angle degrees clockwise (-45 to 45) and crops away the corners
+	EditStraighten EditKind = "straighten"
+	// EditBrightness shifts brightness by Amount (-1 to 1)
+	EditBrightness EditKind = "brightness"
+	// EditContrast scales contrast by Amount (-1 to 1)
+	EditContrast EditKind = "contrast"
+	// EditSaturation scales saturation by Amount (-1 to 1)
+	EditSaturation EditKind = "saturation"
+	// EditGrayscale removes colour
+	EditGrayscale EditKind = "grayscale"
+	// EditSepia applies a sepia tone
+	EditSepia EditKind = "sepia"
+)
+
+// maxStraightenAngle is the largest straighten angle in either direction
+const maxStraightenAngle = 45
+
+// Edit is a single operation of an edit recipe
+type Edit struct {
+	Kind   EditKind
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+	Angle  float64
+	Amount float64
+}
+
+// ValidateEdits checks every operation of a recipe
+func ValidateEdits(edits []Edit) error {
+	for i, edit := range edits {
+		if err := edit.validate(); err != nil {
+			return fmt.Errorf("operation %d (%s): %w", i+1, edit.Kind, err)
+		}
+	}
+	return nil
+}
+
+func (e Edit) validate() error {
+	switch e.Kind {
+	case EditCrop:
+		if e.X < 0 || e.Y < 0 || e.Width <= 0 || e.Height <= 0 || e.X+e.Width > 1 || e.Y+e.Height > 1 {
+			return fmt.Errorf("crop rectangle must lie within the image, as fractions from 0 to 1")
+		}
+	case EditStraighten:
+		if math.Abs(e.Angle) > maxStraightenAngle {
+			return fmt.Errorf("angle must be between -%d and %d degrees", maxStraightenAngle, maxStraightenAngle)
+		}
+	case EditBrightness, EditContrast, EditSaturation:
+		if e.Amount < -1 || e.Amount > 1 {
+			return fmt.Errorf("amount must be between -1 and 1")
+		}
+	case EditGrayscale, EditSepia:
+	default:
+		return fmt.Errorf("unknown operation")
+	}
+	return nil
+}
+
+// ApplyEdits applies a recipe to an image in order
+func ApplyEdits(img image.Image, edits []Edit) image.Image {
+	if len(edits) == 0 {
+		return img
+	}
+
+	current := toNRGBA(img)
+	for _, edit := range edits {
+		switch edit.Kind {
+		case EditCrop:
+			current = crop(current, edit)
+		case EditStraighten:
+			current = straighten(current, edit.Angle)
+		case EditBrightness:
+			shift := edit.Amount * 255
+			mapChannels(current, func(c float64) float64 { return c + shift })
+		case EditContrast:
+			factor := 1 + edit.Amount
+			mapChannels(current, func(c float64) float64 { return (c-128)*factor + 128 })
+		case EditSaturation:
+			factor := 1 + edit.Amount
+			mapPixels(current, func(r, g, b float64) (float64, float64, float64) {
+				gray := luma(r, g, b)
+				return gray + (r-gray)*factor, gray + (g-gray)*factor, gray + (b-gray)*factor
+			})
+		case EditGrayscale:
+			mapPixels(current, func(r, g, b float64) (float64, float64, float64) {
+				gray := luma(r, g, b)
+				return gray, gray, gray
+			})
+		case EditSepia:
+			mapPixels(current, func(r, g, b float64) (float64, float64, float64) {
+				return 0.393*r + 0.769*g + 0.189*b, 0.349*r + 0.686*g + 0.168*b, 0.272*r + 0.534*g + 0.131*b
+			})
+		}
+	}
+	return current
+}
+
+// ReorientEdits adapts a recipe defined on the displayed image to the image
+// after it is rotated clockwise by degrees and then flipped, so that crops keep
+// covering the same content
+func ReorientEdits(edits []Edit, degrees int, flip Flip) []Edit {
+	turns := (((degrees % 360) + 360) % 360) / 90
+
+	result := make([]Edit, len(edits))
+	for i, edit := range edits {
+		for t := 0; t < turns; t++ {
+			if edit.Kind == EditCrop {
+				edit.X, edit.Y, edit.Width, edit.Height = 1-edit.Y-edit.Height, edit.X, edit.Height, edit.Width
+			}
+		}
+		switch flip {
+		case FlipHorizontal:
+			if edit.Kind == EditCrop {
+				edit.X = 1 - edit.X - edit.Width
+			}
+			edit.Angle = -edit.Angle
+		case FlipVertical:
+			if edit.Kind == EditCrop {
+				edit.Y = 1 - edit.Y - edit.Height
+			}
+			edit.Angle = -edit.Angle
+		}
+		result[i] = edit
+	}
+	return result
+}
+
+func crop(img *image.NRGBA, edit Edit) *image.NRGBA {
+	w, h := float64(img.Rect.Dx()), float64(img.Rect.Dy())
+	rect := image.Rect(
+		int(math.Round(edit.X*w)),
+		int(math.Round(edit.Y*h)),
+		int(math.Round((edit.X+edit.Width)*w)),
+		int(math.Round((edit.Y+edit.Height)*h)),
+	)
+	if rect.Empty() {
+		return img
+	}
+	return toNRGBA(img.SubImage(rect.Add(img.Rect.Min)))
+}
+
+// straighten rotates the image about its centre and keeps the largest centred
+// rectangle with the original aspect ratio that contains no empty corners
+func straighten(img *image.NRGBA, angle float64) *image.NRGBA {
+	if angle == 0 {
+		return img
+	}
+
+	w, h := float64(img.Rect.Dx()), float64(img.Rect.Dy())
+	theta := angle * math.Pi / 180
+	sin, cos := math.Sin(theta), math.Cos(theta)
+	absSin, absCos := math.Abs(sin), math.Abs(cos)
+	scale := math.Min(w/(w*absCos+h*absSin), h/(w*absSin+h*absCos))
+
+	dstW, dstH := max(int(w*scale), 1), max(int(h*scale), 1)
+	dst := image.NewNRGBA(image.Rect(0, 0, dstW, dstH))
+
+	cx, cy := w/2, h/2
+	dcx, dcy := float64(dstW)/2, float64(dstH)/2
+	for y := 0; y < dstH; y++ {
+		for x := 0; x < dstW; x++ {
+			// Rotate the destination point back into the source
+			px, py := float64(x)+0.5-dcx, float64(y)+0.5-dcy
+			sx := px*cos + py*sin + cx - 0.5
+			sy := -px*sin + py*cos + cy - 0.5
+			offset := dst.PixOffset(x, y)
+			bilinear(img, sx, sy, dst.Pix[offset:offset+4])
+		}
+	}
+	return dst
+}
+
+// bilinear samples the image at a fractional position into out, clamping to its edges
+func bilinear(img *image.NRGBA, x, y float64, out []byte) {
+	maxX, maxY := img.Rect.Dx()-1, img.Rect.Dy()-1
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	fx, fy := x-float64(x0), y-float64(y0)
+
+	clamp := func(v, hi int) int { return min(max(v, 0), hi) }
+	pixel := func(px, py int) []byte {
+		offset := img.PixOffset(img.Rect.Min.X+clamp(px, maxX), img.Rect.Min.Y+clamp(py, maxY))
+		return img.Pix[offset : offset+4]
+	}
+
+	p00, p10, p01, p11 := pixel(x0, y0), pixel(x0+1, y0), pixel(x0, y0+1), pixel(x0+1, y0+1)
+	for i := range out {
+		top := float64(p00[i])*(1-fx) + float64(p10[i])*fx
+		bottom := float64(p01[i])*(1-fx) + float64(p11[i])*fx
+		out[i] = clampByte(top*(1-fy) + bottom*fy)
+	}
+}
+
+// mapChannels applies a function to the red, green and blue channels of every pixel
+func mapChannels(img *image.NRGBA, fn func(float64) float64) {
+	mapPixels(img, func(r, g, b float64) (float64, float64, float64) {
+		return fn(r), fn(g), fn(b)
+	})
+}
+
+// mapPixels applies a colour function to every pixel, leaving alpha untouched
+func mapPixels(img *image.NRGBA, fn func(r, g, b float64) (float64, float64, float64)) {
+	for i := 0; i+3 < len(img.Pix); i += 4 {
+		r, g, b := fn(float64(img.Pix[i]), float64(img.Pix[i+1]), float64(img.Pix[i+2]))
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2] = clampByte(r), clampByte(g), clampByte(b)
+	}
+}
+
+func luma(r, g, b float64) float64 {
+	return 0.299*r + 0.587*g + 0.114*b
+}
+
+func clampByte(v float64) byte {
+	return byte(math.Round(math.Min(math.Max(v, 0), 255)))
+}
+
+// toNRGBA returns a copy of the image as an NRGBA image whose bounds start at the origin
+func toNRGBA(img image.Image) *image.NRGBA {
+	bounds := img.Bounds()
+	nrgba := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(nrgba, nrgba.Rect, img, bounds.Min, draw.Src)
+	return nrgba
+}
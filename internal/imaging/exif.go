@@ -0,0 +1,106 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+const (
+	// exifOrientationTag is the TIFF tag holding the EXIF orientation
+	exifOrientationTag = 0x0112
+	// exifTypeShort is the TIFF type of a 16-bit unsigned value
+	exifTypeShort = 3
+)
+
+// ReadOrientation returns the EXIF orientation (1 to 8) of a JPEG, given at
+// least its leading segments. It reports false when there is none.
+func ReadOrientation(data []byte) (int, bool) {
+	offset, order, ok := findOrientation(data)
+	if !ok {
+		return 0, false
+	}
+
+	orientation := int(order.Uint16(data[offset:]))
+	if orientation < 1 || orientation > 8 {
+		return 0, false
+	}
+	return orientation, true
+}
+
+// SetOrientation rewrites the EXIF orientation of a JPEG in place, without
+// touching the image data. It reports false when the JPEG has no orientation tag.
+func SetOrientation(data []byte, orientation int) bool {
+	offset, order, ok := findOrientation(data)
+	if !ok {
+		return false
+	}
+
+	order.PutUint16(data[offset:], uint16(orientation))
+	return true
+}
+
+// findOrientation locates the value of the orientation tag in IFD0 of a JPEG's EXIF segment
+func findOrientation(data []byte) (int, binary.ByteOrder, bool) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 0, nil, false
+	}
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return 0, nil, false
+		}
+		marker := data[pos+1]
+		// Start of scan: the metadata segments are over
+		if marker == 0xDA || marker == 0xD9 {
+			return 0, nil, false
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		segment := pos + 4
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return 0, nil, false
+		}
+
+		if marker == 0xE1 && bytes.HasPrefix(data[segment:end], []byte("Exif\x00\x00")) {
+			return findOrientationInTIFF(data, segment+6, end)
+		}
+		pos = end
+	}
+	return 0, nil, false
+}
+
+// findOrientationInTIFF walks IFD0 of the TIFF structure between start and end
+func findOrientationInTIFF(data []byte, start, end int) (int, binary.ByteOrder, bool) {
+	if start+8 > end {
+		return 0, nil, false
+	}
+
+	var order binary.ByteOrder
+	switch string(data[start : start+2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0, nil, false
+	}
+
+	ifd := start + int(order.Uint32(data[start+4:]))
+	if ifd+2 > end {
+		return 0, nil, false
+	}
+
+	count := int(order.Uint16(data[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > end {
+			return 0, nil, false
+		}
+		if order.Uint16(data[entry:]) == exifOrientationTag && order.Uint16(data[entry+2:]) == exifTypeShort {
+			return entry + 8, order, true
+		}
+	}
+	return 0, nil, false
+}
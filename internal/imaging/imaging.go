@@ -0,0 +1,163 @@
+// Package imaging decodes, transforms and encodes images in pure Go.
+package imaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+
+	// Register the WebP decoder so WebP originals can be transformed
+	_ "golang.org/x/image/webp"
+)
+
+// ErrTooLarge is returned when an image has more pixels than allowed
+var ErrTooLarge = errors.New("image is too large to process")
+
+// Decode decodes an image, refusing images with more than maxPixels pixels
+// before their pixel data is allocated
+func Decode(r io.ReadSeeker, maxPixels int) (image.Image, string, error) {
+	config, format, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read image header: %w", err)
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, "", fmt.Errorf("%w: %dx%d", ErrTooLarge, config.Width, config.Height)
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, "", err
+	}
+
+	img, format, err := image.Decode(r)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, format, nil
+}
+
+// Encoder writes an image in a particular format. Quality ranges from 1 to 100
+// and may be ignored by lossless formats. Encoders that run external programs
+// stop them when the context is done.
+type Encoder func(ctx context.Context, w io.Writer, img image.Image, quality int) error
+
+var encoders = map[Format]Encoder{
+	FormatJPEG: func(_ context.Context, w io.Writer, img image.Image, quality int) error {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	},
+	FormatPNG: func(_ context.Context, w io.Writer, img image.Image, _ int) error {
+		return png.Encode(w, img)
+	},
+	FormatGIF: func(_ context.Context, w io.Writer, img image.Image, _ int) error {
+		return gif.Encode(w, img, nil)
+	},
+}
+
+// RegisterEncoder makes a format encodable. It must be called during startup,
+// before images are encoded.
+func RegisterEncoder(format Format, encoder Encoder) {
+	encoders[format] = encoder
+}
+
+// Encode writes the image in the given format
+func Encode(ctx context.Context, w io.Writer, img image.Image, format Format, quality int) error {
+	encoder, ok := encoders[format]
+	if !ok {
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+	return encoder(ctx, w, img, quality)
+}
+
+// IsEncodable reports whether images can be written in the format
+func IsEncodable(format Format) bool {
+	_, ok := encoders[format]
+	return ok
+}
+
+// ContentType returns the MIME type of a format
+func ContentType(format Format) string {
+	return "image/" + string(format)
+}
+
+// Extension returns the file extension of a format, including the dot
+func Extension(format Format) string {
+	if format == FormatJPEG {
+		return ".jpg"
+	}
+	return "." + string(format)
+}
+
+// FormatFromContentType returns the output format matching a MIME type, if it can be encoded
+func FormatFromContentType(contentType string) (Format, bool) {
+	format, ok := mimeFormats[contentType]
+	if !ok || !IsEncodable(format) {
+		return "", false
+	}
+	return format, true
+}
+
+// Resize scales the image into the box described by the options. A zero width
+// or height is derived from the aspect ratio. Contain never enlarges the image.
+func Resize(img image.Image, opts Options) image.Image {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if opts.Width == 0 && opts.Height == 0 {
+		return img
+	}
+
+	switch opts.Fit {
+	case FitFill:
+		return scale(img, bounds, opts.Width, opts.Height)
+
+	case FitCover:
+		// Crop the source to the target aspect ratio around its centre, then scale
+		crop := bounds
+		if srcW*opts.Height > srcH*opts.Width {
+			w := srcH * opts.Width / opts.Height
+			crop.Min.X += (srcW - w) / 2
+			crop.Max.X = crop.Min.X + w
+		} else {
+			h := srcW * opts.Height / opts.Width
+			crop.Min.Y += (srcH - h) / 2
+			crop.Max.Y = crop.Min.Y + h
+		}
+		return scale(img, crop, opts.Width, opts.Height)
+
+	default:
+		w, h := containSize(srcW, srcH, opts.Width, opts.Height)
+		if w == srcW && h == srcH {
+			return img
+		}
+		return scale(img, bounds, w, h)
+	}
+}
+
+// containSize returns the largest size with the source aspect ratio that fits
+// in the box without exceeding the source size
+func containSize(srcW, srcH, boxW, boxH int) (int, int) {
+	if boxW == 0 || boxW > srcW {
+		boxW = srcW
+	}
+	if boxH == 0 || boxH > srcH {
+		boxH = srcH
+	}
+
+	w, h := boxW, srcH*boxW/srcW
+	if h > boxH {
+		w, h = srcW*boxH/srcH, boxH
+	}
+	return max(w, 1), max(h, 1)
+}
+
+// scale resamples the source rectangle of an image to a new size
+func scale(img image.Image, src image.Rectangle, width, height int) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, src, draw.Src, nil)
+	return dst
+}
@@ -0,0 +1,49 @@
+package imaging
+
+import (
+	"strconv"
+	"strings"
+)
+
+// negotiablePreference lists the formats offered through content negotiation, best first
+var negotiablePreference = []Format{FormatAVIF, FormatWebP}
+
+// Negotiate picks the best encodable format that the Accept header names
+// explicitly. Wildcards such as image/* do not count, since every browser sends
+// them. It reports false when the original format should be served.
+func Negotiate(accept string) (Format, bool) {
+	weights := parseAccept(accept)
+
+	best, bestWeight := Format(""), 0.0
+	for _, format := range negotiablePreference {
+		weight := weights[ContentType(format)]
+		if weight > bestWeight && IsEncodable(format) {
+			best, bestWeight = format, weight
+		}
+	}
+	return best, best != ""
+}
+
+// parseAccept returns the quality value of each media type in an Accept header
+func parseAccept(accept string) map[string]float64 {
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		if mediaType == "" {
+			continue
+		}
+
+		weight := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if found && strings.TrimSpace(name) == "q" {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					weight = q
+				}
+			}
+		}
+		weights[mediaType] = weight
+	}
+	return weights
+}
@@ -0,0 +1,163 @@
+package imaging
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Fit controls how an image is scaled into the requested box
+type Fit string
+
+const (
+	// FitContain scales the image to fit inside the box, keeping its aspect ratio
+	FitContain Fit = "contain"
+	// FitCover scales the image to cover the box and crops the overflow around the centre
+	FitCover Fit = "cover"
+	// FitFill stretches the image to exactly the box
+	FitFill Fit = "fill"
+)
+
+// Format is an output image format
+type Format string
+
+const (
+	FormatJPEG Format = "jpeg"
+	FormatPNG  Format = "png"
+	FormatGIF  Format = "gif"
+	FormatWebP Format = "webp"
+	FormatAVIF Format = "avif"
+)
+
+// mimeFormats maps MIME types to formats
+var mimeFormats = map[string]Format{
+	"image/jpeg": FormatJPEG,
+	"image/png":  FormatPNG,
+	"image/gif":  FormatGIF,
+	"image/webp": FormatWebP,
+	"image/avif": FormatAVIF,
+}
+
+const (
+	// DefaultQuality is the JPEG quality used when none is requested
+	DefaultQuality = 85
+)
+
+// Options describes a transformation. Zero values mean "not specified".
+type Options struct {
+	Width   int
+	Height  int
+	Fit     Fit
+	Format  Format
+	Quality int
+}
+
+// ParseOptions reads options from the w, h, fit, fmt and q query parameters
+func ParseOptions(query url.Values) (Options, error) {
+	var opts Options
+	var err error
+
+	if opts.Width, err = parseInt(query, "w"); err != nil {
+		return opts, err
+	}
+	if opts.Height, err = parseInt(query, "h"); err != nil {
+		return opts, err
+	}
+	if opts.Quality, err = parseInt(query, "q"); err != nil {
+		return opts, err
+	}
+	opts.Fit = Fit(query.Get("fit"))
+	opts.Format = Format(query.Get("fmt"))
+	if opts.Format == "jpg" {
+		opts.Format = FormatJPEG
+	}
+
+	return opts, nil
+}
+
+// Validate checks the options against the supported values and the maximum output dimension
+func (o Options) Validate(maxDimension int) error {
+	if o.Width < 0 || o.Height < 0 {
+		return fmt.Errorf("width and height must not be negative")
+	}
+	if o.Width > maxDimension || o.Height > maxDimension {
+		return fmt.Errorf("width and height must not exceed %d", maxDimension)
+	}
+
+	switch o.Fit {
+	case "", FitContain:
+	case FitCover, FitFill:
+		if o.Width == 0 || o.Height == 0 {
+			return fmt.Errorf("fit %q requires both width and height", o.Fit)
+		}
+	default:
+		return fmt.Errorf("unsupported fit %q", o.Fit)
+	}
+
+	if o.Format != "" && !IsEncodable(o.Format) {
+		if _, known := mimeFormats[ContentType(o.Format)]; known {
+			return fmt.Errorf("format %q needs an external encoder and none is configured", o.Format)
+		}
+		return fmt.Errorf("unsupported format %q", o.Format)
+	}
+
+	if o.Quality < 0 || o.Quality > 100 {
+		return fmt.Errorf("quality must be between 1 and 100")
+	}
+
+	return nil
+}
+
+// WithDefaults fills unspecified fit, format and quality. The fallback format
+// is used when no format was requested.
+func (o Options) WithDefaults(fallback Format) Options {
+	if o.Fit == "" {
+		o.Fit = FitContain
+	}
+	if o.Format == "" {
+		o.Format = fallback
+	}
+	if o.Quality == 0 {
+		o.Quality = DefaultQuality
+	}
+	return o
+}
+
+// Query encodes the specified options as query parameters, omitting zero values.
+// The encoding is canonical, so it can be signed and hashed.
+func (o Options) Query() url.Values {
+	query := url.Values{}
+	if o.Width > 0 {
+		query.Set("w", strconv.Itoa(o.Width))
+	}
+	if o.Height > 0 {
+		query.Set("h", strconv.Itoa(o.Height))
+	}
+	if o.Fit != "" {
+		query.Set("fit", string(o.Fit))
+	}
+	if o.Format != "" {
+		query.Set("fmt", string(o.Format))
+	}
+	if o.Quality > 0 {
+		query.Set("q", strconv.Itoa(o.Quality))
+	}
+	return query
+}
+
+// Canonical returns the canonical string form of the options
+func (o Options) Canonical() string {
+	return o.Query().Encode()
+}
+
+func parseInt(query url.Values, name string) (int, error) {
+	value := query.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer", name)
+	}
+	return parsed, nil
+}
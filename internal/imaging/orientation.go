@@ -0,0 +1,117 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Flip mirrors an image along an axis
+type Flip string
+
+const (
+	FlipNone       Flip = ""
+	FlipHorizontal Flip = "horizontal"
+	FlipVertical   Flip = "vertical"
+)
+
+// orientationMatrices maps each EXIF orientation to the transform that turns
+// the stored pixels into the displayed image, as a 2x2 matrix {a, b, c, d}
+// taking (x, y) to (ax+by, cx+dy) with y pointing down
+var orientationMatrices = map[int][4]int{
+	1: {1, 0, 0, 1},   // identity
+	2: {-1, 0, 0, 1},  // flip horizontal
+	3: {-1, 0, 0, -1}, // rotate 180
+	4: {1, 0, 0, -1},  // flip vertical
+	5: {0, 1, 1, 0},   // transpose
+	6: {0, -1, 1, 0},  // rotate 90 clockwise
+	7: {0, -1, -1, 0}, // transverse
+	8: {0, 1, -1, 0},  // rotate 270 clockwise
+}
+
+// NormalizeOrientation maps unknown orientations to 1, the identity
+func NormalizeOrientation(orientation int) int {
+	if _, ok := orientationMatrices[orientation]; !ok {
+		return 1
+	}
+	return orientation
+}
+
+// Reorient returns the orientation of an image after it is rotated clockwise
+// by degrees (a multiple of 90) and then flipped, as seen by the viewer
+func Reorient(orientation, degrees int, flip Flip) int {
+	current := orientationMatrices[NormalizeOrientation(orientation)]
+
+	var op [4]int
+	switch ((degrees % 360) + 360) % 360 {
+	case 90:
+		op = orientationMatrices[6]
+	case 180:
+		op = orientationMatrices[3]
+	case 270:
+		op = orientationMatrices[8]
+	default:
+		op = orientationMatrices[1]
+	}
+	switch flip {
+	case FlipHorizontal:
+		op = multiply(orientationMatrices[2], op)
+	case FlipVertical:
+		op = multiply(orientationMatrices[4], op)
+	}
+
+	result := multiply(op, current)
+	for value, matrix := range orientationMatrices {
+		if matrix == result {
+			return value
+		}
+	}
+	return 1
+}
+
+// ApplyOrientation returns the image as it should be displayed for the EXIF orientation
+func ApplyOrientation(img image.Image, orientation int) image.Image {
+	orientation = NormalizeOrientation(orientation)
+	if orientation == 1 {
+		return img
+	}
+	m := orientationMatrices[orientation]
+
+	src := toRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dstW, dstH := w, h
+	if m[0] == 0 {
+		dstW, dstH = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+
+	// Translation that moves the transformed pixel grid back to the origin
+	tx := -min(0, m[0]*(w-1), m[1]*(h-1), m[0]*(w-1)+m[1]*(h-1))
+	ty := -min(0, m[2]*(w-1), m[3]*(h-1), m[2]*(w-1)+m[3]*(h-1))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx := m[0]*x + m[1]*y + tx
+			dy := m[2]*x + m[3]*y + ty
+			copy(dst.Pix[dst.PixOffset(dx, dy):dst.PixOffset(dx, dy)+4], src.Pix[src.PixOffset(x, y):src.PixOffset(x, y)+4])
+		}
+	}
+	return dst
+}
+
+func multiply(a, b [4]int) [4]int {
+	return [4]int{
+		a[0]*b[0] + a[1]*b[2], a[0]*b[1] + a[1]*b[3],
+		a[2]*b[0] + a[3]*b[2], a[2]*b[1] + a[3]*b[3],
+	}
+}
+
+// toRGBA returns the image as an RGBA image whose bounds start at the origin
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Rect, img, bounds.Min, draw.Src)
+	return rgba
+}
@@ -0,0 +1,204 @@
+package importing
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"photocloud/internal/domain/models"
+)
+
+// xmpDateLayouts are the forms dates take in XMP and EXIF, tried in turn. Dates without
+// a time zone are taken as UTC.
+var xmpDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006:01:02 15:04:05Z07:00",
+	"2006:01:02 15:04:05",
+	"2006-01-02",
+}
+
+// appleSidecarFor finds the XMP sidecar of a photo among the files of its folder. Apple Photos
+// names it after the photo with the extension replaced; other tools add ".xmp" instead.
+func appleSidecarFor(folder folderIndex, name string) string {
+	stem := strings.TrimSuffix(name, path.Ext(name))
+	for _, candidate := range []string{stem + ".xmp", name + ".xmp"} {
+		if sidecar, ok := folder[strings.ToLower(candidate)]; ok {
+			return sidecar
+		}
+	}
+	return ""
+}
+
+// appleAlbum returns the album of a folder of an Apple Photos export, which is named after it.
+// Photos at the top level are in no album.
+func appleAlbum(folder string) *Album {
+	if folder == "." || folder == "" {
+		return nil
+	}
+	return &Album{Title: path.Base(folder)}
+}
+
+func parseXMPSidecar(data []byte) (*Metadata, error) {
+	properties, err := readXMPProperties(data)
+	if err != nil {
+		return nil, err
+	}
+	first := func(names ...string) string {
+		for _, name := range names {
+			if values := properties[name]; len(values) > 0 {
+				return values[0]
+			}
+		}
+		return ""
+	}
+
+	// Apple Photos keeps the caption as the description and may have a title instead
+	metadata := &Metadata{
+		Description: first("description", "title"),
+		Tags:        properties["subject"],
+	}
+	if takenAt, ok := parseXMPDate(first("DateTimeOriginal", "DateCreated", "CreateDate")); ok {
+		metadata.TakenAt = &takenAt
+	}
+
+	latitude, latOK := parseXMPCoordinate(first("GPSLatitude"))
+	longitude, lonOK := parseXMPCoordinate(first("GPSLongitude"))
+	if latOK && lonOK {
+		metadata.Location = &models.GeoLocation{Latitude: latitude, Longitude: longitude}
+		if altitude, ok := parseRational(first("GPSAltitude")); ok {
+			// An altitude reference of 1 means below sea level
+			if first("GPSAltitudeRef") == "1" {
+				altitude = -altitude
+			}
+			metadata.Location.Altitude = altitude
+		}
+	}
+	return metadata, nil
+}
+
+// readXMPProperties collects the values of the properties of an XMP packet by their name
+// without namespace. Properties are read from the attributes of rdf:Description elements
+// and from their child elements, whose values may be lists of rdf:li items.
+func readXMPProperties(data []byte) (map[string][]string, error) {
+	// inDescription stands for an rdf:Description element on the stack of open elements;
+	// it cannot be mistaken for a property name, which has no colon
+	const inDescription = "rdf:Description"
+
+	properties := make(map[string][]string)
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	// The property each open element belongs to, empty outside of properties
+	var open []string
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return properties, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			parent := ""
+			if len(open) > 0 {
+				parent = open[len(open)-1]
+			}
+			switch {
+			case parent == "" && token.Name.Local == "Description":
+				for _, attr := range token.Attr {
+					if attr.Name.Space != "" && attr.Name.Space != "xmlns" && attr.Name.Local != "about" {
+						properties[attr.Name.Local] = append(properties[attr.Name.Local], strings.TrimSpace(attr.Value))
+					}
+				}
+				open = append(open, inDescription)
+			case parent == inDescription:
+				open = append(open, token.Name.Local)
+			default:
+				open = append(open, parent)
+			}
+		case xml.EndElement:
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+		case xml.CharData:
+			if len(open) == 0 || open[len(open)-1] == "" || open[len(open)-1] == inDescription {
+				continue
+			}
+			if value := strings.TrimSpace(string(token)); value != "" {
+				property := open[len(open)-1]
+				properties[property] = append(properties[property], value)
+			}
+		}
+	}
+}
+
+func parseXMPDate(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range xmpDateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseXMPCoordinate parses a GPS coordinate as XMP writes it, "DDD,MM,SSk" or "DDD,MM.mmk"
+// with k one of N, S, E and W, or as plain decimal degrees
+func parseXMPCoordinate(value string) (float64, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if decimal, err := strconv.ParseFloat(value, 64); err == nil {
+		return decimal, math.Abs(decimal) <= 180
+	}
+
+	sign := 1.0
+	switch value[len(value)-1] {
+	case 'N', 'E', 'n', 'e':
+	case 'S', 'W', 's', 'w':
+		sign = -1
+	default:
+		return 0, false
+	}
+
+	degrees := 0.0
+	scale := 1.0
+	for _, part := range strings.Split(value[:len(value)-1], ",") {
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || scale < 1.0/3600 {
+			return 0, false
+		}
+		degrees += parsed * scale
+		scale /= 60
+	}
+	return sign * degrees, degrees <= 180
+}
+
+// parseRational parses an EXIF rational such as "1234/100", or a decimal number
+func parseRational(value string) (float64, bool) {
+	numerator, denominator, isFraction := strings.Cut(strings.TrimSpace(value), "/")
+	n, err := strconv.ParseFloat(numerator, 64)
+	if err != nil {
+		return 0, false
+	}
+	if !isFraction {
+		return n, true
+	}
+	d, err := strconv.ParseFloat(denominator, 64)
+	if err != nil || d == 0 {
+		return 0, false
+	}
+	return n / d, true
+}
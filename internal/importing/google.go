@@ -0,0 +1,138 @@
+package importing
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"photocloud/internal/domain/models"
+)
+
+const (
+	// googleSidecarNameLimit is the length Google Takeout cuts the names of sidecars to, ".json" included
+	googleSidecarNameLimit = 51
+	// googleAlbumMetadata is the file in an album folder of a Takeout that describes the album
+	googleAlbumMetadata = "metadata.json"
+)
+
+// numberedName matches names of files that Takeout numbered to keep them apart, like "IMG_1234(1)"
+var numberedName = regexp.MustCompile(`^(.*)(\(\d+\))$`)
+
+// googleSidecarFor finds the sidecar of a photo among the files of its folder. Takeout names
+// sidecars after the photo with ".json" or ".supplemental-metadata.json" added, but moves the
+// number of numbered photos behind the extension, shares the sidecar of an original with its
+// "-edited" copy and cuts long names short.
+func googleSidecarFor(folder folderIndex, name string) string {
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(strings.TrimSuffix(name, ext), "-edited")
+	number := ""
+	if match := numberedName.FindStringSubmatch(stem); match != nil {
+		stem, number = match[1], match[2]
+	}
+
+	original := stem + ext
+	for _, suffix := range []string{".supplemental-metadata", ""} {
+		candidate := original + suffix
+		if sidecar, ok := folder[strings.ToLower(candidate+number+".json")]; ok {
+			return sidecar
+		}
+		if cut := googleSidecarNameLimit - len(number+".json"); len(candidate) > cut && cut > 0 {
+			if sidecar, ok := folder[strings.ToLower(candidate[:cut]+number+".json")]; ok {
+				return sidecar
+			}
+		}
+	}
+	return ""
+}
+
+// googleTitleFor returns the name a file had in Google Photos, given the title of the sidecar
+// it shares with the original when it is an edited copy
+func googleTitleFor(name, title string) string {
+	ext := path.Ext(name)
+	if title == "" || !strings.HasSuffix(strings.TrimSuffix(name, ext), "-edited") {
+		return title
+	}
+	titleExt := path.Ext(title)
+	return strings.TrimSuffix(title, titleExt) + "-edited" + titleExt
+}
+
+// googleSidecar is the JSON Takeout keeps the metadata of a photo in
+type googleSidecar struct {
+	Title          string `json:"title"`
+	Description    string `json:"description"`
+	PhotoTakenTime struct {
+		Timestamp string `json:"timestamp"`
+	} `json:"photoTakenTime"`
+	GeoData     googleGeoData `json:"geoData"`
+	GeoDataExif googleGeoData `json:"geoDataExif"`
+}
+
+type googleGeoData struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude"`
+}
+
+func parseGoogleSidecar(data []byte) (*Metadata, error) {
+	var sidecar googleSidecar
+	if err := json.Unmarshal(data, &sidecar); err != nil {
+		return nil, err
+	}
+
+	metadata := &Metadata{
+		Title:       strings.TrimSpace(sidecar.Title),
+		Description: strings.TrimSpace(sidecar.Description),
+	}
+	if seconds, err := strconv.ParseInt(sidecar.PhotoTakenTime.Timestamp, 10, 64); err == nil && seconds > 0 {
+		takenAt := time.Unix(seconds, 0).UTC()
+		metadata.TakenAt = &takenAt
+	}
+	// Takeout writes zero coordinates when it knows no location
+	for _, geo := range []googleGeoData{sidecar.GeoData, sidecar.GeoDataExif} {
+		if geo.Latitude != 0 || geo.Longitude != 0 {
+			metadata.Location = &models.GeoLocation{Latitude: geo.Latitude, Longitude: geo.Longitude, Altitude: geo.Altitude}
+			break
+		}
+	}
+	return metadata, nil
+}
+
+// googleAlbumFile is the metadata.json of an album folder. Older Takeouts nest it in albumData.
+type googleAlbumFile struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	AlbumData   *struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+	} `json:"albumData"`
+}
+
+// googleAlbum reads the album of a Takeout folder. Only album folders have a metadata.json;
+// the folders Takeout sorts all photos into by year stand for no album.
+func googleAlbum(fsys fs.FS, folder string) (*Album, error) {
+	data, err := readSidecar(fsys, path.Join(folder, googleAlbumMetadata))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil && !errors.Is(err, errSidecarTooLarge) {
+		return nil, err
+	}
+
+	var file googleAlbumFile
+	if err != nil || json.Unmarshal(data, &file) != nil {
+		// The folder is taken for an ordinary one rather than failing its photos
+		return nil, nil
+	}
+	if file.AlbumData != nil {
+		file.Title, file.Description = file.AlbumData.Title, file.AlbumData.Description
+	}
+	if strings.TrimSpace(file.Title) == "" {
+		return nil, nil
+	}
+	return &Album{Title: strings.TrimSpace(file.Title), Description: strings.TrimSpace(file.Description)}, nil
+}
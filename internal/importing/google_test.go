@@ -0,0 +1,122 @@
+package importing
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGoogleSidecarFor(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		file  string
+		want  string
+	}{
+		{
+			name:  "named after the photo",
+			files: []string{"IMG_1234.jpg", "IMG_1234.jpg.json"},
+			file:  "IMG_1234.jpg",
+			want:  "IMG_1234.jpg.json",
+		},
+		{
+			name:  "supplemental metadata",
+			files: []string{"IMG_1234.jpg", "IMG_1234.jpg.supplemental-metadata.json"},
+			file:  "IMG_1234.jpg",
+			want:  "IMG_1234.jpg.supplemental-metadata.json",
+		},
+		{
+			name:  "case differs",
+			files: []string{"IMG_1234.JPG", "IMG_1234.jpg.json"},
+			file:  "IMG_1234.JPG",
+			want:  "IMG_1234.jpg.json",
+		},
+		{
+			name:  "number behind the extension",
+			files: []string{"IMG_1234(1).jpg", "IMG_1234.jpg(1).json", "IMG_1234.jpg.json"},
+			file:  "IMG_1234(1).jpg",
+			want:  "IMG_1234.jpg(1).json",
+		},
+		{
+			name:  "edited copy shares the original's sidecar",
+			files: []string{"IMG_1234.jpg", "IMG_1234-edited.jpg", "IMG_1234.jpg.json"},
+			file:  "IMG_1234-edited.jpg",
+			want:  "IMG_1234.jpg.json",
+		},
+		{
+			name:  "numbered edited copy",
+			files: []string{"IMG_1234(2)-edited.jpg", "IMG_1234.jpg(2).json"},
+			file:  "IMG_1234(2)-edited.jpg",
+			want:  "IMG_1234.jpg(2).json",
+		},
+		{
+			name:  "long name cut to 51 characters",
+			files: []string{"Screenshot_20230615-101010_Photos_Application.png", "Screenshot_20230615-101010_Photos_Application..json"},
+			file:  "Screenshot_20230615-101010_Photos_Application.png",
+			want:  "Screenshot_20230615-101010_Photos_Application..json",
+		},
+		{
+			name:  "supplemental metadata cut short",
+			files: []string{"PXL_20230615_101010123.PORTRAIT.jpg", "PXL_20230615_101010123.PORTRAIT.jpg.supplement.json"},
+			file:  "PXL_20230615_101010123.PORTRAIT.jpg",
+			want:  "PXL_20230615_101010123.PORTRAIT.jpg.supplement.json",
+		},
+		{
+			name:  "numbered supplemental metadata cut short",
+			files: []string{"PXL_20230615_101010123.PORTRAIT(1).jpg", "PXL_20230615_101010123.PORTRAIT.jpg.supplem(1).json"},
+			file:  "PXL_20230615_101010123.PORTRAIT(1).jpg",
+			want:  "PXL_20230615_101010123.PORTRAIT.jpg.supplem(1).json",
+		},
+		{
+			name:  "numbered photo does not take the original's sidecar",
+			files: []string{"IMG_1234(1).jpg", "IMG_1234.jpg.json"},
+			file:  "IMG_1234(1).jpg",
+			want:  "",
+		},
+		{
+			name:  "no sidecar",
+			files: []string{"IMG_1234.jpg", "IMG_5678.jpg.json"},
+			file:  "IMG_1234.jpg",
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folder := make(folderIndex)
+			for _, name := range tt.files {
+				folder[strings.ToLower(name)] = "Takeout/Google Photos/Trip/" + name
+			}
+			want := ""
+			if tt.want != "" {
+				want = "Takeout/Google Photos/Trip/" + tt.want
+			}
+			if got := googleSidecarFor(folder, tt.file); got != want {
+				t.Errorf("googleSidecarFor(%q) = %q, want %q", tt.file, got, want)
+			}
+		})
+	}
+}
+
+func TestGoogleTitleFor(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		title string
+		want  string
+	}{
+		{name: "original", file: "IMG_1234.jpg", title: "Beach.jpg", want: "Beach.jpg"},
+		{name: "edited copy", file: "IMG_1234-edited.jpg", title: "Beach.jpg", want: "Beach-edited.jpg"},
+		{name: "numbered edited copy", file: "IMG_1234(1)-edited.jpg", title: "Beach.jpg", want: "Beach-edited.jpg"},
+		{name: "title without extension", file: "IMG_1234-edited.jpg", title: "Beach", want: "Beach-edited"},
+		{name: "no title", file: "IMG_1234-edited.jpg", title: "", want: ""},
+		{name: "edited in the title only", file: "IMG_1234.jpg", title: "Beach-edited.jpg", want: "Beach-edited.jpg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := googleTitleFor(tt.file, tt.title); got != tt.want {
+				t.Errorf("googleTitleFor(%q, %q) = %q, want %q", tt.file, tt.title, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+// Package importing reads photo libraries exported from other services: which
+// files they hold, the metadata sidecar that belongs to each file and the albums
+// their folders stand for.
+package importing
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"photocloud/internal/domain/models"
+)
+
+// sidecarSizeLimit is the largest sidecar that is read. Sidecars hold a few kilobytes of
+// metadata, so anything larger is not one.
+const sidecarSizeLimit = 1 << 20
+
+// errSidecarTooLarge is returned for sidecars larger than sidecarSizeLimit
+var errSidecarTooLarge = errors.New("larger than a sidecar can be")
+
+// Metadata is what a sidecar tells about a photo. Zero fields are unknown.
+type Metadata struct {
+	// Title is the file name the photo had in its library, when that differs from the exported one
+	Title       string
+	Description string
+	TakenAt     *time.Time
+	Location    *models.GeoLocation
+	Tags        []string
+}
+
+// Album is a folder of an export that stands for an album
+type Album struct {
+	Title       string
+	Description string
+}
+
+// File is a file of an export other than sidecars and the export's own bookkeeping
+type File struct {
+	// Path is the slash-separated path of the file inside the export
+	Path string
+	// Sidecar is the path of the file's metadata sidecar, or empty when it has none
+	Sidecar string
+}
+
+// Folder returns the folder the file is in, or "." at the top level
+func (f File) Folder() string {
+	return path.Dir(f.Path)
+}
+
+// Library is an export of a photo library, read from a ZIP archive or a directory
+type Library struct {
+	Source models.ImportSource
+	// Files lists the files of the export, in the order they are stored in
+	Files []File
+
+	fsys fs.FS
+}
+
+// Open lists the files of an export and matches them with their sidecars. ZIP archives
+// are listed in the order of their entries, so that reading files in turn reads the
+// archive from start to end; directories are listed in lexical order.
+func Open(fsys fs.FS, source models.ImportSource) (*Library, error) {
+	if !source.IsValid() {
+		return nil, fmt.Errorf("unknown import source %q", source)
+	}
+
+	var paths []string
+	if archive, ok := fsys.(*zip.Reader); ok {
+		for _, entry := range archive.File {
+			if !entry.FileInfo().IsDir() && fs.ValidPath(entry.Name) {
+				paths = append(paths, entry.Name)
+			}
+		}
+	} else {
+		err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.Type().IsRegular() {
+				paths = append(paths, name)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Sidecars are looked up by the folder they are in
+	folders := make(map[string]folderIndex)
+	for _, name := range paths {
+		dir, base := path.Split(name)
+		index, ok := folders[dir]
+		if !ok {
+			index = make(folderIndex)
+			folders[dir] = index
+		}
+		index[strings.ToLower(base)] = name
+	}
+
+	library := &Library{Source: source, fsys: fsys}
+	for _, name := range paths {
+		if isIgnored(name) || isSidecar(name) {
+			continue
+		}
+		dir, base := path.Split(name)
+		file := File{Path: name}
+		switch source {
+		case models.ImportSourceGoogleTakeout:
+			file.Sidecar = googleSidecarFor(folders[dir], base)
+		case models.ImportSourceApplePhotos:
+			file.Sidecar = appleSidecarFor(folders[dir], base)
+		}
+		library.Files = append(library.Files, file)
+	}
+	return library, nil
+}
+
+// Open opens a file of the export for reading
+func (l *Library) Open(file File) (fs.File, error) {
+	return l.fsys.Open(file.Path)
+}
+
+// Metadata reads the sidecar of a file. It returns nil when the file has none.
+func (l *Library) Metadata(file File) (*Metadata, error) {
+	if file.Sidecar == "" {
+		return nil, nil
+	}
+	data, err := readSidecar(l.fsys, file.Sidecar)
+	if errors.Is(err, errSidecarTooLarge) {
+		return nil, fmt.Errorf("invalid sidecar %s: %w", file.Sidecar, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata *Metadata
+	switch l.Source {
+	case models.ImportSourceGoogleTakeout:
+		metadata, err = parseGoogleSidecar(data)
+		if err == nil {
+			metadata.Title = googleTitleFor(file.Path, metadata.Title)
+		}
+	case models.ImportSourceApplePhotos:
+		metadata, err = parseXMPSidecar(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid sidecar %s: %w", file.Sidecar, err)
+	}
+	return metadata, nil
+}
+
+// Album returns the album a folder stands for, or nil when it stands for none
+func (l *Library) Album(folder string) (*Album, error) {
+	switch l.Source {
+	case models.ImportSourceGoogleTakeout:
+		return googleAlbum(l.fsys, folder)
+	case models.ImportSourceApplePhotos:
+		return appleAlbum(folder), nil
+	}
+	return nil, nil
+}
+
+// readSidecar reads a sidecar, refusing those larger than sidecarSizeLimit. The size a ZIP
+// entry claims is checked first, but the read is capped as well since the claim can lie.
+func readSidecar(fsys fs.FS, name string) ([]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil && info.Size() > sidecarSizeLimit {
+		return nil, errSidecarTooLarge
+	}
+
+	data, err := io.ReadAll(io.LimitReader(f, sidecarSizeLimit+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > sidecarSizeLimit {
+		return nil, errSidecarTooLarge
+	}
+	return data, nil
+}
+
+// folderIndex maps the lower-cased names of the files in a folder to their paths
+type folderIndex map[string]string
+
+// isIgnored reports whether a file is bookkeeping of the system or service that made the export
+func isIgnored(name string) bool {
+	base := path.Base(name)
+	return strings.HasPrefix(base, ".") || strings.HasPrefix(name, "__MACOSX/") ||
+		strings.EqualFold(base, "archive_browser.html") || strings.EqualFold(base, "Thumbs.db")
+}
+
+// isSidecar reports whether a file holds metadata rather than a photo
+func isSidecar(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	return ext == ".json" || ext == ".xmp"
+}
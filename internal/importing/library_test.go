@@ -0,0 +1,47 @@
+package importing
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestReadSidecar(t *testing.T) {
+	fsys := fstest.MapFS{
+		"small.json": {Data: []byte(`{"title":"IMG_1234.jpg"}`)},
+		"limit.json": {Data: []byte(strings.Repeat(" ", sidecarSizeLimit))},
+		"large.json": {Data: []byte(strings.Repeat(" ", sidecarSizeLimit+1))},
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		wantLen int
+		wantErr error
+	}{
+		{name: "small", file: "small.json", wantLen: 24},
+		{name: "at the limit", file: "limit.json", wantLen: sidecarSizeLimit},
+		{name: "over the limit", file: "large.json", wantErr: errSidecarTooLarge},
+		{name: "missing", file: "missing.json", wantErr: fs.ErrNotExist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := readSidecar(fsys, tt.file)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("readSidecar(%q) error = %v, want %v", tt.file, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readSidecar(%q) error = %v", tt.file, err)
+			}
+			if len(data) != tt.wantLen {
+				t.Errorf("readSidecar(%q) read %d bytes, want %d", tt.file, len(data), tt.wantLen)
+			}
+		})
+	}
+}
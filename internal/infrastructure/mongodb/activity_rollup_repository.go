@@ -0,0 +1,50 @@
+package mongodb
+
+import (
+	"context"
+
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const activityRollupCollection = "activity_rollups"
+
+type mongoActivityRollupRepository struct {
+	*BaseRepository
+}
+
+// NewActivityRollupRepository creates a new MongoDB activity rollup repository
+func NewActivityRollupRepository(db *mongo.Database) repositories.ActivityRollupRepository {
+	return &mongoActivityRollupRepository{
+		BaseRepository: NewBaseRepository(db, activityRollupCollection),
+	}
+}
+
+func (r *mongoActivityRollupRepository) Add(ctx context.Context, rollups []models.ActivityRollup) error {
+	if len(rollups) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(rollups))
+	for _, rollup := range rollups {
+		filter := bson.M{
+			"date":     rollup.Date,
+			"type":     rollup.Type,
+			"user_id":  rollup.UserID,
+			"photo_id": rollup.PhotoID,
+		}
+		update := bson.M{
+			"$inc": bson.M{"count": rollup.Count},
+			"$min": bson.M{"first_at": rollup.FirstAt},
+			"$max": bson.M{"last_at": rollup.LastAt},
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update).SetUpsert(true))
+	}
+
+	_, err := r.BulkWriteWithOptions(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
@@ -0,0 +1,112 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const albumInvitationCollection = "album_invitations"
+
+type mongoAlbumInvitationRepository struct {
+	*BaseRepository
+}
+
+// NewAlbumInvitationRepository creates a new MongoDB album invitation repository
+func NewAlbumInvitationRepository(db *mongo.Database) repositories.AlbumInvitationRepository {
+	return &mongoAlbumInvitationRepository{
+		BaseRepository: NewBaseRepository(db, albumInvitationCollection),
+	}
+}
+
+func (r *mongoAlbumInvitationRepository) Create(ctx context.Context, invitation *models.AlbumInvitation) error {
+	id, err := r.InsertOne(ctx, invitation)
+	if err != nil {
+		return err
+	}
+	invitation.ID = id
+	return nil
+}
+
+func (r *mongoAlbumInvitationRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.AlbumInvitation, error) {
+	var invitation models.AlbumInvitation
+	err := r.FindOne(ctx, bson.M{"_id": id}, &invitation)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *mongoAlbumInvitationRepository) ListPendingByAlbum(ctx context.Context, albumID primitive.ObjectID) ([]models.AlbumInvitation, error) {
+	return r.list(ctx, bson.M{"album_id": albumID, "status": models.InvitationStatusPending})
+}
+
+func (r *mongoAlbumInvitationRepository) ListPendingForUser(ctx context.Context, userID, username, email string) ([]models.AlbumInvitation, error) {
+	filter := addressedTo(userID, username, email)
+	filter["status"] = models.InvitationStatusPending
+	return r.list(ctx, filter)
+}
+
+func (r *mongoAlbumInvitationRepository) FindPending(ctx context.Context, albumID primitive.ObjectID, userID, username, email string) (*models.AlbumInvitation, error) {
+	filter := addressedTo(userID, username, email)
+	filter["album_id"] = albumID
+	filter["status"] = models.InvitationStatusPending
+
+	var invitation models.AlbumInvitation
+	err := r.FindOne(ctx, filter, &invitation)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *mongoAlbumInvitationRepository) Respond(ctx context.Context, id primitive.ObjectID, status models.InvitationStatus, respondedAt time.Time) error {
+	filter := bson.M{"_id": id, "status": models.InvitationStatusPending}
+	update := bson.M{"$set": bson.M{"status": status, "responded_at": respondedAt}}
+
+	result, err := r.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *mongoAlbumInvitationRepository) list(ctx context.Context, filter bson.M) ([]models.AlbumInvitation, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	var invitations []models.AlbumInvitation
+	err := r.FindMany(ctx, filter, opts, &invitations)
+	if err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// addressedTo matches invitations addressed to a user ID or, when given, a username or email
+// that had no account when the invitation was made
+func addressedTo(userID, username, email string) bson.M {
+	conditions := bson.A{bson.M{"invitee_id": userID}}
+	if username != "" {
+		conditions = append(conditions, bson.M{"invitee_id": bson.M{"$exists": false}, "username": username})
+	}
+	if email != "" {
+		conditions = append(conditions, bson.M{"invitee_id": bson.M{"$exists": false}, "email": email})
+	}
+	return bson.M{"$or": conditions}
+}
@@ -0,0 +1,151 @@
+package mongodb
+
+import (
+	"context"
+
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const albumCollection = "albums"
+
+type mongoAlbumRepository struct {
+	*BaseRepository
+}
+
+// NewAlbumRepository creates a new MongoDB album repository
+func NewAlbumRepository(db *mongo.Database) repositories.AlbumRepository {
+	return &mongoAlbumRepository{
+		BaseRepository: NewBaseRepository(db, albumCollection),
+	}
+}
+
+func (r *mongoAlbumRepository) Create(ctx context.Context, album *models.Album) error {
+	id, err := r.InsertOne(ctx, album)
+	if err != nil {
+		return err
+	}
+	album.ID = id
+	return nil
+}
+
+func (r *mongoAlbumRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Album, error) {
+	var album models.Album
+	err := r.FindOne(ctx, bson.M{"_id": id}, &album)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &album, nil
+}
+
+func (r *mongoAlbumRepository) Update(ctx context.Context, album *models.Album) error {
+	update := bson.M{"$set": bson.M{
+		"name":        album.Name,
+		"description": album.Description,
+		"updated_at":  album.UpdatedAt,
+	}}
+
+	result, err := r.UpdateOne(ctx, bson.M{"_id": album.ID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *mongoAlbumRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *mongoAlbumRepository) ListByOwner(ctx context.Context, ownerID string, page, limit int) ([]models.Album, error) {
+	return r.list(ctx, bson.M{"owner_id": ownerID}, page, limit)
+}
+
+func (r *mongoAlbumRepository) ListByMember(ctx context.Context, userID string, page, limit int) ([]models.Album, error) {
+	return r.list(ctx, bson.M{"members.user_id": userID}, page, limit)
+}
+
+func (r *mongoAlbumRepository) ListAccessible(ctx context.Context, ids []primitive.ObjectID, userID string) ([]models.Album, error) {
+	filter := bson.M{
+		"_id": bson.M{"$in": ids},
+		"$or": bson.A{
+			bson.M{"owner_id": userID},
+			bson.M{"members.user_id": userID},
+		},
+	}
+
+	var albums []models.Album
+	err := r.FindMany(ctx, filter, options.Find(), &albums)
+	if err != nil {
+		return nil, err
+	}
+	return albums, nil
+}
+
+func (r *mongoAlbumRepository) SetMember(ctx context.Context, id primitive.ObjectID, member models.AlbumMember) error {
+	// Change the role of an existing member first
+	result, err := r.UpdateOne(ctx,
+		bson.M{"_id": id, "members.user_id": member.UserID},
+		bson.M{"$set": bson.M{"members.$.role": member.Role}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	result, err = r.UpdateOne(ctx,
+		bson.M{"_id": id, "members.user_id": bson.M{"$ne": member.UserID}},
+		bson.M{"$push": bson.M{"members": member}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		// Either the album is gone or a concurrent request added the member
+		exists, err := r.CountDocuments(ctx, bson.M{"_id": id})
+		if err != nil {
+			return err
+		}
+		if exists == 0 {
+			return mongo.ErrNoDocuments
+		}
+	}
+	return nil
+}
+
+func (r *mongoAlbumRepository) RemoveMember(ctx context.Context, id primitive.ObjectID, userID string) error {
+	_, err := r.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$pull": bson.M{"members": bson.M{"user_id": userID}}})
+	return err
+}
+
+func (r *mongoAlbumRepository) list(ctx context.Context, filter bson.M, page, limit int) ([]models.Album, error) {
+	skip := (page - 1) * limit
+	opts := options.Find().
+		SetSkip(int64(skip)).
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	var albums []models.Album
+	err := r.FindMany(ctx, filter, opts, &albums)
+	if err != nil {
+		return nil, err
+	}
+	return albums, nil
+}
@@ -0,0 +1,73 @@
+package mongodb
+
+import (
+	"context"
+
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const auditCheckpointCollection = "audit_checkpoints"
+
+type mongoAuditCheckpointRepository struct {
+	*BaseRepository
+}
+
+// NewAuditCheckpointRepository creates a new MongoDB audit checkpoint repository
+func NewAuditCheckpointRepository(db *mongo.Database) repositories.AuditCheckpointRepository {
+	return &mongoAuditCheckpointRepository{
+		BaseRepository: NewBaseRepository(db, auditCheckpointCollection),
+	}
+}
+
+func (r *mongoAuditCheckpointRepository) Create(ctx context.Context, checkpoint *models.AuditCheckpoint) error {
+	id, err := r.InsertOne(ctx, checkpoint)
+	if err != nil {
+		return err
+	}
+	checkpoint.ID = id
+	return nil
+}
+
+func (r *mongoAuditCheckpointRepository) GetLatest(ctx context.Context) (*models.AuditCheckpoint, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}})
+
+	var checkpoint models.AuditCheckpoint
+	err := r.FindOneWithOptions(ctx, bson.M{}, opts, &checkpoint)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+func (r *mongoAuditCheckpointRepository) GetLatestPruned(ctx context.Context) (*models.AuditCheckpoint, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}})
+
+	var checkpoint models.AuditCheckpoint
+	err := r.FindOneWithOptions(ctx, bson.M{"prefix_pruned": true}, opts, &checkpoint)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+func (r *mongoAuditCheckpointRepository) List(ctx context.Context) ([]models.AuditCheckpoint, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "seq", Value: 1}, {Key: "created_at", Value: 1}})
+
+	var checkpoints []models.AuditCheckpoint
+	err := r.FindMany(ctx, bson.M{}, opts, &checkpoints)
+	if err != nil {
+		return nil, err
+	}
+	return checkpoints, nil
+}
@@ -0,0 +1,77 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const bulkJobCollection = "bulk_jobs"
+
+type mongoBulkJobRepository struct {
+	*BaseRepository
+}
+
+// NewBulkJobRepository creates a new MongoDB bulk job repository
+func NewBulkJobRepository(db *mongo.Database) repositories.BulkJobRepository {
+	return &mongoBulkJobRepository{
+		BaseRepository: NewBaseRepository(db, bulkJobCollection),
+	}
+}
+
+func (r *mongoBulkJobRepository) Create(ctx context.Context, job *models.BulkJob) error {
+	id, err := r.InsertOne(ctx, job)
+	if err != nil {
+		return err
+	}
+	job.ID = id
+	return nil
+}
+
+func (r *mongoBulkJobRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.BulkJob, error) {
+	var job models.BulkJob
+	err := r.FindOne(ctx, bson.M{"_id": id}, &job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *mongoBulkJobRepository) ListByOwner(ctx context.Context, ownerID string, page, limit int) ([]models.BulkJob, error) {
+	skip := (page - 1) * limit
+	opts := options.Find().
+		SetSkip(int64(skip)).
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetProjection(bson.M{"photo_ids": 0})
+
+	var jobs []models.BulkJob
+	if err := r.FindMany(ctx, bson.M{"owner_id": ownerID}, opts, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *mongoBulkJobRepository) ClaimNext(ctx context.Context, now, leaseUntil time.Time) (*models.BulkJob, error) {
+	return claimNextJob[models.BulkJob](ctx, r.BaseRepository, now, leaseUntil)
+}
+
+func (r *mongoBulkJobRepository) SaveProgress(ctx context.Context, job *models.BulkJob) error {
+	return saveJobProgress(ctx, r.BaseRepository, &job.Job, bson.M{
+		"processed": job.Processed,
+		"succeeded": job.Succeeded,
+		"failed":    job.Failed,
+		"failures":  job.Failures,
+		"cursor":    job.Cursor,
+	})
+}
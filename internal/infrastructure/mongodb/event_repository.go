@@ -0,0 +1,70 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const eventCollection = "events"
+
+type mongoEventRepository struct {
+	*BaseRepository
+}
+
+// NewEventRepository creates a new MongoDB event repository. Watching it
+// needs change streams, which MongoDB only offers on replica sets.
+func NewEventRepository(db *mongo.Database) repositories.EventRepository {
+	return &mongoEventRepository{
+		BaseRepository: NewBaseRepository(db, eventCollection),
+	}
+}
+
+func (r *mongoEventRepository) Create(ctx context.Context, event *models.Event) error {
+	id, err := r.InsertOne(ctx, event)
+	if err != nil {
+		return err
+	}
+	event.ID = id
+	return nil
+}
+
+func (r *mongoEventRepository) Watch(ctx context.Context, resumeAfter []byte, handle func(event *models.Event, resumeToken []byte)) error {
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{"operationType": "insert"}}}}
+	opts := options.ChangeStream()
+	if len(resumeAfter) > 0 {
+		opts.SetResumeAfter(bson.Raw(resumeAfter))
+	}
+
+	stream, err := r.BaseRepository.Watch(ctx, pipeline, opts)
+	if err != nil {
+		return err
+	}
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		var change struct {
+			Event models.Event `bson:"fullDocument"`
+		}
+		if err := stream.Decode(&change); err != nil {
+			return err
+		}
+		token := make([]byte, len(stream.ResumeToken()))
+		copy(token, stream.ResumeToken())
+		handle(&change.Event, token)
+	}
+	if err := stream.Err(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	// The stream ends without an error when it is invalidated, e.g. by dropping the collection
+	return errors.New("the change stream was closed")
+}
@@ -0,0 +1,112 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const exportJobCollection = "export_jobs"
+
+type mongoExportJobRepository struct {
+	*BaseRepository
+}
+
+// NewExportJobRepository creates a new MongoDB export job repository
+func NewExportJobRepository(db *mongo.Database) repositories.ExportJobRepository {
+	return &mongoExportJobRepository{
+		BaseRepository: NewBaseRepository(db, exportJobCollection),
+	}
+}
+
+func (r *mongoExportJobRepository) Create(ctx context.Context, job *models.ExportJob) error {
+	id, err := r.InsertOne(ctx, job)
+	if err != nil {
+		return err
+	}
+	job.ID = id
+	return nil
+}
+
+func (r *mongoExportJobRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.ExportJob, error) {
+	var job models.ExportJob
+	err := r.FindOne(ctx, bson.M{"_id": id}, &job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *mongoExportJobRepository) ListByOwner(ctx context.Context, ownerID string, page, limit int) ([]models.ExportJob, error) {
+	skip := (page - 1) * limit
+	opts := options.Find().
+		SetSkip(int64(skip)).
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetProjection(bson.M{"photo_ids": 0})
+
+	var jobs []models.ExportJob
+	if err := r.FindMany(ctx, bson.M{"owner_id": ownerID}, opts, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *mongoExportJobRepository) GetPending(ctx context.Context, ownerID string, kind models.ExportKind) (*models.ExportJob, error) {
+	filter := bson.M{
+		"owner_id": ownerID,
+		"kind":     kind,
+		"status":   bson.M{"$in": []models.ExportJobStatus{models.ExportJobQueued, models.ExportJobRunning}},
+	}
+	opts := options.FindOne().SetProjection(bson.M{"photo_ids": 0})
+
+	var job models.ExportJob
+	err := r.FindOneWithOptions(ctx, filter, opts, &job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *mongoExportJobRepository) ClaimNext(ctx context.Context, now, leaseUntil time.Time) (*models.ExportJob, error) {
+	return claimNextJob[models.ExportJob](ctx, r.BaseRepository, now, leaseUntil)
+}
+
+func (r *mongoExportJobRepository) SaveProgress(ctx context.Context, job *models.ExportJob) error {
+	return saveJobProgress(ctx, r.BaseRepository, &job.Job, bson.M{
+		"total":       job.Total,
+		"processed":   job.Processed,
+		"failed":      job.Failed,
+		"failures":    job.Failures,
+		"storage_key": job.StorageKey,
+		"size":        job.Size,
+		"expires_at":  job.ExpiresAt,
+	})
+}
+
+func (r *mongoExportJobRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]models.ExportJob, error) {
+	filter := bson.M{"status": models.ExportJobCompleted, "expires_at": bson.M{"$lte": now}}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "expires_at", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"photo_ids": 0})
+
+	var jobs []models.ExportJob
+	if err := r.FindMany(ctx, filter, opts, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
@@ -0,0 +1,94 @@
+package mongodb
+
+import (
+	"context"
+
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const importFileCollection = "import_files"
+
+type mongoImportFileRepository struct {
+	*BaseRepository
+}
+
+// NewImportFileRepository creates a new MongoDB repository for the file outcomes of imports
+func NewImportFileRepository(db *mongo.Database) repositories.ImportFileRepository {
+	return &mongoImportFileRepository{
+		BaseRepository: NewBaseRepository(db, importFileCollection),
+	}
+}
+
+func (r *mongoImportFileRepository) CreateMany(ctx context.Context, files []models.ImportFile) error {
+	if len(files) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(files))
+	for _, file := range files {
+		filter := bson.M{"job_id": file.JobID, "path": file.Path}
+		update := bson.M{"$setOnInsert": file}
+		writes = append(writes, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update).SetUpsert(true))
+	}
+
+	_, err := r.BulkWriteWithOptions(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+func (r *mongoImportFileRepository) ListByJob(ctx context.Context, jobID primitive.ObjectID, status models.ImportFileStatus, page, limit int) ([]models.ImportFile, error) {
+	filter := bson.M{"job_id": jobID}
+	if status != "" {
+		filter["status"] = status
+	}
+	skip := (page - 1) * limit
+	opts := options.Find().
+		SetSkip(int64(skip)).
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "_id", Value: 1}})
+
+	var files []models.ImportFile
+	if err := r.FindMany(ctx, filter, opts, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func (r *mongoImportFileRepository) ListPaths(ctx context.Context, jobID primitive.ObjectID) ([]string, error) {
+	opts := options.Find().SetProjection(bson.M{"path": 1})
+
+	var files []models.ImportFile
+	if err := r.FindMany(ctx, bson.M{"job_id": jobID}, opts, &files); err != nil {
+		return nil, err
+	}
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = file.Path
+	}
+	return paths, nil
+}
+
+func (r *mongoImportFileRepository) CountByStatus(ctx context.Context, jobID primitive.ObjectID) (map[models.ImportFileStatus]int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"job_id": jobID}}},
+		{{Key: "$group", Value: bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}}},
+	}
+
+	var groups []struct {
+		Status models.ImportFileStatus `bson:"_id"`
+		Count  int64                   `bson:"count"`
+	}
+	if err := r.Aggregate(ctx, pipeline, &groups); err != nil {
+		return nil, err
+	}
+	counts := make(map[models.ImportFileStatus]int64, len(groups))
+	for _, group := range groups {
+		counts[group.Status] = group.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,79 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const importJobCollection = "import_jobs"
+
+type mongoImportJobRepository struct {
+	*BaseRepository
+}
+
+// NewImportJobRepository creates a new MongoDB import job repository
+func NewImportJobRepository(db *mongo.Database) repositories.ImportJobRepository {
+	return &mongoImportJobRepository{
+		BaseRepository: NewBaseRepository(db, importJobCollection),
+	}
+}
+
+func (r *mongoImportJobRepository) Create(ctx context.Context, job *models.ImportJob) error {
+	id, err := r.InsertOne(ctx, job)
+	if err != nil {
+		return err
+	}
+	job.ID = id
+	return nil
+}
+
+func (r *mongoImportJobRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.ImportJob, error) {
+	var job models.ImportJob
+	err := r.FindOne(ctx, bson.M{"_id": id}, &job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *mongoImportJobRepository) ListByOwner(ctx context.Context, ownerID string, page, limit int) ([]models.ImportJob, error) {
+	skip := (page - 1) * limit
+	opts := options.Find().
+		SetSkip(int64(skip)).
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	var jobs []models.ImportJob
+	if err := r.FindMany(ctx, bson.M{"owner_id": ownerID}, opts, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *mongoImportJobRepository) ClaimNext(ctx context.Context, now, leaseUntil time.Time) (*models.ImportJob, error) {
+	return claimNextJob[models.ImportJob](ctx, r.BaseRepository, now, leaseUntil)
+}
+
+func (r *mongoImportJobRepository) SaveProgress(ctx context.Context, job *models.ImportJob) error {
+	return saveJobProgress(ctx, r.BaseRepository, &job.Job, bson.M{
+		"total":       job.Total,
+		"processed":   job.Processed,
+		"imported":    job.Imported,
+		"duplicates":  job.Duplicates,
+		"skipped":     job.Skipped,
+		"failed":      job.Failed,
+		"albums":      job.Albums,
+		"storage_key": job.StorageKey,
+	})
+}
@@ -0,0 +1,131 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// eventRetentionSeconds is how long shared events are kept
+const eventRetentionSeconds = 24 * 60 * 60
+
+// bulkJobRetentionSeconds is how long finished bulk jobs are kept
+const bulkJobRetentionSeconds = 30 * 24 * 60 * 60
+
+// exportJobRetentionSeconds is how long finished export jobs are kept. It is longer than
+// archives are kept, so their archives are deleted before the jobs are.
+const exportJobRetentionSeconds = 30 * 24 * 60 * 60
+
+// importJobRetentionSeconds is how long finished import jobs and the outcomes of their files are kept
+const importJobRetentionSeconds = 30 * 24 * 60 * 60
+
+// webhookDeliveryRetentionSeconds is how long the delivery log of webhooks is kept
+const webhookDeliveryRetentionSeconds = 30 * 24 * 60 * 60
+
+// collectionIndexes lists the indexes each collection needs
+var collectionIndexes = map[string][]mongo.IndexModel{
+	userCollection: {
+		{Keys: bson.D{{Key: "username", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+	photoCollection: {
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "uploaded_at", Value: -1}}},
+		{Keys: bson.D{{Key: "album_ids", Value: 1}, {Key: "uploaded_at", Value: -1}}},
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "content_hash", Value: 1}}},
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "versions.hash", Value: 1}}},
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "tags", Value: 1}}},
+	},
+	albumCollection: {
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "members.user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	albumInvitationCollection: {
+		{Keys: bson.D{{Key: "album_id", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "invitee_id", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "username", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "email", Value: 1}, {Key: "status", Value: 1}}},
+	},
+	shareLinkCollection: {
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	userActivityCollection: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "photo_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "timestamp", Value: -1}}},
+		// Each audit chain position is taken once, even with several writers
+		{Keys: bson.D{{Key: "seq", Value: 1}}, Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"seq": bson.M{"$exists": true}})},
+	},
+	activityRollupCollection: {
+		{Keys: bson.D{{Key: "date", Value: 1}, {Key: "type", Value: 1}, {Key: "user_id", Value: 1}, {Key: "photo_id", Value: 1}},
+			Options: options.Index().SetUnique(true)},
+	},
+	auditCheckpointCollection: {
+		{Keys: bson.D{{Key: "seq", Value: -1}}},
+		{Keys: bson.D{{Key: "prefix_pruned", Value: 1}, {Key: "seq", Value: -1}}, Options: options.Index().
+			SetPartialFilterExpression(bson.M{"prefix_pruned": true})},
+	},
+	// Stream tickets are used up at once, and removed once they expire unused
+	streamTicketCollection: {
+		{Keys: bson.D{{Key: "ticket_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	},
+	// Events only need to outlive the change stream resume window
+	eventCollection: {
+		{Keys: bson.D{{Key: "timestamp", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(eventRetentionSeconds)},
+	},
+	syncChangeCollection: {
+		// Each position in a user's feed is taken once, even with several writers
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "seq", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+	bulkJobCollection: {
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+		// Only finished jobs have a finish time, so running ones never expire
+		{Keys: bson.D{{Key: "finished_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(bulkJobRetentionSeconds)},
+	},
+	exportJobCollection: {
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "expires_at", Value: 1}}},
+		{Keys: bson.D{{Key: "finished_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(exportJobRetentionSeconds)},
+	},
+	importJobCollection: {
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "finished_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(importJobRetentionSeconds)},
+	},
+	importFileCollection: {
+		// Each file of a job has one outcome, even when an interrupted job goes over it again
+		{Keys: bson.D{{Key: "job_id", Value: 1}, {Key: "path", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "job_id", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "job_id", Value: 1}, {Key: "status", Value: 1}, {Key: "_id", Value: 1}}},
+		// Outcomes are recorded while their job runs, so they expire slightly before it
+		{Keys: bson.D{{Key: "created_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(importJobRetentionSeconds)},
+	},
+	webhookCollection: {
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	},
+	webhookDeliveryCollection: {
+		{Keys: bson.D{{Key: "webhook_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "created_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(webhookDeliveryRetentionSeconds)},
+	},
+}
+
+// EnsureIndexes creates any missing indexes. Existing indexes are left untouched.
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	for collection, indexes := range collectionIndexes {
+		if _, err := NewBaseRepository(db, collection).CreateIndexes(ctx, indexes); err != nil {
+			return fmt.Errorf("failed to create indexes on %s: %w", collection, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"photocloud/internal/domain/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// claimNextJob marks the oldest queued job of a collection, or a running job whose lease
+// ran out by now, as running until leaseUntil and counts the attempt. It returns nil when
+// there is none.
+func claimNextJob[J any](ctx context.Context, r *BaseRepository, now, leaseUntil time.Time) (*J, error) {
+	filter := bson.M{"$or": []bson.M{
+		{"status": models.JobQueued},
+		{"status": models.JobRunning, "lease_until": bson.M{"$lte": now}},
+	}}
+	update := bson.M{
+		"$set": bson.M{"status": models.JobRunning, "lease_until": leaseUntil},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job J
+	err := r.FindOneAndUpdate(ctx, filter, update, opts, &job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// saveJobProgress stores the status, lease and times of a job along with the progress
+// fields of its kind, provided no other worker claimed it since. It returns
+// mongo.ErrNoDocuments otherwise.
+func saveJobProgress(ctx context.Context, r *BaseRepository, job *models.Job, progress bson.M) error {
+	fields := bson.M{
+		"status":      job.Status,
+		"error":       job.Error,
+		"lease_until": job.LeaseUntil,
+		"started_at":  job.StartedAt,
+		"finished_at": job.FinishedAt,
+	}
+	for key, value := range progress {
+		fields[key] = value
+	}
+
+	result, err := r.UpdateOne(ctx, bson.M{"_id": job.ID, "attempts": job.Attempts}, bson.M{"$set": fields})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
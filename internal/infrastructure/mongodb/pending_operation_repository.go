@@ -0,0 +1,63 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const pendingOperationCollection = "pending_operations"
+
+type mongoPendingOperationRepository struct {
+	*BaseRepository
+}
+
+// NewPendingOperationRepository creates a new MongoDB pending operation repository
+func NewPendingOperationRepository(db *mongo.Database) repositories.PendingOperationRepository {
+	return &mongoPendingOperationRepository{
+		BaseRepository: NewBaseRepository(db, pendingOperationCollection),
+	}
+}
+
+func (r *mongoPendingOperationRepository) Create(ctx context.Context, op *models.PendingOperation) error {
+	id, err := r.InsertOne(ctx, op)
+	if err != nil {
+		return err
+	}
+	op.ID = id
+	return nil
+}
+
+func (r *mongoPendingOperationRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *mongoPendingOperationRepository) RecordFailure(ctx context.Context, id primitive.ObjectID, message string) error {
+	update := bson.M{
+		"$inc": bson.M{"attempts": 1},
+		"$set": bson.M{"last_error": message, "updated_at": time.Now()},
+	}
+	_, err := r.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+func (r *mongoPendingOperationRepository) ListStale(ctx context.Context, cutoff time.Time, limit int) ([]models.PendingOperation, error) {
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	var ops []models.PendingOperation
+	err := r.FindMany(ctx, bson.M{"created_at": bson.M{"$lt": cutoff}}, opts, &ops)
+	if err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
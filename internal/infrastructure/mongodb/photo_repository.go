@@ -2,6 +2,8 @@ package mongodb
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"photocloud/internal/domain/models"
 	"photocloud/internal/domain/repositories"
@@ -14,6 +16,24 @@ import (
 
 const photoCollection = "photos"
 
+// ownedBy narrows a filter to the photos of an owner; an empty owner ID matches every owner
+func ownedBy(ownerID string, filter bson.M) bson.M {
+	if ownerID == "" {
+		return filter
+	}
+	owned := bson.M{"owner_id": ownerID}
+	for key, value := range filter {
+		owned[key] = value
+	}
+	return owned
+}
+
+// notTrashed matches photos that have not been moved to the trash
+var notTrashed = bson.M{"deleted_at": bson.M{"$exists": false}}
+
+// trashed matches photos that have been moved to the trash
+var trashed = bson.M{"deleted_at": bson.M{"$exists": true}}
+
 type mongoPhotoRepository struct {
 	*BaseRepository
 }
@@ -46,8 +66,49 @@ func (r *mongoPhotoRepository) GetByID(ctx context.Context, id primitive.ObjectI
 	return &photo, nil
 }
 
-func (r *mongoPhotoRepository) Update(ctx context.Context, photo *models.Photo) error {
-	result, err := r.UpdateOne(ctx, bson.M{"_id": photo.ID}, bson.M{"$set": photo})
+func (r *mongoPhotoRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Photo, error) {
+	var photos []models.Photo
+	if err := r.FindMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find(), &photos); err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
+
+func (r *mongoPhotoRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *mongoPhotoRepository) List(ctx context.Context, ownerID string, page, limit int) ([]models.Photo, error) {
+	skip := (page - 1) * limit
+	opts := options.Find().
+		SetSkip(int64(skip)).
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "uploaded_at", Value: -1}})
+
+	var photos []models.Photo
+	err := r.FindMany(ctx, ownedBy(ownerID, notTrashed), opts, &photos)
+	if err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
+
+func (r *mongoPhotoRepository) Count(ctx context.Context) (int64, error) {
+	return r.CountDocuments(ctx, notTrashed)
+}
+
+func (r *mongoPhotoRepository) MoveToTrash(ctx context.Context, id primitive.ObjectID, deletedAt time.Time) error {
+	filter := bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"deleted_at": deletedAt, "updated_at": deletedAt}}
+
+	result, err := r.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return err
 	}
@@ -57,23 +118,255 @@ func (r *mongoPhotoRepository) Update(ctx context.Context, photo *models.Photo)
 	return nil
 }
 
-func (r *mongoPhotoRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
-	result, err := r.DeleteOne(ctx, bson.M{"_id": id})
+func (r *mongoPhotoRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id, "deleted_at": bson.M{"$exists": true}}
+	update := bson.M{
+		"$unset": bson.M{"deleted_at": ""},
+		"$set":   bson.M{"updated_at": time.Now()},
+	}
+
+	result, err := r.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return err
 	}
-	if result.DeletedCount == 0 {
+	if result.MatchedCount == 0 {
 		return mongo.ErrNoDocuments
 	}
 	return nil
 }
 
-func (r *mongoPhotoRepository) List(ctx context.Context, page, limit int) ([]models.Photo, error) {
+func (r *mongoPhotoRepository) MoveManyToTrash(ctx context.Context, ids []primitive.ObjectID, deletedAt time.Time) (int64, error) {
+	filter := bson.M{"_id": bson.M{"$in": ids}, "deleted_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"deleted_at": deletedAt, "updated_at": deletedAt}}
+
+	result, err := r.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (r *mongoPhotoRepository) RestoreMany(ctx context.Context, ids []primitive.ObjectID, updatedAt time.Time) (int64, error) {
+	filter := bson.M{"_id": bson.M{"$in": ids}, "deleted_at": bson.M{"$exists": true}}
+	update := bson.M{
+		"$unset": bson.M{"deleted_at": ""},
+		"$set":   bson.M{"updated_at": updatedAt},
+	}
+
+	result, err := r.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (r *mongoPhotoRepository) UpdateTags(ctx context.Context, ids []primitive.ObjectID, add, remove []string, updatedAt time.Time) error {
+	// A tag cannot be added and pulled in the same update, so each is a write of its own
+	filter := bson.M{"_id": bson.M{"$in": ids}}
+	var writes []mongo.WriteModel
+	if len(add) > 0 {
+		writes = append(writes, mongo.NewUpdateManyModel().SetFilter(filter).SetUpdate(bson.M{
+			"$addToSet": bson.M{"tags": bson.M{"$each": add}},
+			"$set":      bson.M{"updated_at": updatedAt},
+		}))
+	}
+	if len(remove) > 0 {
+		writes = append(writes, mongo.NewUpdateManyModel().SetFilter(filter).SetUpdate(bson.M{
+			"$pull": bson.M{"tags": bson.M{"$in": remove}},
+			"$set":  bson.M{"updated_at": updatedAt},
+		}))
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+
+	_, err := r.BulkWrite(ctx, writes)
+	return err
+}
+
+// photoFilter builds the query for the photos of an owner matching a filter
+func photoFilter(ownerID string, filter models.PhotoFilter, inTrash bool) bson.M {
+	query := bson.M{"owner_id": ownerID}
+	if inTrash {
+		query["deleted_at"] = trashed["deleted_at"]
+	} else {
+		query["deleted_at"] = notTrashed["deleted_at"]
+	}
+	if !filter.AlbumID.IsZero() {
+		query["album_ids"] = filter.AlbumID
+	}
+	if len(filter.Tags) > 0 {
+		query["tags"] = bson.M{"$all": filter.Tags}
+	}
+	uploaded := bson.M{}
+	if filter.UploadedAfter != nil {
+		uploaded["$gte"] = *filter.UploadedAfter
+	}
+	if filter.UploadedBefore != nil {
+		uploaded["$lt"] = *filter.UploadedBefore
+	}
+	if len(uploaded) > 0 {
+		query["uploaded_at"] = uploaded
+	}
+	return query
+}
+
+func (r *mongoPhotoRepository) ListByFilter(ctx context.Context, ownerID string, filter models.PhotoFilter, trashed bool, afterID primitive.ObjectID, limit int) ([]models.Photo, error) {
+	query := photoFilter(ownerID, filter, trashed)
+	if !afterID.IsZero() {
+		query["_id"] = bson.M{"$gt": afterID}
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+
+	var photos []models.Photo
+	if err := r.FindMany(ctx, query, opts, &photos); err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
+
+func (r *mongoPhotoRepository) CountByFilter(ctx context.Context, ownerID string, filter models.PhotoFilter, trashed bool) (int64, error) {
+	return r.CountDocuments(ctx, photoFilter(ownerID, filter, trashed))
+}
+
+func (r *mongoPhotoRepository) ListTrashed(ctx context.Context, ownerID string, page, limit int) ([]models.Photo, error) {
 	skip := (page - 1) * limit
 	opts := options.Find().
 		SetSkip(int64(skip)).
 		SetLimit(int64(limit)).
-		SetSort(bson.D{{Key: "uploaded_at", Value: -1}})
+		SetSort(bson.D{{Key: "deleted_at", Value: -1}})
+
+	var photos []models.Photo
+	err := r.FindMany(ctx, ownedBy(ownerID, trashed), opts, &photos)
+	if err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
+
+func (r *mongoPhotoRepository) ListTrashedBefore(ctx context.Context, cutoff time.Time, limit int) ([]models.Photo, error) {
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "deleted_at", Value: 1}})
+
+	filter := bson.M{"deleted_at": bson.M{"$lt": cutoff}}
+
+	var photos []models.Photo
+	err := r.FindMany(ctx, filter, opts, &photos)
+	if err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
+
+func (r *mongoPhotoRepository) SetOrientation(ctx context.Context, photo *models.Photo, previousUpdatedAt time.Time) error {
+	filter := bson.M{"_id": photo.ID, "updated_at": previousUpdatedAt}
+	set := bson.M{
+		"orientation": photo.Orientation,
+		"updated_at":  photo.UpdatedAt,
+	}
+	if len(photo.Versions) > 0 {
+		set[fmt.Sprintf("versions.%d.orientation", len(photo.Versions)-1)] = photo.Versions[len(photo.Versions)-1].Orientation
+	}
+	update := bson.M{"$set": set}
+	if len(photo.Edits) > 0 {
+		set["edits"] = photo.Edits
+	} else {
+		update["$unset"] = bson.M{"edits": ""}
+	}
+
+	result, err := r.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *mongoPhotoRepository) SetEdits(ctx context.Context, id primitive.ObjectID, edits []models.EditOperation, updatedAt time.Time) error {
+	update := bson.M{"$set": bson.M{"edits": edits, "updated_at": updatedAt}}
+	if len(edits) == 0 {
+		update = bson.M{
+			"$unset": bson.M{"edits": ""},
+			"$set":   bson.M{"updated_at": updatedAt},
+		}
+	}
+
+	result, err := r.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *mongoPhotoRepository) AggregateUsage(ctx context.Context, ownerIDs ...string) ([]models.StorageUsage, error) {
+	var pipeline bson.A
+	if len(ownerIDs) > 0 {
+		pipeline = append(pipeline, bson.M{"$match": bson.M{"owner_id": bson.M{"$in": ownerIDs}}})
+	}
+	pipeline = append(pipeline,
+		// Previous versions are the recorded versions other than the current file
+		bson.M{"$project": bson.M{
+			"owner_id": bson.M{"$ifNull": bson.A{"$owner_id", ""}},
+			"size":     1,
+			"previous": bson.M{"$filter": bson.M{
+				"input": bson.M{"$ifNull": bson.A{"$versions", bson.A{}}},
+				"as":    "version",
+				"cond":  bson.M{"$ne": bson.A{"$$version.s3_key", "$s3_key"}},
+			}},
+		}},
+		bson.M{"$group": bson.M{
+			"_id":           "$owner_id",
+			"photos":        bson.M{"$sum": 1},
+			"original_size": bson.M{"$sum": "$size"},
+			"version_size":  bson.M{"$sum": bson.M{"$sum": "$previous.size"}},
+			"version_files": bson.M{"$sum": bson.M{"$size": "$previous"}},
+		}},
+		bson.M{"$project": bson.M{
+			"photos":    1,
+			"originals": bson.M{"bytes": "$original_size", "files": "$photos"},
+			"versions":  bson.M{"bytes": "$version_size", "files": "$version_files"},
+		}},
+	)
+
+	var usages []models.StorageUsage
+	if err := r.Aggregate(ctx, pipeline, &usages); err != nil {
+		return nil, err
+	}
+	return usages, nil
+}
+
+func (r *mongoPhotoRepository) GetOwners(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]string, error) {
+	opts := options.Find().SetProjection(bson.M{"owner_id": 1})
+
+	var photos []models.Photo
+	err := r.FindMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, opts, &photos)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := make(map[primitive.ObjectID]string, len(photos))
+	for _, photo := range photos {
+		owners[photo.ID] = photo.OwnerID
+	}
+	return owners, nil
+}
+
+func (r *mongoPhotoRepository) ListStorageKeys(ctx context.Context) ([]models.Photo, error) {
+	opts := options.Find().SetProjection(bson.M{
+		"name":       1,
+		"size":       1,
+		"s3_key":     1,
+		"versions":   1,
+		"deleted_at": 1,
+	})
 
 	var photos []models.Photo
 	err := r.FindMany(ctx, bson.M{}, opts, &photos)
@@ -83,6 +376,142 @@ func (r *mongoPhotoRepository) List(ctx context.Context, page, limit int) ([]mod
 	return photos, nil
 }
 
-func (r *mongoPhotoRepository) Count(ctx context.Context) (int64, error) {
-	return r.CountDocuments(ctx, bson.M{})
+func (r *mongoPhotoRepository) UpdateVersions(ctx context.Context, photo *models.Photo, previousVersion int) error {
+	filter := bson.M{"_id": photo.ID, "version": previousVersion}
+	if previousVersion == 0 {
+		// Photos uploaded before versions were tracked have no version number
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	update := bson.M{"$set": bson.M{
+		"s3_key":       photo.S3Key,
+		"size":         photo.Size,
+		"content_type": photo.ContentType,
+		"content_hash": photo.ContentHash,
+		"orientation":  photo.Orientation,
+		"version":      photo.Version,
+		"versions":     photo.Versions,
+		"updated_at":   photo.UpdatedAt,
+	}}
+
+	result, err := r.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *mongoPhotoRepository) RemoveVersion(ctx context.Context, id primitive.ObjectID, key string) (bool, error) {
+	filter := bson.M{"_id": id, "s3_key": bson.M{"$ne": key}}
+	update := bson.M{"$pull": bson.M{"versions": bson.M{"s3_key": key}}}
+
+	result, err := r.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+func (r *mongoPhotoRepository) ListWithPrunableVersions(ctx context.Context, keep int, cutoff time.Time, limit int) ([]models.Photo, error) {
+	// Versions are kept oldest first and the last one is current
+	var conditions bson.A
+	if keep >= 0 {
+		conditions = append(conditions, bson.M{fmt.Sprintf("versions.%d", keep+1): bson.M{"$exists": true}})
+	}
+	if !cutoff.IsZero() {
+		conditions = append(conditions, bson.M{
+			"versions.1":            bson.M{"$exists": true},
+			"versions.0.created_at": bson.M{"$lt": cutoff},
+		})
+	}
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+
+	var photos []models.Photo
+	err := r.FindMany(ctx, bson.M{"$or": conditions}, options.Find().SetLimit(int64(limit)), &photos)
+	if err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
+
+func (r *mongoPhotoRepository) ListByContentHash(ctx context.Context, ownerID string, hashes []string) ([]models.Photo, error) {
+	opts := options.Find().SetProjection(bson.M{
+		"content_hash":  1,
+		"size":          1,
+		"versions.hash": 1,
+		"versions.size": 1,
+		"deleted_at":    1,
+	})
+
+	var photos []models.Photo
+	filter := bson.M{"owner_id": ownerID, "$or": bson.A{
+		bson.M{"content_hash": bson.M{"$in": hashes}},
+		bson.M{"versions.hash": bson.M{"$in": hashes}},
+	}}
+	if err := r.FindMany(ctx, filter, opts, &photos); err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
+
+func (r *mongoPhotoRepository) ClaimUnowned(ctx context.Context, ownerID, fromOwnerID string) (int64, error) {
+	filter := bson.M{"owner_id": bson.M{"$exists": false}}
+	if fromOwnerID != "" {
+		filter = bson.M{"$or": bson.A{filter, bson.M{"owner_id": fromOwnerID}}}
+	}
+	update := bson.M{"$set": bson.M{"owner_id": ownerID}}
+
+	result, err := r.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (r *mongoPhotoRepository) AddToAlbum(ctx context.Context, albumID primitive.ObjectID, photoIDs []primitive.ObjectID, ownerID string) (int64, error) {
+	filter := ownedBy(ownerID, bson.M{"_id": bson.M{"$in": photoIDs}})
+	update := bson.M{"$addToSet": bson.M{"album_ids": albumID}}
+
+	result, err := r.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (r *mongoPhotoRepository) RemoveFromAlbum(ctx context.Context, albumID primitive.ObjectID, photoIDs []primitive.ObjectID, ownerID string) (int64, error) {
+	filter := ownedBy(ownerID, bson.M{"_id": bson.M{"$in": photoIDs}, "album_ids": albumID})
+	update := bson.M{"$pull": bson.M{"album_ids": albumID}}
+
+	result, err := r.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (r *mongoPhotoRepository) RemoveAlbum(ctx context.Context, albumID primitive.ObjectID) error {
+	_, err := r.UpdateMany(ctx, bson.M{"album_ids": albumID}, bson.M{"$pull": bson.M{"album_ids": albumID}})
+	return err
+}
+
+func (r *mongoPhotoRepository) ListByAlbum(ctx context.Context, albumID primitive.ObjectID, page, limit int) ([]models.Photo, error) {
+	skip := (page - 1) * limit
+	opts := options.Find().
+		SetSkip(int64(skip)).
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "uploaded_at", Value: -1}})
+
+	filter := bson.M{"album_ids": albumID, "deleted_at": bson.M{"$exists": false}}
+
+	var photos []models.Photo
+	err := r.FindMany(ctx, filter, opts, &photos)
+	if err != nil {
+		return nil, err
+	}
+	return photos, nil
 }
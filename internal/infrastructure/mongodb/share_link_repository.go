@@ -0,0 +1,89 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const shareLinkCollection = "share_links"
+
+type mongoShareLinkRepository struct {
+	*BaseRepository
+}
+
+// NewShareLinkRepository creates a new MongoDB share link repository
+func NewShareLinkRepository(db *mongo.Database) repositories.ShareLinkRepository {
+	return &mongoShareLinkRepository{
+		BaseRepository: NewBaseRepository(db, shareLinkCollection),
+	}
+}
+
+func (r *mongoShareLinkRepository) Create(ctx context.Context, link *models.ShareLink) error {
+	id, err := r.InsertOne(ctx, link)
+	if err != nil {
+		return err
+	}
+	link.ID = id
+	return nil
+}
+
+func (r *mongoShareLinkRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.ShareLink, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *mongoShareLinkRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.ShareLink, error) {
+	return r.findOne(ctx, bson.M{"token_hash": tokenHash})
+}
+
+func (r *mongoShareLinkRepository) ListByOwner(ctx context.Context, ownerID string, page, limit int) ([]models.ShareLink, error) {
+	skip := (page - 1) * limit
+	opts := options.Find().
+		SetSkip(int64(skip)).
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	var links []models.ShareLink
+	err := r.FindMany(ctx, bson.M{"owner_id": ownerID}, opts, &links)
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+func (r *mongoShareLinkRepository) Revoke(ctx context.Context, id primitive.ObjectID, revokedAt time.Time) error {
+	filter := bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"revoked_at": revokedAt}}
+
+	_, err := r.UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (r *mongoShareLinkRepository) RecordView(ctx context.Context, id primitive.ObjectID, viewedAt time.Time) error {
+	update := bson.M{
+		"$inc": bson.M{"view_count": 1},
+		"$set": bson.M{"last_viewed_at": viewedAt},
+	}
+
+	_, err := r.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+func (r *mongoShareLinkRepository) findOne(ctx context.Context, filter bson.M) (*models.ShareLink, error) {
+	var link models.ShareLink
+	err := r.FindOne(ctx, filter, &link)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
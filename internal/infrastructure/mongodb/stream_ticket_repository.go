@@ -0,0 +1,47 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const streamTicketCollection = "stream_tickets"
+
+type mongoStreamTicketRepository struct {
+	*BaseRepository
+}
+
+// NewStreamTicketRepository creates a new MongoDB stream ticket repository
+func NewStreamTicketRepository(db *mongo.Database) repositories.StreamTicketRepository {
+	return &mongoStreamTicketRepository{
+		BaseRepository: NewBaseRepository(db, streamTicketCollection),
+	}
+}
+
+func (r *mongoStreamTicketRepository) Create(ctx context.Context, ticket *models.StreamTicket) error {
+	id, err := r.InsertOne(ctx, ticket)
+	if err != nil {
+		return err
+	}
+	ticket.ID = id
+	return nil
+}
+
+func (r *mongoStreamTicketRepository) Consume(ctx context.Context, ticketHash string, now time.Time) (*models.StreamTicket, error) {
+	// Deleting the ticket as it is read makes sure only one connection can use it
+	var ticket models.StreamTicket
+	err := r.FindOneAndDelete(ctx, bson.M{"ticket_hash": ticketHash, "expires_at": bson.M{"$gt": now}}, nil, &ticket)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
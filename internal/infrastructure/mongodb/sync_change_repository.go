@@ -0,0 +1,66 @@
+package mongodb
+
+import (
+	"context"
+
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const syncChangeCollection = "sync_changes"
+
+type mongoSyncChangeRepository struct {
+	*BaseRepository
+}
+
+// NewSyncChangeRepository creates a new MongoDB sync change repository
+func NewSyncChangeRepository(db *mongo.Database) repositories.SyncChangeRepository {
+	return &mongoSyncChangeRepository{
+		BaseRepository: NewBaseRepository(db, syncChangeCollection),
+	}
+}
+
+func (r *mongoSyncChangeRepository) Create(ctx context.Context, change *models.SyncChange) error {
+	id, err := r.InsertOne(ctx, change)
+	if err != nil {
+		return err
+	}
+	change.ID = id
+	return nil
+}
+
+func (r *mongoSyncChangeRepository) GetLatest(ctx context.Context, userID string) (*models.SyncChange, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}})
+
+	var change models.SyncChange
+	err := r.FindOneWithOptions(ctx, bson.M{"user_id": userID}, opts, &change)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+func (r *mongoSyncChangeRepository) ListAfter(ctx context.Context, userID string, afterSeq int64, limit int) ([]models.SyncChange, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "seq", Value: 1}}).
+		SetLimit(int64(limit))
+
+	var changes []models.SyncChange
+	filter := bson.M{"user_id": userID, "seq": bson.M{"$gt": afterSeq}}
+	if err := r.FindMany(ctx, filter, opts, &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func (r *mongoSyncChangeRepository) DeleteUpTo(ctx context.Context, userID string, seq int64) error {
+	_, err := r.DeleteMany(ctx, bson.M{"user_id": userID, "seq": bson.M{"$lte": seq}})
+	return err
+}
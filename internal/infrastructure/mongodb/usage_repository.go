@@ -0,0 +1,102 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const usageCollection = "storage_usage"
+
+type mongoUsageRepository struct {
+	*BaseRepository
+}
+
+// NewUsageRepository creates a new MongoDB storage usage repository
+func NewUsageRepository(db *mongo.Database) repositories.UsageRepository {
+	return &mongoUsageRepository{
+		BaseRepository: NewBaseRepository(db, usageCollection),
+	}
+}
+
+func (r *mongoUsageRepository) Get(ctx context.Context, ownerID string) (*models.StorageUsage, error) {
+	var usage models.StorageUsage
+	err := r.FindOne(ctx, bson.M{"_id": ownerID}, &usage)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+func (r *mongoUsageRepository) Add(ctx context.Context, ownerID string, delta models.StorageUsage) error {
+	_, err := r.UpdateOneWithOptions(ctx, bson.M{"_id": ownerID}, usageIncrement(delta), options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *mongoUsageRepository) Reserve(ctx context.Context, ownerID string, delta models.StorageUsage, quota models.Quota) (bool, error) {
+	filter := bson.M{"_id": ownerID}
+	if bytes := delta.QuotaBytes(); quota.MaxBytes > 0 && bytes > 0 {
+		filter["$expr"] = bson.M{"$lte": bson.A{
+			bson.M{"$add": bson.A{"$originals.bytes", "$versions.bytes", bytes}},
+			quota.MaxBytes,
+		}}
+	}
+	if quota.MaxPhotos > 0 && delta.Photos > 0 {
+		filter["photos"] = bson.M{"$lte": quota.MaxPhotos - delta.Photos}
+	}
+
+	result, err := r.UpdateOne(ctx, filter, usageIncrement(delta))
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// usageIncrement returns the update adding the counters of delta to a usage document
+func usageIncrement(delta models.StorageUsage) bson.M {
+	return bson.M{
+		"$inc": bson.M{
+			"photos":           delta.Photos,
+			"originals.bytes":  delta.Originals.Bytes,
+			"originals.files":  delta.Originals.Files,
+			"versions.bytes":   delta.Versions.Bytes,
+			"versions.files":   delta.Versions.Files,
+			"renditions.bytes": delta.Renditions.Bytes,
+			"renditions.files": delta.Renditions.Files,
+		},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+}
+
+func (r *mongoUsageRepository) Set(ctx context.Context, usage *models.StorageUsage) error {
+	_, err := r.Collection().ReplaceOne(ctx, bson.M{"_id": usage.OwnerID}, usage, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *mongoUsageRepository) List(ctx context.Context) ([]models.StorageUsage, error) {
+	var usages []models.StorageUsage
+	err := r.FindMany(ctx, bson.M{}, options.Find(), &usages)
+	if err != nil {
+		return nil, err
+	}
+	return usages, nil
+}
+
+func (r *mongoUsageRepository) Invalidate(ctx context.Context, ownerID string, at time.Time) error {
+	update := bson.M{
+		"$unset": bson.M{"verified_at": ""},
+		"$set":   bson.M{"updated_at": at},
+	}
+
+	_, err := r.UpdateOneWithOptions(ctx, bson.M{"_id": ownerID}, update, options.Update().SetUpsert(true))
+	return err
+}
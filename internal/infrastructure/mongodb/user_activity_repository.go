@@ -13,7 +13,11 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-const userActivityCollection = "user_activities"
+const (
+	userActivityCollection = "user_activities"
+	// activityExpiryIndex is the name of the TTL index used when the database expires activity
+	activityExpiryIndex = "timestamp_ttl"
+)
 
 type mongoUserActivityRepository struct {
 	*BaseRepository
@@ -62,6 +66,19 @@ func (r *mongoUserActivityRepository) GetUserActivities(ctx context.Context, use
 	return activities, nil
 }
 
+func (r *mongoUserActivityRepository) ListUserActivities(ctx context.Context, userID string, afterID primitive.ObjectID, limit int) ([]models.UserActivity, error) {
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "_id", Value: 1}})
+
+	var activities []models.UserActivity
+	err := r.FindMany(ctx, bson.M{"user_id": userID, "_id": bson.M{"$gt": afterID}}, opts, &activities)
+	if err != nil {
+		return nil, err
+	}
+	return activities, nil
+}
+
 func (r *mongoUserActivityRepository) GetPhotoActivities(ctx context.Context, photoID primitive.ObjectID, page, limit int) ([]models.UserActivity, error) {
 	skip := (page - 1) * limit
 	opts := options.Find().
@@ -84,11 +101,13 @@ func (r *mongoUserActivityRepository) GetActivitiesByTimeRange(ctx context.Conte
 		SetLimit(int64(limit)).
 		SetSort(bson.D{{Key: "timestamp", Value: -1}})
 
+	// Pruned entries keep their timestamp but have no content to show
 	filter := bson.M{
 		"timestamp": bson.M{
 			"$gte": startTime,
 			"$lte": endTime,
 		},
+		"pruned_at": bson.M{"$exists": false},
 	}
 
 	var activities []models.UserActivity
@@ -102,3 +121,262 @@ func (r *mongoUserActivityRepository) GetActivitiesByTimeRange(ctx context.Conte
 func (r *mongoUserActivityRepository) Count(ctx context.Context) (int64, error) {
 	return r.CountDocuments(ctx, bson.M{})
 }
+
+func (r *mongoUserActivityRepository) GetChainHead(ctx context.Context) (*models.UserActivity, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}})
+
+	var activity models.UserActivity
+	err := r.FindOneWithOptions(ctx, bson.M{"seq": bson.M{"$gt": 0}}, opts, &activity)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &activity, nil
+}
+
+func (r *mongoUserActivityRepository) ListChain(ctx context.Context, afterSequence int64, limit int) ([]models.UserActivity, error) {
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "seq", Value: 1}})
+
+	var activities []models.UserActivity
+	err := r.FindMany(ctx, bson.M{"seq": bson.M{"$gt": afterSequence}}, opts, &activities)
+	if err != nil {
+		return nil, err
+	}
+	return activities, nil
+}
+
+func (r *mongoUserActivityRepository) ListExpired(ctx context.Context, expiry repositories.ActivityExpiry, limit int) ([]models.UserActivity, error) {
+	var conditions bson.A
+	ownPeriods := make(bson.A, 0, len(expiry.Types))
+	for activityType, cutoff := range expiry.Types {
+		ownPeriods = append(ownPeriods, activityType)
+		if !cutoff.IsZero() {
+			conditions = append(conditions, bson.M{"type": activityType, "timestamp": bson.M{"$lt": cutoff}})
+		}
+	}
+	if !expiry.Default.IsZero() {
+		conditions = append(conditions, bson.M{"type": bson.M{"$nin": ownPeriods}, "timestamp": bson.M{"$lt": expiry.Default}})
+	}
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+
+	filter := bson.M{
+		"$or":       conditions,
+		"pruned_at": bson.M{"$exists": false},
+	}
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "timestamp", Value: 1}})
+
+	var activities []models.UserActivity
+	err := r.FindMany(ctx, filter, opts, &activities)
+	if err != nil {
+		return nil, err
+	}
+	return activities, nil
+}
+
+func (r *mongoUserActivityRepository) Prune(ctx context.Context, ids []primitive.ObjectID, at time.Time) (int64, error) {
+	deleted, err := r.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}, "seq": bson.M{"$exists": false}})
+	if err != nil {
+		return 0, err
+	}
+
+	update := bson.M{
+		"$set": bson.M{"pruned_at": at},
+		"$unset": bson.M{
+			"user_id":    "",
+			"photo_id":   "",
+			"ip":         "",
+			"user_agent": "",
+			"request_id": "",
+			"metadata":   "",
+		},
+	}
+	pruned, err := r.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}, "seq": bson.M{"$exists": true}}, update)
+	if err != nil {
+		return deleted.DeletedCount, err
+	}
+	return deleted.DeletedCount + pruned.ModifiedCount, nil
+}
+
+func (r *mongoUserActivityRepository) GetFirstUnpruned(ctx context.Context) (*models.UserActivity, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "seq", Value: 1}})
+
+	var activity models.UserActivity
+	filter := bson.M{"seq": bson.M{"$gt": 0}, "pruned_at": bson.M{"$exists": false}}
+	err := r.FindOneWithOptions(ctx, filter, opts, &activity)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &activity, nil
+}
+
+func (r *mongoUserActivityRepository) DeletePrunedBefore(ctx context.Context, sequence int64) (int64, error) {
+	result, err := r.DeleteMany(ctx, bson.M{"seq": bson.M{"$lt": sequence}, "pruned_at": bson.M{"$exists": true}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (r *mongoUserActivityRepository) SetExpiry(ctx context.Context, ttl time.Duration) error {
+	indexes := r.Collection().Indexes()
+	specs, err := indexes.ListSpecifications(ctx)
+	if err != nil {
+		return err
+	}
+
+	seconds := int32(ttl / time.Second)
+	for _, spec := range specs {
+		if spec.Name != activityExpiryIndex {
+			continue
+		}
+		if ttl > 0 && spec.ExpireAfterSeconds != nil && *spec.ExpireAfterSeconds == seconds {
+			return nil
+		}
+		if err := r.DropIndex(ctx, activityExpiryIndex); err != nil {
+			return err
+		}
+	}
+	if ttl <= 0 {
+		return nil
+	}
+
+	opts := options.Index().SetName(activityExpiryIndex).SetExpireAfterSeconds(seconds)
+	_, err = r.CreateIndex(ctx, bson.D{{Key: "timestamp", Value: 1}}, opts)
+	return err
+}
+
+func (r *mongoUserActivityRepository) CountByDay(ctx context.Context, filter repositories.ActivityFilter, timezone string) ([]models.DailyActivityCount, error) {
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	pipeline := bson.A{
+		bson.M{"$match": activityMatch(filter)},
+		bson.M{"$group": bson.M{
+			"_id": bson.M{
+				"date": bson.M{"$dateToString": bson.M{
+					"format":   "%Y-%m-%d",
+					"date":     "$timestamp",
+					"timezone": timezone,
+				}},
+				"type": "$type",
+			},
+			"count": bson.M{"$sum": 1},
+		}},
+		bson.M{"$project": bson.M{
+			"_id":   0,
+			"date":  "$_id.date",
+			"type":  "$_id.type",
+			"count": 1,
+		}},
+		bson.M{"$sort": bson.D{{Key: "date", Value: 1}, {Key: "type", Value: 1}}},
+	}
+
+	var counts []models.DailyActivityCount
+	if err := r.Aggregate(ctx, pipeline, &counts); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (r *mongoUserActivityRepository) TopPhotos(ctx context.Context, filter repositories.ActivityFilter, limit int) ([]models.PhotoActivityCount, error) {
+	match := activityMatch(filter)
+	if filter.PhotoID.IsZero() {
+		match["photo_id"] = bson.M{"$ne": primitive.NilObjectID}
+	}
+
+	pipeline := bson.A{
+		bson.M{"$match": match},
+		bson.M{"$group": bson.M{
+			"_id":     "$photo_id",
+			"count":   bson.M{"$sum": 1},
+			"users":   bson.M{"$addToSet": "$user_id"},
+			"last_at": bson.M{"$max": "$timestamp"},
+		}},
+		bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+		bson.M{"$limit": limit},
+		// Activities without a user are not counted as a distinct user
+		bson.M{"$project": bson.M{
+			"count":   1,
+			"last_at": 1,
+			"users":   bson.M{"$size": bson.M{"$setDifference": bson.A{"$users", bson.A{""}}}},
+		}},
+	}
+
+	var counts []models.PhotoActivityCount
+	if err := r.Aggregate(ctx, pipeline, &counts); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (r *mongoUserActivityRepository) ActiveUsers(ctx context.Context, filter repositories.ActivityFilter, limit int) ([]models.UserActivityCount, error) {
+	match := activityMatch(filter)
+	if filter.UserID == "" {
+		match["user_id"] = bson.M{"$ne": ""}
+	}
+
+	pipeline := bson.A{
+		bson.M{"$match": match},
+		bson.M{"$group": bson.M{
+			"_id":      "$user_id",
+			"count":    bson.M{"$sum": 1},
+			"photos":   bson.M{"$addToSet": "$photo_id"},
+			"first_at": bson.M{"$min": "$timestamp"},
+			"last_at":  bson.M{"$max": "$timestamp"},
+		}},
+		bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+		bson.M{"$limit": limit},
+		bson.M{"$project": bson.M{
+			"count":    1,
+			"first_at": 1,
+			"last_at":  1,
+			"photos":   bson.M{"$size": "$photos"},
+		}},
+	}
+
+	var counts []models.UserActivityCount
+	if err := r.Aggregate(ctx, pipeline, &counts); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// activityMatch builds the $match stage filter for an activity filter. Pruned
+// entries have no content and never match.
+func activityMatch(filter repositories.ActivityFilter) bson.M {
+	match := bson.M{"pruned_at": bson.M{"$exists": false}}
+
+	timestamp := bson.M{}
+	if !filter.Start.IsZero() {
+		timestamp["$gte"] = filter.Start
+	}
+	if !filter.End.IsZero() {
+		timestamp["$lte"] = filter.End
+	}
+	if len(timestamp) > 0 {
+		match["timestamp"] = timestamp
+	}
+
+	if len(filter.Types) > 0 {
+		match["type"] = bson.M{"$in": filter.Types}
+	}
+	if filter.UserID != "" {
+		match["user_id"] = filter.UserID
+	}
+	if !filter.PhotoID.IsZero() {
+		match["photo_id"] = filter.PhotoID
+	}
+	return match
+}
@@ -0,0 +1,105 @@
+package mongodb
+
+import (
+	"context"
+
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const userCollection = "users"
+
+type mongoUserRepository struct {
+	*BaseRepository
+}
+
+// NewUserRepository creates a new MongoDB user repository
+func NewUserRepository(db *mongo.Database) repositories.UserRepository {
+	return &mongoUserRepository{
+		BaseRepository: NewBaseRepository(db, userCollection),
+	}
+}
+
+func (r *mongoUserRepository) Create(ctx context.Context, user *models.User) error {
+	id, err := r.InsertOne(ctx, user)
+	if err != nil {
+		return err
+	}
+	user.ID = id
+	return nil
+}
+
+func (r *mongoUserRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *mongoUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	return r.findOne(ctx, bson.M{"username": username})
+}
+
+func (r *mongoUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+func (r *mongoUserRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.User, error) {
+	return r.findOne(ctx, bson.M{"token_hash": tokenHash})
+}
+
+func (r *mongoUserRepository) SetTokenHash(ctx context.Context, id primitive.ObjectID, tokenHash string) error {
+	result, err := r.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"token_hash": tokenHash}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *mongoUserRepository) SetQuota(ctx context.Context, id primitive.ObjectID, plan string, quota *models.Quota) error {
+	set := bson.M{}
+	unset := bson.M{}
+	if plan != "" {
+		set["plan"] = plan
+	} else {
+		unset["plan"] = ""
+	}
+	if quota != nil {
+		set["quota"] = quota
+	} else {
+		unset["quota"] = ""
+	}
+
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	result, err := r.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *mongoUserRepository) findOne(ctx context.Context, filter bson.M) (*models.User, error) {
+	var user models.User
+	err := r.FindOne(ctx, filter, &user)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
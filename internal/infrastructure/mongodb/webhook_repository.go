@@ -0,0 +1,236 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	webhookCollection         = "webhooks"
+	webhookDeliveryCollection = "webhook_deliveries"
+)
+
+type mongoWebhookRepository struct {
+	*BaseRepository
+}
+
+// NewWebhookRepository creates a new MongoDB webhook repository
+func NewWebhookRepository(db *mongo.Database) repositories.WebhookRepository {
+	return &mongoWebhookRepository{
+		BaseRepository: NewBaseRepository(db, webhookCollection),
+	}
+}
+
+func (r *mongoWebhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	id, err := r.InsertOne(ctx, webhook)
+	if err != nil {
+		return err
+	}
+	webhook.ID = id
+	return nil
+}
+
+func (r *mongoWebhookRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := r.FindOne(ctx, bson.M{"_id": id}, &webhook)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *mongoWebhookRepository) Update(ctx context.Context, webhook *models.Webhook) error {
+	set := bson.M{
+		"url":                  webhook.URL,
+		"events":               webhook.Events,
+		"description":          webhook.Description,
+		"consecutive_failures": webhook.ConsecutiveFailures,
+		"updated_at":           webhook.UpdatedAt,
+	}
+	update := bson.M{"$set": set}
+	if webhook.DisabledAt != nil {
+		set["disabled_at"] = webhook.DisabledAt
+		set["disabled_reason"] = webhook.DisabledReason
+	} else {
+		update["$unset"] = bson.M{"disabled_at": "", "disabled_reason": ""}
+	}
+
+	result, err := r.UpdateOne(ctx, bson.M{"_id": webhook.ID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *mongoWebhookRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *mongoWebhookRepository) ListByOwner(ctx context.Context, ownerID string, page, limit int) ([]models.Webhook, error) {
+	skip := (page - 1) * limit
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64(skip)).
+		SetLimit(int64(limit))
+
+	var webhooks []models.Webhook
+	if err := r.FindMany(ctx, bson.M{"owner_id": ownerID}, opts, &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *mongoWebhookRepository) ListForEvent(ctx context.Context, eventType models.EventType, ownerIDs []string) ([]models.Webhook, error) {
+	filter := bson.M{
+		"owner_id":    bson.M{"$in": ownerIDs},
+		"disabled_at": bson.M{"$exists": false},
+		"$or": bson.A{
+			bson.M{"events": bson.A{}},
+			bson.M{"events": eventType},
+		},
+	}
+
+	var webhooks []models.Webhook
+	if err := r.FindMany(ctx, filter, options.Find(), &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *mongoWebhookRepository) RecordSuccess(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.UpdateOne(ctx,
+		bson.M{"_id": id, "consecutive_failures": bson.M{"$ne": 0}},
+		bson.M{"$set": bson.M{"consecutive_failures": 0}})
+	return err
+}
+
+func (r *mongoWebhookRepository) RecordFailure(ctx context.Context, id primitive.ObjectID, disableAfter int, reason string, now time.Time) (bool, error) {
+	if _, err := r.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"consecutive_failures": 1}}); err != nil {
+		return false, err
+	}
+
+	// Only the attempt that reaches the limit disables the webhook
+	result, err := r.UpdateOne(ctx,
+		bson.M{
+			"_id":                  id,
+			"consecutive_failures": bson.M{"$gte": disableAfter},
+			"disabled_at":          bson.M{"$exists": false},
+		},
+		bson.M{"$set": bson.M{"disabled_at": now, "disabled_reason": reason}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+type mongoWebhookDeliveryRepository struct {
+	*BaseRepository
+}
+
+// NewWebhookDeliveryRepository creates a new MongoDB webhook delivery repository
+func NewWebhookDeliveryRepository(db *mongo.Database) repositories.WebhookDeliveryRepository {
+	return &mongoWebhookDeliveryRepository{
+		BaseRepository: NewBaseRepository(db, webhookDeliveryCollection),
+	}
+}
+
+func (r *mongoWebhookDeliveryRepository) CreateMany(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, len(deliveries))
+	for i := range deliveries {
+		if deliveries[i].ID.IsZero() {
+			deliveries[i].ID = primitive.NewObjectID()
+		}
+		documents[i] = deliveries[i]
+	}
+	_, err := r.InsertMany(ctx, documents)
+	return err
+}
+
+func (r *mongoWebhookDeliveryRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.FindOne(ctx, bson.M{"_id": id}, &delivery)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *mongoWebhookDeliveryRepository) ListByWebhook(ctx context.Context, webhookID primitive.ObjectID, page, limit int) ([]models.WebhookDelivery, error) {
+	skip := (page - 1) * limit
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(skip)).
+		SetLimit(int64(limit))
+
+	var deliveries []models.WebhookDelivery
+	if err := r.FindMany(ctx, bson.M{"webhook_id": webhookID}, opts, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *mongoWebhookDeliveryRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time) (*models.WebhookDelivery, error) {
+	filter := bson.M{
+		"status":          models.WebhookDeliveryPending,
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	update := bson.M{"$set": bson.M{"next_attempt_at": leaseUntil}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var delivery models.WebhookDelivery
+	err := r.FindOneAndUpdate(ctx, filter, update, opts, &delivery)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *mongoWebhookDeliveryRepository) RecordAttempt(ctx context.Context, id primitive.ObjectID, attempt models.WebhookAttempt, status models.WebhookDeliveryStatus, nextAttemptAt time.Time) error {
+	update := bson.M{
+		"$push": bson.M{"attempts": attempt},
+		"$set": bson.M{
+			"status":          status,
+			"next_attempt_at": nextAttemptAt,
+		},
+	}
+	_, err := r.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+func (r *mongoWebhookDeliveryRepository) DeleteByWebhook(ctx context.Context, webhookID primitive.ObjectID) error {
+	_, err := r.DeleteMany(ctx, bson.M{"webhook_id": webhookID})
+	return err
+}
@@ -1,16 +1,28 @@
 package s3
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"photocloud/internal/domain/repositories"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
+// streamPartSize is the size of the parts streamed uploads are split into. S3 needs every
+// part but the last to be at least 5 MiB and allows 10,000 parts, so files up to about
+// 160 GB can be streamed.
+const streamPartSize = 16 << 20
+
 type s3StorageRepository struct {
 	client     *s3.Client
 	bucketName string
@@ -34,15 +46,119 @@ func (r *s3StorageRepository) UploadFile(ctx context.Context, key string, conten
 	return err
 }
 
-func (r *s3StorageRepository) DownloadFile(ctx context.Context, key string) (io.ReadCloser, error) {
-	result, err := r.client.GetObject(ctx, &s3.GetObjectInput{
+func (r *s3StorageRepository) UploadStream(ctx context.Context, key string, content io.Reader, contentType string) error {
+	created, err := r.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(r.bucketName),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return err
+	}
+
+	parts, err := r.uploadParts(ctx, key, created.UploadId, content)
+	if err == nil {
+		_, err = r.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+			Bucket:          aws.String(r.bucketName),
+			Key:             aws.String(key),
+			UploadId:        created.UploadId,
+			MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+		})
+	}
+	if err != nil {
+		// The parts of an unfinished upload are kept, and billed, until it is aborted
+		r.client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(r.bucketName),
+			Key:      aws.String(key),
+			UploadId: created.UploadId,
+		})
+		return err
+	}
+	return nil
+}
+
+// uploadParts reads the content one part at a time and uploads each part as soon as it is full
+func (r *s3StorageRepository) uploadParts(ctx context.Context, key string, uploadID *string, content io.Reader) ([]types.CompletedPart, error) {
+	var parts []types.CompletedPart
+	buf := make([]byte, streamPartSize)
+	for number := int32(1); ; number++ {
+		n, err := io.ReadFull(content, buf)
+		if err == io.EOF && number > 1 {
+			return parts, nil
+		}
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+
+		result, uploadErr := r.client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:     aws.String(r.bucketName),
+			Key:        aws.String(key),
+			UploadId:   uploadID,
+			PartNumber: aws.Int32(number),
+			Body:       bytes.NewReader(buf[:n]),
+		})
+		if uploadErr != nil {
+			return nil, uploadErr
+		}
+		parts = append(parts, types.CompletedPart{ETag: result.ETag, PartNumber: aws.Int32(number)})
+
+		// A short read is the last part
+		if err != nil {
+			return parts, nil
+		}
+	}
+}
+
+func (r *s3StorageRepository) DownloadFile(ctx context.Context, key string, byteRange *repositories.ByteRange) (io.ReadCloser, *repositories.FileInfo, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(r.bucketName),
+		Key:    aws.String(key),
+	}
+	if byteRange != nil {
+		if byteRange.End < 0 {
+			input.Range = aws.String(fmt.Sprintf("bytes=%d-", byteRange.Start))
+		} else {
+			input.Range = aws.String(fmt.Sprintf("bytes=%d-%d", byteRange.Start, byteRange.End))
+		}
+	}
+
+	result, err := r.client.GetObject(ctx, input)
+	if err != nil {
+		return nil, nil, translateError(err)
+	}
+
+	info := &repositories.FileInfo{
+		Key:          key,
+		Size:         aws.ToInt64(result.ContentLength),
+		ContentType:  aws.ToString(result.ContentType),
+		ETag:         aws.ToString(result.ETag),
+		LastModified: aws.ToTime(result.LastModified),
+	}
+	// For ranged reads the content length only covers the range; the full
+	// size is the part of Content-Range after the slash
+	if size, ok := parseContentRangeSize(aws.ToString(result.ContentRange)); ok {
+		info.Size = size
+	}
+
+	return result.Body, info, nil
+}
+
+func (r *s3StorageRepository) StatFile(ctx context.Context, key string) (*repositories.FileInfo, error) {
+	result, err := r.client.HeadObject(ctx, &s3.HeadObjectInput{
 		Bucket: aws.String(r.bucketName),
 		Key:    aws.String(key),
 	})
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
-	return result.Body, nil
+
+	return &repositories.FileInfo{
+		Key:          key,
+		Size:         aws.ToInt64(result.ContentLength),
+		ContentType:  aws.ToString(result.ContentType),
+		ETag:         aws.ToString(result.ETag),
+		LastModified: aws.ToTime(result.LastModified),
+	}, nil
 }
 
 func (r *s3StorageRepository) DeleteFile(ctx context.Context, key string) error {
@@ -69,3 +185,58 @@ func (r *s3StorageRepository) GetFileURL(ctx context.Context, key string, expiry
 
 	return request.URL, nil
 }
+
+func (r *s3StorageRepository) ListFiles(ctx context.Context, prefix string) ([]repositories.FileInfo, error) {
+	paginator := s3.NewListObjectsV2Paginator(r.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(r.bucketName),
+		Prefix: aws.String(prefix),
+	})
+
+	var files []repositories.FileInfo
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range page.Contents {
+			files = append(files, repositories.FileInfo{
+				Key:          aws.ToString(object.Key),
+				Size:         aws.ToInt64(object.Size),
+				LastModified: aws.ToTime(object.LastModified),
+			})
+		}
+	}
+	return files, nil
+}
+
+func (r *s3StorageRepository) CopyFile(ctx context.Context, srcKey, dstKey string) error {
+	_, err := r.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(r.bucketName),
+		Key:        aws.String(dstKey),
+		CopySource: aws.String(url.PathEscape(r.bucketName + "/" + srcKey)),
+	})
+	return err
+}
+
+// translateError maps S3 not-found errors to repositories.ErrFileNotFound
+func translateError(err error) error {
+	var noSuchKey *types.NoSuchKey
+	var notFound *types.NotFound
+	if errors.As(err, &noSuchKey) || errors.As(err, &notFound) {
+		return fmt.Errorf("%w: %v", repositories.ErrFileNotFound, err)
+	}
+	return err
+}
+
+// parseContentRangeSize extracts the complete length from a Content-Range header such as "bytes 0-99/1234"
+func parseContentRangeSize(contentRange string) (int64, bool) {
+	_, total, found := strings.Cut(contentRange, "/")
+	if !found || total == "*" {
+		return 0, false
+	}
+	size, err := strconv.ParseInt(total, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return size, true
+}
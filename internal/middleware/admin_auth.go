@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuth restricts routes to requests carrying the ADMIN_API_TOKEN as a bearer token
+func AdminAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		expected := os.Getenv("ADMIN_API_TOKEN")
+		if expected == "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin API is disabled. Set ADMIN_API_TOKEN to enable it"})
+			c.Abort()
+			return
+		}
+
+		provided, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(provided), []byte(expected)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing admin token"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"photocloud/internal/domain/auth"
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authenticate requires a user API token as a bearer token and makes the user
+// available to handlers and services through the request context. Unless tokens
+// are required, requests without one act as the anonymous account; invalid tokens
+// are always refused.
+func Authenticate(userService services.UserService) gin.HandlerFunc {
+	return authenticate(userService, false)
+}
+
+// AuthenticateStream is Authenticate for event streams. Browsers cannot set headers
+// on EventSource and WebSocket connections, so a single-use stream ticket may be given
+// as the ticket query parameter instead. API tokens are never read from the URL, which
+// ends up in access logs.
+func AuthenticateStream(userService services.UserService) gin.HandlerFunc {
+	return authenticate(userService, true)
+}
+
+func authenticate(userService services.UserService, ticketFromQuery bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found {
+			token = ""
+		}
+
+		var user *models.User
+		var err error
+		if ticket := c.Query("ticket"); token == "" && ticketFromQuery && ticket != "" {
+			user, err = userService.AuthenticateStreamTicket(c.Request.Context(), ticket)
+		} else {
+			user, err = userService.Authenticate(c.Request.Context(), token)
+		}
+		if errors.Is(err, services.ErrUnauthorized) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing API token"})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to authenticate request"})
+			c.Abort()
+			return
+		}
+
+		c.Set("user", user)
+		c.Request = c.Request.WithContext(auth.WithUser(c.Request.Context(), user))
+		c.Next()
+	}
+}
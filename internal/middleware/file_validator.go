@@ -3,34 +3,19 @@ package middleware
 import (
 	"fmt"
 	"net/http"
-	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 
-	"github.com/gin-gonic/gin"
-)
+	"photocloud/config"
+	"photocloud/internal/domain/services"
 
-var (
-	defaultMaxSize   int64 = 10 * 1024 * 1024 // 10MB
-	allowedMimeTypes       = map[string]bool{
-		"image/jpeg": true,
-		"image/png":  true,
-		"image/gif":  true,
-		"image/webp": true,
-	}
+	"github.com/gin-gonic/gin"
 )
 
 // FileValidator validates uploaded files
 func FileValidator() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Get max file size from env or use default
-		maxSize := defaultMaxSize
-		if sizeStr := os.Getenv("MAX_UPLOAD_SIZE"); sizeStr != "" {
-			if size, err := strconv.ParseInt(sizeStr, 10, 64); err == nil {
-				maxSize = size
-			}
-		}
+		maxSize := config.GetMaxUploadSize()
 
 		// Parse multipart form with size limit
 		if err := c.Request.ParseMultipartForm(maxSize); err != nil {
@@ -68,21 +53,11 @@ func FileValidator() gin.HandlerFunc {
 		contentType := fileHeader.Header.Get("Content-Type")
 		if contentType == "" {
 			// Try to detect content type from file extension
-			ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
-			switch ext {
-			case ".jpg", ".jpeg":
-				contentType = "image/jpeg"
-			case ".png":
-				contentType = "image/png"
-			case ".gif":
-				contentType = "image/gif"
-			case ".webp":
-				contentType = "image/webp"
-			}
+			contentType, _ = services.PhotoContentType(fileHeader.Filename)
 		}
 
 		// Validate content type
-		if !allowedMimeTypes[contentType] {
+		if !services.IsPhotoContentType(contentType) {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error":         fmt.Sprintf("Invalid file type %s. Allowed types: JPEG, PNG, GIF, WebP", contentType),
 				"allowed_types": []string{"image/jpeg", "image/png", "image/gif", "image/webp"},
@@ -93,13 +68,7 @@ func FileValidator() gin.HandlerFunc {
 
 		// Check file extension
 		ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
-		validExt := false
-		switch ext {
-		case ".jpg", ".jpeg", ".png", ".gif", ".webp":
-			validExt = true
-		}
-
-		if !validExt {
+		if _, ok := services.PhotoContentType(fileHeader.Filename); !ok {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error":              fmt.Sprintf("Invalid file extension %s. Allowed extensions: .jpg, .jpeg, .png, .gif, .webp", ext),
 				"allowed_extensions": []string{".jpg", ".jpeg", ".png", ".gif", ".webp"},
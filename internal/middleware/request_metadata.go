@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"photocloud/internal/domain/requestmeta"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the ID of a request, from the client or a proxy, or generated here
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds request IDs taken from clients
+const maxRequestIDLength = 128
+
+// RequestMetadata makes the client IP, user agent and request ID available to
+// services through the request context. The request ID is echoed in the response.
+func RequestMetadata() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = newRequestID()
+		}
+		c.Header(RequestIDHeader, requestID)
+
+		metadata := requestmeta.Metadata{
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			RequestID: requestID,
+		}
+		c.Request = c.Request.WithContext(requestmeta.WithMetadata(c.Request.Context(), metadata))
+		c.Next()
+	}
+}
+
+// newRequestID returns a random request ID
+func newRequestID() string {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(raw)
+}
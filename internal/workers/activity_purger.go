@@ -0,0 +1,52 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"photocloud/internal/domain/services"
+)
+
+// ActivityPurger periodically removes user activity whose retention period has expired
+type ActivityPurger struct {
+	retentionService services.ActivityRetentionService
+	interval         time.Duration
+}
+
+// NewActivityPurger creates a new activity purger
+func NewActivityPurger(retentionService services.ActivityRetentionService, interval time.Duration) *ActivityPurger {
+	return &ActivityPurger{
+		retentionService: retentionService,
+		interval:         interval,
+	}
+}
+
+// Start runs the purger in the background until the context is cancelled
+func (p *ActivityPurger) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			p.RunOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce removes every activity that is older than the retention period of its type
+func (p *ActivityPurger) RunOnce(ctx context.Context) {
+	purged, err := p.retentionService.PurgeExpired(ctx, time.Now())
+	if err != nil {
+		log.Printf("activity purger: %v", err)
+	}
+	if purged > 0 {
+		log.Printf("activity purger: removed %d expired activities", purged)
+	}
+}
@@ -0,0 +1,137 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/services"
+)
+
+// activityWriteTimeout bounds a single activity insert
+const activityWriteTimeout = 5 * time.Second
+
+// ActivityWriter appends user activity to the audit chain in the background,
+// so that recording activity never fails the operation it describes. When the
+// buffer is full, recording waits briefly for room. Activities that still find
+// no room, or fail to be written, are counted and recorded in the chain as a gap
+// entry, so that the chain shows where its record is incomplete.
+type ActivityWriter struct {
+	auditService services.AuditService
+	queue        chan *models.UserActivity
+	enqueueWait  time.Duration
+	done         chan struct{}
+	mu           sync.RWMutex
+	closed       bool
+	// lost counts the activities not written since the last gap entry
+	lost    atomic.Int64
+	dropped atomic.Int64
+}
+
+// NewActivityWriter creates an activity writer buffering up to bufferSize activities.
+// Recording waits up to enqueueWait for room in a full buffer.
+func NewActivityWriter(auditService services.AuditService, bufferSize int, enqueueWait time.Duration) *ActivityWriter {
+	return &ActivityWriter{
+		auditService: auditService,
+		queue:        make(chan *models.UserActivity, bufferSize),
+		enqueueWait:  enqueueWait,
+		done:         make(chan struct{}),
+	}
+}
+
+// Record queues an activity for writing, waiting briefly if the buffer is full
+func (w *ActivityWriter) Record(activity *models.UserActivity) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return
+	}
+
+	select {
+	case w.queue <- activity:
+		return
+	default:
+	}
+
+	timer := time.NewTimer(w.enqueueWait)
+	defer timer.Stop()
+	select {
+	case w.queue <- activity:
+	case <-timer.C:
+		w.lost.Add(1)
+		if dropped := w.dropped.Add(1); dropped == 1 || dropped%1000 == 0 {
+			log.Printf("activity writer: buffer full, %d activities dropped so far", dropped)
+		}
+	}
+}
+
+// Start writes queued activities in the background until Close is called.
+// Pending writes are abandoned when the context is cancelled.
+func (w *ActivityWriter) Start(ctx context.Context) {
+	go func() {
+		defer close(w.done)
+		for activity := range w.queue {
+			if ctx.Err() != nil {
+				return
+			}
+			w.write(ctx, activity)
+		}
+		// Activities dropped after the last write still leave a gap entry
+		if ctx.Err() == nil {
+			w.recordGap(ctx)
+		}
+	}()
+}
+
+// Close stops accepting activities and waits up to timeout for the queued ones to be written
+func (w *ActivityWriter) Close(timeout time.Duration) {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+	case <-time.After(timeout):
+		log.Printf("activity writer: gave up on %d unwritten activities", len(w.queue))
+	}
+}
+
+func (w *ActivityWriter) write(ctx context.Context, activity *models.UserActivity) {
+	w.recordGap(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, activityWriteTimeout)
+	defer cancel()
+
+	if err := w.auditService.Append(ctx, activity); err != nil {
+		w.lost.Add(1)
+		log.Printf("activity writer: failed to record %s of photo %s: %v", activity.Type, activity.PhotoID.Hex(), err)
+	}
+}
+
+// recordGap appends a gap entry for the activities lost since the last one
+func (w *ActivityWriter) recordGap(ctx context.Context) {
+	lost := w.lost.Swap(0)
+	if lost == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, activityWriteTimeout)
+	defer cancel()
+
+	gap := &models.UserActivity{
+		Type:      models.ActivityTypeGap,
+		Timestamp: time.Now(),
+		Metadata:  map[string]interface{}{"lost": lost},
+	}
+	if err := w.auditService.Append(ctx, gap); err != nil {
+		// Counted again, for the next gap entry
+		w.lost.Add(lost)
+		log.Printf("activity writer: failed to record a gap of %d activities: %v", lost, err)
+	}
+}
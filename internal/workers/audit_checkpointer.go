@@ -0,0 +1,53 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"photocloud/internal/domain/services"
+)
+
+// AuditCheckpointer periodically signs the head of the activity audit chain
+type AuditCheckpointer struct {
+	auditService services.AuditService
+	interval     time.Duration
+}
+
+// NewAuditCheckpointer creates a new audit checkpointer
+func NewAuditCheckpointer(auditService services.AuditService, interval time.Duration) *AuditCheckpointer {
+	return &AuditCheckpointer{
+		auditService: auditService,
+		interval:     interval,
+	}
+}
+
+// Start runs the checkpointer in the background until the context is cancelled
+func (a *AuditCheckpointer) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(a.interval)
+		defer ticker.Stop()
+
+		for {
+			a.RunOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce signs the head of the chain if it moved since the last checkpoint
+func (a *AuditCheckpointer) RunOnce(ctx context.Context) {
+	checkpoint, err := a.auditService.Checkpoint(ctx)
+	if err != nil {
+		log.Printf("audit checkpointer: %v", err)
+		return
+	}
+	if checkpoint != nil {
+		log.Printf("audit checkpointer: signed the chain up to entry %d", checkpoint.Sequence)
+	}
+}
@@ -0,0 +1,107 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/repositories"
+	"photocloud/internal/domain/services"
+)
+
+const (
+	// eventWriteTimeout bounds storing a single event
+	eventWriteTimeout = 5 * time.Second
+	// eventWatchRetryDelay is the pause before a failed change stream is reopened
+	eventWatchRetryDelay = 5 * time.Second
+	// eventWatchResumeAttempts is how often a change stream is resumed where it failed
+	// before it is reopened from the current time
+	eventWatchResumeAttempts = 3
+)
+
+// EventRelay shares events between API instances through the database. Events
+// published on any instance are stored in the background, and every instance
+// watching the stored events hands them to its local subscribers. Events
+// published while the buffer is full are dropped.
+type EventRelay struct {
+	eventRepo repositories.EventRepository
+	local     services.EventPublisher
+	queue     chan *models.Event
+	dropped   atomic.Int64
+}
+
+// NewEventRelay creates an event relay delivering to local and buffering up to bufferSize events
+func NewEventRelay(eventRepo repositories.EventRepository, local services.EventPublisher, bufferSize int) *EventRelay {
+	return &EventRelay{
+		eventRepo: eventRepo,
+		local:     local,
+		queue:     make(chan *models.Event, bufferSize),
+	}
+}
+
+// Publish queues an event for storing without blocking
+func (r *EventRelay) Publish(event *models.Event) {
+	select {
+	case r.queue <- event:
+	default:
+		if dropped := r.dropped.Add(1); dropped == 1 || dropped%1000 == 0 {
+			log.Printf("event relay: buffer full, %d events dropped so far", dropped)
+		}
+	}
+}
+
+// Start stores queued events and delivers stored ones in the background until the context is cancelled
+func (r *EventRelay) Start(ctx context.Context) {
+	go r.write(ctx)
+	go r.watch(ctx)
+}
+
+func (r *EventRelay) write(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-r.queue:
+			writeCtx, cancel := context.WithTimeout(ctx, eventWriteTimeout)
+			if err := r.eventRepo.Create(writeCtx, event); err != nil {
+				log.Printf("event relay: failed to store %s event: %v", event.Type, err)
+			}
+			cancel()
+		}
+	}
+}
+
+// watch delivers stored events until the context is cancelled, reopening the change
+// stream after failures. Events stored while it is down are delivered on resumption.
+func (r *EventRelay) watch(ctx context.Context) {
+	var resumeToken []byte
+	failures := 0
+
+	for {
+		err := r.eventRepo.Watch(ctx, resumeToken, func(event *models.Event, token []byte) {
+			r.local.Publish(event)
+			resumeToken = token
+			failures = 0
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		failures++
+		if failures >= eventWatchResumeAttempts && resumeToken != nil {
+			log.Printf("event relay: change stream failed %d times, continuing from now: %v", failures, err)
+			resumeToken = nil
+			failures = 0
+		} else {
+			log.Printf("event relay: change stream failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(eventWatchRetryDelay):
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"photocloud/internal/domain/services"
+)
+
+// NewExportJobRunner creates a job runner writing the archives of queued export jobs. Before
+// looking for queued jobs every interval, it deletes the archives that expired.
+func NewExportJobRunner(exportService services.ExportService, interval time.Duration) *JobRunner {
+	r := NewJobRunner("export job runner", exportService, interval)
+	r.beforeRound = func(ctx context.Context) {
+		deleted, err := exportService.DeleteExpired(ctx, time.Now())
+		if err != nil {
+			log.Printf("%s: %v", r.name, err)
+		}
+		if deleted > 0 {
+			log.Printf("%s: deleted %d expired archives", r.name, deleted)
+		}
+	}
+	return r
+}
@@ -0,0 +1,70 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// JobQueue is a service with background jobs waiting to run
+type JobQueue interface {
+	// RunNext claims the oldest queued job, or one whose worker stopped, and runs it. It
+	// reports whether there was a job.
+	RunNext(ctx context.Context) (bool, error)
+}
+
+// JobRunner works through the queued jobs of a service one at a time. Jobs are
+// claimed from the database, so every instance can run one and interrupted jobs
+// are picked up again by whichever instance finds them first.
+type JobRunner struct {
+	name     string
+	queue    JobQueue
+	interval time.Duration
+	// beforeRound runs before each round of jobs
+	beforeRound func(ctx context.Context)
+}
+
+// NewJobRunner creates a job runner looking for queued jobs every interval. The name,
+// such as "bulk job runner", prefixes what it logs.
+func NewJobRunner(name string, queue JobQueue, interval time.Duration) *JobRunner {
+	return &JobRunner{
+		name:     name,
+		queue:    queue,
+		interval: interval,
+	}
+}
+
+// Start runs jobs in the background until the context is cancelled
+func (r *JobRunner) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			r.RunOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce runs jobs until none is queued
+func (r *JobRunner) RunOnce(ctx context.Context) {
+	if r.beforeRound != nil {
+		r.beforeRound(ctx)
+	}
+
+	for ctx.Err() == nil {
+		ran, err := r.queue.RunNext(ctx)
+		if err != nil {
+			log.Printf("%s: %v", r.name, err)
+		}
+		if !ran || err != nil {
+			return
+		}
+	}
+}
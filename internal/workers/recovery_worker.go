@@ -0,0 +1,58 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"photocloud/internal/domain/services"
+)
+
+// RecoveryWorker completes or rolls back photo operations that were interrupted
+// between storage and the database, for example by a crash
+type RecoveryWorker struct {
+	photoService services.PhotoService
+	timeout      time.Duration
+	interval     time.Duration
+}
+
+// NewRecoveryWorker creates a new recovery worker. Operations younger than the
+// timeout are assumed to still be in flight and are left alone.
+func NewRecoveryWorker(photoService services.PhotoService, timeout, interval time.Duration) *RecoveryWorker {
+	return &RecoveryWorker{
+		photoService: photoService,
+		timeout:      timeout,
+		interval:     interval,
+	}
+}
+
+// Start runs recovery immediately and then periodically until the context is cancelled
+func (w *RecoveryWorker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			w.RunOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce resolves every operation journaled longer ago than the timeout
+func (w *RecoveryWorker) RunOnce(ctx context.Context) {
+	cutoff := time.Now().Add(-w.timeout)
+
+	recovered, err := w.photoService.RecoverPendingOperations(ctx, cutoff)
+	if err != nil {
+		log.Printf("recovery worker: %v", err)
+	}
+	if recovered > 0 {
+		log.Printf("recovery worker: resolved %d interrupted operations", recovered)
+	}
+}
@@ -0,0 +1,38 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/services"
+)
+
+// syncRecordTimeout bounds recording the changes of a single event
+const syncRecordTimeout = 10 * time.Second
+
+// SyncRecorder adds the photos and albums events are about to the change feeds of
+// sync clients. Changes are recorded before Publish returns, in the path of the
+// operation that made them, so that a sync token never skips a change that was
+// dropped under load or lost when the process stopped.
+type SyncRecorder struct {
+	syncService services.SyncService
+}
+
+// NewSyncRecorder creates a sync recorder
+func NewSyncRecorder(syncService services.SyncService) *SyncRecorder {
+	return &SyncRecorder{syncService: syncService}
+}
+
+// Publish records the changes an event is about. The operation does not fail when
+// they cannot be recorded; the failure is logged.
+func (r *SyncRecorder) Publish(event *models.Event) {
+	// The changes are recorded even when the request that made the event goes away
+	ctx, cancel := context.WithTimeout(context.Background(), syncRecordTimeout)
+	defer cancel()
+
+	if err := r.syncService.RecordChanges(ctx, event); err != nil {
+		log.Printf("sync recorder: %s event %s: %v", event.Type, event.ID.Hex(), err)
+	}
+}
@@ -0,0 +1,56 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"photocloud/internal/domain/services"
+)
+
+// TrashPurger periodically and permanently deletes photos whose retention period in the trash has expired
+type TrashPurger struct {
+	photoService services.PhotoService
+	retention    time.Duration
+	interval     time.Duration
+}
+
+// NewTrashPurger creates a new trash purger
+func NewTrashPurger(photoService services.PhotoService, retention, interval time.Duration) *TrashPurger {
+	return &TrashPurger{
+		photoService: photoService,
+		retention:    retention,
+		interval:     interval,
+	}
+}
+
+// Start runs the purger in the background until the context is cancelled
+func (p *TrashPurger) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			p.RunOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce purges every photo that has been in the trash longer than the retention period
+func (p *TrashPurger) RunOnce(ctx context.Context) {
+	cutoff := time.Now().Add(-p.retention)
+
+	purged, err := p.photoService.PurgeExpiredPhotos(ctx, cutoff)
+	if err != nil {
+		log.Printf("trash purger: %v", err)
+	}
+	if purged > 0 {
+		log.Printf("trash purger: permanently deleted %d photos", purged)
+	}
+}
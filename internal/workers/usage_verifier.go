@@ -0,0 +1,52 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"photocloud/internal/domain/services"
+)
+
+// UsageVerifier periodically recomputes storage usage to correct drift in the incrementally kept counters
+type UsageVerifier struct {
+	usageService services.UsageService
+	interval     time.Duration
+}
+
+// NewUsageVerifier creates a new usage verifier
+func NewUsageVerifier(usageService services.UsageService, interval time.Duration) *UsageVerifier {
+	return &UsageVerifier{
+		usageService: usageService,
+		interval:     interval,
+	}
+}
+
+// Start runs the verifier in the background until the context is cancelled
+func (v *UsageVerifier) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(v.interval)
+		defer ticker.Stop()
+
+		for {
+			v.RunOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce recomputes the storage usage of every owner
+func (v *UsageVerifier) RunOnce(ctx context.Context) {
+	drifted, err := v.usageService.VerifyUsage(ctx)
+	if err != nil {
+		log.Printf("usage verifier: %v", err)
+	}
+	if drifted > 0 {
+		log.Printf("usage verifier: corrected the storage usage of %d owners", drifted)
+	}
+}
@@ -0,0 +1,65 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"photocloud/config"
+	"photocloud/internal/domain/services"
+)
+
+// VersionPruner periodically deletes previous photo versions outside the retention policy
+type VersionPruner struct {
+	photoService services.PhotoService
+	retention    config.VersionRetention
+	interval     time.Duration
+}
+
+// NewVersionPruner creates a new version pruner
+func NewVersionPruner(photoService services.PhotoService, retention config.VersionRetention, interval time.Duration) *VersionPruner {
+	return &VersionPruner{
+		photoService: photoService,
+		retention:    retention,
+		interval:     interval,
+	}
+}
+
+// Start runs the pruner in the background until the context is cancelled.
+// Nothing is started when the policy keeps every version.
+func (p *VersionPruner) Start(ctx context.Context) {
+	if p.retention.Keep < 0 && p.retention.MaxAge == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			p.RunOnce(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce deletes every previous version outside the retention policy
+func (p *VersionPruner) RunOnce(ctx context.Context) {
+	var cutoff time.Time
+	if p.retention.MaxAge > 0 {
+		cutoff = time.Now().Add(-p.retention.MaxAge)
+	}
+
+	pruned, err := p.photoService.PruneVersions(ctx, p.retention.Keep, cutoff)
+	if err != nil {
+		log.Printf("version pruner: %v", err)
+	}
+	if pruned > 0 {
+		log.Printf("version pruner: deleted %d previous versions", pruned)
+	}
+}
@@ -0,0 +1,91 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"photocloud/internal/domain/models"
+	"photocloud/internal/domain/services"
+)
+
+// webhookEnqueueTimeout bounds creating the deliveries of a single event
+const webhookEnqueueTimeout = 5 * time.Second
+
+// WebhookDispatcher turns published events into webhook deliveries and attempts
+// deliveries as they come due. Deliveries are stored before Publish returns, so
+// they are neither dropped under load nor lost when the process stops, and they
+// are attempted by whichever started dispatcher finds them first. Commands that
+// do not start the dispatcher leave their deliveries to the running servers.
+type WebhookDispatcher struct {
+	webhookService services.WebhookService
+	concurrency    int
+	interval       time.Duration
+	wake           chan struct{}
+}
+
+// NewWebhookDispatcher creates a webhook dispatcher attempting up to concurrency deliveries
+// at once and looking for due retries every interval
+func NewWebhookDispatcher(webhookService services.WebhookService, concurrency int, interval time.Duration) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		webhookService: webhookService,
+		concurrency:    concurrency,
+		interval:       interval,
+		wake:           make(chan struct{}, 1),
+	}
+}
+
+// Publish stores the deliveries of an event in the path of the operation that made it.
+// The operation does not fail when they cannot be stored; the failure is logged.
+func (d *WebhookDispatcher) Publish(event *models.Event) {
+	// The deliveries are stored even when the request that made the event goes away
+	ctx, cancel := context.WithTimeout(context.Background(), webhookEnqueueTimeout)
+	created, err := d.webhookService.Enqueue(ctx, event)
+	cancel()
+	if err != nil {
+		log.Printf("webhook dispatcher: failed to enqueue %s event %s: %v", event.Type, event.ID.Hex(), err)
+	}
+	if created > 0 {
+		// Deliver right away instead of at the next poll
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Start delivers in the background until the context is cancelled
+func (d *WebhookDispatcher) Start(ctx context.Context) {
+	for i := 0; i < d.concurrency; i++ {
+		go d.deliver(ctx)
+	}
+}
+
+func (d *WebhookDispatcher) deliver(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+		d.RunOnce(ctx)
+	}
+}
+
+// RunOnce attempts deliveries until none is due
+func (d *WebhookDispatcher) RunOnce(ctx context.Context) {
+	for ctx.Err() == nil {
+		delivered, err := d.webhookService.DeliverNext(ctx, time.Now())
+		if err != nil {
+			log.Printf("webhook dispatcher: failed to deliver: %v", err)
+			return
+		}
+		if !delivered {
+			return
+		}
+	}
+}
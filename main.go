@@ -2,22 +2,51 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"photocloud/config"
+	"photocloud/internal/app"
+	"photocloud/internal/workers"
 	"photocloud/routes"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
+const usage = `Usage: photocloud [command] [flags]
+
+Commands:
+  serve       Start the HTTP server (default)
+  reconcile   Compare stored objects with photo records and report mismatches
+  audit       Check the activity audit chain (audit verify [-public-key KEY], audit checkpoint,
+              audit public-key)
+  user        Manage user accounts (user create -username NAME -email EMAIL,
+              user token -username NAME, user quota -username NAME [-plan PLAN] [-max-bytes SIZE -max-photos N])
+  import      Upload the photos in a directory tree, one album per folder
+              (import -username NAME [-concurrency N] [-journal FILE] [-albums=false] DIR)
+  import-archive
+              Import a Google Takeout or Apple Photos export from a ZIP archive or directory
+              (import-archive -username NAME -source SOURCE PATH)
+`
+
+// activityDrainTimeout bounds how long queued user activity is written on exit
+const activityDrainTimeout = 10 * time.Second
+
 func main() {
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Fatal("Error loading .env file")
 	}
 
+	command := "serve"
+	args := os.Args[1:]
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
 	// Initialize MongoDB connection
 	mongoClient, err := config.ConnectMongoDB()
 	if err != nil {
@@ -31,11 +60,85 @@ func main() {
 		log.Fatal("Error initializing AWS:", err)
 	}
 
+	if config.GetActivityRetention().TTL {
+		log.Fatal("ACTIVITY_RETENTION_MODE=ttl is not supported: a TTL index would delete audit chain entries, which only the purger may prune")
+	}
+
+	// Initialize repositories and services
+	container := app.NewContainer(mongoClient, s3Client)
+	if err := container.EnsureIndexes(context.Background()); err != nil {
+		log.Fatal("Error creating database indexes:", err)
+	}
+	container.ActivityWriter.Start(context.Background())
+
+	switch command {
+	case "serve":
+		err = runServer(container)
+	case "reconcile":
+		err = runReconcile(container, args)
+	case "audit":
+		err = runAudit(container, args)
+	case "user":
+		err = runUser(container, args)
+	case "import":
+		err = runImport(container, args)
+	case "import-archive":
+		err = runImportArchive(container, args)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		err = fmt.Errorf("unknown command %q", command)
+	}
+
+	// Activity recorded by the command is written before exiting
+	container.ActivityWriter.Close(activityDrainTimeout)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// runServer starts the background workers and serves the HTTP API
+func runServer(container *app.Container) error {
+	// Start background workers
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if !config.GetAuthRequired() {
+		anonymous, claimed, err := container.UserService.EnsureAnonymousUser(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to set up the anonymous account: %w", err)
+		}
+		log.Printf("AUTH_REQUIRED is not set: requests without an API token act as the %s account (%s)", anonymous.Username, anonymous.ID.Hex())
+		if claimed > 0 {
+			log.Printf("assigned %d photos without an owner to the %s account", claimed, anonymous.Username)
+		}
+	}
+	workers.NewRecoveryWorker(container.PhotoService, config.GetPendingOperationTimeout(), config.GetRecoveryInterval()).Start(ctx)
+	workers.NewTrashPurger(container.PhotoService, config.GetTrashRetention(), config.GetTrashPurgeInterval()).Start(ctx)
+	workers.NewVersionPruner(container.PhotoService, config.GetVersionRetention(), config.GetVersionPruneInterval()).Start(ctx)
+	workers.NewUsageVerifier(container.UsageService, config.GetUsageVerifyInterval()).Start(ctx)
+	workers.NewActivityPurger(container.RetentionService, config.GetActivityPurgeInterval()).Start(ctx)
+	if auditConfig := config.GetAuditConfig(); len(auditConfig.SigningKey) > 0 {
+		workers.NewAuditCheckpointer(container.AuditService, auditConfig.CheckpointInterval).Start(ctx)
+	}
+	if container.EventRelay != nil {
+		container.EventRelay.Start(ctx)
+	}
+	container.WebhookDispatcher.Start(ctx)
+	workers.NewJobRunner("bulk job runner", container.BulkService, config.GetBulkJobPollInterval()).Start(ctx)
+	workers.NewExportJobRunner(container.ExportService, config.GetExportConfig().PollInterval).Start(ctx)
+	workers.NewJobRunner("import job runner", container.ImportService, config.GetImportConfig().PollInterval).Start(ctx)
+	logMissingEncoders(config.GetTransformConfig())
+
 	// Initialize Gin router
 	router := gin.Default()
+	// Client IPs are recorded in the audit chain, so forwarding headers are only
+	// believed when they come from a configured proxy
+	if err := router.SetTrustedProxies(config.GetTrustedProxies()); err != nil {
+		return fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
 
 	// Setup routes
-	routes.SetupRoutes(router, mongoClient, s3Client)
+	routes.SetupRoutes(router, container)
 
 	// Start server
 	port := os.Getenv("PORT")
@@ -44,6 +147,18 @@ func main() {
 	}
 
 	if err := router.Run(":" + port); err != nil {
-		log.Fatal("Error starting server:", err)
+		return fmt.Errorf("error starting server: %w", err)
+	}
+	return nil
+}
+
+// logMissingEncoders warns about negotiable formats without an encoder, since clients
+// asking for them through the Accept header quietly get the original format
+func logMissingEncoders(cfg config.TransformConfig) {
+	if cfg.WebPEncoderCommand == "" {
+		log.Println("WEBP_ENCODER_COMMAND is not set: WebP transforms are refused and clients accepting WebP get the original format")
+	}
+	if cfg.AVIFEncoderCommand == "" {
+		log.Println("AVIF_ENCODER_COMMAND is not set: AVIF transforms are refused and clients accepting AVIF get the original format")
 	}
 }
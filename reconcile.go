@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"photocloud/internal/app"
+	"photocloud/internal/domain/models"
+)
+
+// runReconcile compares storage with the photos collection and prints the report as JSON
+func runReconcile(container *app.Container, args []string) error {
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	quarantine := flags.Bool("quarantine", false, "move orphan objects under the quarantine/ prefix")
+	deleteOrphans := flags.Bool("delete", false, "permanently delete orphan objects")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	action := models.ReconcileActionReport
+	switch {
+	case *quarantine && *deleteOrphans:
+		return fmt.Errorf("-quarantine and -delete cannot be combined")
+	case *quarantine:
+		action = models.ReconcileActionQuarantine
+	case *deleteOrphans:
+		action = models.ReconcileActionDelete
+	}
+
+	report, err := container.ReconciliationService.Reconcile(context.Background(), action)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "%d orphan objects (%d bytes), %d dangling records (%d bytes)\n",
+		len(report.Orphans), report.OrphanBytes, len(report.Dangling), report.DanglingBytes)
+	return nil
+}
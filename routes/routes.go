@@ -1,29 +1,32 @@
 package routes
 
 import (
-	"os"
-
-	"photocloud/internal/domain/services"
+	"photocloud/internal/app"
 	"photocloud/internal/handlers"
-	"photocloud/internal/infrastructure/mongodb"
-	s3repo "photocloud/internal/infrastructure/s3"
 	"photocloud/internal/middleware"
 
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func SetupRoutes(router *gin.Engine, mongoClient *mongo.Client, s3Client *s3.Client) {
-	// Initialize repositories
-	photoRepo := mongodb.NewPhotoRepository(mongoClient.Database(os.Getenv("MONGODB_DATABASE")))
-	storageRepo := s3repo.NewStorageRepository(s3Client, os.Getenv("AWS_S3_BUCKET"))
-
-	// Initialize services
-	photoService := services.NewPhotoService(photoRepo, storageRepo)
-
+func SetupRoutes(router *gin.Engine, container *app.Container) {
 	// Initialize handlers
-	photoHandler := handlers.NewPhotoHandler(photoService)
+	photoHandler := handlers.NewPhotoHandler(container.PhotoService, container.TransformService)
+	adminHandler := handlers.NewAdminHandler(container.ReconciliationService)
+	transformHandler := handlers.NewTransformHandler(container.TransformService)
+	albumHandler := handlers.NewAlbumHandler(container.AlbumService)
+	shareHandler := handlers.NewShareHandler(container.ShareService)
+	usageHandler := handlers.NewUsageHandler(container.UsageService)
+	activityHandler := handlers.NewActivityHandler(container.ActivityService)
+	eventHandler := handlers.NewEventHandler(container.EventService, container.UserService)
+	webhookHandler := handlers.NewWebhookHandler(container.WebhookService)
+	syncHandler := handlers.NewSyncHandler(container.SyncService)
+	bulkHandler := handlers.NewBulkHandler(container.BulkService)
+	exportHandler := handlers.NewExportHandler(container.ExportService)
+	importHandler := handlers.NewImportHandler(container.ImportService)
+	authenticate := middleware.Authenticate(container.UserService)
+
+	// Request metadata is recorded with user activity
+	router.Use(middleware.RequestMetadata())
 
 	// Health check route
 	router.GET("/health", func(c *gin.Context) {
@@ -35,11 +38,163 @@ func SetupRoutes(router *gin.Engine, mongoClient *mongo.Client, s3Client *s3.Cli
 	// API v1 group
 	v1 := router.Group("/api/v1")
 	{
-		// Photo routes
-		photos := v1.Group("/photos")
+		// Signed transform URLs are authorized by their signature, so they can be
+		// embedded in pages viewed without an API token
+		v1.GET("/photos/:id/transform", transformHandler.TransformPhoto)
+
+		// Photo routes, guarded by user API tokens
+		photos := v1.Group("/photos", authenticate)
 		{
 			// Upload photo endpoint with file validation middleware
 			photos.POST("/upload", middleware.FileValidator(), photoHandler.UploadPhoto)
+			photos.POST("/check", photoHandler.CheckUploaded)
+			photos.GET("", photoHandler.ListPhotos)
+			photos.GET("/:id", photoHandler.GetPhoto)
+			photos.GET("/:id/content", photoHandler.GetPhotoContent)
+			photos.HEAD("/:id/content", photoHandler.GetPhotoContent)
+			photos.GET("/:id/transform-url", transformHandler.SignTransformURL)
+			photos.POST("/:id/rotate", photoHandler.RotatePhoto)
+			photos.PUT("/:id/edits", photoHandler.UpdateEdits)
+			photos.DELETE("/:id/edits", photoHandler.ResetEdits)
+			photos.POST("/:id/export", photoHandler.ExportPhoto)
+			photos.POST("/:id/versions", middleware.FileValidator(), photoHandler.UploadVersion)
+			photos.GET("/:id/versions", photoHandler.ListVersions)
+			photos.GET("/:id/versions/:version/content", photoHandler.GetVersionContent)
+			photos.HEAD("/:id/versions/:version/content", photoHandler.GetVersionContent)
+			photos.POST("/:id/versions/:version/revert", photoHandler.RevertVersion)
+			photos.GET("/:id/activity", activityHandler.ListPhotoActivity)
+			photos.GET("/:id/activity/daily", activityHandler.PhotoDailyActivity)
+			photos.DELETE("/:id", photoHandler.DeletePhoto)
+		}
+
+		// Album routes, guarded by user API tokens
+		albums := v1.Group("/albums", authenticate)
+		{
+			albums.POST("", albumHandler.CreateAlbum)
+			albums.GET("", albumHandler.ListAlbums)
+			albums.GET("/shared", albumHandler.ListSharedAlbums)
+			albums.GET("/:id", albumHandler.GetAlbum)
+			albums.PATCH("/:id", albumHandler.UpdateAlbum)
+			albums.DELETE("/:id", albumHandler.DeleteAlbum)
+			albums.GET("/:id/photos", albumHandler.ListAlbumPhotos)
+			albums.POST("/:id/photos", albumHandler.AddPhotos)
+			albums.DELETE("/:id/photos", albumHandler.RemovePhotos)
+			albums.GET("/:id/members", albumHandler.ListMembers)
+			albums.PATCH("/:id/members/:user_id", albumHandler.UpdateMember)
+			albums.DELETE("/:id/members/:user_id", albumHandler.RemoveMember)
+			albums.POST("/:id/invitations", albumHandler.InviteMember)
+			albums.GET("/:id/invitations", albumHandler.ListAlbumInvitations)
+			albums.DELETE("/:id/invitations/:invitation_id", albumHandler.CancelInvitation)
+		}
+
+		// Album invitations addressed to the caller, guarded by user API tokens
+		invitations := v1.Group("/invitations", authenticate)
+		{
+			invitations.GET("", albumHandler.ListMyInvitations)
+			invitations.POST("/:id/accept", albumHandler.AcceptInvitation)
+			invitations.POST("/:id/decline", albumHandler.DeclineInvitation)
+		}
+
+		// Share link management, guarded by user API tokens
+		shares := v1.Group("/shares", authenticate)
+		{
+			shares.POST("", shareHandler.CreateShare)
+			shares.GET("", shareHandler.ListShares)
+			shares.DELETE("/:id", shareHandler.RevokeShare)
+		}
+
+		// Shared content, authorized by the share token and optional password
+		shared := v1.Group("/s")
+		{
+			shared.GET("/:token", shareHandler.OpenShare)
+			shared.GET("/:token/photos/:photo_id", shareHandler.GetSharedPhotoURLs)
+		}
+
+		// The caller's own account, guarded by user API tokens
+		me := v1.Group("/me", authenticate)
+		{
+			me.GET("/usage", usageHandler.GetUsage)
+			me.GET("/activity", activityHandler.ListMyActivity)
+			me.GET("/activity/daily", activityHandler.DailyActivity)
+			me.POST("/export", exportHandler.ExportAccount)
+		}
+
+		// The caller's event stream, guarded by user API tokens or, for browsers, by single-use
+		// tickets in the query that are issued to API tokens
+		v1.POST("/events/tickets", authenticate, eventHandler.IssueTicket)
+		events := v1.Group("/events", middleware.AuthenticateStream(container.UserService))
+		{
+			events.GET("", eventHandler.Stream)
+			events.GET("/ws", eventHandler.WebSocket)
+		}
+
+		// Actions on many of the caller's photos, run as background jobs and guarded by user API tokens
+		bulkJobs := v1.Group("/bulk-jobs", authenticate)
+		{
+			bulkJobs.POST("", bulkHandler.CreateJob)
+			bulkJobs.GET("", bulkHandler.ListJobs)
+			bulkJobs.GET("/:id", bulkHandler.GetJob)
+		}
+
+		// ZIP exports of the caller's photos and albums, guarded by user API tokens
+		exports := v1.Group("/exports", authenticate)
+		{
+			exports.POST("", exportHandler.CreateExport)
+			exports.GET("", exportHandler.ListExports)
+			exports.GET("/:id", exportHandler.GetExport)
+		}
+
+		// Imports of libraries exported from other services, guarded by user API tokens
+		imports := v1.Group("/imports", authenticate)
+		{
+			imports.POST("", importHandler.CreateImport)
+			imports.GET("", importHandler.ListImports)
+			imports.GET("/:id", importHandler.GetImport)
+			imports.GET("/:id/files", importHandler.ListImportFiles)
+		}
+
+		// The caller's change feed for sync clients, guarded by user API tokens
+		sync := v1.Group("/sync", authenticate)
+		{
+			sync.GET("/changes", syncHandler.ListChanges)
+		}
+
+		// The caller's webhooks, guarded by user API tokens
+		webhooks := v1.Group("/webhooks", authenticate)
+		registerWebhookRoutes(webhooks, webhookHandler)
+
+		// Trash routes, guarded by user API tokens
+		trash := v1.Group("/trash", authenticate)
+		{
+			trash.GET("", photoHandler.ListTrash)
+			trash.POST("/:id/restore", photoHandler.RestorePhoto)
+			trash.DELETE("/:id", photoHandler.PurgePhoto)
+		}
+
+		// Admin routes, guarded by the admin token
+		admin := v1.Group("/admin", middleware.AdminAuth())
+		{
+			admin.POST("/reconcile", adminHandler.Reconcile)
+			admin.GET("/activity", activityHandler.ListActivity)
+			admin.GET("/analytics/daily", activityHandler.DailyActivity)
+			admin.GET("/analytics/top-photos", activityHandler.TopPhotos)
+			admin.GET("/analytics/active-users", activityHandler.ActiveUsers)
+
+			// System webhooks receive every event
+			registerWebhookRoutes(admin.Group("/webhooks"), webhookHandler)
 		}
 	}
 }
+
+// registerWebhookRoutes adds the webhook management routes to a group. The same
+// routes manage user webhooks and, under the admin token, system webhooks.
+func registerWebhookRoutes(group *gin.RouterGroup, webhookHandler *handlers.WebhookHandler) {
+	group.POST("", webhookHandler.CreateWebhook)
+	group.GET("", webhookHandler.ListWebhooks)
+	group.GET("/:id", webhookHandler.GetWebhook)
+	group.PATCH("/:id", webhookHandler.UpdateWebhook)
+	group.DELETE("/:id", webhookHandler.DeleteWebhook)
+	group.GET("/:id/deliveries", webhookHandler.ListDeliveries)
+	group.GET("/:id/deliveries/:delivery_id", webhookHandler.GetDelivery)
+	group.POST("/:id/deliveries/:delivery_id/redeliver", webhookHandler.Redeliver)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"photocloud/config"
+	"photocloud/internal/app"
+	"photocloud/internal/domain/models"
+)
+
+const userUsage = `usage: photocloud user create -username NAME -email EMAIL [-claim-unowned]
+       photocloud user token -username NAME
+       photocloud user quota -username NAME [-plan PLAN] [-max-bytes SIZE -max-photos N]`
+
+// runUser dispatches the user management subcommands
+func runUser(container *app.Container, args []string) error {
+	if len(args) == 0 {
+		return errors.New(userUsage)
+	}
+
+	switch args[0] {
+	case "create":
+		return runUserCreate(container, args[1:])
+	case "token":
+		return runUserToken(container, args[1:])
+	case "quota":
+		return runUserQuota(container, args[1:])
+	default:
+		return errors.New(userUsage)
+	}
+}
+
+// runUserCreate creates a user and prints their API token
+func runUserCreate(container *app.Container, args []string) error {
+	flags := flag.NewFlagSet("user create", flag.ContinueOnError)
+	username := flags.String("username", "", "unique name of the user")
+	email := flags.String("email", "", "unique email address of the user")
+	claimUnowned := flags.Bool("claim-unowned", false, "make the user the owner of photos uploaded before accounts existed or without a token")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	user, token, err := container.UserService.CreateUser(ctx, *username, *email)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "created user %s (%s)\n", user.Username, user.ID.Hex())
+
+	if *claimUnowned {
+		claimed, err := container.UserService.ClaimUnownedPhotos(ctx, user)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "assigned %d unowned photos to %s\n", claimed, user.Username)
+	}
+
+	// The token is printed alone on stdout so it can be captured; it cannot be shown again
+	fmt.Println(token)
+	return nil
+}
+
+// runUserToken replaces the API token of a user and prints the new one
+func runUserToken(container *app.Container, args []string) error {
+	flags := flag.NewFlagSet("user token", flag.ContinueOnError)
+	username := flags.String("username", "", "name of the user, or anonymous for the account of requests without a token")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	token, err := container.UserService.ResetToken(context.Background(), *username)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "replaced the API token of %s; the previous token no longer works\n", *username)
+
+	// The token is printed alone on stdout so it can be captured; it cannot be shown again
+	fmt.Println(token)
+	return nil
+}
+
+// runUserQuota moves a user to a plan and optionally gives them their own limits
+func runUserQuota(container *app.Container, args []string) error {
+	flags := flag.NewFlagSet("user quota", flag.ContinueOnError)
+	username := flags.String("username", "", "name of the user")
+	plan := flags.String("plan", "", "quota plan of the user; empty for the default plan")
+	maxBytes := flags.String("max-bytes", "", "storage limit replacing the plan's, e.g. 20GB; 0 for no limit")
+	maxPhotos := flags.Int64("max-photos", 0, "photo limit replacing the plan's when -max-bytes is set; 0 for no limit")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var quota *models.Quota
+	if *maxBytes != "" {
+		parsed, ok := config.ParseSize(*maxBytes)
+		if !ok {
+			return fmt.Errorf("invalid -max-bytes %q", *maxBytes)
+		}
+		quota = &models.Quota{MaxBytes: parsed, MaxPhotos: *maxPhotos}
+	}
+
+	quotas := config.GetQuotaConfig()
+	if _, ok := quotas.Plans[*plan]; *plan != "" && !ok {
+		fmt.Fprintf(os.Stderr, "warning: plan %q is not configured in QUOTA_PLANS and has no limits\n", *plan)
+	}
+
+	user, err := container.UserService.SetQuota(context.Background(), *username, *plan, quota)
+	if err != nil {
+		return err
+	}
+
+	planName := user.Plan
+	if planName == "" {
+		planName = quotas.DefaultPlan
+	}
+	if user.Quota != nil {
+		fmt.Fprintf(os.Stderr, "%s is on plan %s with its own limits of %d bytes and %d photos\n",
+			user.Username, planName, user.Quota.MaxBytes, user.Quota.MaxPhotos)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s is on plan %s\n", user.Username, planName)
+	}
+	return nil
+}